@@ -64,6 +64,46 @@ func GetDefaultEmailTemplates() []DefaultEmailTemplate {
 			Description: "Password reset email sent to users who request password reset",
 			Locale:      "en",
 		},
+		{
+			Code:        domain.EmailCodeOTP,
+			Name:        "One-Time Code",
+			Subject:     "Your {{.app_name}} verification code",
+			ContentFile: "otp.html",
+			Description: "One-time code sent to users to confirm a sensitive action",
+			Locale:      "en",
+		},
+		{
+			Code:        domain.EmailCodeAccountLocked,
+			Name:        "Account Locked",
+			Subject:     "Your {{.app_name}} account has been locked",
+			ContentFile: "account_locked.html",
+			Description: "Security notice sent when an account is locked after repeated failed logins",
+			Locale:      "en",
+		},
+		{
+			Code:        domain.EmailCodeMagicLink,
+			Name:        "Magic Link Login",
+			Subject:     "Your {{.app_name}} login link",
+			ContentFile: "magic_link.html",
+			Description: "Single-use login link sent to users who log in without a password",
+			Locale:      "en",
+		},
+		{
+			Code:        domain.EmailCodeNewLogin,
+			Name:        "New Sign-in Alert",
+			Subject:     "New sign-in to your {{.app_name}} account",
+			ContentFile: "new_login.html",
+			Description: "Security notice sent when an account is signed in to from an unfamiliar device or network",
+			Locale:      "en",
+		},
+		{
+			Code:        domain.EmailCodeOrganizationInvitation,
+			Name:        "Organization Invitation",
+			Subject:     "{{.inviter_name}} invited you to join {{.organization_name}} on {{.app_name}}",
+			ContentFile: "organization_invitation.html",
+			Description: "Invitation to join an organization, with the link to accept it",
+			Locale:      "en",
+		},
 	}
 }
 
@@ -264,7 +304,8 @@ func GetTemplatePreview(templateType domain.EmailCode) map[string]interface{} {
 	case domain.EmailCodeVerification:
 		baseData["verification_code"] = "123456"
 		baseData["verification_url"] = "https://yourapp.com/verify?token=abc123"
-		baseData["expires_in"] = "15 minutes"
+		baseData["code_expires_in"] = "5 minutes"
+		baseData["expires_in"] = "24 hours"
 		return baseData
 
 	case domain.EmailCodePasswordReset:
@@ -274,6 +315,46 @@ func GetTemplatePreview(templateType domain.EmailCode) map[string]interface{} {
 		baseData["expires_in"] = "24 hours"
 		return baseData
 
+	case domain.EmailCodeOTP:
+		baseData["otp_code"] = "482913"
+		baseData["action"] = "verify your email address"
+		baseData["expires_in"] = "5 minutes"
+		return baseData
+
+	case domain.EmailCodeAccountLocked:
+		baseData["failed_attempts"] = 5
+		baseData["attempt_time"] = "2024-01-01 10:30:00 UTC"
+		baseData["ip_address"] = "192.168.1.1"
+		baseData["locked_until"] = "2024-01-01 10:45:00 UTC"
+		baseData["reset_url"] = "https://yourapp.com/forgot-password"
+		return baseData
+
+	case domain.EmailCodeMagicLink:
+		baseData["login_url"] = "https://yourapp.com/magic-login?token=ghi789"
+		baseData["request_time"] = "2024-01-01 10:30:00 UTC"
+		baseData["ip_address"] = "192.168.1.1"
+		baseData["expires_in"] = "15 minutes"
+		return baseData
+
+	case domain.EmailCodeNewLogin:
+		baseData["deny_url"] = "https://yourapp.com/deny-login?token=jkl012"
+		baseData["login_time"] = "2024-01-01 10:30:00 UTC"
+		baseData["ip_address"] = "192.168.1.1"
+		baseData["browser"] = "Chrome 120.0"
+		baseData["os"] = "Windows"
+		baseData["device"] = "desktop"
+		baseData["reasons"] = []string{"a device or browser you have not signed in with before"}
+		baseData["expires_in"] = "7 days"
+		return baseData
+
+	case domain.EmailCodeOrganizationInvitation:
+		baseData["inviter_name"] = "Jane Smith"
+		baseData["organization_name"] = "Acme Inc"
+		baseData["role"] = "member"
+		baseData["accept_url"] = "https://yourapp.com/accept-invitation?token=mno345"
+		baseData["expires_in"] = "7 days"
+		return baseData
+
 	default:
 		return baseData
 	}
@@ -0,0 +1,199 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/log"
+)
+
+// RoleRepository interface for role operations
+type RoleRepository interface {
+	FindMany(ctx context.Context, filter *domain.RoleFilter, option *domain.FindManyOption) ([]*domain.Role, error)
+	Create(ctx context.Context, role *domain.Role) error
+	AddPermissions(ctx context.Context, id domain.RoleID, permissionIDs []domain.PermissionID) error
+}
+
+// PermissionRepository interface for permission operations
+type PermissionRepository interface {
+	FindMany(ctx context.Context, filter *domain.PermissionFilter, option *domain.FindManyOption) ([]*domain.Permission, error)
+	CreateMany(ctx context.Context, permissions []*domain.Permission) error
+}
+
+// PermissionCache drops the cached permissions of roles
+type PermissionCache interface {
+	Invalidate(ctx context.Context, roleIDs ...domain.RoleID) error
+}
+
+// DefaultRole represents a built-in role
+type DefaultRole struct {
+	ID          domain.RoleID
+	Name        string
+	Description string
+	Permissions []domain.PermissionID
+}
+
+// GetDefaultPermissions returns every permission checked by the application. The resources are
+// named in the plural, the email ones included.
+func GetDefaultPermissions() []*domain.Permission {
+	return []*domain.Permission{
+		{ID: domain.PermissionUsersRead, Description: "View any user account"},
+		{ID: domain.PermissionUsersWrite, Description: "Edit and unlock any user account"},
+		{ID: domain.PermissionUsersImpersonate, Description: "Sign in as another user for support"},
+		{ID: domain.PermissionRolesRead, Description: "View roles and permissions"},
+		{ID: domain.PermissionRolesWrite, Description: "Manage roles and assign them to users"},
+		{ID: domain.PermissionSessionsManage, Description: "View and revoke the sessions of any user"},
+		{ID: domain.PermissionAPIKeysManage, Description: "Manage the API keys of other services"},
+		{ID: domain.PermissionSecurityEventsRead, Description: "View the security events of all users"},
+		{ID: domain.PermissionEmailsSend, Description: "Send emails (emails:send, resources are plural as in users:read)"},
+		{ID: domain.PermissionEmailsRead, Description: "View sent emails"},
+		{ID: domain.PermissionEmailTemplatesRead, Description: "View email templates"},
+		{ID: domain.PermissionEmailTemplatesWrite, Description: "Manage email templates"},
+	}
+}
+
+// GetDefaultRoles returns the built-in roles with the permissions they start with. Super admins
+// always hold every permission, the other roles can be changed once created.
+func GetDefaultRoles() []DefaultRole {
+	var adminPermissions []domain.PermissionID
+	for _, permission := range GetDefaultPermissions() {
+		// Administrators cannot hand out roles, nor act as other users
+		if permission.ID == domain.PermissionRolesWrite || permission.ID == domain.PermissionUsersImpersonate {
+			continue
+		}
+		adminPermissions = append(adminPermissions, permission.ID)
+	}
+
+	return []DefaultRole{
+		{
+			ID:          domain.RoleIDSuperAdmin,
+			Name:        "Super Admin",
+			Description: "Full access, including roles and impersonation",
+		},
+		{
+			ID:          domain.RoleIDAdmin,
+			Name:        "Admin",
+			Description: "Administration of users, sessions, API keys and emails",
+			Permissions: adminPermissions,
+		},
+		{
+			ID:          domain.RoleIDUser,
+			Name:        "User",
+			Description: "Regular user",
+		},
+		{
+			ID:          domain.RoleIDGuest,
+			Name:        "Guest",
+			Description: "User with limited access",
+		},
+	}
+}
+
+// RoleSeeder provides methods for seeding permissions and built-in roles
+type RoleSeeder struct {
+	roleRepo        RoleRepository
+	permissionRepo  PermissionRepository
+	permissionCache PermissionCache
+	logger          log.Logger
+}
+
+// NewRoleSeeder creates a new role seeder
+func NewRoleSeeder(
+	roleRepo RoleRepository,
+	permissionRepo PermissionRepository,
+	permissionCache PermissionCache,
+	logger log.Logger,
+) *RoleSeeder {
+	return &RoleSeeder{
+		roleRepo:        roleRepo,
+		permissionRepo:  permissionRepo,
+		permissionCache: permissionCache,
+		logger:          logger,
+	}
+}
+
+// Seed creates the missing permissions and built-in roles, and grants every permission to super
+// admins. Built-in roles that already exist keep the changes made to them.
+func (s *RoleSeeder) Seed(ctx context.Context) error {
+	s.logger.Info("Initializing roles and permissions...")
+
+	permissionIDs, err := s.seedPermissions(ctx)
+	if err != nil {
+		return err
+	}
+
+	defaultRoles := GetDefaultRoles()
+	roleIDs := make([]domain.RoleID, 0, len(defaultRoles))
+	for _, defaultRole := range defaultRoles {
+		roleIDs = append(roleIDs, defaultRole.ID)
+	}
+	existing, err := s.roleRepo.FindMany(ctx, &domain.RoleFilter{IDIn: roleIDs}, nil)
+	if err != nil {
+		return fmt.Errorf("failed to check existing roles: %w", err)
+	}
+	existingIDs := make(map[domain.RoleID]struct{}, len(existing))
+	for _, role := range existing {
+		existingIDs[role.ID] = struct{}{}
+	}
+
+	for _, defaultRole := range defaultRoles {
+		if _, ok := existingIDs[defaultRole.ID]; ok {
+			continue
+		}
+		role := &domain.Role{
+			ID:          defaultRole.ID,
+			Name:        defaultRole.Name,
+			Description: defaultRole.Description,
+			BuiltIn:     true,
+		}
+		for _, permissionID := range defaultRole.Permissions {
+			role.Permissions = append(role.Permissions, &domain.Permission{ID: permissionID})
+		}
+		if err := s.roleRepo.Create(ctx, role); err != nil {
+			return fmt.Errorf("failed to create role %s: %w", role.ID, err)
+		}
+		s.logger.Info("Created role", log.String("id", string(role.ID)))
+	}
+
+	if err := s.roleRepo.AddPermissions(ctx, domain.RoleIDSuperAdmin, permissionIDs); err != nil {
+		return fmt.Errorf("failed to grant permissions to super admins: %w", err)
+	}
+	if err := s.permissionCache.Invalidate(ctx, domain.RoleIDSuperAdmin); err != nil {
+		return fmt.Errorf("failed to invalidate cached permissions: %w", err)
+	}
+
+	s.logger.Info("Roles and permissions initialization completed")
+	return nil
+}
+
+// seedPermissions creates the missing permissions and returns the IDs of all default permissions
+func (s *RoleSeeder) seedPermissions(ctx context.Context) ([]domain.PermissionID, error) {
+	defaultPermissions := GetDefaultPermissions()
+	permissionIDs := make([]domain.PermissionID, 0, len(defaultPermissions))
+	for _, permission := range defaultPermissions {
+		permissionIDs = append(permissionIDs, permission.ID)
+	}
+
+	existing, err := s.permissionRepo.FindMany(ctx, &domain.PermissionFilter{IDIn: permissionIDs}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing permissions: %w", err)
+	}
+	existingIDs := make(map[domain.PermissionID]struct{}, len(existing))
+	for _, permission := range existing {
+		existingIDs[permission.ID] = struct{}{}
+	}
+
+	var missing []*domain.Permission
+	for _, permission := range defaultPermissions {
+		if _, ok := existingIDs[permission.ID]; !ok {
+			missing = append(missing, permission)
+		}
+	}
+	if len(missing) > 0 {
+		if err := s.permissionRepo.CreateMany(ctx, missing); err != nil {
+			return nil, fmt.Errorf("failed to create permissions: %w", err)
+		}
+		s.logger.Info("Created permissions", log.Int("count", len(missing)))
+	}
+	return permissionIDs, nil
+}
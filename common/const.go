@@ -1,8 +1,18 @@
 package common
 
 const (
-	FieldRoles = "Roles"
+	FieldRoles       = "Roles"
+	FieldPermissions = "Permissions"
 
-	UserContextKey      = "user"
-	SessionIDContextKey = "session_id"
+	UserContextKey        = "user"
+	ActorContextKey       = "actor"
+	SessionIDContextKey   = "session_id"
+	TokenClaimsContextKey = "token_claims"
+	APIKeyContextKey      = "api_key"
+	ScopesContextKey      = "scopes"
+	PermissionsContextKey = "permissions"
+	MembershipContextKey  = "membership"
+
+	// OrganizationHeader selects the organization a request acts in, over the org claim of the token
+	OrganizationHeader = "X-Organization-ID"
 )
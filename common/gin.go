@@ -73,6 +73,78 @@ func GetUserFromCtx(c *gin.Context) *domain.User {
 	return userFromCtx
 }
 
+// GetActorFromCtx returns the administrator impersonating the user of the request, nil when the
+// user is acting on their own
+func GetActorFromCtx(c *gin.Context) *domain.User {
+	if v, ok := c.Get(ActorContextKey); ok {
+		if actor, ok := v.(*domain.User); ok {
+			return actor
+		}
+	}
+	return nil
+}
+
+// GetTokenClaimsFromCtx returns the claims of the access token that authenticated the request
+func GetTokenClaimsFromCtx(c *gin.Context) *domain.JwtClaims {
+	if v, ok := c.Get(TokenClaimsContextKey); ok {
+		if claims, ok := v.(*domain.JwtClaims); ok {
+			return claims
+		}
+	}
+	return nil
+}
+
+// GetAPIKeyFromCtx returns the API key that authenticated the request, nil for a user
+func GetAPIKeyFromCtx(c *gin.Context) *domain.APIKey {
+	if v, ok := c.Get(APIKeyContextKey); ok {
+		if key, ok := v.(*domain.APIKey); ok {
+			return key
+		}
+	}
+	return nil
+}
+
+// GetScopesFromCtx returns the scopes granted to the caller, by its API key or by the user's roles
+func GetScopesFromCtx(c *gin.Context) []domain.Scope {
+	if v, ok := c.Get(ScopesContextKey); ok {
+		if scopes, ok := v.([]domain.Scope); ok {
+			return scopes
+		}
+	}
+	return nil
+}
+
+// GetPermissionsFromCtx returns the permissions the roles of the user grant, nil for an API key
+func GetPermissionsFromCtx(c *gin.Context) []domain.PermissionID {
+	if v, ok := c.Get(PermissionsContextKey); ok {
+		if permissions, ok := v.([]domain.PermissionID); ok {
+			return permissions
+		}
+	}
+	return nil
+}
+
+// GetPrincipalFromCtx returns the authenticated user with its permissions, for the policies to
+// decide on. It is nil when the request is not made by a user.
+func GetPrincipalFromCtx(c *gin.Context) *domain.Principal {
+	user := GetUserFromCtx(c)
+	if user == nil {
+		return nil
+	}
+	return domain.NewPrincipal(user, GetPermissionsFromCtx(c))
+}
+
+// GetMembershipFromCtx returns the membership of the user in the organization the request acts in,
+// nil outside any organization
+func GetMembershipFromCtx(c *gin.Context) *domain.OrganizationMember {
+	if v, ok := c.Get(MembershipContextKey); ok {
+		if member, ok := v.(*domain.OrganizationMember); ok {
+			return member
+		}
+	}
+	return nil
+}
+
 func GetSessionIDFromCtx(c *gin.Context) string {
 	var sIDFromCtx string
 	if v, ok := c.Get(SessionIDContextKey); ok {
@@ -1,6 +1,7 @@
 package common
 
 import (
+	"encoding/json"
 	"errors"
 	"go-clean-arch/domain"
 	"go-clean-arch/proto/pb"
@@ -23,7 +24,7 @@ func ToGRPCError(err error) error {
 			Reason:  de.ReasonField,
 			Debug:   de.DebugField,
 			Message: de.ErrorField,
-			// Details: map[string]string{}, // add if needed
+			Details: encodeErrorDetails(de.DetailsField),
 		})
 		return st.Err()
 	}
@@ -50,9 +51,40 @@ func IsDetailError(err error) (*domain.DetailedError, bool) {
 				ReasonField:     pbErr.Reason,
 				DebugField:      pbErr.Debug,
 				ErrorField:      pbErr.Message,
-				// DetailsField:  map[string]interface{}{}, // add if needed
+				DetailsField:    decodeErrorDetails(pbErr.Details),
 			}, true
 		}
 	}
 	return nil, false
 }
+
+// encodeErrorDetails encodes every detail as JSON, the proto only carries string values
+func encodeErrorDetails(details map[string]interface{}) map[string]string {
+	if len(details) == 0 {
+		return nil
+	}
+	encoded := make(map[string]string, len(details))
+	for key, value := range details {
+		data, err := json.Marshal(value)
+		if err != nil {
+			continue
+		}
+		encoded[key] = string(data)
+	}
+	return encoded
+}
+
+func decodeErrorDetails(details map[string]string) map[string]interface{} {
+	if len(details) == 0 {
+		return nil
+	}
+	decoded := make(map[string]interface{}, len(details))
+	for key, value := range details {
+		var detail interface{}
+		if err := json.Unmarshal([]byte(value), &detail); err != nil {
+			detail = value
+		}
+		decoded[key] = detail
+	}
+	return decoded
+}
@@ -1,20 +1,164 @@
 package common
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"go-clean-arch/domain"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-type BcryptHasher struct{}
+var errInvalidPasswordHash = errors.New("invalid password hash")
+
+// PasswordHashAlgorithm hashes passwords with a single algorithm and recognizes its own hashes.
+type PasswordHashAlgorithm interface {
+	Hash(password string) (string, error)
+	Compare(hashed, password string) bool
+	// Recognizes reports whether the hash was produced by this algorithm
+	Recognizes(hashed string) bool
+	// NeedsRehash reports whether the hash was produced with other parameters than the current ones
+	NeedsRehash(hashed string) bool
+}
+
+type BcryptHasher struct {
+	cost int
+}
 
-func NewBcryptHasher() *BcryptHasher {
-	return &BcryptHasher{}
+func NewBcryptHasher(cost int) *BcryptHasher {
+	return &BcryptHasher{cost: cost}
 }
 
 func (h *BcryptHasher) Hash(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
 	return string(bytes), err
 }
 
 func (h *BcryptHasher) Compare(hashed, password string) bool {
 	return bcrypt.CompareHashAndPassword([]byte(hashed), []byte(password)) == nil
 }
+
+func (h *BcryptHasher) Recognizes(hashed string) bool {
+	return strings.HasPrefix(hashed, "$2a$") || strings.HasPrefix(hashed, "$2b$") || strings.HasPrefix(hashed, "$2y$")
+}
+
+func (h *BcryptHasher) NeedsRehash(hashed string) bool {
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return err != nil || cost != h.cost
+}
+
+// Argon2idParams are the cost parameters of Argon2id, Memory is in KiB.
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Argon2idHasher stores hashes in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>, so every hash carries the parameters it was made with.
+type Argon2idHasher struct {
+	params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) *Argon2idHasher {
+	return &Argon2idHasher{params: params}
+}
+
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		h.params.Memory,
+		h.params.Iterations,
+		h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *Argon2idHasher) Compare(hashed, password string) bool {
+	params, salt, key, err := decodeArgon2idHash(hashed)
+	if err != nil {
+		return false
+	}
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func (h *Argon2idHasher) Recognizes(hashed string) bool {
+	return strings.HasPrefix(hashed, "$argon2id$")
+}
+
+func (h *Argon2idHasher) NeedsRehash(hashed string) bool {
+	params, _, _, err := decodeArgon2idHash(hashed)
+	return err != nil || params != h.params
+}
+
+func decodeArgon2idHash(hashed string) (params Argon2idParams, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=65536,t=3,p=2", salt, key
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[1] != domain.PasswordHashAlgorithmArgon2id {
+		return params, nil, nil, errInvalidPasswordHash
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errInvalidPasswordHash
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, errInvalidPasswordHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, errInvalidPasswordHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return params, nil, nil, errInvalidPasswordHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+// PasswordHasher hashes new passwords with the primary algorithm and verifies hashes of every
+// known algorithm, so users can be moved to the primary one the next time they log in.
+type PasswordHasher struct {
+	primary    PasswordHashAlgorithm
+	algorithms []PasswordHashAlgorithm
+}
+
+func NewPasswordHasher(primary PasswordHashAlgorithm, legacy ...PasswordHashAlgorithm) *PasswordHasher {
+	return &PasswordHasher{
+		primary:    primary,
+		algorithms: append([]PasswordHashAlgorithm{primary}, legacy...),
+	}
+}
+
+func (h *PasswordHasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+func (h *PasswordHasher) Compare(hashed, password string) bool {
+	for _, algorithm := range h.algorithms {
+		if algorithm.Recognizes(hashed) {
+			return algorithm.Compare(hashed, password)
+		}
+	}
+	return false
+}
+
+// NeedsRehash reports whether the hash was produced by another algorithm than the primary one or
+// with outdated parameters.
+func (h *PasswordHasher) NeedsRehash(hashed string) bool {
+	return !h.primary.Recognizes(hashed) || h.primary.NeedsRehash(hashed)
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2idParams keeps the tests fast, production parameters come from the configuration
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHasher(t *testing.T) {
+	hasher := NewArgon2idHasher(testArgon2idParams)
+	hashed, err := hasher.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if !strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("Hash() = %q, want a PHC string with the parameters", hashed)
+	}
+	again, _ := hasher.Hash("correct horse")
+	if again == hashed {
+		t.Error("Hash() returned the same hash twice, the salt must be random")
+	}
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+
+	tests := []struct {
+		name            string
+		hasher          *Argon2idHasher
+		hashed          string
+		password        string
+		wantMatch       bool
+		wantNeedsRehash bool
+	}{
+		{name: "right password", hasher: hasher, hashed: hashed, password: "correct horse", wantMatch: true},
+		{name: "wrong password", hasher: hasher, hashed: hashed, password: "wrong horse"},
+		{name: "hash of older parameters", hasher: NewArgon2idHasher(stronger), hashed: hashed, password: "correct horse", wantMatch: true, wantNeedsRehash: true},
+		{name: "malformed hash", hasher: hasher, hashed: "$argon2id$v=19$m=1024$salt", password: "correct horse", wantNeedsRehash: true},
+		{name: "other version", hasher: hasher, hashed: strings.Replace(hashed, "v=19", "v=16", 1), password: "correct horse", wantNeedsRehash: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.Compare(tt.hashed, tt.password); got != tt.wantMatch {
+				t.Errorf("Compare() = %v, want %v", got, tt.wantMatch)
+			}
+			if got := tt.hasher.NeedsRehash(tt.hashed); got != tt.wantNeedsRehash {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.wantNeedsRehash)
+			}
+		})
+	}
+}
+
+func TestPasswordHasherMigration(t *testing.T) {
+	argon2id := NewArgon2idHasher(testArgon2idParams)
+	bcryptHasher := NewBcryptHasher(bcrypt.MinCost)
+
+	argon2idHash, _ := argon2id.Hash("correct horse")
+	bcryptHash, _ := bcryptHasher.Hash("correct horse")
+	outdatedBcryptHash, _ := NewBcryptHasher(bcrypt.MinCost + 1).Hash("correct horse")
+
+	tests := []struct {
+		name            string
+		hasher          *PasswordHasher
+		hashed          string
+		password        string
+		wantMatch       bool
+		wantNeedsRehash bool
+	}{
+		{name: "primary argon2id hash", hasher: NewPasswordHasher(argon2id, bcryptHasher), hashed: argon2idHash, password: "correct horse", wantMatch: true},
+		{name: "legacy bcrypt hash is verified and rehashed", hasher: NewPasswordHasher(argon2id, bcryptHasher), hashed: bcryptHash, password: "correct horse", wantMatch: true, wantNeedsRehash: true},
+		{name: "legacy hash with a wrong password", hasher: NewPasswordHasher(argon2id, bcryptHasher), hashed: bcryptHash, password: "wrong horse", wantNeedsRehash: true},
+		{name: "primary bcrypt hash", hasher: NewPasswordHasher(bcryptHasher, argon2id), hashed: bcryptHash, password: "correct horse", wantMatch: true},
+		{name: "bcrypt hash of another cost", hasher: NewPasswordHasher(bcryptHasher, argon2id), hashed: outdatedBcryptHash, password: "correct horse", wantMatch: true, wantNeedsRehash: true},
+		{name: "argon2id hash while bcrypt is primary", hasher: NewPasswordHasher(bcryptHasher, argon2id), hashed: argon2idHash, password: "correct horse", wantMatch: true, wantNeedsRehash: true},
+		{name: "unknown algorithm", hasher: NewPasswordHasher(argon2id, bcryptHasher), hashed: "plain", password: "plain", wantNeedsRehash: true},
+		{name: "algorithm no longer supported", hasher: NewPasswordHasher(argon2id), hashed: bcryptHash, password: "correct horse", wantNeedsRehash: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.Compare(tt.hashed, tt.password); got != tt.wantMatch {
+				t.Errorf("Compare() = %v, want %v", got, tt.wantMatch)
+			}
+			if got := tt.hasher.NeedsRehash(tt.hashed); got != tt.wantNeedsRehash {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.wantNeedsRehash)
+			}
+		})
+	}
+
+	hashed, _ := NewPasswordHasher(argon2id, bcryptHasher).Hash("correct horse")
+	if !argon2id.Recognizes(hashed) {
+		t.Errorf("Hash() = %q, want a hash of the primary algorithm", hashed)
+	}
+}
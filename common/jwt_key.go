@@ -0,0 +1,140 @@
+package common
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"fmt"
+	"go-clean-arch/domain"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTKeySpec describes an asymmetric signing key stored in PEM files. The private key is optional
+// for keys that are only kept to verify tokens signed before a rotation.
+type JWTKeySpec struct {
+	KID            string
+	Algorithm      string // RS256 or EdDSA
+	PrivateKeyFile string
+	PublicKeyFile  string
+	ActiveAt       time.Time // When the key starts signing, zero means immediately
+	RetireAt       time.Time // When tokens signed with the key stop being accepted, zero means never
+}
+
+type jwtKey struct {
+	kid        string
+	method     jwt.SigningMethod
+	privateKey crypto.PrivateKey
+	publicKey  crypto.PublicKey
+	activeAt   time.Time
+	retireAt   time.Time
+}
+
+func (k *jwtKey) isRetired(now time.Time) bool {
+	return !k.retireAt.IsZero() && !now.Before(k.retireAt)
+}
+
+func (k *jwtKey) jwk() domain.JSONWebKey {
+	key := domain.JSONWebKey{
+		Use: "sig",
+		Alg: k.method.Alg(),
+		Kid: k.kid,
+	}
+	switch pub := k.publicKey.(type) {
+	case *rsa.PublicKey:
+		key.Kty = "RSA"
+		key.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		key.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		key.Kty = "OKP"
+		key.Crv = "Ed25519"
+		key.X = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	return key
+}
+
+func loadJWTKey(spec JWTKeySpec) (*jwtKey, error) {
+	key := &jwtKey{
+		kid:      spec.KID,
+		activeAt: spec.ActiveAt,
+		retireAt: spec.RetireAt,
+	}
+
+	var err error
+	switch spec.Algorithm {
+	case domain.JWTAlgorithmRS256:
+		key.method = jwt.SigningMethodRS256
+		err = loadRSAKey(key, spec)
+	case domain.JWTAlgorithmEdDSA:
+		key.method = jwt.SigningMethodEdDSA
+		err = loadEdDSAKey(key, spec)
+	default:
+		err = fmt.Errorf("unsupported algorithm %s", spec.Algorithm)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwt key %s: %w", spec.KID, err)
+	}
+	return key, nil
+}
+
+func loadRSAKey(key *jwtKey, spec JWTKeySpec) error {
+	if spec.PrivateKeyFile != "" {
+		pemBytes, err := os.ReadFile(spec.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return err
+		}
+		key.privateKey = privateKey
+		key.publicKey = &privateKey.PublicKey
+		return nil
+	}
+
+	pemBytes, err := os.ReadFile(spec.PublicKeyFile)
+	if err != nil {
+		return err
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+	if err != nil {
+		return err
+	}
+	key.publicKey = publicKey
+	return nil
+}
+
+func loadEdDSAKey(key *jwtKey, spec JWTKeySpec) error {
+	if spec.PrivateKeyFile != "" {
+		pemBytes, err := os.ReadFile(spec.PrivateKeyFile)
+		if err != nil {
+			return err
+		}
+		privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return err
+		}
+		edKey, ok := privateKey.(ed25519.PrivateKey)
+		if !ok {
+			return fmt.Errorf("private key is not an Ed25519 key")
+		}
+		key.privateKey = edKey
+		key.publicKey = edKey.Public()
+		return nil
+	}
+
+	pemBytes, err := os.ReadFile(spec.PublicKeyFile)
+	if err != nil {
+		return err
+	}
+	publicKey, err := jwt.ParseEdPublicKeyFromPEM(pemBytes)
+	if err != nil {
+		return err
+	}
+	key.publicKey = publicKey
+	return nil
+}
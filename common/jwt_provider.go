@@ -10,6 +10,12 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+func init() {
+	// iat carries milliseconds, so the revocation of a user does not reject the tokens of a login
+	// that happened later within the same second
+	jwt.TimePrecision = time.Millisecond
+}
+
 type JwtProviderConfig interface {
 	AccessTokenExpiresIn() time.Duration
 	AccessTokenSecret() string
@@ -18,18 +24,29 @@ type JwtProviderConfig interface {
 	TokenIssuer() string
 }
 
+// JWTProvider issues and verifies access tokens. Tokens are signed with the newest active
+// asymmetric key and carry its kid, or with HS256 and the shared secret when no key is configured.
 type JWTProvider struct {
-	cfg JwtProviderConfig
+	cfg  JwtProviderConfig
+	keys []*jwtKey
 }
 
-func NewJWTProvider(cfg JwtProviderConfig) *JWTProvider {
-	return &JWTProvider{cfg: cfg}
+func NewJWTProvider(cfg JwtProviderConfig, keySpecs ...JWTKeySpec) (*JWTProvider, error) {
+	keys := make([]*jwtKey, 0, len(keySpecs))
+	for _, spec := range keySpecs {
+		key, err := loadJWTKey(spec)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return &JWTProvider{cfg: cfg, keys: keys}, nil
 }
 
 func (j *JWTProvider) Generate(tokenType domain.TokenType, userID, sessionID string) (string, error) {
 	switch tokenType {
 	case domain.TokenTypeAccess:
-		return j.generateAccessToken(userID, sessionID)
+		return j.GenerateAccessToken(userID, sessionID, "")
 	case domain.TokenTypeRefresh:
 		return j.generateRefreshToken()
 	default:
@@ -37,19 +54,75 @@ func (j *JWTProvider) Generate(tokenType domain.TokenType, userID, sessionID str
 	}
 }
 
-func (j *JWTProvider) generateAccessToken(userID, sessionID string) (string, error) {
+// GenerateAccessToken issues an access token for the session, acting in the organization when
+// one is given
+func (j *JWTProvider) GenerateAccessToken(userID, sessionID, organizationID string) (string, error) {
+	now := time.Now()
+	claims := domain.JwtClaims{
+		Sub: userID,
+		Sid: sessionID,
+		Org: organizationID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        GenerateUUID(),
+			Issuer:    j.cfg.TokenIssuer(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.cfg.AccessTokenExpiresIn())),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Subject:   userID,
+		},
+	}
+	return j.sign(claims, now)
+}
+
+// GenerateImpersonationToken issues an access token for the user on behalf of the actor, with the
+// actor recorded in the act claim. It expires at the given time instead of the usual lifetime.
+func (j *JWTProvider) GenerateImpersonationToken(userID, sessionID, actorID string, expiresAt time.Time) (*domain.JwtClaims, string, error) {
+	now := time.Now()
 	claims := domain.JwtClaims{
 		Sub: userID,
 		Sid: sessionID,
+		Act: &domain.JwtActor{Sub: actorID},
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        GenerateUUID(),
 			Issuer:    j.cfg.TokenIssuer(),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(j.cfg.AccessTokenExpiresIn())),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
 			Subject:   userID,
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.cfg.AccessTokenSecret()))
+	token, err := j.sign(claims, now)
+	if err != nil {
+		return nil, "", err
+	}
+	return &claims, token, nil
+}
+
+func (j *JWTProvider) sign(claims domain.JwtClaims, now time.Time) (string, error) {
+	key := j.signingKey(now)
+	if key == nil {
+		if len(j.keys) > 0 || j.cfg.AccessTokenSecret() == "" {
+			return "", errors.New("no active jwt signing key")
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(j.cfg.AccessTokenSecret()))
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.privateKey)
+}
+
+// signingKey returns the most recently activated key that can sign at the given time.
+func (j *JWTProvider) signingKey(now time.Time) *jwtKey {
+	var current *jwtKey
+	for _, key := range j.keys {
+		if key.privateKey == nil || key.isRetired(now) || key.activeAt.After(now) {
+			continue
+		}
+		if current == nil || key.activeAt.After(current.activeAt) {
+			current = key
+		}
+	}
+	return current
 }
 
 func (j *JWTProvider) generateRefreshToken() (string, error) {
@@ -67,9 +140,7 @@ func (j *JWTProvider) Verify(tokenType domain.TokenType, tokenStr string) (*doma
 		return nil, errors.New("only access tokens can be verified with JWT")
 	}
 
-	token, err := jwt.ParseWithClaims(tokenStr, &domain.JwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(j.cfg.AccessTokenSecret()), nil
-	})
+	token, err := jwt.ParseWithClaims(tokenStr, &domain.JwtClaims{}, j.verificationKey)
 	if err != nil {
 		return nil, err
 	}
@@ -79,3 +150,44 @@ func (j *JWTProvider) Verify(tokenType domain.TokenType, tokenStr string) (*doma
 	}
 	return claims, nil
 }
+
+// verificationKey picks the key matching the kid header of the token. Tokens without a kid were
+// signed with HS256 and the shared secret before asymmetric keys were introduced.
+func (j *JWTProvider) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		if token.Method != jwt.SigningMethodHS256 || j.cfg.AccessTokenSecret() == "" {
+			return nil, errors.New("unexpected signing method")
+		}
+		return []byte(j.cfg.AccessTokenSecret()), nil
+	}
+
+	for _, key := range j.keys {
+		if key.kid != kid {
+			continue
+		}
+		if key.isRetired(time.Now()) {
+			return nil, errors.New("signing key is retired")
+		}
+		// The algorithm is bound to the key, never trust the one in the header alone
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, errors.New("unexpected signing method")
+		}
+		return key.publicKey, nil
+	}
+	return nil, errors.New("unknown signing key")
+}
+
+// JWKS returns the public keys of every key that is not retired, including keys scheduled to
+// become active later so verifiers can fetch them ahead of the rotation.
+func (j *JWTProvider) JWKS() *domain.JSONWebKeySet {
+	now := time.Now()
+	set := &domain.JSONWebKeySet{Keys: make([]domain.JSONWebKey, 0, len(j.keys))}
+	for _, key := range j.keys {
+		if key.isRetired(now) {
+			continue
+		}
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	return set
+}
@@ -0,0 +1,269 @@
+package common
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"go-clean-arch/domain"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type testJWTConfig struct {
+	secret string
+}
+
+func (c testJWTConfig) AccessTokenExpiresIn() time.Duration  { return time.Minute }
+func (c testJWTConfig) AccessTokenSecret() string            { return c.secret }
+func (c testJWTConfig) RefreshTokenExpiresIn() time.Duration { return time.Hour }
+func (c testJWTConfig) RefreshTokenSecret() string           { return "refresh" }
+func (c testJWTConfig) TokenIssuer() string                  { return "test" }
+
+// writeKeyPair writes a new private and public key of the algorithm as PEM files and returns their paths.
+func writeKeyPair(t *testing.T, algorithm string) (string, string) {
+	t.Helper()
+	var privateKey, publicKey any
+	switch algorithm {
+	case domain.JWTAlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey, publicKey = key, &key.PublicKey
+	case domain.JWTAlgorithmEdDSA:
+		pub, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privateKey, publicKey = key, pub
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	privateFile := filepath.Join(dir, "private.pem")
+	publicFile := filepath.Join(dir, "public.pem")
+	if err := os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return privateFile, publicFile
+}
+
+func tokenKID(t *testing.T, token string) string {
+	t.Helper()
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &domain.JwtClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified() error = %v", err)
+	}
+	kid, _ := parsed.Header["kid"].(string)
+	return kid
+}
+
+func TestJWTProviderSigningKeyRotation(t *testing.T) {
+	now := time.Now()
+	oldPrivate, _ := writeKeyPair(t, domain.JWTAlgorithmRS256)
+	currentPrivate, _ := writeKeyPair(t, domain.JWTAlgorithmEdDSA)
+	nextPrivate, _ := writeKeyPair(t, domain.JWTAlgorithmEdDSA)
+	_, verifyOnlyPublic := writeKeyPair(t, domain.JWTAlgorithmEdDSA)
+
+	tests := []struct {
+		name    string
+		keys    []JWTKeySpec
+		wantKID string
+		wantErr bool
+	}{
+		{
+			name: "newest active key signs",
+			keys: []JWTKeySpec{
+				{KID: "old", Algorithm: domain.JWTAlgorithmRS256, PrivateKeyFile: oldPrivate, ActiveAt: now.Add(-48 * time.Hour)},
+				{KID: "current", Algorithm: domain.JWTAlgorithmEdDSA, PrivateKeyFile: currentPrivate, ActiveAt: now.Add(-time.Hour)},
+				{KID: "next", Algorithm: domain.JWTAlgorithmEdDSA, PrivateKeyFile: nextPrivate, ActiveAt: now.Add(time.Hour)},
+			},
+			wantKID: "current",
+		},
+		{
+			name: "retired key does not sign",
+			keys: []JWTKeySpec{
+				{KID: "old", Algorithm: domain.JWTAlgorithmRS256, PrivateKeyFile: oldPrivate, ActiveAt: now.Add(-48 * time.Hour)},
+				{KID: "current", Algorithm: domain.JWTAlgorithmEdDSA, PrivateKeyFile: currentPrivate, ActiveAt: now.Add(-time.Hour), RetireAt: now.Add(-time.Minute)},
+			},
+			wantKID: "old",
+		},
+		{
+			name: "verify only key does not sign",
+			keys: []JWTKeySpec{
+				{KID: "verify-only", Algorithm: domain.JWTAlgorithmEdDSA, PublicKeyFile: verifyOnlyPublic},
+			},
+			wantErr: true,
+		},
+		{
+			name: "no key active yet",
+			keys: []JWTKeySpec{
+				{KID: "next", Algorithm: domain.JWTAlgorithmEdDSA, PrivateKeyFile: nextPrivate, ActiveAt: now.Add(time.Hour)},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewJWTProvider(testJWTConfig{secret: "secret"}, tt.keys...)
+			if err != nil {
+				t.Fatalf("NewJWTProvider() error = %v", err)
+			}
+			token, err := provider.GenerateAccessToken("user-1", "session-1", "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if kid := tokenKID(t, token); kid != tt.wantKID {
+				t.Errorf("token kid = %q, want %q", kid, tt.wantKID)
+			}
+			claims, err := provider.Verify(domain.TokenTypeAccess, token)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if claims.Sub != "user-1" || claims.Sid != "session-1" {
+				t.Errorf("Verify() claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestJWTProviderVerifyAcrossRotation(t *testing.T) {
+	now := time.Now()
+	oldPrivate, oldPublic := writeKeyPair(t, domain.JWTAlgorithmRS256)
+	newPrivate, _ := writeKeyPair(t, domain.JWTAlgorithmEdDSA)
+
+	// Tokens issued before the rotation
+	before, err := NewJWTProvider(testJWTConfig{secret: "secret"},
+		JWTKeySpec{KID: "old", Algorithm: domain.JWTAlgorithmRS256, PrivateKeyFile: oldPrivate},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldToken, err := before.GenerateAccessToken("user-1", "session-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy, _ := NewJWTProvider(testJWTConfig{secret: "secret"})
+	hsToken, err := legacy.GenerateAccessToken("user-1", "session-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := tokenKID(t, hsToken); kid != "" {
+		t.Fatalf("HS256 token kid = %q, want none", kid)
+	}
+
+	newKey := JWTKeySpec{KID: "new", Algorithm: domain.JWTAlgorithmEdDSA, PrivateKeyFile: newPrivate, ActiveAt: now.Add(-time.Minute)}
+	tests := []struct {
+		name    string
+		cfg     testJWTConfig
+		keys    []JWTKeySpec
+		token   string
+		wantErr bool
+	}{
+		{
+			name:  "old key kept to verify",
+			cfg:   testJWTConfig{secret: "secret"},
+			keys:  []JWTKeySpec{{KID: "old", Algorithm: domain.JWTAlgorithmRS256, PublicKeyFile: oldPublic}, newKey},
+			token: oldToken,
+		},
+		{
+			name:    "old key retired",
+			cfg:     testJWTConfig{secret: "secret"},
+			keys:    []JWTKeySpec{{KID: "old", Algorithm: domain.JWTAlgorithmRS256, PublicKeyFile: oldPublic, RetireAt: now.Add(-time.Second)}, newKey},
+			token:   oldToken,
+			wantErr: true,
+		},
+		{
+			name:    "old key removed",
+			cfg:     testJWTConfig{secret: "secret"},
+			keys:    []JWTKeySpec{newKey},
+			token:   oldToken,
+			wantErr: true,
+		},
+		{
+			name:    "kid bound to another algorithm",
+			cfg:     testJWTConfig{secret: "secret"},
+			keys:    []JWTKeySpec{{KID: "old", Algorithm: domain.JWTAlgorithmEdDSA, PrivateKeyFile: newPrivate}},
+			token:   oldToken,
+			wantErr: true,
+		},
+		{
+			name:  "HS256 token without kid while the secret is kept",
+			cfg:   testJWTConfig{secret: "secret"},
+			keys:  []JWTKeySpec{newKey},
+			token: hsToken,
+		},
+		{
+			name:    "HS256 token without kid once the secret is removed",
+			cfg:     testJWTConfig{},
+			keys:    []JWTKeySpec{newKey},
+			token:   hsToken,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewJWTProvider(tt.cfg, tt.keys...)
+			if err != nil {
+				t.Fatalf("NewJWTProvider() error = %v", err)
+			}
+			_, err = provider.Verify(domain.TokenTypeAccess, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTProviderJWKS(t *testing.T) {
+	now := time.Now()
+	rsaPrivate, _ := writeKeyPair(t, domain.JWTAlgorithmRS256)
+	edPrivate, _ := writeKeyPair(t, domain.JWTAlgorithmEdDSA)
+	_, nextPublic := writeKeyPair(t, domain.JWTAlgorithmEdDSA)
+
+	provider, err := NewJWTProvider(testJWTConfig{},
+		JWTKeySpec{KID: "retired", Algorithm: domain.JWTAlgorithmRS256, PrivateKeyFile: rsaPrivate, RetireAt: now.Add(-time.Second)},
+		JWTKeySpec{KID: "rsa", Algorithm: domain.JWTAlgorithmRS256, PrivateKeyFile: rsaPrivate},
+		JWTKeySpec{KID: "ed", Algorithm: domain.JWTAlgorithmEdDSA, PrivateKeyFile: edPrivate},
+		JWTKeySpec{KID: "next", Algorithm: domain.JWTAlgorithmEdDSA, PublicKeyFile: nextPublic, ActiveAt: now.Add(time.Hour)},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := map[string]domain.JSONWebKey{}
+	for _, key := range provider.JWKS().Keys {
+		keys[key.Kid] = key
+	}
+	if _, ok := keys["retired"]; ok {
+		t.Error("JWKS() contains the retired key")
+	}
+	if _, ok := keys["next"]; !ok {
+		t.Error("JWKS() does not contain the key scheduled for the next rotation")
+	}
+	if key := keys["rsa"]; key.Kty != "RSA" || key.Alg != "RS256" || key.N == "" || key.E == "" {
+		t.Errorf("RSA key = %+v", key)
+	}
+	if key := keys["ed"]; key.Kty != "OKP" || key.Crv != "Ed25519" || key.Alg != "EdDSA" || key.X == "" {
+		t.Errorf("Ed25519 key = %+v", key)
+	}
+}
@@ -9,27 +9,19 @@ func ToPbUser(u *domain.User) *pb.User {
 	if u == nil {
 		return nil
 	}
-	var status pb.UserStatus
-	switch u.Status {
-	case domain.UserSTTWaitingVerify:
-		status = pb.UserStatus_USER_STATUS_WAITING_VERIFY
-	case domain.UserSTTActive:
-		status = pb.UserStatus_USER_STATUS_ACTIVE
-	case domain.UserSTTBanned:
-		status = pb.UserStatus_USER_STATUS_BANNED
-	default:
-		status = pb.UserStatus_USER_STATUS_UNSPECIFIED
-	}
 	return &pb.User{
 		Id:        u.ID,
+		Username:  u.Username,
 		Email:     u.Email,
 		Password:  u.Password,
 		FirstName: u.FirstName,
 		LastName:  u.LastName,
-		Status:    status,
+		Status:    ToPbUserStatus(u.Status),
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 		DeletedAt: u.DeletedAt,
+
+		PasswordResetRequired: u.PasswordResetRequired,
 	}
 }
 
@@ -37,17 +29,6 @@ func ToDomainUser(u *pb.User) *domain.User {
 	if u == nil {
 		return nil
 	}
-	var status domain.UserStatus
-	switch u.Status {
-	case pb.UserStatus_USER_STATUS_WAITING_VERIFY:
-		status = domain.UserSTTWaitingVerify
-	case pb.UserStatus_USER_STATUS_ACTIVE:
-		status = domain.UserSTTActive
-	case pb.UserStatus_USER_STATUS_BANNED:
-		status = domain.UserSTTBanned
-	default:
-		status = domain.UserStatus("")
-	}
 	return &domain.User{
 		SQLModel: domain.SQLModel{
 			ID:        u.Id,
@@ -55,11 +36,40 @@ func ToDomainUser(u *pb.User) *domain.User {
 			UpdatedAt: u.UpdatedAt,
 			DeletedAt: u.DeletedAt,
 		},
+		Username:  u.Username,
 		Email:     u.Email,
 		Password:  u.Password,
 		FirstName: u.FirstName,
 		LastName:  u.LastName,
-		Status:    status,
+		Status:    ToDomainUserStatus(u.Status),
+
+		PasswordResetRequired: u.PasswordResetRequired,
+	}
+}
+
+func ToPbUserStatus(status domain.UserStatus) pb.UserStatus {
+	switch status {
+	case domain.UserSTTWaitingVerify:
+		return pb.UserStatus_USER_STATUS_WAITING_VERIFY
+	case domain.UserSTTActive:
+		return pb.UserStatus_USER_STATUS_ACTIVE
+	case domain.UserSTTBanned:
+		return pb.UserStatus_USER_STATUS_BANNED
+	default:
+		return pb.UserStatus_USER_STATUS_UNSPECIFIED
+	}
+}
+
+func ToDomainUserStatus(status pb.UserStatus) domain.UserStatus {
+	switch status {
+	case pb.UserStatus_USER_STATUS_WAITING_VERIFY:
+		return domain.UserSTTWaitingVerify
+	case pb.UserStatus_USER_STATUS_ACTIVE:
+		return domain.UserSTTActive
+	case pb.UserStatus_USER_STATUS_BANNED:
+		return domain.UserSTTBanned
+	default:
+		return domain.UserStatus("")
 	}
 }
 
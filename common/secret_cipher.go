@@ -0,0 +1,71 @@
+package common
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// encryptedSecretPrefix marks a value produced by SecretCipher, values without it were stored in
+// plain text before secrets were encrypted.
+const encryptedSecretPrefix = "enc:v1:"
+
+var errInvalidEncryptedSecret = errors.New("invalid encrypted secret")
+
+// SecretCipher encrypts secrets that have to be stored in a recoverable form, like TOTP secrets,
+// with AES-256-GCM. The key is derived from the configured secret with SHA-256.
+type SecretCipher struct {
+	aead cipher.AEAD
+}
+
+func NewSecretCipher(secret string) (*SecretCipher, error) {
+	if secret == "" {
+		return nil, errors.New("secret cipher key must be not empty")
+	}
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretCipher{aead: aead}, nil
+}
+
+// Encrypt returns the prefixed base64 encoding of a random nonce followed by the sealed plaintext.
+func (c *SecretCipher) Encrypt(plaintext string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := c.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encryptedSecretPrefix + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value produced by Encrypt. A value without the prefix is returned unchanged.
+func (c *SecretCipher) Decrypt(value string) (string, error) {
+	if !IsEncryptedSecret(value) {
+		return value, nil
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(value, encryptedSecretPrefix))
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return "", errInvalidEncryptedSecret
+	}
+	nonce, ciphertext := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errInvalidEncryptedSecret
+	}
+	return string(plaintext), nil
+}
+
+// IsEncryptedSecret reports whether the value was produced by SecretCipher.Encrypt
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, encryptedSecretPrefix)
+}
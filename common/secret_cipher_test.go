@@ -0,0 +1,63 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSecretCipher(t *testing.T) {
+	cipher, err := NewSecretCipher("test-key")
+	if err != nil {
+		t.Fatalf("NewSecretCipher() error = %v", err)
+	}
+	otherCipher, _ := NewSecretCipher("other-key")
+
+	encrypted, err := cipher.Encrypt("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Encrypt() error = %v", err)
+	}
+	if !IsEncryptedSecret(encrypted) || strings.Contains(encrypted, "JBSWY3DPEHPK3PXP") {
+		t.Fatalf("Encrypt() = %q, want an encrypted value", encrypted)
+	}
+	again, _ := cipher.Encrypt("JBSWY3DPEHPK3PXP")
+	if again == encrypted {
+		t.Errorf("Encrypt() returned the same value twice, the nonce must be random")
+	}
+
+	tampered := encrypted[:len(encrypted)-2] + "AA"
+	if tampered == encrypted {
+		tampered = encrypted[:len(encrypted)-2] + "BB"
+	}
+
+	tests := []struct {
+		name    string
+		cipher  *SecretCipher
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "encrypted value", cipher: cipher, value: encrypted, want: "JBSWY3DPEHPK3PXP"},
+		{name: "plain text value is returned as is", cipher: cipher, value: "JBSWY3DPEHPK3PXP", want: "JBSWY3DPEHPK3PXP"},
+		{name: "other key", cipher: otherCipher, value: encrypted, wantErr: true},
+		{name: "tampered ciphertext", cipher: cipher, value: tampered, wantErr: true},
+		{name: "invalid encoding", cipher: cipher, value: encryptedSecretPrefix + "!!", wantErr: true},
+		{name: "too short", cipher: cipher, value: encryptedSecretPrefix + "AAAA", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.cipher.Decrypt(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Decrypt() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSecretCipherRequiresKey(t *testing.T) {
+	if _, err := NewSecretCipher(""); err == nil {
+		t.Error("NewSecretCipher(\"\") error = nil, want an error")
+	}
+}
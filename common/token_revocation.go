@@ -0,0 +1,112 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/cache"
+	"strconv"
+	"time"
+)
+
+type TokenRevocationConfig interface {
+	AccessTokenExpiresIn() time.Duration
+	SessionMaxLifetime() time.Duration
+}
+
+// TokenRevocationList keeps revoked access tokens in the cache until they would have expired on
+// their own, so a valid JWT can be trusted without looking its session up in the database.
+// Tokens are revoked one by one (jti), per session, or for every session a user started so far.
+type TokenRevocationList struct {
+	cache cache.Client
+	cfg   TokenRevocationConfig
+}
+
+func NewTokenRevocationList(cache cache.Client, cfg TokenRevocationConfig) *TokenRevocationList {
+	return &TokenRevocationList{cache: cache, cfg: cfg}
+}
+
+func revokedTokenKey(tokenID string) string {
+	return "revoked_token:" + tokenID
+}
+
+func revokedSessionKey(sessionID string) string {
+	return "revoked_session:" + sessionID
+}
+
+func revokedUserKey(userID string) string {
+	return "revoked_user:" + userID
+}
+
+// RevokeToken revokes a single access token until it expires.
+func (l *TokenRevocationList) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if tokenID == "" || ttl <= 0 {
+		return nil
+	}
+	return l.cache.Set(ctx, revokedTokenKey(tokenID), []byte("1"), ttl)
+}
+
+// RevokeSession revokes every access token issued for the session. Tokens are issued until the
+// session ends, so the entry lives as long as the last one of them can.
+func (l *TokenRevocationList) RevokeSession(ctx context.Context, sessionID string) error {
+	return l.cache.Set(ctx, revokedSessionKey(sessionID), []byte("1"), l.cfg.AccessTokenExpiresIn())
+}
+
+// RevokeUser revokes every access token issued to the user so far and every session started before
+// now, e.g. after a password change or a ban. The entry lives as long as such a session could.
+func (l *TokenRevocationList) RevokeUser(ctx context.Context, userID string) error {
+	revokedAt := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return l.cache.Set(ctx, revokedUserKey(userID), []byte(revokedAt), l.cfg.SessionMaxLifetime())
+}
+
+// IsRevoked reports whether the access token was revoked by any of its jti, session or user.
+func (l *TokenRevocationList) IsRevoked(ctx context.Context, claims *domain.JwtClaims) (bool, error) {
+	keys := []string{revokedSessionKey(claims.Sid), revokedUserKey(claims.Sub)}
+	if claims.ID != "" {
+		keys = append(keys, revokedTokenKey(claims.ID))
+	}
+	values, err := l.cache.GetMultiple(ctx, keys)
+	if err != nil {
+		return false, err
+	}
+
+	if _, ok := values[revokedSessionKey(claims.Sid)]; ok {
+		return true, nil
+	}
+	if claims.ID != "" {
+		if _, ok := values[revokedTokenKey(claims.ID)]; ok {
+			return true, nil
+		}
+	}
+	if value, ok := values[revokedUserKey(claims.Sub)]; ok {
+		// A token issued in the same millisecond as the revocation already belongs to the new login
+		if claims.IssuedAt == nil || claims.IssuedAt.UnixMilli() < parseRevokedAt(value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsSessionRevoked reports whether the session was started before its user got revoked, so it
+// must not issue new tokens anymore.
+func (l *TokenRevocationList) IsSessionRevoked(ctx context.Context, session *domain.UserSession) (bool, error) {
+	value, err := l.cache.Get(ctx, revokedUserKey(session.UserID))
+	if err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return session.CreatedAt < parseRevokedAt(value), nil
+}
+
+// parseRevokedAt reads the revocation time of a user in milliseconds. Entries written before
+// milliseconds were stored hold seconds, they revoke everything up to the end of that second.
+func parseRevokedAt(value []byte) int64 {
+	revokedAt, _ := strconv.ParseInt(string(value), 10, 64)
+	if revokedAt < 1e12 {
+		return (revokedAt + 1) * 1000
+	}
+	return revokedAt
+}
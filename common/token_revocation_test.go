@@ -0,0 +1,208 @@
+package common
+
+import (
+	"context"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/cache"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type nopCacheLogger struct{}
+
+func (nopCacheLogger) Info(string, ...interface{})   {}
+func (nopCacheLogger) Error(string, ...interface{})  {}
+func (nopCacheLogger) Debug(string, ...interface{})  {}
+func (nopCacheLogger) Infof(string, ...interface{})  {}
+func (nopCacheLogger) Errorf(string, ...interface{}) {}
+func (nopCacheLogger) Debugf(string, ...interface{}) {}
+
+type testRevocationConfig struct{}
+
+func (testRevocationConfig) AccessTokenExpiresIn() time.Duration { return time.Minute }
+func (testRevocationConfig) SessionMaxLifetime() time.Duration   { return time.Hour }
+
+func newTestRevocationList() (*TokenRevocationList, cache.Client) {
+	client := cache.NewMemoryCache(&cache.Config{}, nopCacheLogger{})
+	return NewTokenRevocationList(client, testRevocationConfig{}), client
+}
+
+func TestTokenRevocationListIsRevoked(t *testing.T) {
+	ctx := context.Background()
+	revokedAt := time.UnixMilli(1_700_000_000_500)
+	claims := func(tokenID, sessionID string, issuedAt time.Time) *domain.JwtClaims {
+		return &domain.JwtClaims{
+			Sub: "user-1",
+			Sid: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:       tokenID,
+				IssuedAt: jwt.NewNumericDate(issuedAt),
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		setup  func(l *TokenRevocationList, c cache.Client)
+		claims *domain.JwtClaims
+		want   bool
+	}{
+		{
+			name:   "nothing revoked",
+			claims: claims("token-1", "session-1", revokedAt),
+		},
+		{
+			name: "token revoked",
+			setup: func(l *TokenRevocationList, _ cache.Client) {
+				_ = l.RevokeToken(ctx, "token-1", time.Now().Add(time.Minute))
+			},
+			claims: claims("token-1", "session-1", revokedAt),
+			want:   true,
+		},
+		{
+			name: "other token revoked",
+			setup: func(l *TokenRevocationList, _ cache.Client) {
+				_ = l.RevokeToken(ctx, "token-2", time.Now().Add(time.Minute))
+			},
+			claims: claims("token-1", "session-1", revokedAt),
+		},
+		{
+			name: "session revoked",
+			setup: func(l *TokenRevocationList, _ cache.Client) {
+				_ = l.RevokeSession(ctx, "session-1")
+			},
+			claims: claims("token-1", "session-1", revokedAt),
+			want:   true,
+		},
+		{
+			name:   "user revoked after the token was issued",
+			setup:  revokeUserAt(revokedAt),
+			claims: claims("token-1", "session-1", revokedAt.Add(-time.Millisecond)),
+			want:   true,
+		},
+		{
+			name:   "token issued later within the same second",
+			setup:  revokeUserAt(revokedAt),
+			claims: claims("token-1", "session-1", revokedAt.Add(200*time.Millisecond)),
+		},
+		{
+			name:   "token issued in the millisecond of the revocation",
+			setup:  revokeUserAt(revokedAt),
+			claims: claims("token-1", "session-1", revokedAt),
+		},
+		{
+			name:   "token without iat",
+			setup:  revokeUserAt(revokedAt),
+			claims: &domain.JwtClaims{Sub: "user-1", Sid: "session-1"},
+			want:   true,
+		},
+		{
+			name: "legacy entry in seconds revokes its whole second",
+			setup: func(_ *TokenRevocationList, c cache.Client) {
+				_ = c.Set(ctx, revokedUserKey("user-1"), []byte(strconv.FormatInt(revokedAt.Unix(), 10)), time.Hour)
+			},
+			claims: claims("token-1", "session-1", revokedAt.Add(200*time.Millisecond)),
+			want:   true,
+		},
+		{
+			name: "legacy entry in seconds keeps later tokens",
+			setup: func(_ *TokenRevocationList, c cache.Client) {
+				_ = c.Set(ctx, revokedUserKey("user-1"), []byte(strconv.FormatInt(revokedAt.Unix(), 10)), time.Hour)
+			},
+			claims: claims("token-1", "session-1", revokedAt.Add(time.Second)),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, client := newTestRevocationList()
+			if tt.setup != nil {
+				tt.setup(list, client)
+			}
+			got, err := list.IsRevoked(ctx, tt.claims)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenRevocationListIsSessionRevoked(t *testing.T) {
+	ctx := context.Background()
+	revokedAt := time.UnixMilli(1_700_000_000_500)
+
+	tests := []struct {
+		name      string
+		createdAt time.Time
+		want      bool
+	}{
+		{name: "session started before", createdAt: revokedAt.Add(-time.Millisecond), want: true},
+		{name: "session started later within the same second", createdAt: revokedAt.Add(300 * time.Millisecond)},
+		{name: "session started in the millisecond of the revocation", createdAt: revokedAt},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, client := newTestRevocationList()
+			revokeUserAt(revokedAt)(list, client)
+			session := &domain.UserSession{UserID: "user-1"}
+			session.CreatedAt = tt.createdAt.UnixMilli()
+
+			got, err := list.IsSessionRevoked(ctx, session)
+			if err != nil {
+				t.Fatalf("IsSessionRevoked() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsSessionRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRevokeUserKeepsLaterLogins(t *testing.T) {
+	ctx := context.Background()
+	list, _ := newTestRevocationList()
+	provider, _ := NewJWTProvider(testJWTConfig{secret: "secret"})
+
+	oldToken, _ := provider.GenerateAccessToken("user-1", "session-1", "")
+	time.Sleep(2 * time.Millisecond)
+	if err := list.RevokeUser(ctx, "user-1"); err != nil {
+		t.Fatalf("RevokeUser() error = %v", err)
+	}
+	time.Sleep(2 * time.Millisecond)
+	newToken, _ := provider.GenerateAccessToken("user-1", "session-2", "")
+
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  bool
+	}{
+		{name: "token issued before", token: oldToken, want: true},
+		{name: "token issued after", token: newToken},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := provider.Verify(domain.TokenTypeAccess, tt.token)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			got, err := list.IsRevoked(ctx, claims)
+			if err != nil {
+				t.Fatalf("IsRevoked() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsRevoked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// revokeUserAt stores a user revocation at the given time, RevokeUser always uses the current time.
+func revokeUserAt(revokedAt time.Time) func(l *TokenRevocationList, c cache.Client) {
+	return func(_ *TokenRevocationList, c cache.Client) {
+		_ = c.Set(context.Background(), revokedUserKey("user-1"), []byte(strconv.FormatInt(revokedAt.UnixMilli(), 10)), time.Hour)
+	}
+}
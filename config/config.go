@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"os"
 	"time"
 )
 
@@ -11,6 +12,24 @@ const (
 	ProductionEnv  = "prod"
 )
 
+const (
+	OAuthProviderGoogle = "google"
+	OAuthProviderGitHub = "github"
+	OAuthProviderOIDC   = "oidc"
+)
+
+const (
+	WebAuthnUserVerificationRequired    = "required"
+	WebAuthnUserVerificationPreferred   = "preferred"
+	WebAuthnUserVerificationDiscouraged = "discouraged"
+)
+
+const (
+	RefreshCookieSameSiteStrict = "strict"
+	RefreshCookieSameSiteLax    = "lax"
+	RefreshCookieSameSiteNone   = "none"
+)
+
 type Config interface {
 	App() AppConfig
 	Server() ServerConfig
@@ -22,6 +41,15 @@ type Config interface {
 	Upload() UploadConfig
 	External() ExternalConfig
 	RPC() RPCConfig
+	PasswordPolicy() PasswordPolicyConfig
+	PasswordHash() PasswordHashConfig
+	OAuth() OAuthConfig
+	WebAuthn() WebAuthnConfig
+	LoginAlerts() LoginAlertsConfig
+	SecurityEvents() SecurityEventsConfig
+	UserBans() UserBansConfig
+	RefreshCookie() RefreshCookieConfig
+	Organizations() OrganizationsConfig
 }
 
 type AppConfig interface {
@@ -31,17 +59,45 @@ type AppConfig interface {
 	IsProduction() bool
 	AccessTokenExpiresIn() time.Duration
 	AccessTokenSecret() string
+	JWTKeys() []JWTKeyConfig
 	RefreshTokenExpiresIn() time.Duration
 	RefreshTokenSecret() string
+	RefreshTokenReuseGracePeriod() time.Duration
 	TokenIssuer() string
+	EmailVerificationTokenExpiresIn() time.Duration
+	PasswordResetTokenExpiresIn() time.Duration
+	MFAChallengeExpiresIn() time.Duration
+	MFASecretKey() string
+	LoginMaxFailedAttempts() int
+	LoginIPMaxFailedAttempts() int
+	LoginFailureWindow() time.Duration
+	LoginFailureBaseDelay() time.Duration
+	LoginLockoutDuration() time.Duration
+	MagicLinkExpiresIn() time.Duration
+	MagicLinkMaxRequests() int
+	MagicLinkRequestWindow() time.Duration
+	MagicLinkRequireSameDevice() bool
+	ImpersonationExpiresIn() time.Duration
+	SessionMaxLifetime() time.Duration
 	SessionLimitPerUser() int
 	UserSessionLimitEnabled() bool
-	APIKey() string
+	SessionLimitPolicy() string
 	SystemAdminDefaultPhone() string
 	SystemAdminDefaultEmail() string
 	SystemAdminDefaultPassword() string
 }
 
+// JWTKeyConfig describes an asymmetric key used to sign access tokens. A key signs tokens from
+// ActiveAt on until a newer key becomes active, and tokens signed with it are accepted until RetireAt.
+type JWTKeyConfig interface {
+	KID() string
+	Algorithm() string
+	PrivateKeyFile() string
+	PublicKeyFile() string
+	ActiveAt() time.Time
+	RetireAt() time.Time
+}
+
 type ServerConfig interface {
 	Host() string
 	Domain() string
@@ -97,6 +153,7 @@ type OTPConfig interface {
 	ExpiresIn() time.Duration
 	RetryBaseWaitTime() time.Duration
 	RetryMaxWaitTime() time.Duration
+	MaxAttempts() int
 }
 
 type UploadConfig interface {
@@ -121,6 +178,90 @@ type RPCConfig interface {
 	Port() int
 }
 
+type PasswordPolicyConfig interface {
+	MinLength() int
+	MaxLength() int
+	RequireUppercase() bool
+	RequireLowercase() bool
+	RequireDigit() bool
+	RequireSymbol() bool
+	DenyCommonPasswords() bool
+	DenyPersonalInfo() bool
+	HistorySize() int
+}
+
+type PasswordHashConfig interface {
+	Algorithm() string
+	Argon2Memory() uint32
+	Argon2Iterations() uint32
+	Argon2Parallelism() uint8
+	Argon2SaltLength() uint32
+	Argon2KeyLength() uint32
+	BcryptCost() int
+}
+
+type OAuthConfig interface {
+	StateExpiresIn() time.Duration
+	Providers() []OAuthProviderConfig
+}
+
+// OAuthProviderConfig describes an identity provider users can log in with. The endpoints of
+// google and github have defaults, oidc providers must set all of them.
+type OAuthProviderConfig interface {
+	Name() string
+	Type() string
+	ClientID() string
+	ClientSecret() string
+	Issuer() string
+	AuthURL() string
+	TokenURL() string
+	JWKSURL() string
+	APIURL() string
+	Scopes() []string
+	RedirectURIs() []string
+}
+
+type WebAuthnConfig interface {
+	RPID() string
+	RPName() string
+	Origins() []string
+	ChallengeExpiresIn() time.Duration
+	UserVerification() string
+}
+
+type LoginAlertsConfig interface {
+	Enabled() bool
+	HistorySize() int
+	IPv4PrefixLength() int
+	IPv6PrefixLength() int
+	DenyLinkExpiresIn() time.Duration
+}
+
+type SecurityEventsConfig interface {
+	Retention() time.Duration
+	PurgeInterval() time.Duration
+}
+
+type UserBansConfig interface {
+	LiftInterval() time.Duration
+}
+
+type OrganizationsConfig interface {
+	InvitationExpiresIn() time.Duration
+}
+
+type RefreshCookieConfig interface {
+	Enabled() bool
+	Name() string
+	Path() string
+	Domain() string
+	Secure() bool
+	SameSite() string
+	CSRFCookieName() string
+	CSRFHeaderName() string
+	Origins() []string
+}
+
 // config holds the actual configuration implementation
 type config struct {
 	AppCfg      appConfig      `yaml:"app"`
@@ -133,6 +274,16 @@ type config struct {
 	UploadCfg   uploadConfig   `yaml:"upload"`
 	ExternalCfg externalConfig `yaml:"external"`
 	RPCCfg      rpcConfig      `yaml:"rpc"`
+
+	PasswordPolicyCfg passwordPolicyConfig `yaml:"password_policy"`
+	PasswordHashCfg   passwordHashConfig   `yaml:"password_hash"`
+	OAuthCfg          oauthConfig          `yaml:"oauth"`
+	WebAuthnCfg       webAuthnConfig       `yaml:"webauthn"`
+	LoginAlertsCfg    loginAlertsConfig    `yaml:"login_alerts"`
+	SecurityEventsCfg securityEventsConfig `yaml:"security_events"`
+	UserBansCfg       userBansConfig       `yaml:"user_bans"`
+	RefreshCookieCfg  refreshCookieConfig  `yaml:"refresh_cookie"`
+	OrganizationsCfg  organizationsConfig  `yaml:"organizations"`
 }
 
 func (c *config) App() AppConfig {
@@ -175,6 +326,42 @@ func (c *config) RPC() RPCConfig {
 	return &c.RPCCfg
 }
 
+func (c *config) PasswordPolicy() PasswordPolicyConfig {
+	return &c.PasswordPolicyCfg
+}
+
+func (c *config) PasswordHash() PasswordHashConfig {
+	return &c.PasswordHashCfg
+}
+
+func (c *config) OAuth() OAuthConfig {
+	return &c.OAuthCfg
+}
+
+func (c *config) WebAuthn() WebAuthnConfig {
+	return &c.WebAuthnCfg
+}
+
+func (c *config) LoginAlerts() LoginAlertsConfig {
+	return &c.LoginAlertsCfg
+}
+
+func (c *config) SecurityEvents() SecurityEventsConfig {
+	return &c.SecurityEventsCfg
+}
+
+func (c *config) UserBans() UserBansConfig {
+	return &c.UserBansCfg
+}
+
+func (c *config) RefreshCookie() RefreshCookieConfig {
+	return &c.RefreshCookieCfg
+}
+
+func (c *config) Organizations() OrganizationsConfig {
+	return &c.OrganizationsCfg
+}
+
 type appConfig struct {
 	NameStr        string `yaml:"name"`
 	VersionStr     string `yaml:"version"`
@@ -185,13 +372,34 @@ type appConfig struct {
 	AccessTokenExpiresInDur time.Duration `yaml:"access_token_expires_in"`
 	AccessTokenSecretStr    string        `env:"ACCESS_TOKEN_SECRET"`
 
+	JWTKeysArr []jwtKeyConfig `yaml:"jwt_keys"`
+
 	RefreshTokenExpiresInDur time.Duration `yaml:"refresh_token_expires_in"`
 	RefreshTokenSecretStr    string        `env:"REFRESH_TOKEN_SECRET"`
 
-	SessionLimitPerUserInt      int  `yaml:"session_limit_per_user"`
-	UserSessionLimitEnabledBool bool `yaml:"user_session_limit_enabled"`
+	RefreshTokenReuseGracePeriodDur time.Duration `yaml:"refresh_token_reuse_grace_period" env-default:"10s"`
+
+	EmailVerificationTokenExpiresInDur time.Duration `yaml:"email_verification_token_expires_in" env-default:"24h"`
+	PasswordResetTokenExpiresInDur     time.Duration `yaml:"password_reset_token_expires_in" env-default:"1h"`
+	MFAChallengeExpiresInDur           time.Duration `yaml:"mfa_challenge_expires_in" env-default:"5m"`
+	MFASecretKeyStr                    string        `env:"MFA_SECRET_KEY"`
+
+	LoginMaxFailedAttemptsInt   int           `yaml:"login_max_failed_attempts" env-default:"5"`
+	LoginIPMaxFailedAttemptsInt int           `yaml:"login_ip_max_failed_attempts" env-default:"20"`
+	LoginFailureWindowDur       time.Duration `yaml:"login_failure_window" env-default:"15m"`
+	LoginFailureBaseDelayDur    time.Duration `yaml:"login_failure_base_delay" env-default:"1s"`
+	LoginLockoutDurationDur     time.Duration `yaml:"login_lockout_duration" env-default:"15m"`
 
-	APIKeyStr string `env:"API_KEY" env-default:""`
+	MagicLinkExpiresInDur          time.Duration `yaml:"magic_link_expires_in" env-default:"15m"`
+	MagicLinkMaxRequestsInt        int           `yaml:"magic_link_max_requests" env-default:"3"`
+	MagicLinkRequestWindowDur      time.Duration `yaml:"magic_link_request_window" env-default:"15m"`
+	MagicLinkRequireSameDeviceBool bool          `yaml:"magic_link_require_same_device"`
+	ImpersonationExpiresInDur      time.Duration `yaml:"impersonation_expires_in" env-default:"30m"`
+
+	SessionMaxLifetimeDur       time.Duration `yaml:"session_max_lifetime" env-default:"2160h"`
+	SessionLimitPerUserInt      int           `yaml:"session_limit_per_user"`
+	UserSessionLimitEnabledBool bool          `yaml:"user_session_limit_enabled"`
+	SessionLimitPolicyStr       string        `yaml:"session_limit_policy" env-default:"evict_oldest"`
 
 	SysAdminDefaultPhoneStr    string `env:"SYSTEM_ADMIN_DEFAULT_PHONE" env-default:""`
 	SysAdminDefaultEmailStr    string `env:"SYSTEM_ADMIN_DEFAULT_EMAIL" env-default:""`
@@ -222,6 +430,14 @@ func (c *appConfig) AccessTokenSecret() string {
 	return c.AccessTokenSecretStr
 }
 
+func (c *appConfig) JWTKeys() []JWTKeyConfig {
+	keys := make([]JWTKeyConfig, 0, len(c.JWTKeysArr))
+	for i := range c.JWTKeysArr {
+		keys = append(keys, &c.JWTKeysArr[i])
+	}
+	return keys
+}
+
 func (c *appConfig) RefreshTokenExpiresIn() time.Duration {
 	return c.RefreshTokenExpiresInDur
 }
@@ -230,10 +446,74 @@ func (c *appConfig) RefreshTokenSecret() string {
 	return c.RefreshTokenSecretStr
 }
 
+func (c *appConfig) RefreshTokenReuseGracePeriod() time.Duration {
+	return c.RefreshTokenReuseGracePeriodDur
+}
+
 func (c *appConfig) TokenIssuer() string {
 	return c.TokenIssuerStr
 }
 
+func (c *appConfig) EmailVerificationTokenExpiresIn() time.Duration {
+	return c.EmailVerificationTokenExpiresInDur
+}
+
+func (c *appConfig) PasswordResetTokenExpiresIn() time.Duration {
+	return c.PasswordResetTokenExpiresInDur
+}
+
+func (c *appConfig) MFAChallengeExpiresIn() time.Duration {
+	return c.MFAChallengeExpiresInDur
+}
+
+func (c *appConfig) MFASecretKey() string {
+	return c.MFASecretKeyStr
+}
+
+func (c *appConfig) LoginMaxFailedAttempts() int {
+	return c.LoginMaxFailedAttemptsInt
+}
+
+func (c *appConfig) LoginIPMaxFailedAttempts() int {
+	return c.LoginIPMaxFailedAttemptsInt
+}
+
+func (c *appConfig) LoginFailureWindow() time.Duration {
+	return c.LoginFailureWindowDur
+}
+
+func (c *appConfig) LoginFailureBaseDelay() time.Duration {
+	return c.LoginFailureBaseDelayDur
+}
+
+func (c *appConfig) LoginLockoutDuration() time.Duration {
+	return c.LoginLockoutDurationDur
+}
+
+func (c *appConfig) MagicLinkExpiresIn() time.Duration {
+	return c.MagicLinkExpiresInDur
+}
+
+func (c *appConfig) MagicLinkMaxRequests() int {
+	return c.MagicLinkMaxRequestsInt
+}
+
+func (c *appConfig) MagicLinkRequestWindow() time.Duration {
+	return c.MagicLinkRequestWindowDur
+}
+
+func (c *appConfig) MagicLinkRequireSameDevice() bool {
+	return c.MagicLinkRequireSameDeviceBool
+}
+
+func (c *appConfig) ImpersonationExpiresIn() time.Duration {
+	return c.ImpersonationExpiresInDur
+}
+
+func (c *appConfig) SessionMaxLifetime() time.Duration {
+	return c.SessionMaxLifetimeDur
+}
+
 func (c *appConfig) SessionLimitPerUser() int {
 	return c.SessionLimitPerUserInt
 }
@@ -242,8 +522,8 @@ func (c *appConfig) UserSessionLimitEnabled() bool {
 	return c.UserSessionLimitEnabledBool
 }
 
-func (c *appConfig) APIKey() string {
-	return c.APIKeyStr
+func (c *appConfig) SessionLimitPolicy() string {
+	return c.SessionLimitPolicyStr
 }
 
 func (c *appConfig) SystemAdminDefaultPhone() string {
@@ -258,6 +538,39 @@ func (c *appConfig) SystemAdminDefaultPassword() string {
 	return c.SysAdminDefaultPasswordStr
 }
 
+type jwtKeyConfig struct {
+	KIDStr            string    `yaml:"kid"`
+	AlgorithmStr      string    `yaml:"algorithm"`
+	PrivateKeyFileStr string    `yaml:"private_key_file"`
+	PublicKeyFileStr  string    `yaml:"public_key_file"`
+	ActiveAtTime      time.Time `yaml:"active_at"` // Empty means active immediately
+	RetireAtTime      time.Time `yaml:"retire_at"` // Empty means never retired
+}
+
+func (k *jwtKeyConfig) KID() string {
+	return k.KIDStr
+}
+
+func (k *jwtKeyConfig) Algorithm() string {
+	return k.AlgorithmStr
+}
+
+func (k *jwtKeyConfig) PrivateKeyFile() string {
+	return k.PrivateKeyFileStr
+}
+
+func (k *jwtKeyConfig) PublicKeyFile() string {
+	return k.PublicKeyFileStr
+}
+
+func (k *jwtKeyConfig) ActiveAt() time.Time {
+	return k.ActiveAtTime
+}
+
+func (k *jwtKeyConfig) RetireAt() time.Time {
+	return k.RetireAtTime
+}
+
 type serverConfig struct {
 	HostStr           string   `yaml:"host"`
 	DomainStr         string   `yaml:"domain"`
@@ -442,6 +755,7 @@ type otpConfig struct {
 	ExpiresInStr         string `yaml:"expires_in"`
 	RetryBaseWaitTimeStr string `yaml:"retry_base_wait_time"`
 	RetryMaxWaitTimeStr  string `yaml:"retry_max_wait_time"`
+	MaxAttemptsInt       int    `yaml:"max_attempts" env-default:"5"`
 }
 
 func (o *otpConfig) ExpiresIn() time.Duration {
@@ -459,6 +773,10 @@ func (o *otpConfig) RetryMaxWaitTime() time.Duration {
 	return duration
 }
 
+func (o *otpConfig) MaxAttempts() int {
+	return o.MaxAttemptsInt
+}
+
 type uploadConfig struct {
 	ProviderStr        string `yaml:"provider"`
 	LocalDirStr        string `yaml:"local_dir"`
@@ -552,3 +870,301 @@ func (r *rpcConfig) Host() string {
 func (r *rpcConfig) Port() int {
 	return r.PortInt
 }
+
+// passwordPolicyConfig has no defaults for its switches, a default would override an explicit false
+type passwordPolicyConfig struct {
+	MinLengthInt            int  `yaml:"min_length" env-default:"8"`
+	MaxLengthInt            int  `yaml:"max_length" env-default:"128"`
+	RequireUppercaseBool    bool `yaml:"require_uppercase"`
+	RequireLowercaseBool    bool `yaml:"require_lowercase"`
+	RequireDigitBool        bool `yaml:"require_digit"`
+	RequireSymbolBool       bool `yaml:"require_symbol"`
+	DenyCommonPasswordsBool bool `yaml:"deny_common_passwords"`
+	DenyPersonalInfoBool    bool `yaml:"deny_personal_info"`
+	HistorySizeInt          int  `yaml:"history_size"`
+}
+
+func (p *passwordPolicyConfig) MinLength() int {
+	return p.MinLengthInt
+}
+
+func (p *passwordPolicyConfig) MaxLength() int {
+	return p.MaxLengthInt
+}
+
+func (p *passwordPolicyConfig) RequireUppercase() bool {
+	return p.RequireUppercaseBool
+}
+
+func (p *passwordPolicyConfig) RequireLowercase() bool {
+	return p.RequireLowercaseBool
+}
+
+func (p *passwordPolicyConfig) RequireDigit() bool {
+	return p.RequireDigitBool
+}
+
+func (p *passwordPolicyConfig) RequireSymbol() bool {
+	return p.RequireSymbolBool
+}
+
+func (p *passwordPolicyConfig) DenyCommonPasswords() bool {
+	return p.DenyCommonPasswordsBool
+}
+
+func (p *passwordPolicyConfig) DenyPersonalInfo() bool {
+	return p.DenyPersonalInfoBool
+}
+
+func (p *passwordPolicyConfig) HistorySize() int {
+	return p.HistorySizeInt
+}
+
+type passwordHashConfig struct {
+	AlgorithmStr         string `yaml:"algorithm" env-default:"argon2id"`
+	Argon2MemoryInt      uint32 `yaml:"argon2_memory" env-default:"65536"`
+	Argon2IterationsInt  uint32 `yaml:"argon2_iterations" env-default:"3"`
+	Argon2ParallelismInt uint8  `yaml:"argon2_parallelism" env-default:"2"`
+	Argon2SaltLengthInt  uint32 `yaml:"argon2_salt_length" env-default:"16"`
+	Argon2KeyLengthInt   uint32 `yaml:"argon2_key_length" env-default:"32"`
+	BcryptCostInt        int    `yaml:"bcrypt_cost" env-default:"10"`
+}
+
+func (p *passwordHashConfig) Algorithm() string {
+	return p.AlgorithmStr
+}
+
+func (p *passwordHashConfig) Argon2Memory() uint32 {
+	return p.Argon2MemoryInt
+}
+
+func (p *passwordHashConfig) Argon2Iterations() uint32 {
+	return p.Argon2IterationsInt
+}
+
+func (p *passwordHashConfig) Argon2Parallelism() uint8 {
+	return p.Argon2ParallelismInt
+}
+
+func (p *passwordHashConfig) Argon2SaltLength() uint32 {
+	return p.Argon2SaltLengthInt
+}
+
+func (p *passwordHashConfig) Argon2KeyLength() uint32 {
+	return p.Argon2KeyLengthInt
+}
+
+func (p *passwordHashConfig) BcryptCost() int {
+	return p.BcryptCostInt
+}
+
+type oauthConfig struct {
+	StateExpiresInDur time.Duration         `yaml:"state_expires_in" env-default:"10m"`
+	ProvidersArr      []oauthProviderConfig `yaml:"providers"`
+}
+
+func (c *oauthConfig) StateExpiresIn() time.Duration {
+	return c.StateExpiresInDur
+}
+
+func (c *oauthConfig) Providers() []OAuthProviderConfig {
+	providers := make([]OAuthProviderConfig, 0, len(c.ProvidersArr))
+	for i := range c.ProvidersArr {
+		providers = append(providers, &c.ProvidersArr[i])
+	}
+	return providers
+}
+
+type oauthProviderConfig struct {
+	NameStr            string   `yaml:"name"`
+	TypeStr            string   `yaml:"type"`
+	ClientIDStr        string   `yaml:"client_id"`
+	ClientSecretEnvStr string   `yaml:"client_secret_env"` // Environment variable holding the client secret
+	IssuerStr          string   `yaml:"issuer"`
+	AuthURLStr         string   `yaml:"auth_url"`
+	TokenURLStr        string   `yaml:"token_url"`
+	JWKSURLStr         string   `yaml:"jwks_url"`
+	APIURLStr          string   `yaml:"api_url"`
+	ScopesArr          []string `yaml:"scopes"`
+	RedirectURIsArr    []string `yaml:"redirect_uris"`
+}
+
+func (p *oauthProviderConfig) Name() string {
+	return p.NameStr
+}
+
+func (p *oauthProviderConfig) Type() string {
+	return p.TypeStr
+}
+
+func (p *oauthProviderConfig) ClientID() string {
+	return p.ClientIDStr
+}
+
+func (p *oauthProviderConfig) ClientSecret() string {
+	if p.ClientSecretEnvStr == "" {
+		return ""
+	}
+	return os.Getenv(p.ClientSecretEnvStr)
+}
+
+func (p *oauthProviderConfig) Issuer() string {
+	return p.IssuerStr
+}
+
+func (p *oauthProviderConfig) AuthURL() string {
+	return p.AuthURLStr
+}
+
+func (p *oauthProviderConfig) TokenURL() string {
+	return p.TokenURLStr
+}
+
+func (p *oauthProviderConfig) JWKSURL() string {
+	return p.JWKSURLStr
+}
+
+func (p *oauthProviderConfig) APIURL() string {
+	return p.APIURLStr
+}
+
+func (p *oauthProviderConfig) Scopes() []string {
+	return p.ScopesArr
+}
+
+func (p *oauthProviderConfig) RedirectURIs() []string {
+	return p.RedirectURIsArr
+}
+
+type webAuthnConfig struct {
+	RPIDStr               string        `yaml:"rp_id"`
+	RPNameStr             string        `yaml:"rp_name"`
+	OriginsArr            []string      `yaml:"origins"`
+	ChallengeExpiresInDur time.Duration `yaml:"challenge_expires_in" env-default:"5m"`
+	UserVerificationStr   string        `yaml:"user_verification" env-default:"preferred"`
+}
+
+func (c *webAuthnConfig) RPID() string {
+	return c.RPIDStr
+}
+
+func (c *webAuthnConfig) RPName() string {
+	return c.RPNameStr
+}
+
+func (c *webAuthnConfig) Origins() []string {
+	return c.OriginsArr
+}
+
+func (c *webAuthnConfig) ChallengeExpiresIn() time.Duration {
+	return c.ChallengeExpiresInDur
+}
+
+func (c *webAuthnConfig) UserVerification() string {
+	return c.UserVerificationStr
+}
+
+type loginAlertsConfig struct {
+	EnabledBool          bool          `yaml:"enabled"`
+	HistorySizeInt       int           `yaml:"history_size" env-default:"20"`
+	IPv4PrefixLengthInt  int           `yaml:"ipv4_prefix_length" env-default:"24"`
+	IPv6PrefixLengthInt  int           `yaml:"ipv6_prefix_length" env-default:"48"`
+	DenyLinkExpiresInDur time.Duration `yaml:"deny_link_expires_in" env-default:"168h"`
+}
+
+func (c *loginAlertsConfig) Enabled() bool {
+	return c.EnabledBool
+}
+
+func (c *loginAlertsConfig) HistorySize() int {
+	return c.HistorySizeInt
+}
+
+func (c *loginAlertsConfig) IPv4PrefixLength() int {
+	return c.IPv4PrefixLengthInt
+}
+
+func (c *loginAlertsConfig) IPv6PrefixLength() int {
+	return c.IPv6PrefixLengthInt
+}
+
+func (c *loginAlertsConfig) DenyLinkExpiresIn() time.Duration {
+	return c.DenyLinkExpiresInDur
+}
+
+type securityEventsConfig struct {
+	RetentionDur     time.Duration `yaml:"retention" env-default:"2160h"`
+	PurgeIntervalDur time.Duration `yaml:"purge_interval" env-default:"1h"`
+}
+
+func (c *securityEventsConfig) Retention() time.Duration {
+	return c.RetentionDur
+}
+
+func (c *securityEventsConfig) PurgeInterval() time.Duration {
+	return c.PurgeIntervalDur
+}
+
+type userBansConfig struct {
+	LiftIntervalDur time.Duration `yaml:"lift_interval" env-default:"1m"`
+}
+
+func (c *userBansConfig) LiftInterval() time.Duration {
+	return c.LiftIntervalDur
+}
+
+type organizationsConfig struct {
+	InvitationExpiresInDur time.Duration `yaml:"invitation_expires_in" env-default:"168h"`
+}
+
+func (c *organizationsConfig) InvitationExpiresIn() time.Duration {
+	return c.InvitationExpiresInDur
+}
+
+type refreshCookieConfig struct {
+	EnabledBool       bool     `yaml:"enabled"`
+	NameStr           string   `yaml:"name" env-default:"refresh_token"`
+	PathStr           string   `yaml:"path" env-default:"/api/v1/auth/refresh-token"`
+	DomainStr         string   `yaml:"domain"`
+	SecureBool        bool     `yaml:"secure"`
+	SameSiteStr       string   `yaml:"same_site" env-default:"strict"`
+	CSRFCookieNameStr string   `yaml:"csrf_cookie_name" env-default:"csrf_token"`
+	CSRFHeaderNameStr string   `yaml:"csrf_header_name" env-default:"X-CSRF-Token"`
+	OriginsArr        []string `yaml:"origins"`
+}
+
+func (c *refreshCookieConfig) Enabled() bool {
+	return c.EnabledBool
+}
+
+func (c *refreshCookieConfig) Name() string {
+	return c.NameStr
+}
+
+func (c *refreshCookieConfig) Path() string {
+	return c.PathStr
+}
+
+func (c *refreshCookieConfig) Domain() string {
+	return c.DomainStr
+}
+
+func (c *refreshCookieConfig) Secure() bool {
+	return c.SecureBool
+}
+
+func (c *refreshCookieConfig) SameSite() string {
+	return c.SameSiteStr
+}
+
+func (c *refreshCookieConfig) CSRFCookieName() string {
+	return c.CSRFCookieNameStr
+}
+
+func (c *refreshCookieConfig) CSRFHeaderName() string {
+	return c.CSRFHeaderNameStr
+}
+
+func (c *refreshCookieConfig) Origins() []string {
+	return c.OriginsArr
+}
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"go-clean-arch/domain"
 	"net"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -49,6 +51,33 @@ func Validate(cfg Config) error {
 	if err := validateRPC(cfg.RPC()); err != nil {
 		return fmt.Errorf("rpc config validation failed: %w", err)
 	}
+	if err := validatePasswordPolicy(cfg.PasswordPolicy()); err != nil {
+		return fmt.Errorf("password policy config validation failed: %w", err)
+	}
+	if err := validatePasswordHash(cfg.PasswordHash(), cfg.PasswordPolicy()); err != nil {
+		return fmt.Errorf("password hash config validation failed: %w", err)
+	}
+	if err := validateOAuth(cfg.OAuth()); err != nil {
+		return fmt.Errorf("oauth config validation failed: %w", err)
+	}
+	if err := validateWebAuthn(cfg.WebAuthn()); err != nil {
+		return fmt.Errorf("webauthn config validation failed: %w", err)
+	}
+	if err := validateLoginAlerts(cfg.LoginAlerts()); err != nil {
+		return fmt.Errorf("login_alerts config validation failed: %w", err)
+	}
+	if err := validateSecurityEvents(cfg.SecurityEvents()); err != nil {
+		return fmt.Errorf("security_events config validation failed: %w", err)
+	}
+	if err := validateUserBans(cfg.UserBans()); err != nil {
+		return fmt.Errorf("user_bans config validation failed: %w", err)
+	}
+	if err := validateRefreshCookie(cfg.RefreshCookie()); err != nil {
+		return fmt.Errorf("refresh_cookie config validation failed: %w", err)
+	}
+	if err := validateOrganizations(cfg.Organizations()); err != nil {
+		return fmt.Errorf("organizations config validation failed: %w", err)
+	}
 	return nil
 }
 
@@ -75,25 +104,91 @@ func validateApp(cfg AppConfig) error {
 		return fmt.Errorf("refresh_token_expires_in must be positive")
 	}
 
+	if cfg.RefreshTokenReuseGracePeriod() < 0 {
+		return fmt.Errorf("refresh_token_reuse_grace_period must not be negative")
+	}
+
+	if cfg.EmailVerificationTokenExpiresIn() <= 0 {
+		return fmt.Errorf("email_verification_token_expires_in must be positive")
+	}
+
+	if cfg.PasswordResetTokenExpiresIn() <= 0 {
+		return fmt.Errorf("password_reset_token_expires_in must be positive")
+	}
+
+	if cfg.MFAChallengeExpiresIn() <= 0 {
+		return fmt.Errorf("mfa_challenge_expires_in must be positive")
+	}
+
+	if cfg.LoginMaxFailedAttempts() <= 0 {
+		return fmt.Errorf("login_max_failed_attempts must be positive")
+	}
+
+	if cfg.LoginIPMaxFailedAttempts() < cfg.LoginMaxFailedAttempts() {
+		return fmt.Errorf("login_ip_max_failed_attempts must be greater than or equal to login_max_failed_attempts")
+	}
+
+	if cfg.LoginFailureWindow() <= 0 {
+		return fmt.Errorf("login_failure_window must be positive")
+	}
+
+	if cfg.LoginFailureBaseDelay() < 0 {
+		return fmt.Errorf("login_failure_base_delay must not be negative")
+	}
+
+	if cfg.LoginLockoutDuration() <= 0 {
+		return fmt.Errorf("login_lockout_duration must be positive")
+	}
+
+	if cfg.MagicLinkExpiresIn() <= 0 {
+		return fmt.Errorf("magic_link_expires_in must be positive")
+	}
+
+	if cfg.MagicLinkMaxRequests() <= 0 {
+		return fmt.Errorf("magic_link_max_requests must be positive")
+	}
+
+	if cfg.MagicLinkRequestWindow() <= 0 {
+		return fmt.Errorf("magic_link_request_window must be positive")
+	}
+
+	if cfg.ImpersonationExpiresIn() <= 0 {
+		return fmt.Errorf("impersonation_expires_in must be positive")
+	}
+
 	if cfg.SessionLimitPerUser() <= 0 {
 		return fmt.Errorf("session_limit_per_user must be positive")
 	}
 
+	if !domain.SessionLimitPolicy(cfg.SessionLimitPolicy()).IsValid() {
+		return fmt.Errorf("session_limit_policy=%s is invalid, only accept `%s`, `%s`", cfg.SessionLimitPolicy(), domain.SessionLimitPolicyEvictOldest, domain.SessionLimitPolicyReject)
+	}
+
+	// A session is extended on every refresh, up to its absolute lifetime
+	if cfg.SessionMaxLifetime() < cfg.RefreshTokenExpiresIn() {
+		return fmt.Errorf("session_max_lifetime must be greater than or equal to refresh_token_expires_in")
+	}
+
 	// Validate JWT access token is shorter than refresh token
 	if cfg.AccessTokenExpiresIn() >= cfg.RefreshTokenExpiresIn() {
 		return fmt.Errorf("access_token_expires_in must be less than refresh_token_expires_in")
 	}
 
-	if cfg.AccessTokenSecret() == "" {
-		return fmt.Errorf("access token secret is required, please set ACCESS_TOKEN_SECRET env variable")
+	if err := validateJWTKeys(cfg.JWTKeys(), time.Now()); err != nil {
+		return err
+	}
+
+	// HS256 with the shared secret is used when no signing key is configured
+	if len(cfg.JWTKeys()) == 0 && cfg.AccessTokenSecret() == "" {
+		return fmt.Errorf("access token secret is required when no jwt_keys are configured, please set ACCESS_TOKEN_SECRET env variable")
 	}
 
 	if cfg.RefreshTokenSecret() == "" {
 		return fmt.Errorf("refresh token secret is required, please set REFRESH_TOKEN_SECRET env variable")
 	}
 
-	if cfg.APIKey() == "" {
-		return fmt.Errorf("api key is required, please set API_KEY env variable")
+	if cfg.MFASecretKey() == "" {
+		return fmt.Errorf("mfa secret key is required, please set MFA_SECRET_KEY env variable")
 	}
 
 	if cfg.SystemAdminDefaultPhone() == "" {
@@ -111,6 +206,46 @@ func validateApp(cfg AppConfig) error {
 	return nil
 }
 
+// validateJWTKeys checks the signing keys, one of them has to be able to sign tokens at now.
+func validateJWTKeys(keys []JWTKeyConfig, now time.Time) error {
+	kids := make(map[string]bool, len(keys))
+	canSign := false
+	for _, key := range keys {
+		if key.KID() == "" {
+			return fmt.Errorf("jwt_keys: kid is required")
+		}
+		if kids[key.KID()] {
+			return fmt.Errorf("jwt_keys: duplicate kid %s", key.KID())
+		}
+		kids[key.KID()] = true
+
+		switch key.Algorithm() {
+		case domain.JWTAlgorithmRS256, domain.JWTAlgorithmEdDSA:
+		default:
+			return fmt.Errorf("jwt_keys: algorithm=%s of key %s is invalid, only accept `%s`, `%s`", key.Algorithm(), key.KID(), domain.JWTAlgorithmRS256, domain.JWTAlgorithmEdDSA)
+		}
+
+		if key.PrivateKeyFile() == "" && key.PublicKeyFile() == "" {
+			return fmt.Errorf("jwt_keys: key %s requires private_key_file or public_key_file", key.KID())
+		}
+
+		if !key.RetireAt().IsZero() && !key.RetireAt().After(key.ActiveAt()) {
+			return fmt.Errorf("jwt_keys: retire_at of key %s must be after its active_at", key.KID())
+		}
+
+		// Same rule as the provider uses to pick the signing key
+		retired := !key.RetireAt().IsZero() && !now.Before(key.RetireAt())
+		if key.PrivateKeyFile() != "" && !retired && !key.ActiveAt().After(now) {
+			canSign = true
+		}
+	}
+
+	if len(keys) > 0 && !canSign {
+		return fmt.Errorf("jwt_keys: no key with a private_key_file is active to sign tokens, check their active_at and retire_at")
+	}
+	return nil
+}
+
 func validateServer(cfg ServerConfig) error {
 	if cfg.Host() == "" {
 		return fmt.Errorf("host is required")
@@ -318,6 +453,10 @@ func validateOTP(cfg OTPConfig) error {
 		return fmt.Errorf("otp retry_max_wait_time must be positive")
 	}
 
+	if cfg.MaxAttempts() <= 0 {
+		return fmt.Errorf("otp max_attempts must be positive")
+	}
+
 	if cfg.RetryBaseWaitTime() >= cfg.RetryMaxWaitTime() {
 		return fmt.Errorf("retry_base_wait_time must be less than retry_max_wait_time")
 	}
@@ -412,3 +551,226 @@ func validateRPC(cfg RPCConfig) error {
 	}
 	return nil
 }
+
+func validatePasswordPolicy(cfg PasswordPolicyConfig) error {
+	if cfg.MinLength() <= 0 {
+		return fmt.Errorf("min_length must be positive")
+	}
+
+	if cfg.MaxLength() < cfg.MinLength() {
+		return fmt.Errorf("max_length must not be less than min_length")
+	}
+
+	if cfg.HistorySize() < 0 {
+		return fmt.Errorf("history_size must not be negative")
+	}
+
+	return nil
+}
+
+func validatePasswordHash(cfg PasswordHashConfig, policyCfg PasswordPolicyConfig) error {
+	switch cfg.Algorithm() {
+	case domain.PasswordHashAlgorithmArgon2id, domain.PasswordHashAlgorithmBcrypt:
+	default:
+		return fmt.Errorf("algorithm %s is invalid, only accept `%s`, `%s`", cfg.Algorithm(), domain.PasswordHashAlgorithmArgon2id, domain.PasswordHashAlgorithmBcrypt)
+	}
+
+	// Lower bounds follow the OWASP recommendations for Argon2id
+	if cfg.Argon2Memory() < 19*1024 {
+		return fmt.Errorf("argon2_memory must be at least 19456 KiB")
+	}
+
+	if cfg.Argon2Iterations() == 0 {
+		return fmt.Errorf("argon2_iterations must be positive")
+	}
+
+	if cfg.Argon2Parallelism() == 0 {
+		return fmt.Errorf("argon2_parallelism must be positive")
+	}
+
+	if cfg.Argon2SaltLength() < 16 {
+		return fmt.Errorf("argon2_salt_length must be at least 16 bytes")
+	}
+
+	if cfg.Argon2KeyLength() < 16 {
+		return fmt.Errorf("argon2_key_length must be at least 16 bytes")
+	}
+
+	if cfg.BcryptCost() < 10 || cfg.BcryptCost() > 31 {
+		return fmt.Errorf("bcrypt_cost must be between 10 and 31")
+	}
+
+	// bcrypt cannot hash more than 72 bytes
+	if cfg.Algorithm() == domain.PasswordHashAlgorithmBcrypt && policyCfg.MaxLength() > 72 {
+		return fmt.Errorf("password_policy max_length must not exceed 72 with bcrypt")
+	}
+
+	return nil
+}
+
+func validateOAuth(cfg OAuthConfig) error {
+	if cfg.StateExpiresIn() <= 0 {
+		return fmt.Errorf("state_expires_in must be positive")
+	}
+
+	names := make(map[string]bool, len(cfg.Providers()))
+	for _, provider := range cfg.Providers() {
+		if provider.Name() == "" {
+			return fmt.Errorf("providers: name is required")
+		}
+		if names[provider.Name()] {
+			return fmt.Errorf("providers: duplicate name %s", provider.Name())
+		}
+		names[provider.Name()] = true
+
+		switch provider.Type() {
+		case OAuthProviderGoogle, OAuthProviderGitHub:
+		case OAuthProviderOIDC:
+			if provider.Issuer() == "" || provider.AuthURL() == "" || provider.TokenURL() == "" || provider.JWKSURL() == "" {
+				return fmt.Errorf("providers: oidc provider %s requires issuer, auth_url, token_url and jwks_url", provider.Name())
+			}
+		default:
+			return fmt.Errorf("providers: type=%s of provider %s is invalid, only accept `%s`, `%s`, `%s`", provider.Type(), provider.Name(), OAuthProviderGoogle, OAuthProviderGitHub, OAuthProviderOIDC)
+		}
+
+		if provider.ClientID() == "" {
+			return fmt.Errorf("providers: provider %s requires client_id", provider.Name())
+		}
+		if provider.ClientSecret() == "" {
+			return fmt.Errorf("providers: client secret of provider %s is empty, please set the env variable named by client_secret_env", provider.Name())
+		}
+		if len(provider.RedirectURIs()) == 0 {
+			return fmt.Errorf("providers: provider %s requires at least one redirect_uris entry", provider.Name())
+		}
+		for _, uri := range provider.RedirectURIs() {
+			parsed, err := url.Parse(uri)
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				return fmt.Errorf("providers: redirect uri %s of provider %s must be an absolute URL", uri, provider.Name())
+			}
+		}
+	}
+	return nil
+}
+
+func validateWebAuthn(cfg WebAuthnConfig) error {
+	if cfg.RPID() == "" {
+		return fmt.Errorf("rp_id is required")
+	}
+
+	if cfg.RPName() == "" {
+		return fmt.Errorf("rp_name is required")
+	}
+
+	if len(cfg.Origins()) == 0 {
+		return fmt.Errorf("at least one origin is required")
+	}
+	for _, origin := range cfg.Origins() {
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Path != "" {
+			return fmt.Errorf("origin %s must be a scheme and host, e.g. https://app.example.com", origin)
+		}
+		// The RP ID must be the host of the origin or one of its parent domains
+		if host := parsed.Hostname(); host != cfg.RPID() && !strings.HasSuffix(host, "."+cfg.RPID()) {
+			return fmt.Errorf("origin %s is not within rp_id %s", origin, cfg.RPID())
+		}
+	}
+
+	if cfg.ChallengeExpiresIn() <= 0 {
+		return fmt.Errorf("challenge_expires_in must be positive")
+	}
+
+	switch cfg.UserVerification() {
+	case WebAuthnUserVerificationRequired, WebAuthnUserVerificationPreferred, WebAuthnUserVerificationDiscouraged:
+	default:
+		return fmt.Errorf("user_verification=%s is invalid, only accept `%s`, `%s`, `%s`", cfg.UserVerification(), WebAuthnUserVerificationRequired, WebAuthnUserVerificationPreferred, WebAuthnUserVerificationDiscouraged)
+	}
+	return nil
+}
+
+func validateLoginAlerts(cfg LoginAlertsConfig) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	if cfg.HistorySize() <= 0 {
+		return fmt.Errorf("history_size must be positive")
+	}
+
+	if cfg.IPv4PrefixLength() < 0 || cfg.IPv4PrefixLength() > 32 {
+		return fmt.Errorf("ipv4_prefix_length must be between 0 and 32")
+	}
+
+	if cfg.IPv6PrefixLength() < 0 || cfg.IPv6PrefixLength() > 128 {
+		return fmt.Errorf("ipv6_prefix_length must be between 0 and 128")
+	}
+
+	if cfg.DenyLinkExpiresIn() <= 0 {
+		return fmt.Errorf("deny_link_expires_in must be positive")
+	}
+	return nil
+}
+
+func validateSecurityEvents(cfg SecurityEventsConfig) error {
+	if cfg.Retention() < 24*time.Hour {
+		return fmt.Errorf("retention must be at least 24h")
+	}
+
+	if cfg.PurgeInterval() < time.Minute {
+		return fmt.Errorf("purge_interval must be at least 1m")
+	}
+	return nil
+}
+
+func validateUserBans(cfg UserBansConfig) error {
+	if cfg.LiftInterval() < time.Second {
+		return fmt.Errorf("lift_interval must be at least 1s")
+	}
+	return nil
+}
+
+func validateRefreshCookie(cfg RefreshCookieConfig) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	if cfg.Name() == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if !strings.HasPrefix(cfg.Path(), "/") {
+		return fmt.Errorf("path must start with /")
+	}
+
+	switch cfg.SameSite() {
+	case RefreshCookieSameSiteStrict, RefreshCookieSameSiteLax:
+	case RefreshCookieSameSiteNone:
+		// Browsers drop SameSite=None cookies which are not Secure
+		if !cfg.Secure() {
+			return fmt.Errorf("same_site=%s requires secure", RefreshCookieSameSiteNone)
+		}
+	default:
+		return fmt.Errorf("same_site=%s is invalid, only accept `%s`, `%s`, `%s`", cfg.SameSite(), RefreshCookieSameSiteStrict, RefreshCookieSameSiteLax, RefreshCookieSameSiteNone)
+	}
+
+	if cfg.CSRFCookieName() == "" || cfg.CSRFHeaderName() == "" {
+		return fmt.Errorf("csrf_cookie_name and csrf_header_name are required")
+	}
+	if cfg.CSRFCookieName() == cfg.Name() {
+		return fmt.Errorf("csrf_cookie_name must differ from name")
+	}
+
+	for _, origin := range cfg.Origins() {
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || parsed.Path != "" {
+			return fmt.Errorf("origin %s must be a scheme and host, e.g. https://app.example.com", origin)
+		}
+	}
+	return nil
+}
+
+func validateOrganizations(cfg OrganizationsConfig) error {
+	if cfg.InvitationExpiresIn() < time.Hour {
+		return fmt.Errorf("invitation_expires_in must be at least 1h")
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"go-clean-arch/domain"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateJWTKeys(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	signer := func(kid string, activeAt, retireAt time.Time) jwtKeyConfig {
+		return jwtKeyConfig{
+			KIDStr:            kid,
+			AlgorithmStr:      domain.JWTAlgorithmEdDSA,
+			PrivateKeyFileStr: kid + ".pem",
+			ActiveAtTime:      activeAt,
+			RetireAtTime:      retireAt,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		keys    []jwtKeyConfig
+		wantErr string
+	}{
+		{name: "no keys"},
+		{name: "active signer", keys: []jwtKeyConfig{signer("a", time.Time{}, time.Time{})}},
+		{
+			name: "current signer and the next one",
+			keys: []jwtKeyConfig{
+				signer("a", now.AddDate(0, -1, 0), now.AddDate(0, 1, 0)),
+				signer("b", now.AddDate(0, 0, 7), time.Time{}),
+			},
+		},
+		{
+			name:    "only verify keys",
+			keys:    []jwtKeyConfig{{KIDStr: "a", AlgorithmStr: domain.JWTAlgorithmRS256, PublicKeyFileStr: "a.pem"}},
+			wantErr: "no key with a private_key_file is active",
+		},
+		{
+			name:    "signer not active yet",
+			keys:    []jwtKeyConfig{signer("a", now.Add(time.Hour), time.Time{})},
+			wantErr: "no key with a private_key_file is active",
+		},
+		{
+			name:    "signer retired",
+			keys:    []jwtKeyConfig{signer("a", now.AddDate(0, -2, 0), now.AddDate(0, -1, 0))},
+			wantErr: "no key with a private_key_file is active",
+		},
+		{
+			name:    "missing kid",
+			keys:    []jwtKeyConfig{signer("", time.Time{}, time.Time{})},
+			wantErr: "kid is required",
+		},
+		{
+			name:    "duplicate kid",
+			keys:    []jwtKeyConfig{signer("a", time.Time{}, time.Time{}), signer("a", time.Time{}, time.Time{})},
+			wantErr: "duplicate kid a",
+		},
+		{
+			name:    "unknown algorithm",
+			keys:    []jwtKeyConfig{{KIDStr: "a", AlgorithmStr: "HS256", PrivateKeyFileStr: "a.pem"}},
+			wantErr: "algorithm=HS256 of key a is invalid",
+		},
+		{
+			name:    "no key file",
+			keys:    []jwtKeyConfig{{KIDStr: "a", AlgorithmStr: domain.JWTAlgorithmEdDSA}},
+			wantErr: "requires private_key_file or public_key_file",
+		},
+		{
+			name:    "retired before active",
+			keys:    []jwtKeyConfig{signer("a", now, now.Add(-time.Hour))},
+			wantErr: "retire_at of key a must be after its active_at",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := make([]JWTKeyConfig, 0, len(tt.keys))
+			for i := range tt.keys {
+				keys = append(keys, &tt.keys[i])
+			}
+			err := validateJWTKeys(keys, now)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateJWTKeys() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateJWTKeys() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
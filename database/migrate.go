@@ -7,12 +7,39 @@ import (
 )
 
 func MigrateDB(db *gorm.DB) error {
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
+		&domain.Permission{},
+		&domain.Role{},
 		&domain.User{},
+		&domain.PasswordHistory{},
 		&domain.UserSession{},
+		&domain.RotatedRefreshToken{},
+		&domain.VerificationToken{},
+		&domain.UserMFA{},
+		&domain.MFARecoveryCode{},
+		&domain.APIKey{},
+		&domain.ExternalIdentity{},
+		&domain.WebAuthnCredential{},
+		&domain.Impersonation{},
+		&domain.SecurityEvent{},
 		&domain.File{},
 		&domain.FileLink{},
 		&domain.EmailLog{},
 		&domain.EmailTemplate{},
+		&domain.Organization{},
+		&domain.OrganizationMember{},
+		&domain.OrganizationInvitation{},
 	)
+	if err != nil {
+		return err
+	}
+	return normalizeUserEmails(db)
+}
+
+// normalizeUserEmails lowercases the emails stored before they were normalized. The unique index on
+// the lowercased email is in place, so no two accounts can end up with the same email.
+func normalizeUserEmails(db *gorm.DB) error {
+	return db.Model(&domain.User{}).
+		Where("email <> LOWER(email)").
+		UpdateColumn("email", gorm.Expr("LOWER(email)")).Error
 }
@@ -9,19 +9,57 @@ import (
 	"strings"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SQLHandler[T any, V any] struct {
 	db          *gorm.DB
 	applyFilter func(*gorm.DB, *V) *gorm.DB
+	// Column holding the organization of tenant-owned tables, empty for global tables
+	tenantColumn string
+	// Whether the table is declared global, see WithoutTenantScope
+	global bool
+}
+
+type SQLHandlerOption func(*sqlHandlerOptions)
+
+type sqlHandlerOptions struct {
+	tenantColumn string
+	global       bool
+}
+
+// WithTenantScope scopes every query of the handler to the organization of the tenant context,
+// through the given column. Queries without a tenant in their context fail with ErrTenantRequired,
+// so a repository cannot read or write the records of another organization by mistake. Created
+// records get the organization of the context when they implement domain.TenantOwned.
+//
+// Every handler declares its scope, with this option or WithoutTenantScope. A handler that declares
+// neither fails closed: its queries fail with ErrTenantScopeUndeclared when their context has a
+// tenant, rather than reaching the records of every organization.
+func WithTenantScope(column string) SQLHandlerOption {
+	return func(o *sqlHandlerOptions) {
+		o.tenantColumn = column
+	}
+}
+
+// WithoutTenantScope declares a table shared by every organization, or a handler meant to reach
+// across them, whose queries ignore the tenant of their context.
+func WithoutTenantScope() SQLHandlerOption {
+	return func(o *sqlHandlerOptions) {
+		o.global = true
+	}
 }
 
 func NewSQLHandler[T any, V any](
 	db *gorm.DB,
 	applyFilter func(*gorm.DB, *V) *gorm.DB,
-
+	opts ...SQLHandlerOption,
 ) *SQLHandler[T, V] {
-	return &SQLHandler[T, V]{applyFilter: applyFilter, db: db}
+	options := &sqlHandlerOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return &SQLHandler[T, V]{applyFilter: applyFilter, db: db, tenantColumn: options.tenantColumn, global: options.global}
 }
 
 type DBOption func(*gorm.DB) *gorm.DB
@@ -41,6 +79,13 @@ func WithTx(tx *gorm.DB) DBOption {
 	}
 }
 
+// WithLockForUpdate locks the selected rows until the end of the transaction of the statement
+func WithLockForUpdate() DBOption {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(clause.Locking{Strength: clause.LockingStrengthUpdate})
+	}
+}
+
 func (h *SQLHandler[T, V]) applyDBOptions(opts ...DBOption) *gorm.DB {
 	qb := h.db
 	for _, opt := range opts {
@@ -49,29 +94,106 @@ func (h *SQLHandler[T, V]) applyDBOptions(opts ...DBOption) *gorm.DB {
 	return qb
 }
 
+// query starts a statement with the DB options applied and, for tenant-owned tables, restricted to
+// the organization of the tenant context
+func (h *SQLHandler[T, V]) query(ctx context.Context, opts ...DBOption) (*gorm.DB, error) {
+	qb := h.applyDBOptions(opts...)
+	if h.tenantColumn == "" {
+		return qb, h.checkUnscoped(ctx)
+	}
+	tenantID, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return nil, domain.ErrTenantRequired
+	}
+	return qb.Where(clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: h.tenantColumn},
+		Value:  tenantID,
+	}), nil
+}
+
+// assignTenant gives the entity the organization of the tenant context, and refuses an entity of
+// another organization
+func (h *SQLHandler[T, V]) assignTenant(ctx context.Context, entity *T) error {
+	if h.tenantColumn == "" {
+		return h.checkUnscoped(ctx)
+	}
+	owned, ok := any(entity).(domain.TenantOwned)
+	if !ok {
+		return nil
+	}
+	tenantID, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return domain.ErrTenantRequired
+	}
+	switch owned.GetOrganizationID() {
+	case "":
+		owned.SetOrganizationID(tenantID)
+	case tenantID:
+	default:
+		return domain.ErrTenantMismatch
+	}
+	return nil
+}
+
+// checkUnscoped refuses the queries of a handler without tenant scope in a tenant context, unless
+// its table is declared global
+func (h *SQLHandler[T, V]) checkUnscoped(ctx context.Context) error {
+	if h.global {
+		return nil
+	}
+	if _, ok := domain.TenantFromContext(ctx); ok {
+		return domain.ErrTenantScopeUndeclared
+	}
+	return nil
+}
+
 func (h *SQLHandler[T, V]) Create(ctx context.Context, entity *T, opts ...DBOption) error {
+	if err := h.assignTenant(ctx, entity); err != nil {
+		return err
+	}
 	execDB := h.applyDBOptions(opts...)
 	return execDB.WithContext(ctx).Create(&entity).Error
 }
 
 func (h *SQLHandler[T, V]) CreateMany(ctx context.Context, entities []*T, opts ...DBOption) error {
+	for _, entity := range entities {
+		if err := h.assignTenant(ctx, entity); err != nil {
+			return err
+		}
+	}
 	execDB := h.applyDBOptions(opts...)
 	return execDB.WithContext(ctx).Create(&entities).Error
 }
 
 func (h *SQLHandler[T, V]) FindByID(ctx context.Context, id any, option *domain.FindOneOption, opts ...DBOption) (*T, error) {
+	execDB, err := h.query(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	execDB = h.applyFindOneOption(execDB, option)
+
 	var entity T
-	execDB := h.applyDBOptions(opts...)
-	err := execDB.WithContext(ctx).Where("id = ?", id).First(&entity).Error
-	return &entity, err
+	err = execDB.WithContext(ctx).Where("id = ?", id).First(&entity).Error
+	if err == nil {
+		return &entity, nil
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrRecordNotFound
+	}
+	return nil, err
 }
 
 func (h *SQLHandler[T, V]) FindOne(ctx context.Context, filter *V, option *domain.FindOneOption, opts ...DBOption) (*T, error) {
-	execDB := h.applyDBOptions(opts...)
+	execDB, err := h.query(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
 	execDB = h.applyFilter(execDB, filter)
+	execDB = h.applyFindOneOption(execDB, option)
 
 	var entity T
-	err := execDB.WithContext(ctx).First(&entity).Error
+	err = execDB.WithContext(ctx).First(&entity).Error
 	if err == nil {
 		return &entity, nil
 	}
@@ -82,6 +204,21 @@ func (h *SQLHandler[T, V]) FindOne(ctx context.Context, filter *V, option *domai
 	return nil, err
 }
 
+func (h *SQLHandler[T, V]) applyFindOneOption(db *gorm.DB, option *domain.FindOneOption) *gorm.DB {
+	if option == nil {
+		return db
+	}
+
+	for _, sortField := range option.Sort {
+		db = db.Order(sortField)
+	}
+
+	for _, field := range option.Preloads {
+		db = db.Preload(field)
+	}
+	return db
+}
+
 func (h *SQLHandler[T, V]) applyFindManyOption(db *gorm.DB, option *domain.FindManyOption) *gorm.DB {
 	if option == nil {
 		return db
@@ -110,12 +247,15 @@ func (h *SQLHandler[T, V]) applyFindManyOption(db *gorm.DB, option *domain.FindM
 }
 
 func (h *SQLHandler[T, V]) FindMany(ctx context.Context, filter *V, option *domain.FindManyOption, opts ...DBOption) ([]*T, error) {
-	execDB := h.applyDBOptions(opts...)
+	execDB, err := h.query(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
 	execDB = h.applyFilter(execDB, filter)
 	execDB = h.applyFindManyOption(execDB, option)
 
 	var entities []*T
-	err := execDB.WithContext(ctx).Find(&entities).Error
+	err = execDB.WithContext(ctx).Find(&entities).Error
 	if err != nil {
 		return nil, err
 	}
@@ -142,7 +282,7 @@ func (h *SQLHandler[T, V]) applyFindPageOption(db *gorm.DB, option *domain.FindP
 		}
 
 		for _, field := range option.Preloads {
-			db = db.Preload(field)
+			outDB = outDB.Preload(field)
 		}
 	}
 	offset := (page - 1) * perPage
@@ -151,12 +291,15 @@ func (h *SQLHandler[T, V]) applyFindPageOption(db *gorm.DB, option *domain.FindP
 }
 
 func (h *SQLHandler[T, V]) FindPage(ctx context.Context, filter *V, option *domain.FindPageOption, opts ...DBOption) ([]*T, *domain.Pagination, error) {
-	execDB := h.applyDBOptions(opts...)
+	execDB, err := h.query(ctx, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
 	execDB = h.applyFilter(execDB, filter)
 
 	var totalItems int64
 	countDB := execDB.Session(&gorm.Session{}) // clone for count
-	err := countDB.WithContext(ctx).Model(new(T)).Count(&totalItems).Error
+	err = countDB.WithContext(ctx).Model(new(T)).Count(&totalItems).Error
 	if err != nil {
 		return nil, nil, err
 	}
@@ -173,43 +316,96 @@ func (h *SQLHandler[T, V]) FindPage(ctx context.Context, filter *V, option *doma
 }
 
 func (h *SQLHandler[T, V]) Update(ctx context.Context, entity *T, opts ...DBOption) error {
-	execDB := h.applyDBOptions(opts...)
-	return execDB.WithContext(ctx).Save(&entity).Error
+	if h.tenantColumn == "" {
+		if err := h.checkUnscoped(ctx); err != nil {
+			return err
+		}
+		execDB := h.applyDBOptions(opts...)
+		return execDB.WithContext(ctx).Save(&entity).Error
+	}
+
+	// Save inserts the record when the update matches no row, which would let a tenant overwrite
+	// the record of another one, so scoped updates only ever update
+	if err := h.assignTenant(ctx, entity); err != nil {
+		return err
+	}
+	execDB, err := h.query(ctx, opts...)
+	if err != nil {
+		return err
+	}
+	return h.checkScopedResult(execDB.WithContext(ctx).Model(entity).Select("*").Updates(entity))
 }
 
 func (h *SQLHandler[T, V]) UpdateFields(ctx context.Context, id any, fields map[string]any, opts ...DBOption) error {
-	execDB := h.applyDBOptions(opts...)
+	execDB, err := h.query(ctx, opts...)
+	if err != nil {
+		return err
+	}
 	var entity T
-	return execDB.WithContext(ctx).Model(&entity).Where("id = ?", id).Updates(fields).Error
+	return h.checkScopedResult(execDB.WithContext(ctx).Model(&entity).Where("id = ?", id).Updates(fields))
+}
+
+// UpdateMany applies the given fields to every record matching the filter and returns the number of affected rows.
+func (h *SQLHandler[T, V]) UpdateMany(ctx context.Context, filter *V, fields map[string]any, opts ...DBOption) (int64, error) {
+	execDB, err := h.query(ctx, opts...)
+	if err != nil {
+		return 0, err
+	}
+	execDB = h.applyFilter(execDB, filter)
+	var entity T
+	result := execDB.WithContext(ctx).Model(&entity).Updates(fields)
+	return result.RowsAffected, result.Error
 }
 
 func (h *SQLHandler[T, V]) DeleteByID(ctx context.Context, id any, opts ...DBOption) error {
-	execDB := h.applyDBOptions(opts...)
+	execDB, err := h.query(ctx, opts...)
+	if err != nil {
+		return err
+	}
 	var entity T
-	return execDB.WithContext(ctx).
+	return h.checkScopedResult(execDB.WithContext(ctx).
 		Model(&entity).
 		Where("id = ? AND deleted_at = 0", id).
 		Updates(map[string]any{
 			"deleted_at": utils.NowUnixMillis(),
-		}).Error
+		}))
+}
+
+// checkScopedResult reports a write of a tenant-scoped handler that matched no row as
+// ErrRecordNotFound, the record is missing or belongs to another organization and the caller must
+// not take the write for done
+func (h *SQLHandler[T, V]) checkScopedResult(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if h.tenantColumn != "" && result.RowsAffected == 0 {
+		return domain.ErrRecordNotFound
+	}
+	return nil
 }
 
 func (h *SQLHandler[T, V]) DeleteMany(ctx context.Context, filter *V, opts ...DBOption) (int64, error) {
-	execDB := h.applyDBOptions(opts...)
-	execDB = h.applyFilter(execDB, filter)
-	var entity T
-	err := execDB.WithContext(ctx).Delete(&entity).Error
+	execDB, err := h.query(ctx, opts...)
 	if err != nil {
 		return 0, err
 	}
-	return execDB.RowsAffected, nil
+	execDB = h.applyFilter(execDB, filter)
+	var entity T
+	result := execDB.WithContext(ctx).Delete(&entity)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
 }
 
 func (h *SQLHandler[T, V]) Count(ctx context.Context, filter *V, opts ...DBOption) (int64, error) {
 	var count int64
-	execDB := h.applyDBOptions(opts...)
+	execDB, err := h.query(ctx, opts...)
+	if err != nil {
+		return 0, err
+	}
 	execDB = h.applyFilter(execDB, filter)
-	err := execDB.WithContext(ctx).Model(new(T)).Count(&count).Error
+	err = execDB.WithContext(ctx).Model(new(T)).Count(&count).Error
 	return count, err
 }
 
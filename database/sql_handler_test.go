@@ -0,0 +1,402 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/domain"
+	"slices"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type tenantRecord struct {
+	ID             string
+	OrganizationID string
+	Name           string
+	DeletedAt      int64
+}
+
+func (r *tenantRecord) GetOrganizationID() string {
+	return r.OrganizationID
+}
+
+func (r *tenantRecord) SetOrganizationID(organizationID string) {
+	r.OrganizationID = organizationID
+}
+
+type tenantRecordFilter struct {
+	Name *string
+}
+
+func applyTenantRecordFilter(qb *gorm.DB, filter *tenantRecordFilter) *gorm.DB {
+	if filter != nil && filter.Name != nil {
+		qb = qb.Where("name = ?", *filter.Name)
+	}
+	return qb
+}
+
+type recordedStatement struct {
+	sql  string
+	vars []any
+}
+
+// newDryRunDB opens a database that builds the statements without running them, and records them.
+// Writes affect no row in a dry run, as when the record is missing, and run outside of a
+// transaction, which would need a connection.
+func newDryRunDB(t *testing.T) (*gorm.DB, *[]recordedStatement) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statements []recordedStatement
+	record := func(db *gorm.DB) {
+		statements = append(statements, recordedStatement{sql: db.Statement.SQL.String(), vars: db.Statement.Vars})
+	}
+	callbacks := db.Callback()
+	for name, err := range map[string]error{
+		"create": callbacks.Create().After("gorm:create").Register("test:record", record),
+		"query":  callbacks.Query().After("gorm:query").Register("test:record", record),
+		"update": callbacks.Update().After("gorm:update").Register("test:record", record),
+		"delete": callbacks.Delete().After("gorm:delete").Register("test:record", record),
+	} {
+		if err != nil {
+			t.Fatalf("register the %s callback: %v", name, err)
+		}
+	}
+	return db, &statements
+}
+
+func TestSQLHandlerTenantScope(t *testing.T) {
+	name := "report"
+
+	tests := []struct {
+		name string
+		run  func(context.Context, *SQLHandler[tenantRecord, tenantRecordFilter]) error
+		// Error of the tenant context, the writes by ID match no row in a dry run
+		wantErr error
+	}{
+		{
+			name: "find by ID",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				_, err := h.FindByID(ctx, "record-1", nil)
+				return err
+			},
+		},
+		{
+			name: "find one",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				_, err := h.FindOne(ctx, &tenantRecordFilter{Name: &name}, nil)
+				return err
+			},
+		},
+		{
+			name: "find many",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				_, err := h.FindMany(ctx, &tenantRecordFilter{Name: &name}, nil)
+				return err
+			},
+		},
+		{
+			name: "find page",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				_, _, err := h.FindPage(ctx, nil, nil)
+				return err
+			},
+		},
+		{
+			name: "count",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				_, err := h.Count(ctx, nil)
+				return err
+			},
+		},
+		{
+			name: "update",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				return h.Update(ctx, &tenantRecord{ID: "record-1", Name: name})
+			},
+			wantErr: domain.ErrRecordNotFound,
+		},
+		{
+			name: "update fields",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				return h.UpdateFields(ctx, "record-1", map[string]any{"name": name})
+			},
+			wantErr: domain.ErrRecordNotFound,
+		},
+		{
+			name: "update many",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				_, err := h.UpdateMany(ctx, nil, map[string]any{"name": name})
+				return err
+			},
+		},
+		{
+			name: "delete by ID",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				return h.DeleteByID(ctx, "record-1")
+			},
+			wantErr: domain.ErrRecordNotFound,
+		},
+		{
+			name: "delete many",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				_, err := h.DeleteMany(ctx, &tenantRecordFilter{Name: &name})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := newDryRunDB(t)
+			h := NewSQLHandler[tenantRecord](db, applyTenantRecordFilter, WithTenantScope("organization_id"))
+
+			if err := tt.run(context.Background(), h); !errors.Is(err, domain.ErrTenantRequired) {
+				t.Fatalf("error without a tenant = %v, want %v", err, domain.ErrTenantRequired)
+			}
+			if len(*statements) != 0 {
+				t.Fatalf("statements without a tenant = %v, want none", *statements)
+			}
+
+			ctx := domain.ContextWithTenant(context.Background(), "org-1")
+			if err := tt.run(ctx, h); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if len(*statements) == 0 {
+				t.Fatal("no statement was run")
+			}
+			for _, statement := range *statements {
+				if !strings.Contains(statement.sql, `"tenant_records"."organization_id" = `) || !slices.Contains(statement.vars, any("org-1")) {
+					t.Errorf("statement %q %v is not scoped to the tenant", statement.sql, statement.vars)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLHandlerWithoutTenantScope(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	h := NewSQLHandler[tenantRecord](db, applyTenantRecordFilter, WithoutTenantScope())
+
+	// Global tables are not restricted, in an organization or not, and keep ignoring writes that
+	// match no row
+	for _, ctx := range []context.Context{context.Background(), domain.ContextWithTenant(context.Background(), "org-1")} {
+		if err := h.UpdateFields(ctx, "record-1", map[string]any{"name": "report"}); err != nil {
+			t.Errorf("UpdateFields() error = %v", err)
+		}
+		if err := h.DeleteByID(ctx, "record-1"); err != nil {
+			t.Errorf("DeleteByID() error = %v", err)
+		}
+	}
+	if len(*statements) != 4 {
+		t.Fatalf("statements = %v, want every write run", *statements)
+	}
+	for _, statement := range *statements {
+		if strings.Contains(statement.sql, "organization_id") {
+			t.Errorf("statement %q is scoped to a tenant", statement.sql)
+		}
+	}
+}
+
+func TestSQLHandlerUndeclaredScope(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(context.Context, *SQLHandler[tenantRecord, tenantRecordFilter]) error
+	}{
+		{
+			name: "create",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				return h.Create(ctx, &tenantRecord{ID: "record-1"})
+			},
+		},
+		{
+			name: "find by ID",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				_, err := h.FindByID(ctx, "record-1", nil)
+				return err
+			},
+		},
+		{
+			name: "find many",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				_, err := h.FindMany(ctx, nil, nil)
+				return err
+			},
+		},
+		{
+			name: "update",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				return h.Update(ctx, &tenantRecord{ID: "record-1"})
+			},
+		},
+		{
+			name: "delete by ID",
+			run: func(ctx context.Context, h *SQLHandler[tenantRecord, tenantRecordFilter]) error {
+				return h.DeleteByID(ctx, "record-1")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := newDryRunDB(t)
+			h := NewSQLHandler[tenantRecord](db, applyTenantRecordFilter)
+
+			if err := tt.run(context.Background(), h); err != nil {
+				t.Fatalf("error without a tenant = %v", err)
+			}
+			ran := len(*statements)
+
+			// In an organization, a handler that did not declare its scope fails closed
+			ctx := domain.ContextWithTenant(context.Background(), "org-1")
+			if err := tt.run(ctx, h); !errors.Is(err, domain.ErrTenantScopeUndeclared) {
+				t.Fatalf("error = %v, want %v", err, domain.ErrTenantScopeUndeclared)
+			}
+			if len(*statements) != ran {
+				t.Errorf("statements = %v, want none run in the organization", (*statements)[ran:])
+			}
+		})
+	}
+}
+
+func TestSQLHandlerAssignTenant(t *testing.T) {
+	tests := []struct {
+		name             string
+		tenantID         string
+		organizationID   string
+		wantErr          error
+		wantOrganization string
+	}{
+		{name: "no tenant", organizationID: "org-1", wantErr: domain.ErrTenantRequired, wantOrganization: "org-1"},
+		{name: "record without organization", tenantID: "org-1", wantOrganization: "org-1"},
+		{name: "record of the tenant", tenantID: "org-1", organizationID: "org-1", wantOrganization: "org-1"},
+		{name: "record of another organization", tenantID: "org-1", organizationID: "org-2", wantErr: domain.ErrTenantMismatch, wantOrganization: "org-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := newDryRunDB(t)
+			h := NewSQLHandler[tenantRecord](db, applyTenantRecordFilter, WithTenantScope("organization_id"))
+			ctx := context.Background()
+			if tt.tenantID != "" {
+				ctx = domain.ContextWithTenant(ctx, tt.tenantID)
+			}
+
+			record := &tenantRecord{ID: "record-1", OrganizationID: tt.organizationID}
+			if err := h.Create(ctx, record); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if record.OrganizationID != tt.wantOrganization {
+				t.Errorf("organization = %q, want %q", record.OrganizationID, tt.wantOrganization)
+			}
+			if ran := len(*statements) > 0; ran != (tt.wantErr == nil) {
+				t.Errorf("statements = %v, want them only for an accepted record", *statements)
+			}
+
+			// Updates refuse the records of another organization the same way
+			update := &tenantRecord{ID: "record-1", OrganizationID: tt.organizationID}
+			if err := h.Update(ctx, update); tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWithLockForUpdate(t *testing.T) {
+	db, statements := newDryRunDB(t)
+	h := NewSQLHandler[tenantRecord](db, applyTenantRecordFilter, WithTenantScope("organization_id"))
+	ctx := domain.ContextWithTenant(context.Background(), "org-1")
+
+	if _, err := h.FindMany(ctx, nil, nil, WithTx(db), WithLockForUpdate()); err != nil {
+		t.Fatal(err)
+	}
+	if len(*statements) != 1 || !strings.HasSuffix((*statements)[0].sql, "FOR UPDATE") {
+		t.Errorf("statements = %v, want a locking select", *statements)
+	}
+}
+
+type parentRecord struct {
+	ID       string
+	Children []*childRecord `gorm:"foreignKey:ParentID"`
+}
+
+type childRecord struct {
+	ID       string
+	ParentID string
+}
+
+type parentRecordFilter struct{}
+
+func TestSQLHandlerFindOneOption(t *testing.T) {
+	option := &domain.FindOneOption{Sort: []string{"id DESC"}, Preloads: []string{"Children"}}
+
+	tests := []struct {
+		name string
+		find func(context.Context, *SQLHandler[parentRecord, parentRecordFilter], *domain.FindOneOption) error
+	}{
+		{
+			name: "find by ID",
+			find: func(ctx context.Context, h *SQLHandler[parentRecord, parentRecordFilter], option *domain.FindOneOption) error {
+				_, err := h.FindByID(ctx, "parent-1", option)
+				return err
+			},
+		},
+		{
+			name: "find one",
+			find: func(ctx context.Context, h *SQLHandler[parentRecord, parentRecordFilter], option *domain.FindOneOption) error {
+				_, err := h.FindOne(ctx, nil, option)
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, statements := newDryRunDB(t)
+			// A dry run finds no row, the parent is filled in so the preload has one to load for
+			err := db.Callback().Query().After("gorm:query").Before("gorm:preload").Register("test:parent", func(db *gorm.DB) {
+				if parent, ok := db.Statement.Dest.(*parentRecord); ok {
+					parent.ID = "parent-1"
+				}
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			h := NewSQLHandler[parentRecord](db, func(qb *gorm.DB, _ *parentRecordFilter) *gorm.DB { return qb }, WithoutTenantScope())
+			ctx := context.Background()
+
+			if err := tt.find(ctx, h, nil); err != nil {
+				t.Fatal(err)
+			}
+			if len(*statements) != 1 {
+				t.Fatalf("statements without option = %v, want the lookup only", *statements)
+			}
+
+			*statements = nil
+			if err := tt.find(ctx, h, option); err != nil {
+				t.Fatal(err)
+			}
+			// The preload runs within the lookup, so it is recorded first
+			if len(*statements) != 2 {
+				t.Fatalf("statements = %v, want the lookup and the preload", *statements)
+			}
+			preload, lookup := (*statements)[0], (*statements)[1]
+			if !strings.Contains(lookup.sql, `FROM "parent_records"`) || !strings.Contains(lookup.sql, "ORDER BY id DESC") {
+				t.Errorf("lookup %q is not sorted", lookup.sql)
+			}
+			if !strings.Contains(preload.sql, `FROM "child_records" WHERE "child_records"."parent_id" = `) || !slices.Contains(preload.vars, any("parent-1")) {
+				t.Errorf("preload %q %v does not load the children of the parent", preload.sql, preload.vars)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+)
+
+/*******************************
+*        API key errors        *
+*******************************/
+var (
+	ErrAPIKeyNotFound = &DetailedError{
+		IDField:         "API_KEY_NOT_FOUND",
+		StatusDescField: http.StatusText(http.StatusNotFound),
+		ErrorField:      "API key not found",
+		StatusCodeField: http.StatusNotFound,
+	}
+	ErrInvalidAPIKey = &DetailedError{
+		IDField:         "INVALID_API_KEY",
+		StatusDescField: http.StatusText(http.StatusUnauthorized),
+		ErrorField:      "Invalid or expired API key",
+		StatusCodeField: http.StatusUnauthorized,
+	}
+	ErrAPIKeyValidationFailed = &DetailedError{
+		IDField:         "API_KEY_VALIDATION_FAILED",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "API key validation failed",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrInsufficientScope = &DetailedError{
+		IDField:         "INSUFFICIENT_SCOPE",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "The request requires scopes that were not granted",
+		StatusCodeField: http.StatusForbidden,
+	}
+)
+
+/*****************************************
+*       API key entities and types       *
+*****************************************/
+
+// Scope grants access to a group of endpoints. API keys hold scopes directly, users get them
+// through the permissions of their roles.
+type Scope string
+
+const (
+	ScopeEmailsSend          Scope = "emails:send"
+	ScopeEmailsRead          Scope = "emails:read"
+	ScopeEmailTemplatesRead  Scope = "email_templates:read"
+	ScopeEmailTemplatesWrite Scope = "email_templates:write"
+	ScopeUsersRead           Scope = "users:read"
+	ScopeUsersWrite          Scope = "users:write"
+)
+
+var AllScopes = []Scope{
+	ScopeEmailsSend,
+	ScopeEmailsRead,
+	ScopeEmailTemplatesRead,
+	ScopeEmailTemplatesWrite,
+	ScopeUsersRead,
+	ScopeUsersWrite,
+}
+
+func (s Scope) IsValid() bool {
+	for _, scope := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopesForPermissions returns the scopes among the given permissions. Every scope is also a
+// permission of the same name.
+func ScopesForPermissions(permissions []PermissionID) []Scope {
+	var scopes []Scope
+	for _, permission := range permissions {
+		if scope := Scope(permission); scope.IsValid() {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
+
+// HasAllScopes reports whether granted contains every one of the required scopes.
+func HasAllScopes(granted []Scope, required ...Scope) bool {
+	grantedSet := make(map[Scope]struct{}, len(granted))
+	for _, scope := range granted {
+		grantedSet[scope] = struct{}{}
+	}
+	for _, scope := range required {
+		if _, ok := grantedSet[scope]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// APIKey authenticates another service instead of a user. The raw key is only returned when the key
+// is created, the database keeps its SHA-256 hash and the prefix used to look it up.
+type APIKey struct {
+	SQLModel
+	Name       string      `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string      `json:"prefix" gorm:"type:varchar(16);uniqueIndex;not null"` // Public part of the key, to identify it in logs and lists
+	SecretHash string      `json:"-" gorm:"type:varchar(64);not null"`                  // SHA-256 of the whole raw key
+	OwnerID    string      `json:"owner_id" gorm:"type:varchar(36);index;not null"`     // User who created the key
+	Scopes     StringSlice `json:"scopes" gorm:"type:jsonb;not null"`
+	ExpiresAt  int64       `json:"expires_at"`   // Milli timestamp, 0 means the key never expires
+	LastUsedAt int64       `json:"last_used_at"` // Milli timestamp, 0 means never used
+}
+
+func (k *APIKey) IsExpired(now int64) bool {
+	return k.ExpiresAt > 0 && k.ExpiresAt <= now
+}
+
+func (k *APIKey) GetScopes() []Scope {
+	scopes := make([]Scope, 0, len(k.Scopes))
+	for _, scope := range k.Scopes {
+		scopes = append(scopes, Scope(scope))
+	}
+	return scopes
+}
+
+type APIKeyFilter struct {
+	ID      *string `json:"id,omitempty"`       // Filter by specific key ID
+	Prefix  *string `json:"prefix,omitempty"`   // Filter by key prefix
+	OwnerID *string `json:"owner_id,omitempty"` // Filter by owner
+}
+
+/************************
+*       Usecases        *
+************************/
+type APIKeyUsecase interface {
+	CreateAPIKey(ctx context.Context, req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context, req *ListAPIKeysRequest) ([]*APIKey, *Pagination, error)
+	GetAPIKey(ctx context.Context, id string) (*APIKey, error)
+	UpdateAPIKey(ctx context.Context, req *UpdateAPIKeyRequest) (*APIKey, error)
+	DeleteAPIKey(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, rawKey string) (*APIKey, error)
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+type CreateAPIKeyRequest struct {
+	OwnerID   string     `json:"-"`
+	Principal *Principal `json:"-"` // Creator of the key, which only gets scopes the creator holds
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []Scope    `json:"scopes" validate:"required,min=1"`
+	ExpiresAt int64      `json:"expires_at,omitempty"` // Milli timestamp, omitted for a key that never expires
+}
+
+// CreateAPIKeyResponse carries the raw key, which cannot be retrieved again later.
+type CreateAPIKeyResponse struct {
+	APIKey *APIKey `json:"api_key"`
+	Key    string  `json:"key"`
+}
+
+type ListAPIKeysRequest struct {
+	OwnerID *string `json:"owner_id,omitempty" form:"owner_id"`
+	Page    int     `json:"page" form:"page"`
+	PerPage int     `json:"per_page" form:"per_page"`
+}
+
+type UpdateAPIKeyRequest struct {
+	ID        string     `json:"-"`
+	Principal *Principal `json:"-"` // Administrator changing the key, who only grants scopes they hold
+	Name      *string    `json:"name,omitempty" validate:"omitempty,max=100"`
+	Scopes    []Scope    `json:"scopes,omitempty"`
+	ExpiresAt *int64     `json:"expires_at,omitempty"` // 0 removes the expiry
+}
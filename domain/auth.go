@@ -24,6 +24,12 @@ var (
 		ErrorField:      "Invalid or expired token",
 		StatusCodeField: http.StatusUnauthorized,
 	}
+	ErrInvalidCSRFToken = &DetailedError{
+		IDField:         "INVALID_CSRF_TOKEN",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "Missing or invalid CSRF token",
+		StatusCodeField: http.StatusForbidden,
+	}
 	ErrSessionExpired = &DetailedError{
 		IDField:         "SESSION_EXPIRED",
 		StatusDescField: http.StatusText(http.StatusUnauthorized),
@@ -42,6 +48,18 @@ var (
 		ErrorField:      "Email address is not verified",
 		StatusCodeField: http.StatusForbidden,
 	}
+	ErrAccountLocked = &DetailedError{
+		IDField:         "ACCOUNT_LOCKED",
+		StatusDescField: http.StatusText(http.StatusLocked),
+		ErrorField:      "Account is temporarily locked after too many failed login attempts",
+		StatusCodeField: http.StatusLocked,
+	}
+	ErrTooManyLoginAttempts = &DetailedError{
+		IDField:         "TOO_MANY_LOGIN_ATTEMPTS",
+		StatusDescField: http.StatusText(http.StatusTooManyRequests),
+		ErrorField:      "Too many failed login attempts, please wait before trying again",
+		StatusCodeField: http.StatusTooManyRequests,
+	}
 	ErrAccountBanned = &DetailedError{
 		IDField:         "ACCOUNT_BANNED",
 		StatusDescField: http.StatusText(http.StatusForbidden),
@@ -66,6 +84,66 @@ var (
 		ErrorField:      "Failed to create session",
 		StatusCodeField: http.StatusInternalServerError,
 	}
+	ErrRefreshTokenReused = &DetailedError{
+		IDField:         "REFRESH_TOKEN_REUSED",
+		StatusDescField: http.StatusText(http.StatusUnauthorized),
+		ErrorField:      "Refresh token has already been used, the session has been revoked",
+		StatusCodeField: http.StatusUnauthorized,
+	}
+	ErrRefreshTokenAlreadyRotated = &DetailedError{
+		IDField:         "REFRESH_TOKEN_ALREADY_ROTATED",
+		StatusDescField: http.StatusText(http.StatusConflict),
+		ErrorField:      "Refresh token was just rotated by another request, use the latest refresh token",
+		StatusCodeField: http.StatusConflict,
+	}
+	ErrSessionLimitReached = &DetailedError{
+		IDField:         "SESSION_LIMIT_REACHED",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "Maximum number of active sessions reached, please log out from another device",
+		StatusCodeField: http.StatusForbidden,
+	}
+	ErrInvalidVerificationToken = &DetailedError{
+		IDField:         "INVALID_VERIFICATION_TOKEN",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Invalid or expired verification token",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrEmailAlreadyVerified = &DetailedError{
+		IDField:         "EMAIL_ALREADY_VERIFIED",
+		StatusDescField: http.StatusText(http.StatusConflict),
+		ErrorField:      "Email address is already verified",
+		StatusCodeField: http.StatusConflict,
+	}
+	ErrInvalidPasswordResetToken = &DetailedError{
+		IDField:         "INVALID_PASSWORD_RESET_TOKEN",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Invalid or expired password reset token",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrInvalidMagicLink = &DetailedError{
+		IDField:         "INVALID_MAGIC_LINK",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Invalid or expired login link",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrTooManyMagicLinkRequests = &DetailedError{
+		IDField:         "TOO_MANY_MAGIC_LINK_REQUESTS",
+		StatusDescField: http.StatusText(http.StatusTooManyRequests),
+		ErrorField:      "Too many login links requested for this email, please wait before trying again",
+		StatusCodeField: http.StatusTooManyRequests,
+	}
+	ErrInvalidLoginAlertToken = &DetailedError{
+		IDField:         "INVALID_LOGIN_ALERT_TOKEN",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Invalid or expired link, the sign-in may already have been reported",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrPasswordResetRequired = &DetailedError{
+		IDField:         "PASSWORD_RESET_REQUIRED",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "Your password must be reset before you can log in, please check your email",
+		StatusCodeField: http.StatusForbidden,
+	}
 )
 
 /***************************************
@@ -80,21 +158,75 @@ const (
 )
 
 type JwtClaims struct {
-	Sub string `json:"sub"` // User ID
-	Sid string `json:"sid"` // Session ID
+	Sub string    `json:"sub"`           // User ID
+	Sid string    `json:"sid"`           // Session ID
+	Act *JwtActor `json:"act,omitempty"` // Real caller when Sub is impersonated, as described in RFC 8693
+	Org string    `json:"org,omitempty"` // Organization the session acts in, empty outside any organization
 	jwt.RegisteredClaims
 }
 
+// JwtActor identifies the administrator acting on behalf of the subject of a token.
+type JwtActor struct {
+	Sub string `json:"sub"` // User ID of the actor
+}
+
+func (c *JwtClaims) IsImpersonated() bool {
+	return c.Act != nil && c.Act.Sub != ""
+}
+
+// Algorithms of the asymmetric access token signing keys
+const (
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+)
+
+// JSONWebKey is the public part of a token signing key as described in RFC 7517.
+type JSONWebKey struct {
+	Kty string `json:"kty"`           // Key type, "RSA", "EC" or "OKP"
+	Use string `json:"use"`           // Always "sig"
+	Alg string `json:"alg"`           // Signing algorithm, e.g. "RS256" or "EdDSA"
+	Kid string `json:"kid"`           // Key ID matching the "kid" header of signed tokens
+	N   string `json:"n,omitempty"`   // RSA modulus (base64url)
+	E   string `json:"e,omitempty"`   // RSA public exponent (base64url)
+	Crv string `json:"crv,omitempty"` // Curve of an EC or OKP key, e.g. "P-256" or "Ed25519"
+	X   string `json:"x,omitempty"`   // EC x coordinate or Ed25519 public key (base64url)
+	Y   string `json:"y,omitempty"`   // EC y coordinate (base64url)
+}
+
+// JSONWebKeySet lists the keys other services use to verify access tokens.
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
 type UserSession struct {
 	SQLModel
-	UserID         string `json:"user_id" db:"user_id"`                   // Foreign key reference to User.ID
-	RefreshToken   string `json:"refresh_token" db:"refresh_token"`       // One-time refresh token (random string)
-	FCMToken       string `json:"fcm_token" db:"fcm_token"`               // Firebase Cloud Messaging token for push notifications
-	IPAddress      string `json:"ip_address" db:"ip_address"`             // Client IP address (e.g., "192.168.1.1", "2001:db8::1")
-	UserAgent      string `json:"user_agent" db:"user_agent"`             // HTTP User-Agent string from the client browser/app
-	Active         bool   `json:"active" db:"active"`                     // Whether the session is currently active (not logged out)
-	ExpiresAt      int64  `json:"expires_at" db:"expires_at"`             // When the session expires (absolute timestamp)
-	LastActivityAt int64  `json:"last_activity_at" db:"last_activity_at"` // Last time the session was used for any request (timestamp)
+	UserID         string `json:"user_id" db:"user_id"`                           // Foreign key reference to User.ID
+	ImpersonatorID string `json:"impersonator_id,omitempty" db:"impersonator_id"` // Administrator who started the session on behalf of the user, empty for the user's own sessions
+	RefreshToken   string `json:"-" db:"refresh_token"`                           // SHA-256 hash of the current one-time refresh token
+	FCMToken       string `json:"fcm_token" db:"fcm_token"`                       // Firebase Cloud Messaging token for push notifications
+	IPAddress      string `json:"ip_address" db:"ip_address"`                     // Client IP address (e.g., "192.168.1.1", "2001:db8::1")
+	UserAgent      string `json:"user_agent" db:"user_agent"`                     // HTTP User-Agent string from the client browser/app
+	Active         bool   `json:"active" db:"active"`                             // Whether the session is currently active (not logged out)
+	ExpiresAt      int64  `json:"expires_at" db:"expires_at"`                     // When the session expires (absolute timestamp)
+	LastActivityAt int64  `json:"last_activity_at" db:"last_activity_at"`         // Last time the session was used for any request (timestamp)
+	OrganizationID string `json:"organization_id,omitempty" db:"organization_id"` // Organization the tokens of the session act in, empty outside any organization
+}
+
+// SessionLimitPolicy decides what happens to a new login once a user reached the session limit
+type SessionLimitPolicy string
+
+const (
+	SessionLimitPolicyEvictOldest SessionLimitPolicy = "evict_oldest" // Revoke the oldest sessions to make room
+	SessionLimitPolicyReject      SessionLimitPolicy = "reject"       // Refuse the new login
+)
+
+func (p SessionLimitPolicy) IsValid() bool {
+	switch p {
+	case SessionLimitPolicyEvictOldest, SessionLimitPolicyReject:
+		return true
+	default:
+		return false
+	}
 }
 
 func (s *UserSession) IsActive() bool {
@@ -103,6 +235,7 @@ func (s *UserSession) IsActive() bool {
 
 type UserSessionFilter struct {
 	ID            *string `json:"id,omitempty"`             // Filter by specific session ID
+	IDNe          *string `json:"id_ne,omitempty"`          // Exclude a specific session ID
 	UserID        *string `json:"user_id,omitempty"`        // Filter by user ID (find all sessions for a user)
 	RefreshToken  *string `json:"refresh_token,omitempty"`  // Filter by refresh token
 	FCMToken      *string `json:"fcm_token,omitempty"`      // Filter by FCM token (exact match)
@@ -110,63 +243,225 @@ type UserSessionFilter struct {
 	Active        *bool   `json:"is_active,omitempty"`      // Filter by active status (true=active, false=inactive)
 	ExpiresAfter  *int64  `json:"expires_after,omitempty"`  // Find sessions that expire after this timestamp
 	ExpiresBefore *int64  `json:"expires_before,omitempty"` // Find sessions that expire before this timestamp
+	ValidAt       *int64  `json:"valid_at,omitempty"`       // Find sessions not expired at this timestamp (no expiry counts as valid)
 	CreatedAfter  *int64  `json:"created_after,omitempty"`  // Find sessions created after this timestamp
 	CreatedBefore *int64  `json:"created_before,omitempty"` // Find sessions created before this timestamp
 }
 
+// RotatedRefreshToken records a refresh token that was replaced during rotation. Together with
+// the current token of the session it forms the token lineage of that session, so a token
+// presented again after its rotation can be recognised as reused.
+type RotatedRefreshToken struct {
+	SQLModel
+	SessionID string `json:"session_id" db:"session_id" gorm:"type:varchar(36);index;not null"` // Session (token family) the token belonged to
+	UserID    string `json:"user_id" db:"user_id" gorm:"type:varchar(36);index;not null"`       // Foreign key reference to User.ID
+	TokenHash string `json:"-" db:"token_hash" gorm:"type:varchar(64);uniqueIndex;not null"`    // Hex encoded SHA-256 of the rotated token
+	RotatedAt int64  `json:"rotated_at" db:"rotated_at"`                                        // When the token was replaced (milli timestamp)
+}
+
+type RotatedRefreshTokenFilter struct {
+	ID        *string `json:"id,omitempty"`         // Filter by specific record ID
+	SessionID *string `json:"session_id,omitempty"` // Filter by session
+	TokenHash *string `json:"token_hash,omitempty"` // Filter by hashed token value
+}
+
+type VerificationPurpose string
+
+const (
+	VerificationPurposeEmail         VerificationPurpose = "email_verification"
+	VerificationPurposePasswordReset VerificationPurpose = "password_reset"
+	VerificationPurposeMFAChallenge  VerificationPurpose = "mfa_challenge"
+	VerificationPurposeMagicLink     VerificationPurpose = "magic_link"
+	VerificationPurposeLoginAlert    VerificationPurpose = "login_alert"
+)
+
+// VerificationToken is a single-use secret sent to the user out of band (e.g. by email).
+// Only the SHA-256 hash of the token is persisted.
+type VerificationToken struct {
+	SQLModel
+	UserID    string              `json:"user_id" db:"user_id" gorm:"type:varchar(36);index;not null"`    // Foreign key reference to User.ID
+	Purpose   VerificationPurpose `json:"purpose" db:"purpose" gorm:"type:varchar(30);not null"`          // What the token may be used for
+	TokenHash string              `json:"-" db:"token_hash" gorm:"type:varchar(64);uniqueIndex;not null"` // Hex encoded SHA-256 of the raw token
+	ExpiresAt int64               `json:"expires_at" db:"expires_at"`                                     // When the token expires (milli timestamp)
+	UsedAt    int64               `json:"used_at" db:"used_at"`                                           // When the token was consumed, 0 if not used yet
+	// Hex encoded SHA-256 of a secret kept by the client which requested the token, empty when the
+	// token can be redeemed from any device
+	BindingHash string `json:"-" db:"binding_hash" gorm:"type:varchar(64)"`
+	// Session the token is about, only set for login alerts
+	SessionID string `json:"session_id,omitempty" db:"session_id" gorm:"type:varchar(36)"`
+}
+
+func (t *VerificationToken) IsUsable() bool {
+	return t.UsedAt == 0 && t.ExpiresAt > time.Now().UnixMilli()
+}
+
+type VerificationTokenFilter struct {
+	ID        *string              `json:"id,omitempty"`         // Filter by specific token ID
+	UserID    *string              `json:"user_id,omitempty"`    // Filter by owner
+	Purpose   *VerificationPurpose `json:"purpose,omitempty"`    // Filter by token purpose
+	TokenHash *string              `json:"token_hash,omitempty"` // Filter by hashed token value
+	Used      *bool                `json:"used,omitempty"`       // Filter by consumed status
+}
+
 /*************************************
 *  Auth usecase interfaces and types *
 **************************************/
 type AuthUsecase interface {
 	Register(ctx context.Context, req *RegisterRequest) (*AuthResponse, error)
 
-	Login(ctx context.Context, req *LoginRequest) (*AuthResponse, error)
-	Logout(ctx context.Context, sessionID string) error
+	Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error)
+	VerifyMFALogin(ctx context.Context, req *VerifyMFALoginRequest) (*AuthResponse, error)
+	Logout(ctx context.Context, req *LogoutRequest) error
 	RefreshToken(ctx context.Context, req *RefreshTokenRequest) (*AuthResponse, error)
 
 	SendVerificationEmail(ctx context.Context, req *SendVerificationEmailRequest) error
+	SendVerificationCode(ctx context.Context, req *SendVerificationCodeRequest) (*OTPSendResponse, error)
 	VerifyEmail(ctx context.Context, req *VerifyEmailRequest) error
+
+	ForgotPassword(ctx context.Context, req *ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *ResetPasswordRequest) error
+
+	RequestMagicLink(ctx context.Context, req *MagicLinkRequest) (*MagicLinkResponse, error)
+	LoginWithMagicLink(ctx context.Context, req *MagicLinkLoginRequest) (*LoginResponse, error)
+
+	// DenyLogin handles the "this wasn't me" link of a new sign-in email
+	DenyLogin(ctx context.Context, req *DenyLoginRequest) error
+
+	UnlockAccount(ctx context.Context, req *UnlockAccountRequest) error
+
+	EnrollMFA(ctx context.Context, req *EnrollMFARequest) (*EnrollMFAResponse, error)
+	ConfirmMFA(ctx context.Context, req *ConfirmMFARequest) (*MFARecoveryCodesResponse, error)
+	DisableMFA(ctx context.Context, req *DisableMFARequest) error
+	RegenerateRecoveryCodes(ctx context.Context, req *RegenerateRecoveryCodesRequest) (*MFARecoveryCodesResponse, error)
+
+	BeginPasskeyRegistration(ctx context.Context, req *BeginPasskeyRegistrationRequest) (*PasskeyOptionsResponse, error)
+	FinishPasskeyRegistration(ctx context.Context, req *FinishPasskeyRegistrationRequest) (*WebAuthnCredential, error)
+	BeginPasskeyLogin(ctx context.Context) (*PasskeyOptionsResponse, error)
+	FinishPasskeyLogin(ctx context.Context, req *FinishPasskeyLoginRequest) (*AuthResponse, error)
+	BeginPasskeyMFA(ctx context.Context, req *BeginPasskeyMFARequest) (*PasskeyOptionsResponse, error)
+	VerifyPasskeyMFA(ctx context.Context, req *VerifyPasskeyMFARequest) (*AuthResponse, error)
+	ListPasskeys(ctx context.Context, userID string) ([]*WebAuthnCredential, error)
+	RenamePasskey(ctx context.Context, req *RenamePasskeyRequest) (*WebAuthnCredential, error)
+	DeletePasskey(ctx context.Context, req *DeletePasskeyRequest) error
+
+	StartOAuthLogin(ctx context.Context, req *StartOAuthLoginRequest) (*StartOAuthLoginResponse, error)
+	CompleteOAuthLogin(ctx context.Context, req *CompleteOAuthLoginRequest) (*LoginResponse, error)
 }
 
 type RegisterRequest struct {
 	Username  string `json:"username" validate:"required,min=3,max=50"`
 	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required,min=6"`
+	Password  string `json:"password" validate:"required"`
 	FirstName string `json:"first_name" validate:"required,min=1,max=50"`
 	LastName  string `json:"last_name" validate:"required,min=1,max=50"`
 	IPAddress string `json:"ip_address,omitempty"`
 	UserAgent string `json:"user_agent,omitempty"`
 }
 
+// LoginRequest identifies the user by email or by username, exactly one of them is set
 type LoginRequest struct {
-	Email     string `json:"email" validate:"required,email"`
+	Email     string `json:"email,omitempty" validate:"required_without=Username,excluded_with=Username,omitempty,email"`
+	Username  string `json:"username,omitempty" validate:"required_without=Email"`
 	Password  string `json:"password" validate:"required,min=6"`
 	IPAddress string `json:"ip_address,omitempty"`
 	UserAgent string `json:"user_agent,omitempty"`
 }
 
+// RefreshTokenRequest carries the refresh token in the body, or in the refresh cookie for
+// browser clients in cookie mode.
 type RefreshTokenRequest struct {
-	RefreshToken string `json:"refresh_token" validate:"required"`
+	RefreshToken string `json:"refresh_token"`
 	IPAddress    string `json:"ip_address,omitempty"`
 	UserAgent    string `json:"user_agent,omitempty"`
 }
 
+// AuthResponse carries the tokens of a session. Browser clients in cookie mode get the refresh
+// token in an HttpOnly cookie instead, RefreshToken is then left out.
 type AuthResponse struct {
-	User         *User  `json:"user"`
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	User                  *User  `json:"user"`
+	AccessToken           string `json:"access_token"`
+	RefreshToken          string `json:"refresh_token,omitempty"`
+	RefreshTokenExpiresAt int64  `json:"refresh_token_expires_at"` // Milli timestamp
+}
+
+// LoginResponse carries the issued tokens, or only an MFA challenge token when the account
+// has two-factor authentication enabled and the login must be completed with VerifyMFALogin
+// or VerifyPasskeyMFA, depending on the offered methods.
+type LoginResponse struct {
+	*AuthResponse
+	MFARequired       bool        `json:"mfa_required"`
+	MFAMethods        []MFAMethod `json:"mfa_methods,omitempty"`
+	MFAToken          string      `json:"mfa_token,omitempty"`
+	MFATokenExpiresAt int64       `json:"mfa_token_expires_at,omitempty"`
+}
+
+// UnlockAccountRequest lifts a login lockout and forgets the failed attempts of the account
+type UnlockAccountRequest struct {
+	UserID string `json:"-"`
 }
 
 type LogoutRequest struct {
-	SessionID string `json:"session_id"`
+	SessionID      string    `json:"-"`
+	TokenID        string    `json:"-"` // jti of the access token used to log out
+	TokenExpiresAt time.Time `json:"-"`
+	IPAddress      string    `json:"-"`
+	UserAgent      string    `json:"-"`
 }
 
 type VerifyEmailRequest struct {
-	Email string `json:"email" validate:"required,email"`
-	Code  string `json:"code" validate:"required"`
+	Email     string `json:"email" validate:"required,email"`
+	Code      string `json:"code" validate:"required"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
 }
 
 type SendVerificationEmailRequest struct {
-	UserID string
-	Token  string // or other fields if needed
+	UserID string `json:"-"`
+}
+
+// SendVerificationCodeRequest asks for a one-time code verifying the email address, delivered
+// by the method, email when empty.
+type SendVerificationCodeRequest struct {
+	UserID         string            `json:"-"`
+	DeliveryMethod OTPDeliveryMethod `json:"delivery_method"`
+}
+
+type ForgotPasswordRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+	IPAddress   string `json:"ip_address,omitempty"`
+	UserAgent   string `json:"user_agent,omitempty"`
+}
+
+type MagicLinkRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// MagicLinkResponse is returned whether or not the email is registered. The device token must be
+// kept by the client and sent back with the token of the emailed link.
+type MagicLinkResponse struct {
+	DeviceToken string `json:"device_token"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+type MagicLinkLoginRequest struct {
+	Token       string `json:"token" validate:"required"`
+	DeviceToken string `json:"device_token,omitempty"` // Required when the server only accepts links on the requesting device
+	IPAddress   string `json:"ip_address,omitempty"`
+	UserAgent   string `json:"user_agent,omitempty"`
+}
+
+// DenyLoginRequest reports a sign-in the user did not make, with the token of the new sign-in email.
+type DenyLoginRequest struct {
+	Token     string `json:"token" validate:"required"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
 }
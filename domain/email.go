@@ -26,6 +26,12 @@ const (
 	EmailCodeVerification  EmailCode = "verification"
 	EmailCodePasswordReset EmailCode = "password_reset"
 	EmailCodeWelcome       EmailCode = "welcome"
+	EmailCodeOTP           EmailCode = "otp"
+	EmailCodeAccountLocked EmailCode = "account_locked"
+	EmailCodeMagicLink     EmailCode = "magic_link"
+	EmailCodeNewLogin      EmailCode = "new_login"
+
+	EmailCodeOrganizationInvitation EmailCode = "organization_invitation"
 )
 
 type EmailStatus string
@@ -0,0 +1,118 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+)
+
+/*************************************
+*        Impersonation errors        *
+*************************************/
+var (
+	ErrImpersonationNotFound = &DetailedError{
+		IDField:         "IMPERSONATION_NOT_FOUND",
+		StatusDescField: http.StatusText(http.StatusNotFound),
+		ErrorField:      "Impersonation not found",
+		StatusCodeField: http.StatusNotFound,
+	}
+	ErrImpersonationNotAllowed = &DetailedError{
+		IDField:         "IMPERSONATION_NOT_ALLOWED",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "This user cannot be impersonated",
+		StatusCodeField: http.StatusForbidden,
+	}
+	ErrImpersonationForbidden = &DetailedError{
+		IDField:         "IMPERSONATION_FORBIDDEN",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "This action is not available while impersonating a user",
+		StatusCodeField: http.StatusForbidden,
+	}
+	ErrNotImpersonating = &DetailedError{
+		IDField:         "NOT_IMPERSONATING",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "The current session is not an impersonation",
+		StatusCodeField: http.StatusBadRequest,
+	}
+)
+
+/***********************************************
+*       Impersonation entities and types       *
+***********************************************/
+
+// Impersonation is the audit record of an administrator acting as a user. It is written when the
+// impersonation starts and completed when it is stopped, an impersonation that was never stopped
+// ended at ExpiresAt.
+type Impersonation struct {
+	SQLModel
+	ActorID      string `json:"actor_id" gorm:"type:varchar(36);index;not null"`       // Administrator acting as the user
+	TargetUserID string `json:"target_user_id" gorm:"type:varchar(36);index;not null"` // Impersonated user
+	SessionID    string `json:"session_id" gorm:"type:varchar(36);index;not null"`     // Session created for the impersonation
+	TokenID      string `json:"-" gorm:"type:varchar(36);not null"`                    // jti of the access token, to revoke it
+	Reason       string `json:"reason" gorm:"type:varchar(500);not null"`              // Why the administrator needed access, e.g. a ticket
+	IPAddress    string `json:"ip_address" gorm:"type:varchar(45)"`                    // IP address of the administrator
+	UserAgent    string `json:"user_agent" gorm:"type:text"`                           // User agent of the administrator
+	StartedAt    int64  `json:"started_at" gorm:"not null"`                            // Milli timestamp
+	ExpiresAt    int64  `json:"expires_at" gorm:"not null"`                            // Milli timestamp, the token stops working at this time
+	EndedAt      int64  `json:"ended_at"`                                              // Milli timestamp, 0 while running or when it expired
+	EndedBy      string `json:"ended_by,omitempty" gorm:"type:varchar(36)"`            // User who stopped it
+}
+
+func (i *Impersonation) IsRunning(now int64) bool {
+	return i.EndedAt == 0 && i.ExpiresAt > now
+}
+
+type ImpersonationFilter struct {
+	ID           *string `json:"id,omitempty"`             // Filter by specific record ID
+	ActorID      *string `json:"actor_id,omitempty"`       // Filter by administrator
+	TargetUserID *string `json:"target_user_id,omitempty"` // Filter by impersonated user
+	SessionID    *string `json:"session_id,omitempty"`     // Filter by impersonation session
+	NotEnded     *bool   `json:"not_ended,omitempty"`      // Find impersonations that were not stopped yet
+}
+
+/************************
+*       Usecases        *
+************************/
+type ImpersonationUsecase interface {
+	StartImpersonation(ctx context.Context, req *StartImpersonationRequest) (*StartImpersonationResponse, error)
+	StopImpersonation(ctx context.Context, req *StopImpersonationRequest) error
+	EndImpersonation(ctx context.Context, req *EndImpersonationRequest) (*Impersonation, error)
+	ListImpersonations(ctx context.Context, req *ListImpersonationsRequest) ([]*Impersonation, *Pagination, error)
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+type StartImpersonationRequest struct {
+	ActorID      string `json:"-"`
+	TargetUserID string `json:"-"`
+	Reason       string `json:"reason" validate:"required,max=500"`
+	IPAddress    string `json:"ip_address,omitempty"`
+	UserAgent    string `json:"user_agent,omitempty"`
+}
+
+// StartImpersonationResponse carries an access token for the target user. There is no refresh
+// token, the impersonation ends when the access token expires.
+type StartImpersonationResponse struct {
+	Impersonation *Impersonation `json:"impersonation"`
+	User          *User          `json:"user"`
+	AccessToken   string         `json:"access_token"`
+}
+
+// StopImpersonationRequest ends the impersonation running in the current session.
+type StopImpersonationRequest struct {
+	SessionID string `json:"-"`
+	ActorID   string `json:"-"`
+}
+
+// EndImpersonationRequest ends any impersonation, for an administrator.
+type EndImpersonationRequest struct {
+	ID      string `json:"-"`
+	ActorID string `json:"-"`
+}
+
+type ListImpersonationsRequest struct {
+	ActorID      *string `json:"actor_id,omitempty" form:"actor_id"`
+	TargetUserID *string `json:"target_user_id,omitempty" form:"target_user_id"`
+	Page         int     `json:"page" form:"page"`
+	PerPage      int     `json:"per_page" form:"per_page"`
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"net/http"
+)
+
+/***************************
+*        MFA errors        *
+***************************/
+var (
+	ErrMFAAlreadyEnabled = &DetailedError{
+		IDField:         "MFA_ALREADY_ENABLED",
+		StatusDescField: http.StatusText(http.StatusConflict),
+		ErrorField:      "Two-factor authentication is already enabled",
+		StatusCodeField: http.StatusConflict,
+	}
+	ErrMFANotEnabled = &DetailedError{
+		IDField:         "MFA_NOT_ENABLED",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Two-factor authentication is not enabled",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrMFANotEnrolled = &DetailedError{
+		IDField:         "MFA_NOT_ENROLLED",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Two-factor authentication enrollment has not been started",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrInvalidMFACode = &DetailedError{
+		IDField:         "INVALID_MFA_CODE",
+		StatusDescField: http.StatusText(http.StatusUnauthorized),
+		ErrorField:      "Invalid two-factor authentication code",
+		StatusCodeField: http.StatusUnauthorized,
+	}
+	ErrInvalidMFAToken = &DetailedError{
+		IDField:         "INVALID_MFA_TOKEN",
+		StatusDescField: http.StatusText(http.StatusUnauthorized),
+		ErrorField:      "Invalid or expired MFA challenge token",
+		StatusCodeField: http.StatusUnauthorized,
+	}
+)
+
+/*************************************
+*       MFA entities and types       *
+*************************************/
+const (
+	MFARecoveryCodeCount = 10
+	// MFAChallengeMaxAttempts is the number of wrong codes after which an MFA challenge is discarded
+	MFAChallengeMaxAttempts = 5
+)
+
+// MFAMethod is a second factor a login can be completed with
+type MFAMethod string
+
+const (
+	MFAMethodTOTP    MFAMethod = "totp" // Authenticator app code, or a recovery code
+	MFAMethodPasskey MFAMethod = "passkey"
+)
+
+// UserMFA holds the TOTP configuration of a user. A record with Enabled=false and a
+// secret is a pending enrollment waiting for its confirmation code.
+type UserMFA struct {
+	SQLModel
+	UserID       string `json:"user_id" db:"user_id" gorm:"type:varchar(36);uniqueIndex;not null"` // Foreign key reference to User.ID
+	Secret       string `json:"-" db:"secret" gorm:"type:varchar(255)"`                            // Base32 encoded TOTP secret, encrypted with the MFA secret key
+	Enabled      bool   `json:"enabled" db:"enabled"`                                              // Whether login requires a second factor
+	EnabledAt    int64  `json:"enabled_at" db:"enabled_at"`                                        // When the enrollment was confirmed (milli timestamp)
+	LastUsedStep int64  `json:"-" db:"last_used_step"`                                             // Last accepted TOTP time step, a code cannot be replayed
+}
+
+type UserMFAFilter struct {
+	ID      *string `json:"id,omitempty"`      // Filter by specific record ID
+	UserID  *string `json:"user_id,omitempty"` // Filter by owner
+	Enabled *bool   `json:"enabled,omitempty"` // Filter by enabled status
+
+	LastUsedStepBefore *int64 `json:"last_used_step_before,omitempty"` // Find records whose last accepted TOTP step is older than this one
+}
+
+// MFARecoveryCode is a single-use backup code that can replace a TOTP code.
+// Only the SHA-256 hash of the code is persisted.
+type MFARecoveryCode struct {
+	SQLModel
+	UserID   string `json:"user_id" db:"user_id" gorm:"type:varchar(36);index;not null"`   // Foreign key reference to User.ID
+	CodeHash string `json:"-" db:"code_hash" gorm:"type:varchar(64);uniqueIndex;not null"` // Hex encoded SHA-256 of the normalized code
+	UsedAt   int64  `json:"used_at" db:"used_at"`                                          // When the code was consumed, 0 if not used yet
+}
+
+type MFARecoveryCodeFilter struct {
+	ID       *string `json:"id,omitempty"`        // Filter by specific code ID
+	UserID   *string `json:"user_id,omitempty"`   // Filter by owner
+	CodeHash *string `json:"code_hash,omitempty"` // Filter by hashed code value
+	Used     *bool   `json:"used,omitempty"`      // Filter by consumed status
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+type EnrollMFARequest struct {
+	UserID string `json:"-"`
+}
+
+type EnrollMFAResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type ConfirmMFARequest struct {
+	UserID string `json:"-"`
+	Code   string `json:"code" validate:"required,numeric,len=6"`
+}
+
+type DisableMFARequest struct {
+	UserID   string `json:"-"`
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"` // TOTP or recovery code
+}
+
+type RegenerateRecoveryCodesRequest struct {
+	UserID string `json:"-"`
+	Code   string `json:"code" validate:"required,numeric,len=6"`
+}
+
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type VerifyMFALoginRequest struct {
+	MFAToken  string `json:"mfa_token" validate:"required"`
+	Code      string `json:"code" validate:"required"` // TOTP or recovery code
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
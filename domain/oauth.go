@@ -0,0 +1,104 @@
+package domain
+
+import "net/http"
+
+/*****************************
+*        OAuth errors        *
+*****************************/
+var (
+	ErrOAuthProviderNotFound = &DetailedError{
+		IDField:         "OAUTH_PROVIDER_NOT_FOUND",
+		StatusDescField: http.StatusText(http.StatusNotFound),
+		ErrorField:      "Identity provider not found",
+		StatusCodeField: http.StatusNotFound,
+	}
+	ErrInvalidOAuthRedirectURI = &DetailedError{
+		IDField:         "INVALID_OAUTH_REDIRECT_URI",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Redirect URI is not allowed for this identity provider",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrInvalidOAuthState = &DetailedError{
+		IDField:         "INVALID_OAUTH_STATE",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Invalid or expired login state, please start the login again",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrOAuthExchangeFailed = &DetailedError{
+		IDField:         "OAUTH_EXCHANGE_FAILED",
+		StatusDescField: http.StatusText(http.StatusBadGateway),
+		ErrorField:      "Failed to complete the login with the identity provider",
+		StatusCodeField: http.StatusBadGateway,
+	}
+	ErrInvalidIDToken = &DetailedError{
+		IDField:         "INVALID_ID_TOKEN",
+		StatusDescField: http.StatusText(http.StatusUnauthorized),
+		ErrorField:      "ID token from the identity provider is invalid",
+		StatusCodeField: http.StatusUnauthorized,
+	}
+	ErrExternalEmailNotVerified = &DetailedError{
+		IDField:         "EXTERNAL_EMAIL_NOT_VERIFIED",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "The identity provider did not verify the email address of this account",
+		StatusCodeField: http.StatusForbidden,
+	}
+)
+
+/***************************************
+*       OAuth entities and types       *
+***************************************/
+
+// ExternalIdentity links an account of an identity provider to a user
+type ExternalIdentity struct {
+	SQLModel
+	UserID   string `json:"user_id" gorm:"type:varchar(36);index;not null"`
+	Provider string `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_external_identity_subject"`
+	Subject  string `json:"subject" gorm:"type:varchar(255);not null;uniqueIndex:idx_external_identity_subject"` // Account ID at the provider
+	Email    string `json:"email" gorm:"type:varchar(100)"`                                                      // Email reported by the provider when the identity was linked
+}
+
+type ExternalIdentityFilter struct {
+	ID       *string `json:"id,omitempty"`       // Filter by specific record ID
+	UserID   *string `json:"user_id,omitempty"`  // Filter by linked user
+	Provider *string `json:"provider,omitempty"` // Filter by identity provider
+	Subject  *string `json:"subject,omitempty"`  // Filter by account ID at the provider
+}
+
+// ExternalProfile is the account an identity provider authenticated.
+type ExternalProfile struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+}
+
+// OAuthState is kept between the start and the end of a login with an identity provider.
+type OAuthState struct {
+	Provider     string `json:"provider"`
+	RedirectURI  string `json:"redirect_uri"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+type StartOAuthLoginRequest struct {
+	Provider    string `json:"-"`
+	RedirectURI string `json:"redirect_uri" form:"redirect_uri"` // Must be registered for the provider, the first one is used when empty
+}
+
+type StartOAuthLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+	ExpiresAt        int64  `json:"expires_at"`
+}
+
+type CompleteOAuthLoginRequest struct {
+	Provider  string `json:"-"`
+	Code      string `json:"code" validate:"required"`
+	State     string `json:"state" validate:"required"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
@@ -0,0 +1,310 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+/*************************************
+*        Organization errors         *
+*************************************/
+var (
+	ErrOrganizationNotFound = &DetailedError{
+		IDField:         "ORGANIZATION_NOT_FOUND",
+		StatusDescField: http.StatusText(http.StatusNotFound),
+		ErrorField:      "Organization not found",
+		StatusCodeField: http.StatusNotFound,
+	}
+	ErrOrganizationAlreadyExists = &DetailedError{
+		IDField:         "ORGANIZATION_ALREADY_EXISTS",
+		StatusDescField: http.StatusText(http.StatusConflict),
+		ErrorField:      "Organization with this slug already exists",
+		StatusCodeField: http.StatusConflict,
+	}
+	ErrOrganizationValidationFailed = &DetailedError{
+		IDField:         "ORGANIZATION_VALIDATION_FAILED",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Organization validation failed",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrMemberNotFound = &DetailedError{
+		IDField:         "MEMBER_NOT_FOUND",
+		StatusDescField: http.StatusText(http.StatusNotFound),
+		ErrorField:      "Organization member not found",
+		StatusCodeField: http.StatusNotFound,
+	}
+	ErrAlreadyMember = &DetailedError{
+		IDField:         "ALREADY_MEMBER",
+		StatusDescField: http.StatusText(http.StatusConflict),
+		ErrorField:      "User is already a member of the organization",
+		StatusCodeField: http.StatusConflict,
+	}
+	ErrInvalidOrgRole = &DetailedError{
+		IDField:         "INVALID_ORG_ROLE",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Organization role must be owner, admin or member",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrLastOwner = &DetailedError{
+		IDField:         "LAST_OWNER",
+		StatusDescField: http.StatusText(http.StatusConflict),
+		ErrorField:      "The last owner of an organization cannot leave it or lose the role",
+		StatusCodeField: http.StatusConflict,
+	}
+	ErrInvitationNotFound = &DetailedError{
+		IDField:         "INVITATION_NOT_FOUND",
+		StatusDescField: http.StatusText(http.StatusNotFound),
+		ErrorField:      "Invitation not found",
+		StatusCodeField: http.StatusNotFound,
+	}
+	ErrInvalidInvitation = &DetailedError{
+		IDField:         "INVALID_INVITATION",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Invitation is invalid, expired or already used",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrTenantRequired = &DetailedError{
+		IDField:         "TENANT_REQUIRED",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "No organization selected, send the X-Organization-ID header or switch to an organization",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrTenantMismatch = &DetailedError{
+		IDField:         "TENANT_MISMATCH",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "The record belongs to another organization",
+		StatusCodeField: http.StatusForbidden,
+	}
+	ErrTenantScopeUndeclared = &DetailedError{
+		IDField:         "TENANT_SCOPE_UNDECLARED",
+		StatusDescField: http.StatusText(http.StatusInternalServerError),
+		ErrorField:      "The table is neither scoped to organizations nor declared global",
+		StatusCodeField: http.StatusInternalServerError,
+	}
+	ErrNotOrganizationMember = &DetailedError{
+		IDField:         "NOT_ORGANIZATION_MEMBER",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "You are not a member of this organization",
+		StatusCodeField: http.StatusForbidden,
+	}
+)
+
+/**********************************************
+*       Organization entities and types       *
+**********************************************/
+
+// organizationSlugPattern keeps slugs usable in URLs and subdomains
+var organizationSlugPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]{1,48}[a-z0-9])$`)
+
+// Organization is a customer company hosted on the deployment. Users are global, they join
+// organizations through memberships.
+type Organization struct {
+	SQLModel
+	Name      string `json:"name" gorm:"type:varchar(100);not null"`
+	Slug      string `json:"slug" gorm:"type:varchar(50);uniqueIndex;not null"`
+	CreatedBy string `json:"created_by" gorm:"type:varchar(36);not null"` // User who created the organization
+}
+
+func (o *Organization) Validate() error {
+	if strings.TrimSpace(o.Name) == "" {
+		return ErrOrganizationValidationFailed.WithError("name must be not empty")
+	}
+	if len(o.Name) > 100 {
+		return ErrOrganizationValidationFailed.WithError("name must be at most 100 characters")
+	}
+	if !organizationSlugPattern.MatchString(o.Slug) {
+		return ErrOrganizationValidationFailed.WithError("slug must be 3 to 50 lowercase letters, digits or hyphens, starting and ending with a letter or digit")
+	}
+	return nil
+}
+
+type OrganizationFilter struct {
+	ID   *string  `json:"id,omitempty"`
+	IDIn []string `json:"id_in,omitempty"`
+	Slug *string  `json:"slug,omitempty"`
+}
+
+// OrgRole is the role of a member within one organization, independent of the global roles
+type OrgRole string
+
+const (
+	OrgRoleOwner  OrgRole = "owner"  // Manages the organization, its admins and owners
+	OrgRoleAdmin  OrgRole = "admin"  // Invites and manages members
+	OrgRoleMember OrgRole = "member" // Works in the organization
+)
+
+var orgRoleRanks = map[OrgRole]int{
+	OrgRoleMember: 1,
+	OrgRoleAdmin:  2,
+	OrgRoleOwner:  3,
+}
+
+func (r OrgRole) IsValid() bool {
+	_, ok := orgRoleRanks[r]
+	return ok
+}
+
+// AtLeast reports whether the role grants everything the other role does
+func (r OrgRole) AtLeast(other OrgRole) bool {
+	return orgRoleRanks[r] >= orgRoleRanks[other]
+}
+
+// TenantOwned is implemented by the records that belong to one organization. The tenant scope of
+// the SQL handler fills their organization in, and refuses records of another organization.
+type TenantOwned interface {
+	GetOrganizationID() string
+	SetOrganizationID(organizationID string)
+}
+
+// OrganizationMember is the membership of a user in an organization
+type OrganizationMember struct {
+	SQLModel
+	OrganizationID string        `json:"organization_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_organization_members_org_user"`
+	UserID         string        `json:"user_id" gorm:"type:varchar(36);not null;uniqueIndex:idx_organization_members_org_user;index"`
+	Role           OrgRole       `json:"role" gorm:"type:varchar(20);not null"`
+	Organization   *Organization `json:"organization,omitempty" gorm:"foreignKey:OrganizationID"`
+	User           *User         `json:"user,omitempty" gorm:"foreignKey:UserID"`
+}
+
+func (m *OrganizationMember) GetOrganizationID() string {
+	return m.OrganizationID
+}
+
+func (m *OrganizationMember) SetOrganizationID(organizationID string) {
+	m.OrganizationID = organizationID
+}
+
+type OrganizationMemberFilter struct {
+	UserID *string  `json:"user_id,omitempty"`
+	Role   *OrgRole `json:"role,omitempty"`
+}
+
+// OrganizationInvitation invites an email address to join an organization. Only the hash of the
+// token sent by email is stored.
+type OrganizationInvitation struct {
+	SQLModel
+	OrganizationID string  `json:"organization_id" gorm:"type:varchar(36);not null;index"`
+	Email          string  `json:"email" gorm:"type:varchar(100);not null;index"`
+	Role           OrgRole `json:"role" gorm:"type:varchar(20);not null"`
+	TokenHash      string  `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	InvitedBy      string  `json:"invited_by" gorm:"type:varchar(36);not null"`
+	ExpiresAt      int64   `json:"expires_at" gorm:"not null"`                    // Milli timestamp
+	AcceptedAt     int64   `json:"accepted_at,omitempty"`                         // Milli timestamp, 0 while pending
+	AcceptedBy     string  `json:"accepted_by,omitempty" gorm:"type:varchar(36)"` // User who joined with it
+}
+
+func (i *OrganizationInvitation) GetOrganizationID() string {
+	return i.OrganizationID
+}
+
+func (i *OrganizationInvitation) SetOrganizationID(organizationID string) {
+	i.OrganizationID = organizationID
+}
+
+// IsPending reports whether the invitation can still be accepted
+func (i *OrganizationInvitation) IsPending() bool {
+	return i.AcceptedAt == 0 && i.DeletedAt == 0 && i.ExpiresAt > time.Now().UnixMilli()
+}
+
+type OrganizationInvitationFilter struct {
+	ID        *string `json:"id,omitempty"`
+	Email     *string `json:"email,omitempty"`
+	TokenHash *string `json:"-"`
+	Pending   *bool   `json:"pending,omitempty"` // Not accepted nor expired
+}
+
+type tenantContextKey struct{}
+
+// ContextWithTenant returns a copy of the context acting in the organization. Repositories scoped
+// to tenants only see the records of this organization.
+func ContextWithTenant(ctx context.Context, organizationID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, organizationID)
+}
+
+// TenantFromContext returns the organization the context acts in
+func TenantFromContext(ctx context.Context) (string, bool) {
+	organizationID, ok := ctx.Value(tenantContextKey{}).(string)
+	return organizationID, ok && organizationID != ""
+}
+
+/************************
+*       Usecases        *
+************************/
+type OrganizationUsecase interface {
+	CreateOrganization(ctx context.Context, req *CreateOrganizationRequest) (*Organization, error)
+	ListMyOrganizations(ctx context.Context, userID string) ([]*OrganizationMember, error)
+	SwitchOrganization(ctx context.Context, req *SwitchOrganizationRequest) (*SwitchOrganizationResponse, error)
+	AcceptInvitation(ctx context.Context, req *AcceptInvitationRequest) (*OrganizationMember, error)
+
+	// The methods below act in the organization of the tenant context
+	GetOrganization(ctx context.Context) (*Organization, error)
+	UpdateOrganization(ctx context.Context, req *UpdateOrganizationRequest) (*Organization, error)
+	ListMembers(ctx context.Context) ([]*OrganizationMember, error)
+	UpdateMemberRole(ctx context.Context, req *UpdateMemberRoleRequest) (*OrganizationMember, error)
+	RemoveMember(ctx context.Context, req *RemoveMemberRequest) error
+	ListInvitations(ctx context.Context, req *ListInvitationsRequest) ([]*OrganizationInvitation, error)
+	InviteMember(ctx context.Context, req *InviteMemberRequest) (*OrganizationInvitation, error)
+	RevokeInvitation(ctx context.Context, req *RevokeInvitationRequest) error
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+type CreateOrganizationRequest struct {
+	Name    string `json:"name" validate:"required"`
+	Slug    string `json:"slug" validate:"required"`
+	ActorID string `json:"-"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name   *string             `json:"name,omitempty"`
+	Member *OrganizationMember `json:"-"` // Membership of the caller
+}
+
+// SwitchOrganizationRequest selects the organization the tokens of the session act in. An empty
+// OrganizationID leaves any organization.
+type SwitchOrganizationRequest struct {
+	OrganizationID string `json:"organization_id"`
+	UserID         string `json:"-"`
+	SessionID      string `json:"-"`
+}
+
+type SwitchOrganizationResponse struct {
+	AccessToken    string `json:"access_token"`
+	OrganizationID string `json:"organization_id,omitempty"`
+}
+
+type AcceptInvitationRequest struct {
+	Token  string `json:"token" validate:"required"`
+	UserID string `json:"-"`
+}
+
+type UpdateMemberRoleRequest struct {
+	UserID string              `json:"-"`
+	Role   OrgRole             `json:"role" validate:"required"`
+	Member *OrganizationMember `json:"-"` // Membership of the caller
+}
+
+type RemoveMemberRequest struct {
+	UserID string              `json:"-"`
+	Member *OrganizationMember `json:"-"` // Membership of the caller
+}
+
+type ListInvitationsRequest struct {
+	Pending *bool               `form:"pending"`
+	Member  *OrganizationMember `json:"-"` // Membership of the caller
+}
+
+type InviteMemberRequest struct {
+	Email  string              `json:"email" validate:"required,email"`
+	Role   OrgRole             `json:"role" validate:"required"`
+	Member *OrganizationMember `json:"-"` // Membership of the caller
+}
+
+type RevokeInvitationRequest struct {
+	ID     string              `json:"-"`
+	Member *OrganizationMember `json:"-"` // Membership of the caller
+}
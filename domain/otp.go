@@ -0,0 +1,152 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+/***************************
+*        OTP errors        *
+***************************/
+var (
+	ErrOTPInvalid = &DetailedError{
+		IDField:         "OTP_INVALID",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Invalid or expired OTP code",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrOTPTooManyAttempts = &DetailedError{
+		IDField:         "OTP_TOO_MANY_ATTEMPTS",
+		StatusDescField: http.StatusText(http.StatusTooManyRequests),
+		ErrorField:      "Too many invalid OTP attempts, please request a new code",
+		StatusCodeField: http.StatusTooManyRequests,
+	}
+	ErrOTPResendTooSoon = &DetailedError{
+		IDField:         "OTP_RESEND_TOO_SOON",
+		StatusDescField: http.StatusText(http.StatusTooManyRequests),
+		ErrorField:      "Please wait before requesting a new OTP code",
+		StatusCodeField: http.StatusTooManyRequests,
+	}
+	ErrOTPDeliveryMethodNotSupported = &DetailedError{
+		IDField:         "OTP_DELIVERY_METHOD_NOT_SUPPORTED",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "OTP delivery method is not supported",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrOTPInvalidAction = &DetailedError{
+		IDField:         "OTP_INVALID_ACTION",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Invalid OTP action",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrOTPSendFailed = &DetailedError{
+		IDField:         "OTP_SEND_FAILED",
+		StatusDescField: http.StatusText(http.StatusInternalServerError),
+		ErrorField:      "Failed to send OTP code",
+		StatusCodeField: http.StatusInternalServerError,
+	}
+)
+
+/*************************************
+*       OTP entities and types       *
+*************************************/
+const OTPCodeLength = 6
+
+type Action string
+
+const (
+	ActionRegister      Action = "register"
+	ActionVerifyEmail   Action = "verify_email"
+	ActionLogin         Action = "login"
+	ActionResetPassword Action = "reset_password"
+	ActionDeleteAccount Action = "delete_account"
+)
+
+func (a Action) IsValid() bool {
+	switch a {
+	case ActionRegister, ActionVerifyEmail, ActionLogin, ActionResetPassword, ActionDeleteAccount:
+		return true
+	default:
+		return false
+	}
+}
+
+type OTPDeliveryMethod string
+
+const (
+	OTPDeliveryEmail OTPDeliveryMethod = "email"
+)
+
+func (m OTPDeliveryMethod) IsValid() bool {
+	switch m {
+	case OTPDeliveryEmail:
+		return true
+	default:
+		return false
+	}
+}
+
+// OTP is the cached state of an issued code. Only the hash of the code is kept.
+type OTP struct {
+	Action    Action `json:"action"`
+	Recipient string `json:"recipient"`
+	CodeHash  string `json:"code_hash"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// OTPResendState tracks how many codes were sent for an action/recipient pair and when the next one is allowed.
+type OTPResendState struct {
+	SendCount  int   `json:"send_count"`
+	NextSendAt int64 `json:"next_send_at"`
+}
+
+// OTPCode is a freshly issued code returned to the caller, which is responsible for delivering it.
+type OTPCode struct {
+	Code       string        `json:"-"`
+	ExpiresIn  time.Duration `json:"expires_in"`
+	ExpiresAt  int64         `json:"expires_at"`
+	NextSendAt int64         `json:"next_send_at"`
+}
+
+// OTPMessage is handed to a delivery method to send a code to its recipient.
+type OTPMessage struct {
+	Action    Action
+	Recipient string
+	Code      string
+	ExpiresIn time.Duration
+}
+
+/************************
+*       Usecases        *
+************************/
+type OTPUsecase interface {
+	Generate(ctx context.Context, req *GenerateOTPRequest) (*OTPCode, error)
+	Send(ctx context.Context, req *SendOTPRequest) (*OTPSendResponse, error)
+	Verify(ctx context.Context, req *VerifyOTPRequest) error
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+type GenerateOTPRequest struct {
+	Action    Action `json:"action" validate:"required,action"`
+	Recipient string `json:"recipient" validate:"required"`
+}
+
+type SendOTPRequest struct {
+	Action         Action            `json:"action" validate:"required,action"`
+	Recipient      string            `json:"recipient" validate:"required"`
+	DeliveryMethod OTPDeliveryMethod `json:"delivery_method" validate:"required,otp_delivery_method"`
+}
+
+type OTPSendResponse struct {
+	ExpiresAt  int64 `json:"expires_at"`
+	NextSendAt int64 `json:"next_send_at"`
+}
+
+type VerifyOTPRequest struct {
+	Action    Action `json:"action" validate:"required,action"`
+	Recipient string `json:"recipient" validate:"required"`
+	Code      string `json:"code" validate:"required,numeric,len=6"`
+}
@@ -0,0 +1,223 @@
+package domain
+
+/*******************************************
+*       Policy entities and types          *
+*******************************************/
+
+// Principal is the authenticated user a request acts for, with the permissions its roles grant
+type Principal struct {
+	UserID      string
+	Roles       []RoleID
+	Permissions []PermissionID
+}
+
+func NewPrincipal(user *User, permissions []PermissionID) *Principal {
+	principal := &Principal{
+		UserID:      user.ID,
+		Permissions: permissions,
+	}
+	for _, role := range user.Roles {
+		if role != nil {
+			principal.Roles = append(principal.Roles, role.ID)
+		}
+	}
+	return principal
+}
+
+func (p *Principal) IsSelf(userID string) bool {
+	return p.UserID != "" && p.UserID == userID
+}
+
+func (p *Principal) IsSuperAdmin() bool {
+	for _, roleID := range p.Roles {
+		if roleID == RoleIDSuperAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Principal) HasPermissions(permissions ...PermissionID) bool {
+	return HasAllPermissions(p.Permissions, permissions...)
+}
+
+// UserAction is something a principal can do to a user account
+type UserAction string
+
+const (
+	UserActionView           UserAction = "view"
+	UserActionUpdateProfile  UserAction = "update_profile"
+	UserActionChangePassword UserAction = "change_password"
+	UserActionUpdateStatus   UserAction = "update_status"
+	UserActionManageRoles    UserAction = "manage_roles"
+	UserActionDelete         UserAction = "delete"
+	UserActionRestore        UserAction = "restore"
+	UserActionBan            UserAction = "ban"
+	UserActionForceLogout    UserAction = "force_logout"
+)
+
+// adminUserActions are the actions taken by administrators on other users, with the permission
+// each one requires
+var adminUserActions = map[UserAction]PermissionID{
+	UserActionUpdateStatus: PermissionUsersWrite,
+	UserActionManageRoles:  PermissionRolesWrite,
+	UserActionDelete:       PermissionUsersWrite,
+	UserActionRestore:      PermissionUsersWrite,
+	UserActionBan:          PermissionUsersWrite,
+	UserActionForceLogout:  PermissionUsersWrite,
+}
+
+// AuthorizeUserAction decides whether the principal can take the action on the target user:
+//   - users view themselves, or anyone with the users:read permission
+//   - users change their own profile and password, and nobody else's
+//   - administrative actions need their permission, and never apply to the principal itself
+//   - only super admins act on admins and other super admins
+//
+// A denial is an ErrForbidden with the reason.
+func AuthorizeUserAction(principal *Principal, action UserAction, target *User) error {
+	if err := AuthorizeUserLookup(principal, action, target.ID); err != nil {
+		return err
+	}
+	if _, ok := adminUserActions[action]; ok && target.HasAnyRole(RoleIDAdmin, RoleIDSuperAdmin) && !principal.IsSuperAdmin() {
+		return ErrForbidden.WithReason("only super admins can act on administrators")
+	}
+	return nil
+}
+
+// AuthorizeUserLookup is the part of AuthorizeUserAction decided by the ID of the target user
+// alone. It runs before the user is loaded, so that a denied principal gets the same answer
+// whether the user exists or not.
+func AuthorizeUserLookup(principal *Principal, action UserAction, targetID string) error {
+	if principal == nil {
+		return ErrForbidden.WithReason("the request is not made by a user")
+	}
+
+	switch action {
+	case UserActionView:
+		if principal.IsSelf(targetID) || principal.HasPermissions(PermissionUsersRead) {
+			return nil
+		}
+		return ErrForbidden.WithReason("you can only view your own account")
+	case UserActionUpdateProfile, UserActionChangePassword:
+		if principal.IsSelf(targetID) {
+			return nil
+		}
+		return ErrForbidden.WithReason("you can only change your own account")
+	}
+
+	permission, ok := adminUserActions[action]
+	if !ok {
+		return ErrForbidden.WithReasonf("unknown action %q", action)
+	}
+	if principal.IsSelf(targetID) {
+		return ErrForbidden.WithReason("administrators cannot perform this action on their own account")
+	}
+	if !principal.HasPermissions(permission) {
+		return ErrForbidden.WithReasonf("the %s permission is required", permission)
+	}
+	return nil
+}
+
+// AuthorizeUserCreation decides whether the principal can create user accounts, which is for
+// administrators with the users:write permission. Everyone else signs up through the auth service.
+func AuthorizeUserCreation(principal *Principal) error {
+	if principal == nil {
+		return ErrForbidden.WithReason("the request is not made by a user")
+	}
+	if !principal.HasPermissions(PermissionUsersWrite) {
+		return ErrForbidden.WithReasonf("the %s permission is required", PermissionUsersWrite)
+	}
+	return nil
+}
+
+// AuthorizeAPIKeyScopes decides whether the principal can give the scopes to an API key. Every
+// scope is a permission of the same name, a key never grants more than the principal holds.
+func AuthorizeAPIKeyScopes(principal *Principal, scopes []Scope) error {
+	if principal == nil {
+		return ErrForbidden.WithReason("the request is not made by a user")
+	}
+	for _, scope := range scopes {
+		if !principal.HasPermissions(PermissionID(scope)) {
+			return ErrForbidden.WithReasonf("you cannot grant the %s scope without the permission", scope)
+		}
+	}
+	return nil
+}
+
+// AuthorizeUserUpdate decides whether the principal can apply the update to the target user. The
+// profile fields are self-only, the status is for administrators.
+func AuthorizeUserUpdate(principal *Principal, target *User, req *UserUpdateRequest) error {
+	if req.Username != nil || req.Email != nil || req.FirstName != nil || req.LastName != nil {
+		if err := AuthorizeUserAction(principal, UserActionUpdateProfile, target); err != nil {
+			return err
+		}
+	}
+	if req.Status != nil && *req.Status != target.Status {
+		if err := AuthorizeUserAction(principal, UserActionUpdateStatus, target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthorizeUserUpdateLookup is the AuthorizeUserLookup of an update. Updates of another user
+// with a status need the permission to change it, even when it turns out to be the current one.
+// Principals updating themselves are left to AuthorizeUserUpdate, their account exists.
+func AuthorizeUserUpdateLookup(principal *Principal, targetID string, req *UserUpdateRequest) error {
+	if principal != nil && principal.IsSelf(targetID) {
+		return nil
+	}
+	if req.Username != nil || req.Email != nil || req.FirstName != nil || req.LastName != nil {
+		if err := AuthorizeUserLookup(principal, UserActionUpdateProfile, targetID); err != nil {
+			return err
+		}
+	}
+	if req.Status != nil {
+		if err := AuthorizeUserLookup(principal, UserActionUpdateStatus, targetID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuthorizeOrganizationUpdate decides whether the member can change its organization, which is
+// for owners only.
+func AuthorizeOrganizationUpdate(actor *OrganizationMember) error {
+	if actor == nil || !actor.Role.AtLeast(OrgRoleOwner) {
+		return ErrForbidden.WithReason("only owners can change the organization")
+	}
+	return nil
+}
+
+// AuthorizeOrgRoleGrant decides whether the member can give the role to someone, by inviting them
+// or by changing their role. Admins and owners grant roles up to their own.
+func AuthorizeOrgRoleGrant(actor *OrganizationMember, role OrgRole) error {
+	if actor == nil || !actor.Role.AtLeast(OrgRoleAdmin) {
+		return ErrForbidden.WithReason("only admins and owners can manage members")
+	}
+	if !actor.Role.AtLeast(role) {
+		return ErrForbidden.WithReasonf("you cannot grant the %s role", role)
+	}
+	return nil
+}
+
+// AuthorizeInvitationManagement decides whether the member can see and revoke the invitations of
+// its organization, which is for admins and owners.
+func AuthorizeInvitationManagement(actor *OrganizationMember) error {
+	if actor == nil || !actor.Role.AtLeast(OrgRoleAdmin) {
+		return ErrForbidden.WithReason("only admins and owners can manage invitations")
+	}
+	return nil
+}
+
+// AuthorizeMemberManagement decides whether the member can change the role of the target member
+// or remove it. Admins manage the members below them, owners manage everyone.
+func AuthorizeMemberManagement(actor, target *OrganizationMember) error {
+	if actor == nil || !actor.Role.AtLeast(OrgRoleAdmin) {
+		return ErrForbidden.WithReason("only admins and owners can manage members")
+	}
+	if actor.Role != OrgRoleOwner && target.Role.AtLeast(actor.Role) {
+		return ErrForbidden.WithReason("only owners can manage admins and owners")
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func testUser(id string, roles ...RoleID) *User {
+	user := &User{Status: UserSTTActive}
+	user.ID = id
+	for _, role := range roles {
+		user.Roles = append(user.Roles, &Role{ID: role})
+	}
+	return user
+}
+
+func testPrincipal(id string, roles []RoleID, permissions ...PermissionID) *Principal {
+	return &Principal{UserID: id, Roles: roles, Permissions: permissions}
+}
+
+func TestAuthorizeUserAction(t *testing.T) {
+	self := testPrincipal("user-1", []RoleID{RoleIDUser})
+	reader := testPrincipal("reader-1", []RoleID{RoleIDAdmin}, PermissionUsersRead)
+	admin := testPrincipal("admin-1", []RoleID{RoleIDAdmin}, PermissionUsersRead, PermissionUsersWrite)
+	superAdmin := testPrincipal("super-1", []RoleID{RoleIDSuperAdmin}, PermissionUsersRead, PermissionUsersWrite, PermissionRolesWrite)
+
+	user := testUser("user-1", RoleIDUser)
+	otherAdmin := testUser("admin-2", RoleIDAdmin)
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		action    UserAction
+		target    *User
+		allowed   bool
+	}{
+		{name: "no principal", action: UserActionView, target: user},
+		{name: "user views themselves", principal: self, action: UserActionView, target: user, allowed: true},
+		{name: "user views another user", principal: self, action: UserActionView, target: otherAdmin},
+		{name: "reader views another user", principal: reader, action: UserActionView, target: user, allowed: true},
+		{name: "user updates their profile", principal: self, action: UserActionUpdateProfile, target: user, allowed: true},
+		{name: "admin updates the profile of a user", principal: admin, action: UserActionUpdateProfile, target: user},
+		{name: "admin changes the password of a user", principal: superAdmin, action: UserActionChangePassword, target: user},
+		{name: "user bans themselves", principal: self, action: UserActionBan, target: user},
+		{name: "reader bans a user", principal: reader, action: UserActionBan, target: user},
+		{name: "admin bans a user", principal: admin, action: UserActionBan, target: user, allowed: true},
+		{name: "admin bans themselves", principal: admin, action: UserActionBan, target: testUser("admin-1", RoleIDAdmin)},
+		{name: "admin bans another admin", principal: admin, action: UserActionBan, target: otherAdmin},
+		{name: "super admin bans an admin", principal: superAdmin, action: UserActionBan, target: otherAdmin, allowed: true},
+		{name: "admin manages roles without the permission", principal: admin, action: UserActionManageRoles, target: user},
+		{name: "super admin manages roles", principal: superAdmin, action: UserActionManageRoles, target: user, allowed: true},
+		{name: "unknown action", principal: superAdmin, action: "archive", target: user},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeUserAction(tt.principal, tt.action, tt.target)
+			if tt.allowed && err != nil {
+				t.Errorf("AuthorizeUserAction() error = %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrForbidden) {
+				t.Errorf("AuthorizeUserAction() error = %v, want %v", err, ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserLookup(t *testing.T) {
+	self := testPrincipal("user-1", []RoleID{RoleIDUser})
+	reader := testPrincipal("reader-1", []RoleID{RoleIDAdmin}, PermissionUsersRead)
+	admin := testPrincipal("admin-1", []RoleID{RoleIDAdmin}, PermissionUsersRead, PermissionUsersWrite)
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		action    UserAction
+		targetID  string
+		allowed   bool
+	}{
+		{name: "no principal", action: UserActionView, targetID: "user-1"},
+		{name: "user views themselves", principal: self, action: UserActionView, targetID: "user-1", allowed: true},
+		{name: "user views another user", principal: self, action: UserActionView, targetID: "user-2"},
+		{name: "reader views another user", principal: reader, action: UserActionView, targetID: "user-2", allowed: true},
+		{name: "user changes their password", principal: self, action: UserActionChangePassword, targetID: "user-1", allowed: true},
+		{name: "admin changes the password of a user", principal: admin, action: UserActionChangePassword, targetID: "user-1"},
+		{name: "reader bans a user", principal: reader, action: UserActionBan, targetID: "user-1"},
+		{name: "admin bans themselves", principal: admin, action: UserActionBan, targetID: "admin-1"},
+		// Whether the target is an administrator is only known once it is loaded
+		{name: "admin bans another user", principal: admin, action: UserActionBan, targetID: "admin-2", allowed: true},
+		{name: "unknown action", principal: admin, action: "archive", targetID: "user-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeUserLookup(tt.principal, tt.action, tt.targetID)
+			if tt.allowed && err != nil {
+				t.Errorf("AuthorizeUserLookup() error = %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrForbidden) {
+				t.Errorf("AuthorizeUserLookup() error = %v, want %v", err, ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserCreation(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *Principal
+		allowed   bool
+	}{
+		{name: "no principal"},
+		{name: "user", principal: testPrincipal("user-1", []RoleID{RoleIDUser})},
+		{name: "reader", principal: testPrincipal("reader-1", []RoleID{RoleIDAdmin}, PermissionUsersRead)},
+		{name: "admin", principal: testPrincipal("admin-1", []RoleID{RoleIDAdmin}, PermissionUsersWrite), allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeUserCreation(tt.principal)
+			if tt.allowed && err != nil {
+				t.Errorf("AuthorizeUserCreation() error = %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrForbidden) {
+				t.Errorf("AuthorizeUserCreation() error = %v, want %v", err, ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestAuthorizeUserUpdate(t *testing.T) {
+	self := testPrincipal("user-1", []RoleID{RoleIDUser})
+	admin := testPrincipal("admin-1", []RoleID{RoleIDAdmin}, PermissionUsersWrite)
+	name := "Jane"
+	active, banned := UserSTTActive, UserSTTBanned
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		req       *UserUpdateRequest
+		allowed   bool
+	}{
+		{name: "user changes their name", principal: self, req: &UserUpdateRequest{FirstName: &name}, allowed: true},
+		{name: "user bans themselves", principal: self, req: &UserUpdateRequest{Status: &banned}},
+		{name: "user keeps their status", principal: self, req: &UserUpdateRequest{FirstName: &name, Status: &active}, allowed: true},
+		{name: "admin changes the name of a user", principal: admin, req: &UserUpdateRequest{FirstName: &name}},
+		{name: "admin bans a user", principal: admin, req: &UserUpdateRequest{Status: &banned}, allowed: true},
+		{name: "empty update", principal: admin, req: &UserUpdateRequest{}, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeUserUpdate(tt.principal, testUser("user-1", RoleIDUser), tt.req)
+			if tt.allowed && err != nil {
+				t.Errorf("AuthorizeUserUpdate() error = %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrForbidden) {
+				t.Errorf("AuthorizeUserUpdate() error = %v, want %v", err, ErrForbidden)
+			}
+		})
+	}
+}
+
+func testMember(userID string, role OrgRole) *OrganizationMember {
+	return &OrganizationMember{UserID: userID, Role: role}
+}
+
+func TestAuthorizeOrganizationPolicies(t *testing.T) {
+	owner := testMember("owner-1", OrgRoleOwner)
+	admin := testMember("admin-1", OrgRoleAdmin)
+	member := testMember("member-1", OrgRoleMember)
+
+	tests := []struct {
+		name    string
+		check   func() error
+		allowed bool
+	}{
+		{name: "no member updates the organization", check: func() error { return AuthorizeOrganizationUpdate(nil) }},
+		{name: "admin updates the organization", check: func() error { return AuthorizeOrganizationUpdate(admin) }},
+		{name: "owner updates the organization", check: func() error { return AuthorizeOrganizationUpdate(owner) }, allowed: true},
+
+		{name: "no member grants a role", check: func() error { return AuthorizeOrgRoleGrant(nil, OrgRoleMember) }},
+		{name: "member grants the member role", check: func() error { return AuthorizeOrgRoleGrant(member, OrgRoleMember) }},
+		{name: "admin grants the member role", check: func() error { return AuthorizeOrgRoleGrant(admin, OrgRoleMember) }, allowed: true},
+		{name: "admin grants the admin role", check: func() error { return AuthorizeOrgRoleGrant(admin, OrgRoleAdmin) }, allowed: true},
+		{name: "admin grants the owner role", check: func() error { return AuthorizeOrgRoleGrant(admin, OrgRoleOwner) }},
+		{name: "owner grants the owner role", check: func() error { return AuthorizeOrgRoleGrant(owner, OrgRoleOwner) }, allowed: true},
+
+		{name: "no member manages invitations", check: func() error { return AuthorizeInvitationManagement(nil) }},
+		{name: "member manages invitations", check: func() error { return AuthorizeInvitationManagement(member) }},
+		{name: "admin manages invitations", check: func() error { return AuthorizeInvitationManagement(admin) }, allowed: true},
+		{name: "owner manages invitations", check: func() error { return AuthorizeInvitationManagement(owner) }, allowed: true},
+
+		{name: "no member manages a member", check: func() error { return AuthorizeMemberManagement(nil, member) }},
+		{name: "member manages a member", check: func() error { return AuthorizeMemberManagement(member, testMember("member-2", OrgRoleMember)) }},
+		{name: "admin manages a member", check: func() error { return AuthorizeMemberManagement(admin, member) }, allowed: true},
+		{name: "admin manages another admin", check: func() error { return AuthorizeMemberManagement(admin, testMember("admin-2", OrgRoleAdmin)) }},
+		{name: "admin manages an owner", check: func() error { return AuthorizeMemberManagement(admin, owner) }},
+		{name: "owner manages an admin", check: func() error { return AuthorizeMemberManagement(owner, admin) }, allowed: true},
+		{name: "owner manages another owner", check: func() error { return AuthorizeMemberManagement(owner, testMember("owner-2", OrgRoleOwner)) }, allowed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check()
+			if tt.allowed && err != nil {
+				t.Errorf("error = %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrForbidden) {
+				t.Errorf("error = %v, want %v", err, ErrForbidden)
+			}
+		})
+	}
+}
+
+func TestAuthorizeAPIKeyScopes(t *testing.T) {
+	admin := testPrincipal("admin-1", []RoleID{RoleIDAdmin}, PermissionAPIKeysManage, PermissionID(ScopeEmailsSend), PermissionID(ScopeUsersRead))
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		scopes    []Scope
+		allowed   bool
+	}{
+		{name: "no principal", scopes: []Scope{ScopeEmailsSend}},
+		{name: "held scope", principal: admin, scopes: []Scope{ScopeEmailsSend}, allowed: true},
+		{name: "every held scope", principal: admin, scopes: []Scope{ScopeEmailsSend, ScopeUsersRead}, allowed: true},
+		{name: "scope not held", principal: admin, scopes: []Scope{ScopeEmailsSend, ScopeUsersWrite}},
+		{name: "scope without any permission", principal: testPrincipal("user-1", []RoleID{RoleIDUser}), scopes: []Scope{ScopeUsersRead}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := AuthorizeAPIKeyScopes(tt.principal, tt.scopes)
+			if tt.allowed && err != nil {
+				t.Errorf("AuthorizeAPIKeyScopes() error = %v, want allowed", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrForbidden) {
+				t.Errorf("AuthorizeAPIKeyScopes() error = %v, want %v", err, ErrForbidden)
+			}
+		})
+	}
+}
@@ -1,5 +1,56 @@
 package domain
 
+import (
+	"context"
+	"net/http"
+	"regexp"
+)
+
+/****************************
+*        Role errors        *
+****************************/
+var (
+	ErrRoleNotFound = &DetailedError{
+		IDField:         "ROLE_NOT_FOUND",
+		StatusDescField: http.StatusText(http.StatusNotFound),
+		ErrorField:      "Role not found",
+		StatusCodeField: http.StatusNotFound,
+	}
+	ErrRoleAlreadyExists = &DetailedError{
+		IDField:         "ROLE_ALREADY_EXISTS",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Role with this ID already exists",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrRoleValidationFailed = &DetailedError{
+		IDField:         "ROLE_VALIDATION_FAILED",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Role validation failed",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrRoleProtected = &DetailedError{
+		IDField:         "ROLE_PROTECTED",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "This change is not allowed on a built-in role",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrInvalidPermission = &DetailedError{
+		IDField:         "INVALID_PERMISSION",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Unknown permission",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrPermissionDenied = &DetailedError{
+		IDField:         "PERMISSION_DENIED",
+		StatusDescField: http.StatusText(http.StatusForbidden),
+		ErrorField:      "The request requires permissions that were not granted",
+		StatusCodeField: http.StatusForbidden,
+	}
+)
+
+/**************************************
+*       Role entities and types       *
+**************************************/
 type RoleID string
 
 const (
@@ -9,11 +60,143 @@ const (
 	RoleIDGuest      RoleID = "guest"
 )
 
+// roleIDPattern fits the column of the role ID
+var roleIDPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,19}$`)
+
+func (id RoleID) IsValid() bool {
+	return roleIDPattern.MatchString(string(id))
+}
+
+// Role groups permissions to grant them to users together. Built-in roles are seeded at startup and
+// cannot be deleted.
 type Role struct {
-	ID          RoleID `json:"id" gorm:"type:varchar(20);primary_key"`
-	Name        string `json:"name" gorm:"type:varchar(50);not null"`
-	Description string `json:"description" gorm:"type:varchar(255)"`
-	CreatedAt   int64  `json:"created_at" gorm:"autoCreateTime:milli"`
-	UpdatedAt   int64  `json:"updated_at" gorm:"autoUpdateTime:milli"`
-	DeletedAt   int64  `json:"deleted_at" gorm:"index"`
+	ID          RoleID        `json:"id" gorm:"type:varchar(20);primary_key"`
+	Name        string        `json:"name" gorm:"type:varchar(50);not null"`
+	Description string        `json:"description" gorm:"type:varchar(255)"`
+	BuiltIn     bool          `json:"built_in" gorm:"not null;default:false"`
+	Permissions []*Permission `json:"permissions,omitempty" gorm:"many2many:role_permissions;"`
+	CreatedAt   int64         `json:"created_at" gorm:"autoCreateTime:milli"`
+	UpdatedAt   int64         `json:"updated_at" gorm:"autoUpdateTime:milli"`
+	DeletedAt   int64         `json:"deleted_at" gorm:"index"`
+}
+
+func (r *Role) Validate() error {
+	if !r.ID.IsValid() {
+		return ErrRoleValidationFailed.WithError("id must be 2 to 20 lowercase letters, digits or underscores, starting with a letter")
+	}
+	if r.Name == "" {
+		return ErrRoleValidationFailed.WithError("name must be not empty")
+	}
+	return nil
+}
+
+func (r *Role) PermissionIDs() []PermissionID {
+	ids := make([]PermissionID, 0, len(r.Permissions))
+	for _, permission := range r.Permissions {
+		if permission != nil {
+			ids = append(ids, permission.ID)
+		}
+	}
+	return ids
+}
+
+type RoleFilter struct {
+	ID   *RoleID  `json:"id,omitempty"`    // Filter by specific role ID
+	IDIn []RoleID `json:"id_in,omitempty"` // Filter by multiple role IDs
+}
+
+// PermissionID names an action on a kind of resource as "<resource>:<action>", with the resource
+// in the plural: sending emails is "emails:send", not "email:send". Permissions are defined by the
+// code that checks them and seeded at startup, roles only group them.
+type PermissionID string
+
+const (
+	PermissionUsersRead           PermissionID = "users:read"
+	PermissionUsersWrite          PermissionID = "users:write"
+	PermissionUsersImpersonate    PermissionID = "users:impersonate"
+	PermissionRolesRead           PermissionID = "roles:read"
+	PermissionRolesWrite          PermissionID = "roles:write"
+	PermissionSessionsManage      PermissionID = "sessions:manage"
+	PermissionAPIKeysManage       PermissionID = "api_keys:manage"
+	PermissionSecurityEventsRead  PermissionID = "security_events:read"
+	PermissionEmailsSend          PermissionID = "emails:send"
+	PermissionEmailsRead          PermissionID = "emails:read"
+	PermissionEmailTemplatesRead  PermissionID = "email_templates:read"
+	PermissionEmailTemplatesWrite PermissionID = "email_templates:write"
+)
+
+type Permission struct {
+	ID          PermissionID `json:"id" gorm:"type:varchar(50);primary_key"`
+	Description string       `json:"description" gorm:"type:varchar(255)"`
+	CreatedAt   int64        `json:"created_at" gorm:"autoCreateTime:milli"`
+	UpdatedAt   int64        `json:"updated_at" gorm:"autoUpdateTime:milli"`
+}
+
+type PermissionFilter struct {
+	IDIn []PermissionID `json:"id_in,omitempty"` // Filter by multiple permission IDs
+}
+
+// HasAllPermissions reports whether granted contains every one of the required permissions.
+func HasAllPermissions(granted []PermissionID, required ...PermissionID) bool {
+	grantedSet := make(map[PermissionID]struct{}, len(granted))
+	for _, permission := range granted {
+		grantedSet[permission] = struct{}{}
+	}
+	for _, permission := range required {
+		if _, ok := grantedSet[permission]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+/************************
+*       Usecases        *
+************************/
+type RoleUsecase interface {
+	ListRoles(ctx context.Context) ([]*Role, error)
+	GetRole(ctx context.Context, id RoleID) (*Role, error)
+	CreateRole(ctx context.Context, req *CreateRoleRequest) (*Role, error)
+	UpdateRole(ctx context.Context, req *UpdateRoleRequest) (*Role, error)
+	DeleteRole(ctx context.Context, req *DeleteRoleRequest) error
+	ListPermissions(ctx context.Context) ([]*Permission, error)
+
+	AssignRoles(ctx context.Context, req *AssignRolesRequest) (*User, error)
+	RevokeRole(ctx context.Context, req *RevokeRoleRequest) (*User, error)
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+type CreateRoleRequest struct {
+	ActorID     string         `json:"-"`
+	ID          RoleID         `json:"id" validate:"required,max=20"`
+	Name        string         `json:"name" validate:"required,max=50"`
+	Description string         `json:"description,omitempty" validate:"omitempty,max=255"`
+	Permissions []PermissionID `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	ActorID     string         `json:"-"`
+	ID          RoleID         `json:"-"`
+	Name        *string        `json:"name,omitempty" validate:"omitempty,max=50"`
+	Description *string        `json:"description,omitempty" validate:"omitempty,max=255"`
+	Permissions []PermissionID `json:"permissions,omitempty"` // Replaces the permissions of the role when set
+}
+
+type DeleteRoleRequest struct {
+	ActorID string `json:"-"`
+	ID      RoleID `json:"-"`
+}
+
+type AssignRolesRequest struct {
+	ActorID string   `json:"-"`
+	UserID  string   `json:"-"`
+	RoleIDs []RoleID `json:"role_ids" validate:"required,min=1"`
+}
+
+type RevokeRoleRequest struct {
+	ActorID string `json:"-"`
+	UserID  string `json:"-"`
+	RoleID  RoleID `json:"-"`
 }
@@ -0,0 +1,50 @@
+package domain
+
+import "testing"
+
+func TestHasAllPermissions(t *testing.T) {
+	granted := []PermissionID{PermissionUsersRead, PermissionUsersWrite}
+
+	tests := []struct {
+		name     string
+		granted  []PermissionID
+		required []PermissionID
+		want     bool
+	}{
+		{name: "nothing required", want: true},
+		{name: "nothing required of no permissions", granted: nil, want: true},
+		{name: "one granted permission", granted: granted, required: []PermissionID{PermissionUsersRead}, want: true},
+		{name: "every granted permission", granted: granted, required: granted, want: true},
+		{name: "one missing permission", granted: granted, required: []PermissionID{PermissionUsersRead, PermissionRolesWrite}},
+		{name: "no permissions", required: []PermissionID{PermissionUsersRead}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasAllPermissions(tt.granted, tt.required...); got != tt.want {
+				t.Errorf("HasAllPermissions(%v, %v) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoleIDIsValid(t *testing.T) {
+	tests := []struct {
+		id   RoleID
+		want bool
+	}{
+		{id: RoleIDAdmin, want: true},
+		{id: "support_2", want: true},
+		{id: "a"},
+		{id: "2fa"},
+		{id: "Support"},
+		{id: "support-team"},
+		{id: "a_very_long_role_name"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.id.IsValid(); got != tt.want {
+			t.Errorf("RoleID(%q).IsValid() = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"context"
+)
+
+/************************************************
+*       Security event entities and types       *
+************************************************/
+type SecurityEventType string
+
+const (
+	SecurityEventLoginSucceeded         SecurityEventType = "login_succeeded"
+	SecurityEventLoginFailed            SecurityEventType = "login_failed"
+	SecurityEventAccountLocked          SecurityEventType = "account_locked"
+	SecurityEventLogout                 SecurityEventType = "logout"
+	SecurityEventTokenRefreshed         SecurityEventType = "token_refreshed"
+	SecurityEventRefreshTokenReuse      SecurityEventType = "refresh_token_reuse"
+	SecurityEventPasswordChanged        SecurityEventType = "password_changed"
+	SecurityEventPasswordResetRequested SecurityEventType = "password_reset_requested"
+	SecurityEventPasswordReset          SecurityEventType = "password_reset"
+	SecurityEventEmailVerified          SecurityEventType = "email_verified"
+	SecurityEventEmailChanged           SecurityEventType = "email_changed"
+	SecurityEventMFAEnabled             SecurityEventType = "mfa_enabled"
+	SecurityEventMFADisabled            SecurityEventType = "mfa_disabled"
+	SecurityEventUserBanned             SecurityEventType = "user_banned"
+	SecurityEventUserUnbanned           SecurityEventType = "user_unbanned"
+	SecurityEventUserDeleted            SecurityEventType = "user_deleted"
+	SecurityEventUserRestored           SecurityEventType = "user_restored"
+	SecurityEventForcedLogout           SecurityEventType = "forced_logout"
+	SecurityEventRoleAssigned           SecurityEventType = "role_assigned"
+	SecurityEventRoleRevoked            SecurityEventType = "role_revoked"
+	SecurityEventNewLogin               SecurityEventType = "new_login"    // Login from an unfamiliar device or network
+	SecurityEventLoginDenied            SecurityEventType = "login_denied" // The user reported a login as not theirs
+)
+
+// SecurityEvent describes something security relevant that happened to an account. Events are
+// kept for the retention period of the security events config.
+type SecurityEvent struct {
+	SQLModel
+	Type       SecurityEventType `json:"type" gorm:"type:varchar(50);index;not null"`
+	UserID     string            `json:"user_id" gorm:"type:varchar(36);index"`            // Empty for failed logins to unknown accounts
+	ActorID    string            `json:"actor_id,omitempty" gorm:"type:varchar(36);index"` // Administrator who acted on the account, empty for the user or the system
+	SessionID  string            `json:"session_id,omitempty" gorm:"type:varchar(36)"`
+	IPAddress  string            `json:"ip_address,omitempty" gorm:"type:varchar(45);index"`
+	UserAgent  string            `json:"user_agent,omitempty" gorm:"type:text"`
+	Metadata   JSONB             `json:"metadata,omitempty" gorm:"type:jsonb"`
+	OccurredAt int64             `json:"occurred_at" gorm:"index;not null"` // Milli timestamp
+}
+
+type SecurityEventFilter struct {
+	UserID         *string            `json:"user_id,omitempty"`         // Filter by user ID
+	ActorID        *string            `json:"actor_id,omitempty"`        // Filter by acting administrator
+	Type           *SecurityEventType `json:"type,omitempty"`            // Filter by event type
+	IPAddress      *string            `json:"ip_address,omitempty"`      // Filter by IP address (exact match)
+	OccurredAfter  *int64             `json:"occurred_after,omitempty"`  // Find events that occurred at or after this timestamp
+	OccurredBefore *int64             `json:"occurred_before,omitempty"` // Find events that occurred before this timestamp
+}
+
+// SecurityEventEmitter publishes security events. Emitting must never fail the operation that
+// triggered the event, so implementations handle their own errors.
+type SecurityEventEmitter interface {
+	Emit(ctx context.Context, event *SecurityEvent)
+}
+
+/************************
+*       Usecases        *
+************************/
+type SecurityEventUsecase interface {
+	ListSecurityEvents(ctx context.Context, req *ListSecurityEventsRequest) ([]*SecurityEvent, *Pagination, error)
+	PurgeSecurityEvents(ctx context.Context) (int64, error)
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+type ListSecurityEventsRequest struct {
+	UserID    *string            `json:"user_id,omitempty" form:"user_id"`
+	ActorID   *string            `json:"actor_id,omitempty" form:"actor_id"`
+	Type      *SecurityEventType `json:"type,omitempty" form:"type"`
+	IPAddress *string            `json:"ip_address,omitempty" form:"ip_address"`
+	From      *int64             `json:"from,omitempty" form:"from"` // Milli timestamp, inclusive
+	To        *int64             `json:"to,omitempty" form:"to"`     // Milli timestamp, exclusive
+	Page      int                `json:"page" form:"page"`
+	PerPage   int                `json:"per_page" form:"per_page"`
+}
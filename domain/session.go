@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"context"
+	"net/http"
+)
+
+/*******************************
+*        Session errors        *
+*******************************/
+var (
+	ErrSessionNotFound = &DetailedError{
+		IDField:         "SESSION_NOT_FOUND",
+		StatusDescField: http.StatusText(http.StatusNotFound),
+		ErrorField:      "Session not found",
+		StatusCodeField: http.StatusNotFound,
+	}
+)
+
+/*****************************************
+*       Session entities and types       *
+*****************************************/
+
+// SessionInfo is the client facing view of a UserSession, without its refresh token.
+type SessionInfo struct {
+	ID             string `json:"id"`
+	IPAddress      string `json:"ip_address"`
+	UserAgent      string `json:"user_agent"`
+	Browser        string `json:"browser"`
+	OS             string `json:"os"`
+	Device         string `json:"device"`
+	Current        bool   `json:"current"`      // Whether this is the session making the request
+	Impersonated   bool   `json:"impersonated"` // Started by an administrator on behalf of the user
+	CreatedAt      int64  `json:"created_at"`
+	LastActivityAt int64  `json:"last_activity_at"`
+	ExpiresAt      int64  `json:"expires_at"`
+}
+
+/************************
+*       Usecases        *
+************************/
+type SessionUsecase interface {
+	ListSessions(ctx context.Context, req *ListSessionsRequest) ([]*SessionInfo, *Pagination, error)
+	GetSession(ctx context.Context, req *GetSessionRequest) (*SessionInfo, error)
+	RevokeSession(ctx context.Context, req *RevokeSessionRequest) error
+	RevokeOtherSessions(ctx context.Context, req *RevokeOtherSessionsRequest) (int64, error)
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+type ListSessionsRequest struct {
+	UserID           string `json:"-"`
+	CurrentSessionID string `json:"-"`
+	Page             int    `json:"page" form:"page"`
+	PerPage          int    `json:"per_page" form:"per_page"`
+}
+
+type GetSessionRequest struct {
+	UserID           string `json:"-"`
+	SessionID        string `json:"-"`
+	CurrentSessionID string `json:"-"`
+}
+
+type RevokeSessionRequest struct {
+	UserID    string `json:"-"`
+	SessionID string `json:"-"`
+}
+
+// RevokeOtherSessionsRequest revokes every session of the user except CurrentSessionID.
+// An empty CurrentSessionID revokes all of them.
+type RevokeOtherSessionsRequest struct {
+	UserID           string `json:"-"`
+	CurrentSessionID string `json:"-"`
+}
+
+type RevokeSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
@@ -3,6 +3,9 @@ package domain
 import (
 	"context"
 	"net/http"
+	"regexp"
+	"strings"
+	"time"
 )
 
 /****************************
@@ -27,6 +30,12 @@ var (
 		ErrorField:      "User with this username already exists",
 		StatusCodeField: http.StatusBadRequest,
 	}
+	ErrInvalidUsername = &DetailedError{
+		IDField:         "INVALID_USERNAME",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Username must be 3 to 50 letters, digits, dots, underscores or hyphens, starting with a letter or digit",
+		StatusCodeField: http.StatusBadRequest,
+	}
 	ErrUserCreationFailed = &DetailedError{
 		IDField:         "USER_CREATION_FAILED",
 		StatusDescField: http.StatusText(http.StatusInternalServerError),
@@ -75,6 +84,18 @@ var (
 		ErrorField:      "User account is banned",
 		StatusCodeField: http.StatusForbidden,
 	}
+	ErrUserNotBanned = &DetailedError{
+		IDField:         "USER_NOT_BANNED",
+		StatusDescField: http.StatusText(http.StatusConflict),
+		ErrorField:      "User account is not banned",
+		StatusCodeField: http.StatusConflict,
+	}
+	ErrUserNotDeleted = &DetailedError{
+		IDField:         "USER_NOT_DELETED",
+		StatusDescField: http.StatusText(http.StatusConflict),
+		ErrorField:      "User account is not deleted",
+		StatusCodeField: http.StatusConflict,
+	}
 )
 
 /***************************************
@@ -88,20 +109,44 @@ const (
 	UserSTTBanned        UserStatus = "banned"
 )
 
+// Algorithms new passwords can be hashed with
+const (
+	PasswordHashAlgorithmArgon2id = "argon2id"
+	PasswordHashAlgorithmBcrypt   = "bcrypt"
+)
+
+// usernamePattern leaves out "@", so a login identifier is either an email or a username
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{2,49}$`)
+
 type User struct {
 	SQLModel
-	Email     string     `json:"email" gorm:"type:varchar(100);unique;not null"`
-	Password  string     `json:"-" gorm:"type:varchar(60);not null"`
+	// Unique regardless of case, kept as typed for display. Empty for users created before usernames
+	// and for external users who did not choose one yet
+	Username  string     `json:"username" gorm:"type:varchar(50);not null;default:'';index:idx_users_username_lower,unique,expression:lower(username),where:username <> ''"`
+	Email     string     `json:"email" gorm:"type:varchar(100);unique;not null;index:idx_users_email_lower,unique,expression:lower(email)"`
+	Password  string     `json:"-" gorm:"type:varchar(255);not null"`
 	FirstName string     `json:"first_name" gorm:"type:varchar(50);not null"`
 	LastName  string     `json:"last_name" gorm:"type:varchar(50);not null"`
 	Status    UserStatus `json:"status" gorm:"type:varchar(20);default:'waiting_verify'"`
 	Roles     []*Role    `json:"roles" gorm:"many2many:user_roles;"`
+	// The password may be known to someone else, it must be reset before it can be used to log in
+	PasswordResetRequired bool `json:"password_reset_required" gorm:"not null;default:false"`
+	// Set while the user is banned by an administrator
+	BanReason   string `json:"ban_reason,omitempty" gorm:"type:varchar(500);not null;default:''"`
+	BannedBy    string `json:"banned_by,omitempty" gorm:"type:varchar(36);not null;default:''"`
+	BannedAt    int64  `json:"banned_at,omitempty" gorm:"not null;default:0"`          // Milli timestamp
+	BannedUntil int64  `json:"banned_until,omitempty" gorm:"index;not null;default:0"` // Milli timestamp, 0 for a permanent ban
+	// Status given back when the ban is lifted
+	StatusBeforeBan UserStatus `json:"-" gorm:"type:varchar(20);not null;default:''"`
 }
 
 func (u *User) Validate() error {
 	if u.Email == "" {
 		return ErrUserValidationFailed.WithError("email must be not empty")
 	}
+	if u.Username != "" && !IsValidUsername(u.Username) {
+		return ErrInvalidUsername
+	}
 	if u.FirstName == "" {
 		return ErrUserValidationFailed.WithError("first_name must be not empty")
 	}
@@ -117,6 +162,22 @@ func (u *User) Validate() error {
 	return nil
 }
 
+// NormalizeUsername trims the spaces around a username. The case is kept, usernames are compared
+// case-insensitively instead.
+func NormalizeUsername(username string) string {
+	return strings.TrimSpace(username)
+}
+
+// NormalizeEmail trims the spaces around an email and lowercases it. Emails are stored and looked up
+// normalized, so an address reaches the same account whatever its spelling.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func IsValidUsername(username string) bool {
+	return usernamePattern.MatchString(username)
+}
+
 func (u *User) HasAnyRole(roleIDs ...RoleID) bool {
 	if len(u.Roles) == 0 || len(roleIDs) == 0 {
 		return false
@@ -135,8 +196,13 @@ func (u *User) HasAnyRole(roleIDs ...RoleID) bool {
 	return false
 }
 
+// IsBanned reports whether the user is banned. A ban that expired counts as lifted, even before
+// the status is given back.
 func (u *User) IsBanned() bool {
-	return u.Status == UserSTTBanned
+	if u.Status != UserSTTBanned {
+		return false
+	}
+	return u.BannedUntil == 0 || u.BannedUntil > time.Now().UnixMilli()
 }
 
 func (u *User) IsActive() bool {
@@ -155,6 +221,22 @@ type UserFilter struct {
 	SearchTerm     *string  `json:"search_term" form:"search_term"`
 	SearchFields   []string `json:"search_fields" form:"search_fields"`
 	IncludeDeleted *bool    `json:"include_deleted" form:"include_deleted"`
+	OnlyDeleted    *bool    `json:"only_deleted" form:"only_deleted"`
+
+	BannedUntilBefore *int64 `json:"-" form:"-"` // Temporary bans that expired before this milli timestamp
+}
+
+// PasswordHistory keeps the hashes of previous passwords of a user, so they cannot be reused
+type PasswordHistory struct {
+	SQLModel
+	UserID       string `json:"user_id" gorm:"type:varchar(36);not null;index"`
+	PasswordHash string `json:"-" gorm:"type:varchar(255);not null"`
+}
+
+type PasswordHistoryFilter struct {
+	ID     *string  `json:"id,omitempty"`
+	IDIn   []string `json:"id_in,omitempty"`
+	UserID *string  `json:"user_id,omitempty"`
 }
 
 /**********************************************
@@ -167,15 +249,34 @@ type UserUsecase interface {
 	FindOne(ctx context.Context, filter *UserFilter, option *FindOneOption) (*User, error)
 	Update(ctx context.Context, userID string, req *UserUpdateRequest) error
 	ChangePassword(ctx context.Context, req *UserChangePasswordRequest) error
+	UpdatePassword(ctx context.Context, userID string, newPassword string) error
+	CheckPassword(ctx context.Context, userID string, newPassword string) error
+	RehashPassword(ctx context.Context, userID string, password string) (bool, error)
 	FindPage(ctx context.Context, filter *UserFilter, option *FindPageOption) ([]*User, *Pagination, error)
+
+	// Administration of user accounts, every action is recorded with the administrator who took it
+	ListUsers(ctx context.Context, req *ListUsersRequest) ([]*User, *Pagination, error)
+	Delete(ctx context.Context, req *UserAdminActionRequest) error
+	Restore(ctx context.Context, req *UserAdminActionRequest) (*User, error)
+	Ban(ctx context.Context, req *BanUserRequest) (*User, error)
+	Unban(ctx context.Context, req *UserAdminActionRequest) (*User, error)
+	ForceLogout(ctx context.Context, req *UserAdminActionRequest) error
+	// LiftExpiredBans gives the users whose temporary ban expired their status back and returns how many
+	LiftExpiredBans(ctx context.Context) (int64, error)
 }
 
 type UserCreateRequest struct {
 	Username  string `json:"username" validate:"required"`
 	Email     string `json:"email" validate:"required,email"`
-	Password  string `json:"password" validate:"required"`
+	Password  string `json:"password" validate:"required_without=External"`
 	FirstName string `json:"first_name" validate:"required"`
 	LastName  string `json:"last_name" validate:"required"`
+	// External users sign in with an identity provider which already verified their email, they are
+	// created active and without a password until they set one through a password reset
+	External bool `json:"-"`
+
+	// Caller the creation is authorized for, nil for the trusted calls of other services
+	Principal *Principal `json:"-"`
 }
 
 type UserUpdateRequest struct {
@@ -184,10 +285,48 @@ type UserUpdateRequest struct {
 	FirstName *string     `json:"first_name,omitempty"`
 	LastName  *string     `json:"last_name,omitempty"`
 	Status    *UserStatus `json:"status,omitempty"`
+
+	PasswordResetRequired *bool `json:"-"` // Only set by the auth service
+	// Removes the password and ends the sessions of the user, only set by the auth service when an
+	// account someone else may have registered is taken over by the owner of the email
+	ClearPassword bool `json:"-"`
+
+	// Caller the update is authorized for, nil for the trusted calls of other services
+	Principal *Principal `json:"-"`
 }
 
 type UserChangePasswordRequest struct {
 	UserID      string `json:"user_id" validate:"required"`
 	OldPassword string `json:"old_password" validate:"required"`
 	NewPassword string `json:"new_password" validate:"required"`
+	IPAddress   string `json:"ip_address,omitempty"`
+	UserAgent   string `json:"user_agent,omitempty"`
+
+	Principal *Principal `json:"-"`
+}
+
+// ListUsersRequest searches users for administrators. Sort is a column, prefixed with "-" for the
+// descending order, e.g. "-created_at".
+type ListUsersRequest struct {
+	UserFilter
+	Sort    string `json:"sort,omitempty" form:"sort"`
+	Page    int    `json:"page" form:"page"`
+	PerPage int    `json:"per_page" form:"per_page"`
+}
+
+// UserAdminActionRequest identifies the user an administrator acts on
+type UserAdminActionRequest struct {
+	UserID    string     `json:"-"`
+	Principal *Principal `json:"-"` // Administrator taking the action
+	IPAddress string     `json:"-"`
+	UserAgent string     `json:"-"`
+}
+
+type BanUserRequest struct {
+	UserID    string     `json:"-"`
+	Principal *Principal `json:"-"` // Administrator taking the action
+	IPAddress string     `json:"-"`
+	UserAgent string     `json:"-"`
+	Reason    string     `json:"reason" validate:"required"`
+	ExpiresAt *int64     `json:"expires_at,omitempty"` // Milli timestamp, a permanent ban when empty
 }
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+/**********************************
+*        WebAuthn errors          *
+**********************************/
+var (
+	ErrPasskeyNotFound = &DetailedError{
+		IDField:         "PASSKEY_NOT_FOUND",
+		StatusDescField: http.StatusText(http.StatusNotFound),
+		ErrorField:      "Passkey not found",
+		StatusCodeField: http.StatusNotFound,
+	}
+	ErrPasskeyAlreadyRegistered = &DetailedError{
+		IDField:         "PASSKEY_ALREADY_REGISTERED",
+		StatusDescField: http.StatusText(http.StatusConflict),
+		ErrorField:      "This passkey is already registered",
+		StatusCodeField: http.StatusConflict,
+	}
+	ErrInvalidWebAuthnChallenge = &DetailedError{
+		IDField:         "INVALID_WEBAUTHN_CHALLENGE",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Invalid or expired passkey challenge, please try again",
+		StatusCodeField: http.StatusBadRequest,
+	}
+	ErrWebAuthnVerificationFailed = &DetailedError{
+		IDField:         "WEBAUTHN_VERIFICATION_FAILED",
+		StatusDescField: http.StatusText(http.StatusUnauthorized),
+		ErrorField:      "Passkey verification failed",
+		StatusCodeField: http.StatusUnauthorized,
+	}
+	ErrPasskeyValidationFailed = &DetailedError{
+		IDField:         "PASSKEY_VALIDATION_FAILED",
+		StatusDescField: http.StatusText(http.StatusBadRequest),
+		ErrorField:      "Passkey validation failed",
+		StatusCodeField: http.StatusBadRequest,
+	}
+)
+
+/******************************************
+*       WebAuthn entities and types       *
+******************************************/
+
+// WebAuthnPurpose tells which ceremony a challenge was issued for
+type WebAuthnPurpose string
+
+const (
+	WebAuthnPurposeRegistration WebAuthnPurpose = "registration"
+	WebAuthnPurposeLogin        WebAuthnPurpose = "login"
+	WebAuthnPurposeMFA          WebAuthnPurpose = "mfa"
+)
+
+// WebAuthnCredential is a passkey or security key registered by a user.
+type WebAuthnCredential struct {
+	SQLModel
+	UserID         string      `json:"user_id" gorm:"type:varchar(36);index;not null"`
+	CredentialID   string      `json:"credential_id" gorm:"type:varchar(1400);uniqueIndex;not null"` // base64url, as sent by clients
+	PublicKey      []byte      `json:"-" gorm:"type:bytea;not null"`                                 // COSE encoded public key
+	Algorithm      int64       `json:"algorithm"`                                                    // COSE algorithm identifier
+	SignCount      int64       `json:"-"`                                                            // Last signature counter reported by the authenticator
+	AAGUID         string      `json:"aaguid" gorm:"type:varchar(36)"`                               // Model of the authenticator
+	Transports     StringSlice `json:"transports" gorm:"type:jsonb"`                                 // Hints such as "internal", "hybrid" or "usb"
+	Name           string      `json:"name" gorm:"type:varchar(100);not null"`                       // Friendly name chosen by the user
+	BackupEligible bool        `json:"backup_eligible"`                                              // Synced passkey, as opposed to a device-bound key
+	LastUsedAt     int64       `json:"last_used_at"`                                                 // Milli timestamp, 0 means never used
+}
+
+type WebAuthnCredentialFilter struct {
+	ID           *string `json:"id,omitempty"`            // Filter by specific record ID
+	UserID       *string `json:"user_id,omitempty"`       // Filter by owner
+	CredentialID *string `json:"credential_id,omitempty"` // Filter by credential ID
+
+	SignCountBefore *int64 `json:"sign_count_before,omitempty"` // Find credentials whose sign counter is lower than this one
+}
+
+// WebAuthnChallenge is kept between the start and the end of a ceremony.
+type WebAuthnChallenge struct {
+	Challenge        string          `json:"challenge"`
+	Purpose          WebAuthnPurpose `json:"purpose"`
+	UserID           string          `json:"user_id,omitempty"` // Empty for a login with a discoverable credential
+	UserVerification string          `json:"user_verification"`
+}
+
+/*************************************
+*       Requests and Responses       *
+*************************************/
+
+// PasskeyOptionsResponse carries the options the client passes to the WebAuthn API, and the ID of
+// the challenge to send back with the resulting credential.
+type PasskeyOptionsResponse struct {
+	ChallengeID string `json:"challenge_id"`
+	PublicKey   any    `json:"public_key"`
+}
+
+type BeginPasskeyRegistrationRequest struct {
+	UserID string `json:"-"`
+}
+
+type FinishPasskeyRegistrationRequest struct {
+	UserID      string          `json:"-"`
+	ChallengeID string          `json:"challenge_id" validate:"required"`
+	Name        string          `json:"name" validate:"omitempty,max=100"`
+	Credential  json.RawMessage `json:"credential" validate:"required"` // Result of navigator.credentials.create() serialized with toJSON()
+}
+
+type FinishPasskeyLoginRequest struct {
+	ChallengeID string          `json:"challenge_id" validate:"required"`
+	Credential  json.RawMessage `json:"credential" validate:"required"` // Result of navigator.credentials.get() serialized with toJSON()
+	IPAddress   string          `json:"ip_address,omitempty"`
+	UserAgent   string          `json:"user_agent,omitempty"`
+}
+
+type BeginPasskeyMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+}
+
+type VerifyPasskeyMFARequest struct {
+	MFAToken    string          `json:"mfa_token" validate:"required"`
+	ChallengeID string          `json:"challenge_id" validate:"required"`
+	Credential  json.RawMessage `json:"credential" validate:"required"`
+	IPAddress   string          `json:"ip_address,omitempty"`
+	UserAgent   string          `json:"user_agent,omitempty"`
+}
+
+type RenamePasskeyRequest struct {
+	UserID string `json:"-"`
+	ID     string `json:"-"`
+	Name   string `json:"name" validate:"required,max=100"`
+}
+
+type DeletePasskeyRequest struct {
+	UserID string `json:"-"`
+	ID     string `json:"-"`
+}
@@ -7,17 +7,29 @@ import (
 	"go-clean-arch/common"
 	"go-clean-arch/config"
 	"go-clean-arch/database"
+	"go-clean-arch/domain"
 	"go-clean-arch/middleware"
 	"go-clean-arch/pkg/cache"
 	"go-clean-arch/pkg/email"
 	"go-clean-arch/pkg/log"
+	"go-clean-arch/pkg/password"
+	"go-clean-arch/pkg/webauthn"
 	"go-clean-arch/proto/pb"
 	authClient "go-clean-arch/service/auth/client"
 	authAPI "go-clean-arch/service/auth/delivery/api"
+	authEvent "go-clean-arch/service/auth/event"
+	authIdentity "go-clean-arch/service/auth/identity"
+	"go-clean-arch/service/auth/loginrisk"
 	authRepo "go-clean-arch/service/auth/repository"
 	authUC "go-clean-arch/service/auth/usecase"
+	orgAPI "go-clean-arch/service/organization/delivery/api"
+	orgRepo "go-clean-arch/service/organization/repository"
+	orgUC "go-clean-arch/service/organization/usecase"
+	otpSender "go-clean-arch/service/otp/sender"
+	otpUC "go-clean-arch/service/otp/usecase"
 	userAPI "go-clean-arch/service/user/delivery/api"
 	userRPC "go-clean-arch/service/user/delivery/rpc"
+	userJob "go-clean-arch/service/user/job"
 	userRepo "go-clean-arch/service/user/repository"
 	userUC "go-clean-arch/service/user/usecase"
 	"net"
@@ -116,10 +128,25 @@ func main() {
 	logger.Info("Redis cache connected successfully for rate limiting")
 
 	// Initialize repositories
+	passwordHistoryRepo := userRepo.NewPasswordHistoryRepository(db)
+	roleRepo := userRepo.NewRoleRepository(db)
+	permissionRepo := userRepo.NewPermissionRepository(db)
 	userRepo := userRepo.NewUserRepository(db)
 	sessionRepo := authRepo.NewPgUserSessionRepo(db)
+	rotatedTokenRepo := authRepo.NewPgRotatedRefreshTokenRepo(db)
+	verificationTokenRepo := authRepo.NewPgVerificationTokenRepo(db)
+	userMFARepo := authRepo.NewPgUserMFARepo(db)
+	recoveryCodeRepo := authRepo.NewPgMFARecoveryCodeRepo(db)
+	apiKeyRepo := authRepo.NewPgAPIKeyRepo(db)
+	externalIdentityRepo := authRepo.NewPgExternalIdentityRepo(db)
+	webAuthnCredentialRepo := authRepo.NewPgWebAuthnCredentialRepo(db)
+	impersonationRepo := authRepo.NewPgImpersonationRepo(db)
+	securityEventRepo := authRepo.NewPgSecurityEventRepo(db)
 	emailTemplateRepo := emailRepo.NewEmailTemplateRepository(db)
 	emailLogRepo := emailRepo.NewEmailLogRepository(db)
+	organizationRepo := orgRepo.NewOrganizationRepository(db)
+	organizationMemberRepo := orgRepo.NewOrganizationMemberRepository(db)
+	organizationInvitationRepo := orgRepo.NewOrganizationInvitationRepository(db)
 
 	// Initialize email templates
 	emailTemplateConfig := bootstrap.EmailTemplateConfig{
@@ -134,8 +161,41 @@ func main() {
 		// Don't fail the application, just log the error
 	}
 
-	bcryptHasher := common.NewBcryptHasher()
-	userUsecase := userUC.NewUserUsecase(userRepo, bcryptHasher)
+	// Initialize permissions and built-in roles
+	permissionResolver := userUC.NewPermissionResolver(roleRepo, redisCache)
+	roleSeeder := bootstrap.NewRoleSeeder(roleRepo, permissionRepo, permissionResolver, logger)
+	if err := roleSeeder.Seed(context.Background()); err != nil {
+		logger.Error("Failed to initialize roles and permissions", log.Error(err))
+		// Don't fail the application, routes requiring the missing permissions stay closed
+	}
+
+	argon2idHasher := common.NewArgon2idHasher(common.Argon2idParams{
+		Memory:      cfg.PasswordHash().Argon2Memory(),
+		Iterations:  cfg.PasswordHash().Argon2Iterations(),
+		Parallelism: cfg.PasswordHash().Argon2Parallelism(),
+		SaltLength:  cfg.PasswordHash().Argon2SaltLength(),
+		KeyLength:   cfg.PasswordHash().Argon2KeyLength(),
+	})
+	bcryptHasher := common.NewBcryptHasher(cfg.PasswordHash().BcryptCost())
+	// Hashes of the other algorithm are still verified, and replaced on the next login
+	passwordHasher := common.NewPasswordHasher(argon2idHasher, bcryptHasher)
+	if cfg.PasswordHash().Algorithm() == domain.PasswordHashAlgorithmBcrypt {
+		passwordHasher = common.NewPasswordHasher(bcryptHasher, argon2idHasher)
+	}
+	revocationList := common.NewTokenRevocationList(redisCache, cfg.App())
+	passwordPolicy := password.NewPolicy(cfg.PasswordPolicy())
+	securityEvents := authEvent.NewStoreEmitter(securityEventRepo, logger)
+	userUsecase := userUC.NewUserUsecase(
+		userRepo,
+		passwordHistoryRepo,
+		sessionRepo,
+		passwordHasher,
+		passwordPolicy,
+		revocationList,
+		securityEvents,
+		cfg.PasswordPolicy(),
+	)
+	roleUsecase := userUC.NewRoleUsecase(roleRepo, permissionRepo, userRepo, permissionResolver, securityEvents)
 
 	// Initialize email usecase
 	emailTmplRender := emailUC.NewTemplateRenderer(logger)
@@ -182,16 +242,123 @@ func main() {
 
 	userRpcClient := authClient.NewUserRPCClient(grpcConn)
 	emailRpcClient := authClient.NewEmailRPCClient(grpcConn)
-	jwtProvider := common.NewJWTProvider(cfg.App())
-	authUsecase := authUC.NewAuthUsecase(sessionRepo, userRpcClient, emailRpcClient, jwtProvider, bcryptHasher)
+	otpUsecase := otpUC.NewOTPUsecase(
+		redisCache,
+		cfg.OTP(),
+		otpSender.NewEmailSender(emailRpcClient, cfg.App()),
+	)
+	jwtKeys := make([]common.JWTKeySpec, 0, len(cfg.App().JWTKeys()))
+	for _, key := range cfg.App().JWTKeys() {
+		jwtKeys = append(jwtKeys, common.JWTKeySpec{
+			KID:            key.KID(),
+			Algorithm:      key.Algorithm(),
+			PrivateKeyFile: key.PrivateKeyFile(),
+			PublicKeyFile:  key.PublicKeyFile(),
+			ActiveAt:       key.ActiveAt(),
+			RetireAt:       key.RetireAt(),
+		})
+	}
+	jwtProvider, err := common.NewJWTProvider(cfg.App(), jwtKeys...)
+	if err != nil {
+		logger.Fatal("Failed to load JWT signing keys", log.Error(err))
+	}
+	mfaSecretCipher, err := common.NewSecretCipher(cfg.App().MFASecretKey())
+	if err != nil {
+		logger.Fatal("Failed to initialize the MFA secret cipher", log.Error(err))
+	}
+	identityProviders := make([]authUC.IdentityProvider, 0, len(cfg.OAuth().Providers()))
+	for _, providerCfg := range cfg.OAuth().Providers() {
+		provider, err := authIdentity.New(authIdentity.Config{
+			Name:         providerCfg.Name(),
+			Type:         providerCfg.Type(),
+			ClientID:     providerCfg.ClientID(),
+			ClientSecret: providerCfg.ClientSecret(),
+			Issuer:       providerCfg.Issuer(),
+			AuthURL:      providerCfg.AuthURL(),
+			TokenURL:     providerCfg.TokenURL(),
+			JWKSURL:      providerCfg.JWKSURL(),
+			APIURL:       providerCfg.APIURL(),
+			Scopes:       providerCfg.Scopes(),
+			RedirectURIs: providerCfg.RedirectURIs(),
+		})
+		if err != nil {
+			logger.Fatal("Failed to configure identity provider", log.Error(err))
+		}
+		identityProviders = append(identityProviders, provider)
+	}
+	relyingParty := webauthn.New(webauthn.Config{
+		RPID:             cfg.WebAuthn().RPID(),
+		RPName:           cfg.WebAuthn().RPName(),
+		Origins:          cfg.WebAuthn().Origins(),
+		Timeout:          cfg.WebAuthn().ChallengeExpiresIn(),
+		UserVerification: cfg.WebAuthn().UserVerification(),
+	})
+	loginRiskDetector := loginrisk.NewDetector(
+		loginrisk.NewDeviceRule(),
+		loginrisk.NewNetworkRule(cfg.LoginAlerts().IPv4PrefixLength(), cfg.LoginAlerts().IPv6PrefixLength()),
+	)
+	authUsecase := authUC.NewAuthUsecase(
+		sessionRepo,
+		rotatedTokenRepo,
+		verificationTokenRepo,
+		userMFARepo,
+		recoveryCodeRepo,
+		externalIdentityRepo,
+		identityProviders,
+		webAuthnCredentialRepo,
+		relyingParty,
+		loginRiskDetector,
+		userRpcClient,
+		emailRpcClient,
+		otpUsecase,
+		securityEvents,
+		revocationList,
+		redisCache,
+		jwtProvider,
+		passwordHasher,
+		mfaSecretCipher,
+		cfg.App(),
+		cfg.Server(),
+		cfg.OAuth(),
+		cfg.WebAuthn(),
+		cfg.LoginAlerts(),
+	)
+
+	sessionUsecase := authUC.NewSessionUsecase(sessionRepo, revocationList)
+	apiKeyUsecase := authUC.NewAPIKeyUsecase(apiKeyRepo, userRepo)
+	securityEventUsecase := authUC.NewSecurityEventUsecase(securityEventRepo, cfg.SecurityEvents())
+	impersonationUsecase := authUC.NewImpersonationUsecase(
+		impersonationRepo,
+		sessionRepo,
+		userRepo,
+		jwtProvider,
+		revocationList,
+		cfg.App(),
+	)
+	organizationUsecase := orgUC.NewOrganizationUsecase(
+		organizationRepo,
+		organizationMemberRepo,
+		organizationInvitationRepo,
+		userRepo,
+		sessionRepo,
+		jwtProvider,
+		emailRpcClient,
+		cfg.App(),
+		cfg.Server(),
+		cfg.Organizations(),
+	)
 
 	// Initialize dependencies for middlewares
 	deps := middleware.Dependencies{
-		Cache:       redisCache,
-		Logger:      logger,
-		JwtProvider: jwtProvider,
-		SessionRepo: sessionRepo,
-		UserRepo:    userRepo,
+		Cache:               redisCache,
+		Logger:              logger,
+		JwtProvider:         jwtProvider,
+		RevocationList:      revocationList,
+		SessionRepo:         sessionRepo,
+		UserRepo:            userRepo,
+		PermissionResolver:  permissionResolver,
+		APIKeyVerifier:      apiKeyUsecase,
+		OrganizationMembers: organizationMemberRepo,
 	}
 
 	// Create middlewares instance
@@ -199,8 +366,15 @@ func main() {
 
 	// Initialize handlers
 	userHandler := userAPI.NewUserHandler(userUsecase, middlewares)
-	authHandler := authAPI.NewAuthHandler(authUsecase, middlewares)
+	roleHandler := userAPI.NewRoleHandler(roleUsecase, middlewares)
+	authHandler := authAPI.NewAuthHandler(authUsecase, middlewares, cfg.RefreshCookie())
+	sessionHandler := authAPI.NewSessionHandler(sessionUsecase, middlewares)
+	jwksHandler := authAPI.NewJWKSHandler(jwtProvider)
+	apiKeyHandler := authAPI.NewAPIKeyHandler(apiKeyUsecase, middlewares)
+	impersonationHandler := authAPI.NewImpersonationHandler(impersonationUsecase, middlewares)
+	securityEventHandler := authAPI.NewSecurityEventHandler(securityEventUsecase, middlewares)
 	emailHandler := emailAPI.NewEmailHandler(emailUsecase, emailTmplRender, logger, middlewares)
+	organizationHandler := orgAPI.NewOrganizationHandler(organizationUsecase, middlewares)
 
 	// Disable Gin's default logger and recovery
 	gin.DisableConsoleColor()
@@ -210,7 +384,12 @@ func main() {
 	r := gin.New()
 
 	// Add custom middleware in order
-	r.Use(middlewares.CORSWithLogger())
+	// The frontends send the CSRF token of the refresh cookie back in its header, and read it from there
+	corsConfig := middleware.DefaultCORSConfig()
+	csrfHeader := cfg.RefreshCookie().CSRFHeaderName()
+	corsConfig.AllowHeaders = append(corsConfig.AllowHeaders, csrfHeader)
+	corsConfig.ExposeHeaders = append(corsConfig.ExposeHeaders, csrfHeader)
+	r.Use(middlewares.CORSWithLogger(corsConfig))
 	r.Use(middlewares.RequestIDMiddleware())
 
 	// Add general rate limiting middleware
@@ -233,14 +412,27 @@ func main() {
 	// Register routes
 	apiGroup := r.Group("/api/v1")
 	userHandler.RegisterRoutes(apiGroup)
+	roleHandler.RegisterRoutes(apiGroup)
 	authHandler.RegisterRoutes(apiGroup)
+	sessionHandler.RegisterRoutes(apiGroup)
+	apiKeyHandler.RegisterRoutes(apiGroup)
+	impersonationHandler.RegisterRoutes(apiGroup)
+	securityEventHandler.RegisterRoutes(apiGroup)
 	emailHandler.RegisterRoutes(apiGroup)
+	organizationHandler.RegisterRoutes(apiGroup)
+	jwksHandler.RegisterRoutes(r)
 
 	// Add health check endpoint
 	r.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok", "timestamp": time.Now().Unix()})
 	})
 
+	// Background jobs stop with the server
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go authEvent.NewRetentionJob(securityEventUsecase, cfg.SecurityEvents().PurgeInterval(), logger).Run(jobsCtx)
+	go userJob.NewBanLiftJob(userUsecase, cfg.UserBans().LiftInterval(), logger).Run(jobsCtx)
+
 	// Graceful shutdown setup
 	srv := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.Server().Port()),
@@ -265,6 +457,7 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down server...")
+	stopJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -3,9 +3,12 @@ package middleware
 import (
 	"context"
 	"strings"
+	"time"
 
 	"go-clean-arch/common"
 	"go-clean-arch/domain"
+	"go-clean-arch/pkg/log"
+	"go-clean-arch/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -14,16 +17,34 @@ type JwtProvider interface {
 	Verify(tokenType domain.TokenType, tokenStr string) (*domain.JwtClaims, error)
 }
 
+type TokenRevocationList interface {
+	IsRevoked(ctx context.Context, claims *domain.JwtClaims) (bool, error)
+}
+
 type SessionRepository interface {
 	FindByID(ctx context.Context, sessionID string, option *domain.FindOneOption) (*domain.UserSession, error)
+	TouchLastActivity(ctx context.Context, sessionID string, at int64) error
 }
 
+// sessionActivityInterval throttles LastActivityAt writes to at most one per session per interval
+const sessionActivityInterval = time.Minute
+
 type UserRepository interface {
 	FindByID(ctx context.Context, userID string, option *domain.FindOneOption) (*domain.User, error)
 }
 
+// PermissionResolver resolves the permissions a user holds through its roles
+type PermissionResolver interface {
+	PermissionsForRoles(ctx context.Context, roles []*domain.Role) ([]domain.PermissionID, error)
+}
+
+type APIKeyVerifier interface {
+	Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error)
+}
+
 type headerData struct {
 	AccessToken string
+	APIKey      string
 }
 
 func extractHeaderData(c *gin.Context) *headerData {
@@ -33,6 +54,12 @@ func extractHeaderData(c *gin.Context) *headerData {
 	if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
 		hData.AccessToken = strings.TrimPrefix(authHeader, "Bearer ")
 	}
+	if authHeader != "" && strings.HasPrefix(authHeader, "ApiKey ") {
+		hData.APIKey = strings.TrimPrefix(authHeader, "ApiKey ")
+	}
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		hData.APIKey = apiKey
+	}
 
 	return hData
 }
@@ -47,12 +74,21 @@ func (m *middlewares) Authenticator() gin.HandlerFunc {
 			return
 		}
 
-		session, err := m.sessionRepo.FindByID(c.Request.Context(), claims.Sid, nil)
-		if err != nil && !common.IsRecordNotFound(err) {
-			common.ResponseError(c, err)
-			return
+		// A valid token is trusted unless it was revoked, the session is only looked up when the
+		// revocation list cannot be reached
+		revoked, err := m.revocationList.IsRevoked(c.Request.Context(), claims)
+		if err != nil {
+			m.logger.Warn("Failed to check token revocation, falling back to the session",
+				log.String("session_id", claims.Sid),
+				log.Error(err),
+			)
+			revoked, err = m.isSessionRevoked(c.Request.Context(), claims.Sid)
+			if err != nil {
+				common.ResponseError(c, err)
+				return
+			}
 		}
-		if session == nil || !session.IsActive() {
+		if revoked {
 			common.ResponseError(c, domain.ErrSessionExpired)
 			return
 		}
@@ -62,8 +98,10 @@ func (m *middlewares) Authenticator() gin.HandlerFunc {
 		})
 		if err != nil && !common.IsRecordNotFound(err) {
 			common.ResponseError(c, err)
+			return
 		}
-		if user == nil {
+		// Deleted users keep their row, their tokens must stop working all the same
+		if user == nil || user.DeletedAt > 0 {
 			common.ResponseError(c, domain.ErrUserNotFound)
 			return
 		}
@@ -73,12 +111,110 @@ func (m *middlewares) Authenticator() gin.HandlerFunc {
 			return
 		}
 
+		// The real caller of an impersonated token must still be allowed to impersonate
+		var actor *domain.User
+		if claims.IsImpersonated() {
+			actor, err = m.userRepo.FindByID(c.Request.Context(), claims.Act.Sub, &domain.FindOneOption{
+				Preloads: []string{common.FieldRoles},
+			})
+			if err != nil && !common.IsRecordNotFound(err) {
+				common.ResponseError(c, err)
+				return
+			}
+			if actor == nil || actor.DeletedAt > 0 || actor.IsBanned() {
+				common.ResponseError(c, domain.ErrInvalidToken)
+				return
+			}
+			actorPermissions, err := m.permissionResolver.PermissionsForRoles(c.Request.Context(), actor.Roles)
+			if err != nil {
+				common.ResponseError(c, err)
+				return
+			}
+			if !domain.HasAllPermissions(actorPermissions, domain.PermissionUsersImpersonate) {
+				common.ResponseError(c, domain.ErrInvalidToken)
+				return
+			}
+		}
+
+		permissions, err := m.permissionResolver.PermissionsForRoles(c.Request.Context(), user.Roles)
+		if err != nil {
+			common.ResponseError(c, err)
+			return
+		}
+
+		m.touchSession(c.Request.Context(), claims.Sid)
+
 		c.Set(common.UserContextKey, user)
-		c.Set(common.SessionIDContextKey, session.ID)
+		if actor != nil {
+			c.Set(common.ActorContextKey, actor)
+		}
+		c.Set(common.SessionIDContextKey, claims.Sid)
+		c.Set(common.TokenClaimsContextKey, claims)
+		c.Set(common.PermissionsContextKey, permissions)
+		c.Set(common.ScopesContextKey, domain.ScopesForPermissions(permissions))
 		c.Next()
 	}
 }
 
+// APIKeyAuthenticator authenticates other services by the API key sent in the X-API-Key header or
+// as "Authorization: ApiKey <key>".
+func (m *middlewares) APIKeyAuthenticator() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		headerData := extractHeaderData(c)
+		if headerData.APIKey == "" {
+			common.ResponseError(c, domain.ErrInvalidAPIKey)
+			return
+		}
+
+		key, err := m.apiKeyVerifier.Authenticate(c.Request.Context(), headerData.APIKey)
+		if err != nil {
+			common.ResponseError(c, err)
+			return
+		}
+
+		c.Set(common.APIKeyContextKey, key)
+		c.Set(common.ScopesContextKey, key.GetScopes())
+		c.Next()
+	}
+}
+
+// UserOrAPIKeyAuthenticator accepts an API key when the request carries one, a user access token
+// otherwise. Combine it with RequireScopes to check both kinds of callers the same way.
+func (m *middlewares) UserOrAPIKeyAuthenticator() gin.HandlerFunc {
+	userAuthenticator := m.Authenticator()
+	apiKeyAuthenticator := m.APIKeyAuthenticator()
+	return func(c *gin.Context) {
+		if extractHeaderData(c).APIKey != "" {
+			apiKeyAuthenticator(c)
+			return
+		}
+		userAuthenticator(c)
+	}
+}
+
+func (m *middlewares) isSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	session, err := m.sessionRepo.FindByID(ctx, sessionID, nil)
+	if err != nil && !common.IsRecordNotFound(err) {
+		return false, err
+	}
+	return session == nil || !session.IsActive(), nil
+}
+
+// touchSession records session activity. The cache lock makes sure it is written at most once per
+// interval. Failures are only logged, they must not fail the request.
+func (m *middlewares) touchSession(ctx context.Context, sessionID string) {
+	acquired, err := m.cache.Lock(ctx, "session_activity:"+sessionID, sessionActivityInterval)
+	if err != nil || !acquired {
+		return
+	}
+	if err := m.sessionRepo.TouchLastActivity(ctx, sessionID, utils.NowUnixMillis()); err != nil {
+		m.logger.Warn("Failed to update session activity",
+			log.String("session_id", sessionID),
+			log.Error(err),
+		)
+	}
+}
+
 func (m *middlewares) RequireAnyRoles(roleIDs ...domain.RoleID) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		v, exists := c.Get(common.UserContextKey)
@@ -105,3 +241,50 @@ func (m *middlewares) RequireAnyRoles(roleIDs ...domain.RoleID) gin.HandlerFunc
 		c.Next()
 	}
 }
+
+// RequirePermissions lets the request through only if the roles of the user grant every one of the
+// permissions. It must follow Authenticator, which resolves the permissions.
+func (m *middlewares) RequirePermissions(permissions ...domain.PermissionID) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !domain.HasAllPermissions(common.GetPermissionsFromCtx(c), permissions...) {
+			required := make([]string, len(permissions))
+			for i, permission := range permissions {
+				required[i] = string(permission)
+			}
+			common.ResponseError(c, domain.ErrPermissionDenied.WithDetail("required_permissions", required))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DenyImpersonation rejects requests made by an administrator impersonating the user, for actions
+// only the user may take such as changing the password or the second factor.
+func (m *middlewares) DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if common.GetActorFromCtx(c) != nil {
+			common.ResponseError(c, domain.ErrImpersonationForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireScopes lets the request through only if the caller was granted every one of the scopes,
+// whether it is a user or an API key.
+func (m *middlewares) RequireScopes(scopes ...domain.Scope) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !domain.HasAllScopes(common.GetScopesFromCtx(c), scopes...) {
+			required := make([]string, len(scopes))
+			for i, scope := range scopes {
+				required[i] = string(scope)
+			}
+			common.ResponseError(c, domain.ErrInsufficientScope.WithDetail("required_scopes", required))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -51,6 +51,7 @@ func DefaultCORSConfig() CORSConfig {
 			"X-Real-IP",
 			"X-Forwarded-For",
 			"X-Forwarded-Proto",
+			"X-Token-Mode",
 		},
 		ExposeHeaders: []string{
 			"Content-Length",
@@ -31,34 +31,54 @@ type Middlewares interface {
 
 	// Authentication middlewares
 	Authenticator() gin.HandlerFunc
+	APIKeyAuthenticator() gin.HandlerFunc
+	UserOrAPIKeyAuthenticator() gin.HandlerFunc
 	RequireAnyRoles(roleIDs ...domain.RoleID) gin.HandlerFunc
+	RequirePermissions(permissions ...domain.PermissionID) gin.HandlerFunc
+	DenyImpersonation() gin.HandlerFunc
+	RequireScopes(scopes ...domain.Scope) gin.HandlerFunc
+
+	// Multi-tenancy middlewares
+	Tenant() gin.HandlerFunc
 }
 
 // Dependencies holds all dependencies needed by middlewares
 type Dependencies struct {
-	Cache       cache.Client
-	Logger      log.Logger
-	JwtProvider JwtProvider
-	SessionRepo SessionRepository
-	UserRepo    UserRepository
+	Cache               cache.Client
+	Logger              log.Logger
+	JwtProvider         JwtProvider
+	RevocationList      TokenRevocationList
+	SessionRepo         SessionRepository
+	UserRepo            UserRepository
+	PermissionResolver  PermissionResolver
+	APIKeyVerifier      APIKeyVerifier
+	OrganizationMembers OrganizationMemberRepository
 }
 
 // NewMiddlewares creates a new instance of middlewares with dependencies
 func NewMiddlewares(deps Dependencies) Middlewares {
 	return &middlewares{
-		cache:       deps.Cache,
-		logger:      deps.Logger,
-		jwtProvider: deps.JwtProvider,
-		sessionRepo: deps.SessionRepo,
-		userRepo:    deps.UserRepo,
+		cache:               deps.Cache,
+		logger:              deps.Logger,
+		jwtProvider:         deps.JwtProvider,
+		revocationList:      deps.RevocationList,
+		sessionRepo:         deps.SessionRepo,
+		userRepo:            deps.UserRepo,
+		permissionResolver:  deps.PermissionResolver,
+		apiKeyVerifier:      deps.APIKeyVerifier,
+		organizationMembers: deps.OrganizationMembers,
 	}
 }
 
 // middlewares is the concrete implementation of Middlewares interface
 type middlewares struct {
-	cache       cache.Client
-	logger      log.Logger
-	jwtProvider JwtProvider
-	sessionRepo SessionRepository
-	userRepo    UserRepository
+	cache               cache.Client
+	logger              log.Logger
+	jwtProvider         JwtProvider
+	revocationList      TokenRevocationList
+	sessionRepo         SessionRepository
+	userRepo            UserRepository
+	permissionResolver  PermissionResolver
+	apiKeyVerifier      APIKeyVerifier
+	organizationMembers OrganizationMemberRepository
 }
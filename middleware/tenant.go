@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationMemberRepository interface {
+	FindMembership(ctx context.Context, organizationID, userID string) (*domain.OrganizationMember, error)
+}
+
+// Tenant selects the organization the request acts in, from the X-Organization-ID header or else
+// the org claim of the access token, and makes sure the user is a member of it. The organization is
+// put in the request context for the repositories scoped to tenants, and the membership in the gin
+// context for the handlers. It must follow Authenticator.
+func (m *middlewares) Tenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := common.GetUserFromCtx(c)
+		if user == nil {
+			common.ResponseError(c, domain.ErrUnauthorized)
+			return
+		}
+
+		organizationID := c.GetHeader(common.OrganizationHeader)
+		if organizationID == "" {
+			if claims := common.GetTokenClaimsFromCtx(c); claims != nil {
+				organizationID = claims.Org
+			}
+		}
+		if organizationID == "" {
+			common.ResponseError(c, domain.ErrTenantRequired)
+			return
+		}
+
+		member, err := m.organizationMembers.FindMembership(c.Request.Context(), organizationID, user.ID)
+		if err != nil && !common.IsRecordNotFound(err) {
+			common.ResponseError(c, err)
+			return
+		}
+		// Unknown organizations look the same as foreign ones, so they cannot be probed
+		if member == nil {
+			common.ResponseError(c, domain.ErrNotOrganizationMember)
+			return
+		}
+
+		c.Set(common.MembershipContextKey, member)
+		c.Request = c.Request.WithContext(domain.ContextWithTenant(c.Request.Context(), organizationID))
+		c.Next()
+	}
+}
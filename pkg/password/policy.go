@@ -0,0 +1,144 @@
+// Package password implements a configurable password strength policy: length limits, required
+// character classes, a denylist of common passwords and a check against the user's personal data.
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordsFile string
+
+// Rule identifies a single requirement of the policy.
+type Rule string
+
+const (
+	RuleMinLength      Rule = "min_length"
+	RuleMaxLength      Rule = "max_length"
+	RuleUppercase      Rule = "uppercase"
+	RuleLowercase      Rule = "lowercase"
+	RuleDigit          Rule = "digit"
+	RuleSymbol         Rule = "symbol"
+	RuleCommonPassword Rule = "common_password"
+	RulePersonalInfo   Rule = "personal_info"
+	// RulePasswordReuse is reported by callers holding the password history, the policy itself
+	// never sees previous passwords
+	RulePasswordReuse Rule = "password_reuse"
+)
+
+// minPersonalInfoLength ignores personal values too short to be meaningful, like a 2 letter name
+const minPersonalInfoLength = 3
+
+type Config interface {
+	MinLength() int
+	MaxLength() int
+	RequireUppercase() bool
+	RequireLowercase() bool
+	RequireDigit() bool
+	RequireSymbol() bool
+	DenyCommonPasswords() bool
+	DenyPersonalInfo() bool
+}
+
+// Violation describes a rule the password does not satisfy.
+type Violation struct {
+	Rule    Rule   `json:"rule"`
+	Message string `json:"message"`
+}
+
+type Policy struct {
+	cfg             Config
+	commonPasswords map[string]struct{}
+}
+
+func NewPolicy(cfg Config) *Policy {
+	return &Policy{
+		cfg:             cfg,
+		commonPasswords: parseCommonPasswords(commonPasswordsFile),
+	}
+}
+
+// Validate checks the password against every rule of the policy and returns all violations, so
+// the user can fix them at once. personalInfo lists values the password must not contain, such as
+// the user's email, username or names.
+func (p *Policy) Validate(password string, personalInfo ...string) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if minLength := p.cfg.MinLength(); minLength > 0 && length < minLength {
+		violations = append(violations, Violation{RuleMinLength, fmt.Sprintf("must be at least %d characters long", minLength)})
+	}
+	if maxLength := p.cfg.MaxLength(); maxLength > 0 && length > maxLength {
+		violations = append(violations, Violation{RuleMaxLength, fmt.Sprintf("must be at most %d characters long", maxLength)})
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.cfg.RequireUppercase() && !hasUpper {
+		violations = append(violations, Violation{RuleUppercase, "must contain an uppercase letter"})
+	}
+	if p.cfg.RequireLowercase() && !hasLower {
+		violations = append(violations, Violation{RuleLowercase, "must contain a lowercase letter"})
+	}
+	if p.cfg.RequireDigit() && !hasDigit {
+		violations = append(violations, Violation{RuleDigit, "must contain a digit"})
+	}
+	if p.cfg.RequireSymbol() && !hasSymbol {
+		violations = append(violations, Violation{RuleSymbol, "must contain a symbol"})
+	}
+
+	lower := strings.ToLower(password)
+	if p.cfg.DenyCommonPasswords() {
+		if _, ok := p.commonPasswords[lower]; ok {
+			violations = append(violations, Violation{RuleCommonPassword, "is too common"})
+		}
+	}
+	if p.cfg.DenyPersonalInfo() && containsPersonalInfo(lower, personalInfo) {
+		violations = append(violations, Violation{RulePersonalInfo, "must not contain your email, username or name"})
+	}
+
+	return violations
+}
+
+func containsPersonalInfo(lowerPassword string, personalInfo []string) bool {
+	for _, info := range personalInfo {
+		info = strings.ToLower(strings.TrimSpace(info))
+		// Only the local part of an email address is meaningful
+		if at := strings.IndexByte(info, '@'); at >= 0 {
+			info = info[:at]
+		}
+		if utf8.RuneCountInString(info) >= minPersonalInfoLength && strings.Contains(lowerPassword, info) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCommonPasswords(content string) map[string]struct{} {
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		passwords[strings.ToLower(line)] = struct{}{}
+	}
+	return passwords
+}
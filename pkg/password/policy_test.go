@@ -0,0 +1,103 @@
+package password
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testConfig struct {
+	minLength, maxLength                  int
+	uppercase, lowercase, digit, symbol   bool
+	denyCommonPasswords, denyPersonalInfo bool
+}
+
+func (c testConfig) MinLength() int            { return c.minLength }
+func (c testConfig) MaxLength() int            { return c.maxLength }
+func (c testConfig) RequireUppercase() bool    { return c.uppercase }
+func (c testConfig) RequireLowercase() bool    { return c.lowercase }
+func (c testConfig) RequireDigit() bool        { return c.digit }
+func (c testConfig) RequireSymbol() bool       { return c.symbol }
+func (c testConfig) DenyCommonPasswords() bool { return c.denyCommonPasswords }
+func (c testConfig) DenyPersonalInfo() bool    { return c.denyPersonalInfo }
+
+func TestPolicyValidate(t *testing.T) {
+	strict := testConfig{
+		minLength:           10,
+		maxLength:           20,
+		uppercase:           true,
+		lowercase:           true,
+		digit:               true,
+		symbol:              true,
+		denyCommonPasswords: true,
+		denyPersonalInfo:    true,
+	}
+
+	tests := []struct {
+		name         string
+		cfg          testConfig
+		password     string
+		personalInfo []string
+		want         []Rule
+	}{
+		{name: "strong password", cfg: strict, password: "Tr0ub4dor&3x!"},
+		{name: "too short", cfg: strict, password: "Ab1!xyz", want: []Rule{RuleMinLength}},
+		{name: "too long", cfg: strict, password: "Ab1!xyzAb1!xyzAb1!xyz", want: []Rule{RuleMaxLength}},
+		{name: "length counts runes", cfg: testConfig{minLength: 4}, password: "ééé", want: []Rule{RuleMinLength}},
+		{
+			name:     "missing character classes",
+			cfg:      strict,
+			password: "alllowercaseletters",
+			want:     []Rule{RuleUppercase, RuleDigit, RuleSymbol},
+		},
+		{name: "space counts as a symbol", cfg: testConfig{symbol: true}, password: "two words"},
+		{name: "common password ignoring case", cfg: testConfig{denyCommonPasswords: true}, password: "PassWord", want: []Rule{RuleCommonPassword}},
+		{name: "common passwords allowed when the rule is disabled", cfg: testConfig{}, password: "password"},
+		{
+			name:         "contains the local part of the email",
+			cfg:          testConfig{denyPersonalInfo: true},
+			password:     "MyJohnDoe!2024",
+			personalInfo: []string{"johndoe@example.com"},
+			want:         []Rule{RulePersonalInfo},
+		},
+		{
+			name:         "contains the username ignoring case",
+			cfg:          testConfig{denyPersonalInfo: true},
+			password:     "xxSUPERMANxx",
+			personalInfo: []string{"superman"},
+			want:         []Rule{RulePersonalInfo},
+		},
+		{
+			name:         "short personal values are ignored",
+			cfg:          testConfig{denyPersonalInfo: true},
+			password:     "Al-is-here-2024",
+			personalInfo: []string{"Al", ""},
+		},
+		{
+			name:         "personal info allowed when the rule is disabled",
+			cfg:          testConfig{},
+			password:     "superman",
+			personalInfo: []string{"superman"},
+		},
+		{
+			name:     "every violation is reported",
+			cfg:      strict,
+			password: "",
+			want:     []Rule{RuleMinLength, RuleUppercase, RuleLowercase, RuleDigit, RuleSymbol},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := NewPolicy(tt.cfg).Validate(tt.password, tt.personalInfo...)
+			var got []Rule
+			for _, violation := range violations {
+				if violation.Message == "" {
+					t.Errorf("violation %s has no message", violation.Rule)
+				}
+				got = append(got, violation.Rule)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Validate() rules = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
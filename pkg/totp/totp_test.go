@@ -0,0 +1,91 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// rfcSecret is the base32 encoding of the ASCII seed "12345678901234567890" of RFC 4226 and RFC 6238
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestHOTPRFC4226Vectors(t *testing.T) {
+	// RFC 4226 Appendix D
+	expected := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+	key, err := decodeSecret(rfcSecret)
+	if err != nil {
+		t.Fatalf("decodeSecret() error = %v", err)
+	}
+	for counter, want := range expected {
+		if got := hotp(key, int64(counter)); got != want {
+			t.Errorf("hotp(counter=%d) = %s, want %s", counter, got, want)
+		}
+	}
+}
+
+func TestGenerateCodeRFC6238Vectors(t *testing.T) {
+	// RFC 6238 Appendix B, SHA1 mode. The RFC lists 8 digit codes, the 6 digit code is their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		got, err := GenerateCode(rfcSecret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("GenerateCode(%d) error = %v", tt.unix, err)
+		}
+		if got != tt.want {
+			t.Errorf("GenerateCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current, _ := GenerateCode(rfcSecret, now)
+	previous, _ := GenerateCode(rfcSecret, now.Add(-Period))
+	tooOld, _ := GenerateCode(rfcSecret, now.Add(-2*Period))
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", secret: rfcSecret, code: current, wantStep: Step(now), wantOK: true},
+		{name: "previous step within skew", secret: rfcSecret, code: previous, wantStep: Step(now) - 1, wantOK: true},
+		{name: "outside skew", secret: rfcSecret, code: tooOld},
+		{name: "lower case secret with spaces", secret: "gezd gnbv gy3t qojq gezd gnbv gy3t qojq", code: current, wantStep: Step(now), wantOK: true},
+		{name: "wrong length", secret: rfcSecret, code: current[:5]},
+		{name: "invalid secret", secret: "not-base32!", code: current},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(tt.secret, tt.code, now, 1)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate() = (%d, %v), want (%d, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	key, err := decodeSecret(secret)
+	if err != nil {
+		t.Fatalf("decodeSecret() error = %v", err)
+	}
+	if len(key) != SecretSize {
+		t.Errorf("secret has %d bytes, want %d", len(key), SecretSize)
+	}
+}
@@ -1,6 +1,9 @@
 package utils
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // NowUnixMillis returns the current time in Unix milliseconds.
 func NowUnixMillis() int64 {
@@ -102,3 +105,18 @@ func GetTimeFormat(groupBy string) string {
 	}
 	return formats[groupBy]
 }
+
+// FormatDuration returns a human readable representation of d using its largest whole unit, e.g. "24 hours", "15 minutes".
+func FormatDuration(d time.Duration) string {
+	value, unit := int64(d/time.Second), "second"
+	switch {
+	case d >= time.Hour && d%time.Hour == 0:
+		value, unit = int64(d/time.Hour), "hour"
+	case d >= time.Minute && d%time.Minute == 0:
+		value, unit = int64(d/time.Minute), "minute"
+	}
+	if value == 1 {
+		return fmt.Sprintf("%d %s", value, unit)
+	}
+	return fmt.Sprintf("%d %ss", value, unit)
+}
@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"strings"
+)
+
+const (
+	DeviceTypeDesktop = "desktop"
+	DeviceTypeMobile  = "mobile"
+	DeviceTypeTablet  = "tablet"
+	DeviceTypeBot     = "bot"
+	DeviceTypeUnknown = "unknown"
+)
+
+// UserAgentInfo is a human readable summary of a User-Agent header.
+type UserAgentInfo struct {
+	Browser string `json:"browser"`
+	OS      string `json:"os"`
+	Device  string `json:"device"`
+}
+
+// browserTokens is checked in order, so browsers that also advertise Chrome or Safari come first.
+var browserTokens = []struct {
+	token string
+	name  string
+}{
+	{"Edg/", "Edge"},
+	{"EdgA/", "Edge"},
+	{"EdgiOS/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"YaBrowser/", "Yandex"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"FxiOS/", "Firefox"},
+	{"Firefox/", "Firefox"},
+	{"Version/", "Safari"},
+	{"MSIE ", "Internet Explorer"},
+	{"Trident/", "Internet Explorer"},
+}
+
+// ParseUserAgent extracts the browser, operating system and device type from a User-Agent
+// string. It relies on well known tokens only and returns "Unknown" parts it cannot recognise.
+func ParseUserAgent(ua string) UserAgentInfo {
+	info := UserAgentInfo{Browser: "Unknown", OS: "Unknown", Device: DeviceTypeUnknown}
+	if strings.TrimSpace(ua) == "" {
+		return info
+	}
+	lower := strings.ToLower(ua)
+
+	for _, b := range browserTokens {
+		if version, ok := tokenVersion(ua, b.token); ok {
+			info.Browser = strings.TrimSpace(b.name + " " + version)
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(ua, "Windows NT"):
+		info.OS = "Windows"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPad"), strings.Contains(ua, "iPod"):
+		info.OS = "iOS"
+	case strings.Contains(ua, "Android"):
+		info.OS = "Android"
+	case strings.Contains(ua, "CrOS"):
+		info.OS = "ChromeOS"
+	case strings.Contains(ua, "Mac OS X"), strings.Contains(ua, "Macintosh"):
+		info.OS = "macOS"
+	case strings.Contains(ua, "Linux"):
+		info.OS = "Linux"
+	}
+
+	switch {
+	case strings.Contains(lower, "bot"), strings.Contains(lower, "crawler"), strings.Contains(lower, "spider"):
+		info.Device = DeviceTypeBot
+	case strings.Contains(ua, "iPad"), strings.Contains(ua, "Tablet"),
+		strings.Contains(ua, "Android") && !strings.Contains(ua, "Mobile"):
+		info.Device = DeviceTypeTablet
+	case strings.Contains(ua, "Mobile"), strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPod"):
+		info.Device = DeviceTypeMobile
+	case info.OS != "Unknown":
+		info.Device = DeviceTypeDesktop
+	}
+
+	return info
+}
+
+// tokenVersion returns the major version following token in ua, e.g. "120" for "Chrome/120.0.1".
+func tokenVersion(ua, token string) (string, bool) {
+	idx := strings.Index(ua, token)
+	if idx < 0 {
+		return "", false
+	}
+	rest := ua[idx+len(token):]
+	end := strings.IndexAny(rest, ". ;)")
+	if end >= 0 {
+		rest = rest[:end]
+	}
+	return rest, true
+}
@@ -0,0 +1,107 @@
+package webauthn
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeCBOR(t *testing.T) {
+	nested := []byte{}
+	for i := 0; i <= maxCBORDepth+1; i++ {
+		nested = append(nested, 0x81) // Array of one item
+	}
+	nested = append(nested, 0x00)
+
+	tests := []struct {
+		name     string
+		data     []byte
+		want     any
+		wantUsed int
+		wantErr  bool
+	}{
+		{name: "small integer", data: []byte{0x17}, want: int64(23), wantUsed: 1},
+		{name: "one byte integer", data: []byte{0x18, 0xff}, want: int64(255), wantUsed: 2},
+		{name: "two byte integer", data: []byte{0x19, 0x01, 0x00}, want: int64(256), wantUsed: 3},
+		{name: "negative integer", data: []byte{0x38, 0x63}, want: int64(-100), wantUsed: 2},
+		{name: "COSE algorithm", data: cborEncode(AlgRS256), want: int64(-257), wantUsed: 3},
+		{name: "byte string", data: []byte{0x42, 0x01, 0x02}, want: []byte{0x01, 0x02}, wantUsed: 3},
+		{name: "text string", data: []byte{0x63, 'f', 'm', 't'}, want: "fmt", wantUsed: 4},
+		{name: "array", data: []byte{0x82, 0x01, 0x20}, want: []any{int64(1), int64(-1)}, wantUsed: 3},
+		{
+			name:     "map",
+			data:     cborEncode(cborMap{{1, 2}, {"a", []byte{0xff}}}),
+			want:     map[any]any{int64(1): int64(2), "a": []byte{0xff}},
+			wantUsed: 7,
+		},
+		{name: "booleans and null", data: []byte{0x83, 0xf4, 0xf5, 0xf6}, want: []any{false, true, nil}, wantUsed: 4},
+		{name: "tagged item", data: []byte{0xc2, 0x41, 0x01}, want: []byte{0x01}, wantUsed: 3},
+		{name: "trailing bytes are not used", data: []byte{0x01, 0x02}, want: int64(1), wantUsed: 1},
+		{name: "empty input", data: []byte{}, wantErr: true},
+		{name: "truncated argument", data: []byte{0x19, 0x01}, wantErr: true},
+		{name: "byte string longer than the input", data: []byte{0x45, 0x01}, wantErr: true},
+		{name: "array longer than the input", data: []byte{0x9a, 0xff, 0xff, 0xff, 0xff}, wantErr: true},
+		{name: "map longer than the input", data: []byte{0xbb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, wantErr: true},
+		{name: "integer overflowing int64", data: []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, wantErr: true},
+		{name: "indefinite length", data: []byte{0x5f, 0x41, 0x01, 0xff}, wantErr: true},
+		{name: "array map key", data: []byte{0xa1, 0x80, 0x01}, wantErr: true},
+		{name: "duplicate map key", data: []byte{0xa2, 0x01, 0x01, 0x01, 0x02}, wantErr: true},
+		{name: "map without value", data: []byte{0xa1, 0x01}, wantErr: true},
+		{name: "reserved simple value", data: []byte{0xf8, 0x20}, wantErr: true},
+		{name: "too deeply nested", data: nested, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, used, err := decodeCBOR(tt.data)
+			if tt.wantErr {
+				if !errors.Is(err, errInvalidCBOR) {
+					t.Fatalf("decodeCBOR() error = %v, want %v", err, errInvalidCBOR)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCBOR() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) || used != tt.wantUsed {
+				t.Errorf("decodeCBOR() = %#v, %d, want %#v, %d", got, used, tt.want, tt.wantUsed)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORFloats(t *testing.T) {
+	half := []byte{0xfa, 0x3f, 0x00, 0x00, 0x00}
+	got, _, err := decodeCBOR(half)
+	if err != nil || got != float64(0.5) {
+		t.Errorf("decodeCBOR(float32) = %v, %v, want 0.5", got, err)
+	}
+
+	double := append([]byte{0xfb}, make([]byte, 8)...)
+	bits := math.Float64bits(-2.25)
+	for i := 0; i < 8; i++ {
+		double[8-i] = byte(bits >> (8 * i))
+	}
+	got, _, err = decodeCBOR(double)
+	if err != nil || got != -2.25 {
+		t.Errorf("decodeCBOR(float64) = %v, %v, want -2.25", got, err)
+	}
+
+	if _, _, err := decodeCBOR(half[:3]); !errors.Is(err, errInvalidCBOR) {
+		t.Errorf("decodeCBOR(truncated float) error = %v, want %v", err, errInvalidCBOR)
+	}
+}
+
+func TestDecodeCBORCopiesByteStrings(t *testing.T) {
+	data := []byte{0x41, 0x01}
+	got, _, err := decodeCBOR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[1] = 0x02
+	if !bytes.Equal(got.([]byte), []byte{0x01}) {
+		t.Errorf("decoded byte string changed with its input: %v", got)
+	}
+}
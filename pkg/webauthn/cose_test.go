@@ -0,0 +1,148 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestParsePublicKey(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y := ecKey.X.FillBytes(make([]byte, 32)), ecKey.Y.FillBytes(make([]byte, 32))
+	edKey, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	smallRSAKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exponent := big.NewInt(int64(rsaKey.E)).Bytes()
+
+	tests := []struct {
+		name    string
+		key     []byte
+		wantAlg int64
+		wantErr error
+	}{
+		{
+			name:    "ES256",
+			key:     cborEncode(cborMap{{coseKeyType, coseKeyTypeEC2}, {coseAlgorithm, AlgES256}, {coseCurve, coseCurveP256}, {coseX, x}, {coseY, y}}),
+			wantAlg: AlgES256,
+		},
+		{
+			name:    "EdDSA",
+			key:     cborEncode(cborMap{{coseKeyType, coseKeyTypeOKP}, {coseAlgorithm, AlgEdDSA}, {coseCurve, coseCurveEd25519}, {coseX, []byte(edKey)}}),
+			wantAlg: AlgEdDSA,
+		},
+		{
+			name:    "RS256",
+			key:     cborEncode(cborMap{{coseKeyType, coseKeyTypeRSA}, {coseAlgorithm, AlgRS256}, {coseCurve, rsaKey.N.Bytes()}, {coseX, exponent}}),
+			wantAlg: AlgRS256,
+		},
+		{
+			name:    "EC2 key on another curve",
+			key:     cborEncode(cborMap{{coseKeyType, coseKeyTypeEC2}, {coseAlgorithm, AlgES256}, {coseCurve, 2}, {coseX, x}, {coseY, y}}),
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "EC2 point not on the curve",
+			key:     cborEncode(cborMap{{coseKeyType, coseKeyTypeEC2}, {coseAlgorithm, AlgES256}, {coseCurve, coseCurveP256}, {coseX, x}, {coseY, x}}),
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "EC2 key with the EdDSA algorithm",
+			key:     cborEncode(cborMap{{coseKeyType, coseKeyTypeEC2}, {coseAlgorithm, AlgEdDSA}, {coseCurve, coseCurveP256}, {coseX, x}, {coseY, y}}),
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "short Ed25519 key",
+			key:     cborEncode(cborMap{{coseKeyType, coseKeyTypeOKP}, {coseAlgorithm, AlgEdDSA}, {coseCurve, coseCurveEd25519}, {coseX, []byte(edKey)[:31]}}),
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "RSA key under 2048 bits",
+			key:     cborEncode(cborMap{{coseKeyType, coseKeyTypeRSA}, {coseAlgorithm, AlgRS256}, {coseCurve, smallRSAKey.N.Bytes()}, {coseX, exponent}}),
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "not a map",
+			key:     cborEncode([]any{coseKeyTypeEC2}),
+			wantErr: ErrUnsupportedKey,
+		},
+		{
+			name:    "malformed CBOR",
+			key:     []byte{0xa5, 0x01, 0x02, 0x03},
+			wantErr: errInvalidCBOR,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := parsePublicKey(tt.key)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parsePublicKey() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parsePublicKey() error = %v", err)
+			}
+			if key.algorithm != tt.wantAlg {
+				t.Errorf("parsePublicKey() algorithm = %d, want %d", key.algorithm, tt.wantAlg)
+			}
+		})
+	}
+}
+
+func TestPublicKeyVerify(t *testing.T) {
+	signed := []byte("authenticator data and client data hash")
+	digest := sha256.Sum256(signed)
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecSignature, _ := ecdsa.SignASN1(rand.Reader, ecKey, digest[:])
+	edPublic, edPrivate, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rsaSignature, _ := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, digest[:])
+
+	keys := []struct {
+		name      string
+		key       *publicKey
+		signature []byte
+	}{
+		{name: "ES256", key: &publicKey{algorithm: AlgES256, key: &ecKey.PublicKey}, signature: ecSignature},
+		{name: "EdDSA", key: &publicKey{algorithm: AlgEdDSA, key: edPublic}, signature: ed25519.Sign(edPrivate, signed)},
+		{name: "RS256", key: &publicKey{algorithm: AlgRS256, key: &rsaKey.PublicKey}, signature: rsaSignature},
+	}
+
+	for _, k := range keys {
+		t.Run(k.name, func(t *testing.T) {
+			if err := k.key.verify(signed, k.signature); err != nil {
+				t.Errorf("verify() error = %v", err)
+			}
+			if err := k.key.verify(append(signed, '!'), k.signature); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("verify(other data) error = %v, want %v", err, ErrInvalidSignature)
+			}
+			tampered := append([]byte{}, k.signature...)
+			tampered[len(tampered)/2] ^= 0x01
+			if err := k.key.verify(signed, tampered); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("verify(tampered signature) error = %v, want %v", err, ErrInvalidSignature)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+// Package webauthn implements the relying party side of the WebAuthn registration and
+// authentication ceremonies (https://www.w3.org/TR/webauthn-2/) for passkeys and security keys.
+// Attestation statements are not verified, credentials are requested with "none" attestation.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// ChallengeSize is the number of random bytes of a challenge
+const ChallengeSize = 32
+
+// Authenticator data flags
+const (
+	flagUserPresent            byte = 0x01
+	flagUserVerified           byte = 0x04
+	flagBackupEligible         byte = 0x08
+	flagBackedUp               byte = 0x10
+	flagAttestedCredentialData byte = 0x40
+)
+
+var (
+	ErrInvalidResponse       = errors.New("webauthn: malformed credential response")
+	ErrChallengeMismatch     = errors.New("webauthn: challenge mismatch")
+	ErrOriginNotAllowed      = errors.New("webauthn: origin not allowed")
+	ErrRPIDMismatch          = errors.New("webauthn: relying party ID mismatch")
+	ErrUserNotPresent        = errors.New("webauthn: user presence not asserted")
+	ErrUserNotVerified       = errors.New("webauthn: user verification required")
+	ErrSignCountNotIncreased = errors.New("webauthn: sign counter did not increase, the authenticator may be cloned")
+)
+
+// b64 is the unpadded base64url encoding WebAuthn uses in its JSON serialization
+var b64 = base64.RawURLEncoding
+
+// Config describes the relying party.
+type Config struct {
+	RPID             string        // Domain of the site, e.g. "example.com"
+	RPName           string        // Shown to the user by the authenticator
+	Origins          []string      // Allowed origins of the ceremonies, e.g. "https://app.example.com"
+	Timeout          time.Duration // Hint for the client
+	UserVerification string        // required, preferred or discouraged
+}
+
+type RelyingParty struct {
+	cfg Config
+}
+
+func New(cfg Config) *RelyingParty {
+	if cfg.UserVerification == "" {
+		cfg.UserVerification = UserVerificationPreferred
+	}
+	return &RelyingParty{cfg: cfg}
+}
+
+// NewChallenge returns a random base64url encoded challenge.
+func NewChallenge() (string, error) {
+	bytes := make([]byte, ChallengeSize)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return b64.EncodeToString(bytes), nil
+}
+
+// CredentialDescriptor identifies a credential, with its base64url encoded ID.
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// User is the account a credential is registered for. ID must be opaque and stable, it is returned
+// by discoverable credentials as the user handle.
+type User struct {
+	ID          string
+	Name        string
+	DisplayName string
+}
+
+type rpEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type userEntity struct {
+	ID          string `json:"id"` // base64url
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type credentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type authenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions is the JSON form of PublicKeyCredentialCreationOptions, the client passes it to
+// navigator.credentials.create() through PublicKeyCredential.parseCreationOptionsFromJSON().
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     rpEntity               `json:"rp"`
+	User                   userEntity             `json:"user"`
+	PubKeyCredParams       []credentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection authenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions is the JSON form of PublicKeyCredentialRequestOptions, the client passes it to
+// navigator.credentials.get() through PublicKeyCredential.parseRequestOptionsFromJSON().
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+func (rp *RelyingParty) CreationOptions(challenge string, user User, exclude []CredentialDescriptor) *CreationOptions {
+	params := make([]credentialParameter, 0, len(SupportedAlgorithms))
+	for _, alg := range SupportedAlgorithms {
+		params = append(params, credentialParameter{Type: "public-key", Alg: alg})
+	}
+	return &CreationOptions{
+		Challenge: challenge,
+		RP:        rpEntity{ID: rp.cfg.RPID, Name: rp.cfg.RPName},
+		User: userEntity{
+			ID:          b64.EncodeToString([]byte(user.ID)),
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams:   params,
+		Timeout:            rp.cfg.Timeout.Milliseconds(),
+		ExcludeCredentials: nonNil(exclude),
+		AuthenticatorSelection: authenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: rp.cfg.UserVerification,
+		},
+		Attestation: "none",
+	}
+}
+
+// RequestOptions returns the options of an authentication ceremony. An empty allow list lets the
+// user pick any discoverable credential of the site.
+func (rp *RelyingParty) RequestOptions(challenge string, allow []CredentialDescriptor, userVerification string) *RequestOptions {
+	if userVerification == "" {
+		userVerification = rp.cfg.UserVerification
+	}
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.cfg.Timeout.Milliseconds(),
+		RPID:             rp.cfg.RPID,
+		AllowCredentials: nonNil(allow),
+		UserVerification: userVerification,
+	}
+}
+
+// RegistrationResponse is the JSON form of the credential returned by navigator.credentials.create().
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the JSON form of the credential returned by navigator.credentials.get().
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Credential is a verified new credential, to be stored with the user.
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key
+	Algorithm      int64
+	SignCount      uint32
+	AAGUID         []byte
+	Transports     []string
+	UserVerified   bool
+	BackupEligible bool
+	BackedUp       bool
+}
+
+// Assertion is the outcome of a verified authentication ceremony.
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+	BackedUp     bool
+	UserHandle   string // Decoded user ID, only sent by discoverable credentials
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	// Attested credential data, only present on registration
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+// VerifyRegistration checks the response of a registration ceremony started with the challenge and
+// returns the new credential.
+func (rp *RelyingParty) VerifyRegistration(resp *RegistrationResponse, challenge string) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, ErrInvalidResponse
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := b64.DecodeString(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	decoded, _, err := decodeCBOR(rawAttestation)
+	if err != nil {
+		return nil, err
+	}
+	attestation, ok := decoded.(map[any]any)
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrInvalidResponse
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData, rp.cfg.UserVerification); err != nil {
+		return nil, err
+	}
+	if authData.flags&flagAttestedCredentialData == 0 {
+		return nil, ErrInvalidResponse
+	}
+
+	key, err := parsePublicKey(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if rawID, err := b64.DecodeString(resp.RawID); err != nil || !bytes.Equal(rawID, authData.credentialID) {
+		return nil, ErrInvalidResponse
+	}
+
+	return &Credential{
+		ID:             authData.credentialID,
+		PublicKey:      authData.publicKey,
+		Algorithm:      key.algorithm,
+		SignCount:      authData.signCount,
+		AAGUID:         authData.aaguid,
+		Transports:     resp.Response.Transports,
+		UserVerified:   authData.flags&flagUserVerified != 0,
+		BackupEligible: authData.flags&flagBackupEligible != 0,
+		BackedUp:       authData.flags&flagBackedUp != 0,
+	}, nil
+}
+
+// VerifyAssertion checks the response of an authentication ceremony started with the challenge,
+// against the stored public key and sign counter of the credential.
+func (rp *RelyingParty) VerifyAssertion(resp *AssertionResponse, challenge, userVerification string, coseKey []byte, storedSignCount uint32) (*Assertion, error) {
+	if resp.Type != "public-key" {
+		return nil, ErrInvalidResponse
+	}
+	if userVerification == "" {
+		userVerification = rp.cfg.UserVerification
+	}
+	if err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge); err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := b64.DecodeString(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData, userVerification); err != nil {
+		return nil, err
+	}
+
+	key, err := parsePublicKey(coseKey)
+	if err != nil {
+		return nil, err
+	}
+	rawClientData, _ := b64.DecodeString(resp.Response.ClientDataJSON)
+	signature, err := b64.DecodeString(resp.Response.Signature)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := key.verify(signed, signature); err != nil {
+		return nil, err
+	}
+
+	// Authenticators without a counter always report 0, any other value must grow
+	if (authData.signCount != 0 || storedSignCount != 0) && authData.signCount <= storedSignCount {
+		return nil, ErrSignCountNotIncreased
+	}
+
+	userHandle, err := b64.DecodeString(resp.Response.UserHandle)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	return &Assertion{
+		SignCount:    authData.signCount,
+		UserVerified: authData.flags&flagUserVerified != 0,
+		BackedUp:     authData.flags&flagBackedUp != 0,
+		UserHandle:   string(userHandle),
+	}, nil
+}
+
+func (rp *RelyingParty) verifyClientData(encoded, ceremony, challenge string) error {
+	raw, err := b64.DecodeString(encoded)
+	if err != nil {
+		return ErrInvalidResponse
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return ErrInvalidResponse
+	}
+	if data.Type != ceremony {
+		return fmt.Errorf("%w: unexpected type %q", ErrInvalidResponse, data.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return ErrChallengeMismatch
+	}
+	if !slices.Contains(rp.cfg.Origins, data.Origin) {
+		return ErrOriginNotAllowed
+	}
+	return nil
+}
+
+func (rp *RelyingParty) verifyAuthenticatorData(authData *authenticatorData, userVerification string) error {
+	rpIDHash := sha256.Sum256([]byte(rp.cfg.RPID))
+	if !bytes.Equal(authData.rpIDHash, rpIDHash[:]) {
+		return ErrRPIDMismatch
+	}
+	if authData.flags&flagUserPresent == 0 {
+		return ErrUserNotPresent
+	}
+	if userVerification == UserVerificationRequired && authData.flags&flagUserVerified == 0 {
+		return ErrUserNotVerified
+	}
+	return nil
+}
+
+// parseAuthenticatorData decodes rpIdHash (32) | flags (1) | signCount (4) | attested credential data,
+// the latter being aaguid (16) | credentialIdLength (2) | credentialId | COSE_Key.
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrInvalidResponse
+	}
+	authData := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+	if authData.flags&flagAttestedCredentialData == 0 {
+		return authData, nil
+	}
+
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, ErrInvalidResponse
+	}
+	authData.aaguid = rest[:16]
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+		return nil, ErrInvalidResponse
+	}
+	authData.credentialID = rest[:idLength]
+	rest = rest[idLength:]
+
+	// The COSE key is followed by the extensions when the ED flag is set
+	_, keyLength, err := decodeCBOR(rest)
+	if err != nil {
+		return nil, ErrInvalidResponse
+	}
+	authData.publicKey = rest[:keyLength]
+	return authData, nil
+}
+
+// EncodeID returns the base64url form of a credential ID, as used in the JSON serialization.
+func EncodeID(id []byte) string {
+	return b64.EncodeToString(id)
+}
+
+// DecodeID parses the base64url form of a credential ID.
+func DecodeID(id string) ([]byte, error) {
+	return b64.DecodeString(id)
+}
+
+func nonNil(descriptors []CredentialDescriptor) []CredentialDescriptor {
+	if descriptors == nil {
+		return []CredentialDescriptor{}
+	}
+	return descriptors
+}
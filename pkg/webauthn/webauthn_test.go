@@ -0,0 +1,371 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const (
+	testRPID      = "example.com"
+	testOrigin    = "https://app.example.com"
+	testChallenge = "challenge-1"
+	testUserID    = "user-1"
+)
+
+// cborMap keeps the order of its entries so encoded test data is deterministic
+type cborMap []cborEntry
+
+type cborEntry struct {
+	key, value any
+}
+
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(arg))
+	default:
+		return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(arg))
+	}
+}
+
+// cborEncode encodes the few types authenticators use, it is the counterpart of decodeCBOR
+func cborEncode(value any) []byte {
+	switch v := value.(type) {
+	case int:
+		return cborEncode(int64(v))
+	case int64:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case []any:
+		out := cborHead(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, cborEncode(item)...)
+		}
+		return out
+	case cborMap:
+		out := cborHead(5, uint64(len(v)))
+		for _, entry := range v {
+			out = append(out, cborEncode(entry.key)...)
+			out = append(out, cborEncode(entry.value)...)
+		}
+		return out
+	}
+	panic("cborEncode: unsupported type")
+}
+
+// softAuthenticator is a software authenticator holding a single ES256 credential
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	rpID         string
+	origin       string
+	flags        byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{
+		key:          key,
+		credentialID: []byte("credential-1"),
+		rpID:         testRPID,
+		origin:       testOrigin,
+		flags:        flagUserPresent | flagUserVerified,
+	}
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	return cborEncode(cborMap{
+		{coseKeyType, coseKeyTypeEC2},
+		{coseAlgorithm, AlgES256},
+		{coseCurve, coseCurveP256},
+		{coseX, a.key.X.FillBytes(make([]byte, 32))},
+		{coseY, a.key.Y.FillBytes(make([]byte, 32))},
+	})
+}
+
+func (a *softAuthenticator) authenticatorData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags := a.flags
+	if attested {
+		flags |= flagAttestedCredentialData
+	}
+	data := append(rpIDHash[:], flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, make([]byte, 16)...) // AAGUID
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func (a *softAuthenticator) clientDataJSON(ceremony, challenge string) []byte {
+	data, _ := json.Marshal(clientData{Type: ceremony, Challenge: challenge, Origin: a.origin})
+	return data
+}
+
+func (a *softAuthenticator) register(challenge string) *RegistrationResponse {
+	resp := &RegistrationResponse{ID: b64.EncodeToString(a.credentialID), RawID: b64.EncodeToString(a.credentialID), Type: "public-key"}
+	resp.Response.ClientDataJSON = b64.EncodeToString(a.clientDataJSON("webauthn.create", challenge))
+	resp.Response.AttestationObject = b64.EncodeToString(cborEncode(cborMap{
+		{"fmt", "none"},
+		{"attStmt", cborMap{}},
+		{"authData", a.authenticatorData(true)},
+	}))
+	return resp
+}
+
+func (a *softAuthenticator) assert(t *testing.T, challenge string) *AssertionResponse {
+	t.Helper()
+	a.signCount++
+	authData := a.authenticatorData(false)
+	clientDataJSON := a.clientDataJSON("webauthn.get", challenge)
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp := &AssertionResponse{ID: b64.EncodeToString(a.credentialID), RawID: b64.EncodeToString(a.credentialID), Type: "public-key"}
+	resp.Response.ClientDataJSON = b64.EncodeToString(clientDataJSON)
+	resp.Response.AuthenticatorData = b64.EncodeToString(authData)
+	resp.Response.Signature = b64.EncodeToString(signature)
+	resp.Response.UserHandle = b64.EncodeToString([]byte(testUserID))
+	return resp
+}
+
+func newTestRelyingParty() *RelyingParty {
+	return New(Config{
+		RPID:             testRPID,
+		RPName:           "Example",
+		Origins:          []string{testOrigin},
+		UserVerification: UserVerificationPreferred,
+	})
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator func(a *softAuthenticator)
+		response      func(resp *RegistrationResponse)
+		wantErr       error
+	}{
+		{
+			name: "valid registration",
+		},
+		{
+			name:          "wrong origin",
+			authenticator: func(a *softAuthenticator) { a.origin = "https://evil.example.net" },
+			wantErr:       ErrOriginNotAllowed,
+		},
+		{
+			name:          "wrong RP ID",
+			authenticator: func(a *softAuthenticator) { a.rpID = "evil.example.net" },
+			wantErr:       ErrRPIDMismatch,
+		},
+		{
+			name:          "user not present",
+			authenticator: func(a *softAuthenticator) { a.flags = 0 },
+			wantErr:       ErrUserNotPresent,
+		},
+		{
+			name: "malformed attestation object",
+			response: func(resp *RegistrationResponse) {
+				resp.Response.AttestationObject = b64.EncodeToString([]byte{0xa3, 0x63, 'f', 'm'})
+			},
+			wantErr: errInvalidCBOR,
+		},
+		{
+			name: "raw ID of another credential",
+			response: func(resp *RegistrationResponse) {
+				resp.RawID = b64.EncodeToString([]byte("credential-2"))
+			},
+			wantErr: ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := newSoftAuthenticator(t)
+			if tt.authenticator != nil {
+				tt.authenticator(authenticator)
+			}
+			resp := authenticator.register(testChallenge)
+			if tt.response != nil {
+				tt.response(resp)
+			}
+
+			credential, err := newTestRelyingParty().VerifyRegistration(resp, testChallenge)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("VerifyRegistration() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyRegistration() error = %v", err)
+			}
+			if string(credential.ID) != string(authenticator.credentialID) || credential.Algorithm != AlgES256 || !credential.UserVerified {
+				t.Errorf("VerifyRegistration() = %+v", credential)
+			}
+			if _, err := parsePublicKey(credential.PublicKey); err != nil {
+				t.Errorf("stored public key does not parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	tests := []struct {
+		name             string
+		storedSignCount  uint32
+		userVerification string
+		authenticator    func(a *softAuthenticator)
+		response         func(resp *AssertionResponse)
+		wantErr          error
+	}{
+		{
+			name:            "valid assertion",
+			storedSignCount: 4,
+		},
+		{
+			name: "authenticator without counter",
+			authenticator: func(a *softAuthenticator) {
+				a.signCount = 0
+			},
+		},
+		{
+			name:            "sign count regression",
+			storedSignCount: 10,
+			wantErr:         ErrSignCountNotIncreased,
+		},
+		{
+			name:            "sign count replayed",
+			storedSignCount: 5,
+			wantErr:         ErrSignCountNotIncreased,
+		},
+		{
+			name: "bad signature",
+			response: func(resp *AssertionResponse) {
+				signature, _ := b64.DecodeString(resp.Response.Signature)
+				signature[len(signature)-1] ^= 0xff
+				resp.Response.Signature = b64.EncodeToString(signature)
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			// The client data is valid but not the one the authenticator signed
+			name: "signature of other client data",
+			response: func(resp *AssertionResponse) {
+				data, _ := json.Marshal(map[string]any{
+					"type":        "webauthn.get",
+					"challenge":   testChallenge,
+					"origin":      testOrigin,
+					"crossOrigin": false,
+				})
+				resp.Response.ClientDataJSON = b64.EncodeToString(data)
+			},
+			wantErr: ErrInvalidSignature,
+		},
+		{
+			name:          "wrong origin",
+			authenticator: func(a *softAuthenticator) { a.origin = "https://evil.example.net" },
+			wantErr:       ErrOriginNotAllowed,
+		},
+		{
+			name:          "wrong RP ID",
+			authenticator: func(a *softAuthenticator) { a.rpID = "evil.example.net" },
+			wantErr:       ErrRPIDMismatch,
+		},
+		{
+			name: "challenge mismatch",
+			response: func(resp *AssertionResponse) {
+				data, _ := json.Marshal(clientData{Type: "webauthn.get", Challenge: "challenge-2", Origin: testOrigin})
+				resp.Response.ClientDataJSON = b64.EncodeToString(data)
+			},
+			wantErr: ErrChallengeMismatch,
+		},
+		{
+			name: "registration client data",
+			response: func(resp *AssertionResponse) {
+				data, _ := json.Marshal(clientData{Type: "webauthn.create", Challenge: testChallenge, Origin: testOrigin})
+				resp.Response.ClientDataJSON = b64.EncodeToString(data)
+			},
+			wantErr: ErrInvalidResponse,
+		},
+		{
+			name:             "user verification required",
+			userVerification: UserVerificationRequired,
+			authenticator:    func(a *softAuthenticator) { a.flags = flagUserPresent },
+			wantErr:          ErrUserNotVerified,
+		},
+		{
+			name: "truncated authenticator data",
+			response: func(resp *AssertionResponse) {
+				resp.Response.AuthenticatorData = b64.EncodeToString(make([]byte, 36))
+			},
+			wantErr: ErrInvalidResponse,
+		},
+		{
+			name:     "not a public key credential",
+			response: func(resp *AssertionResponse) { resp.Type = "password" },
+			wantErr:  ErrInvalidResponse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := newSoftAuthenticator(t)
+			coseKey := authenticator.coseKey()
+			authenticator.signCount = 4
+			if tt.authenticator != nil {
+				tt.authenticator(authenticator)
+			}
+			if authenticator.signCount == 0 {
+				// Keep the counter at 0 after the increment of assert
+				authenticator.signCount = ^uint32(0)
+			}
+			resp := authenticator.assert(t, testChallenge)
+			if tt.response != nil {
+				tt.response(resp)
+			}
+
+			assertion, err := newTestRelyingParty().VerifyAssertion(resp, testChallenge, tt.userVerification, coseKey, tt.storedSignCount)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("VerifyAssertion() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("VerifyAssertion() error = %v", err)
+			}
+			if assertion.SignCount != authenticator.signCount || assertion.UserHandle != testUserID || !assertion.UserVerified {
+				t.Errorf("VerifyAssertion() = %+v", assertion)
+			}
+		})
+	}
+}
@@ -79,19 +79,20 @@ func (UserStatus) EnumDescriptor() ([]byte, []int) {
 //
 // *************************************
 type User struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
-	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
-	Password      string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
-	FirstName     string                 `protobuf:"bytes,5,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
-	LastName      string                 `protobuf:"bytes,6,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
-	Status        UserStatus             `protobuf:"varint,7,opt,name=status,proto3,enum=userpb.UserStatus" json:"status,omitempty"`
-	CreatedAt     int64                  `protobuf:"varint,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`  // milli timestamp
-	UpdatedAt     int64                  `protobuf:"varint,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`  // milli timestamp
-	DeletedAt     int64                  `protobuf:"varint,10,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"` // milli timestamp
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Id                    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email                 string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Password              string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	FirstName             string                 `protobuf:"bytes,4,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName              string                 `protobuf:"bytes,5,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	Status                UserStatus             `protobuf:"varint,6,opt,name=status,proto3,enum=userpb.UserStatus" json:"status,omitempty"`
+	CreatedAt             int64                  `protobuf:"varint,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // milli timestamp
+	UpdatedAt             int64                  `protobuf:"varint,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // milli timestamp
+	DeletedAt             int64                  `protobuf:"varint,9,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"` // milli timestamp
+	PasswordResetRequired bool                   `protobuf:"varint,10,opt,name=password_reset_required,json=passwordResetRequired,proto3" json:"password_reset_required,omitempty"`
+	Username              string                 `protobuf:"bytes,11,opt,name=username,proto3" json:"username,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
 }
 
 func (x *User) Reset() {
@@ -131,13 +132,6 @@ func (x *User) GetId() string {
 	return ""
 }
 
-func (x *User) GetUsername() string {
-	if x != nil {
-		return x.Username
-	}
-	return ""
-}
-
 func (x *User) GetEmail() string {
 	if x != nil {
 		return x.Email
@@ -194,6 +188,20 @@ func (x *User) GetDeletedAt() int64 {
 	return 0
 }
 
+func (x *User) GetPasswordResetRequired() bool {
+	if x != nil {
+		return x.PasswordResetRequired
+	}
+	return false
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
 // *********************************************
 //
 //	User usecase interfaces and types      *
@@ -201,11 +209,12 @@ func (x *User) GetDeletedAt() int64 {
 // ********************************************
 type CreateUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
-	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
-	Password      string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
-	FirstName     string                 `protobuf:"bytes,4,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
-	LastName      string                 `protobuf:"bytes,5,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	Email         string                 `protobuf:"bytes,1,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	FirstName     string                 `protobuf:"bytes,3,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName      string                 `protobuf:"bytes,4,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	External      bool                   `protobuf:"varint,5,opt,name=external,proto3" json:"external,omitempty"` // Signed up with an identity provider, created active and without password
+	Username      string                 `protobuf:"bytes,6,opt,name=username,proto3" json:"username,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -240,13 +249,6 @@ func (*CreateUserRequest) Descriptor() ([]byte, []int) {
 	return file_proto_user_proto_rawDescGZIP(), []int{1}
 }
 
-func (x *CreateUserRequest) GetUsername() string {
-	if x != nil {
-		return x.Username
-	}
-	return ""
-}
-
 func (x *CreateUserRequest) GetEmail() string {
 	if x != nil {
 		return x.Email
@@ -275,6 +277,20 @@ func (x *CreateUserRequest) GetLastName() string {
 	return ""
 }
 
+func (x *CreateUserRequest) GetExternal() bool {
+	if x != nil {
+		return x.External
+	}
+	return false
+}
+
+func (x *CreateUserRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
 type CreateUserResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
@@ -408,15 +424,17 @@ func (x *GetUserResponse) GetUser() *User {
 }
 
 type UpdateUserRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
-	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
-	FirstName     string                 `protobuf:"bytes,4,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
-	LastName      string                 `protobuf:"bytes,5,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
-	Status        UserStatus             `protobuf:"varint,6,opt,name=status,proto3,enum=userpb.UserStatus" json:"status,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state                 protoimpl.MessageState `protogen:"open.v1"`
+	Id                    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Email                 string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	FirstName             string                 `protobuf:"bytes,3,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	LastName              string                 `protobuf:"bytes,4,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	Status                UserStatus             `protobuf:"varint,5,opt,name=status,proto3,enum=userpb.UserStatus" json:"status,omitempty"`
+	PasswordResetRequired *bool                  `protobuf:"varint,6,opt,name=password_reset_required,json=passwordResetRequired,proto3,oneof" json:"password_reset_required,omitempty"`
+	Username              string                 `protobuf:"bytes,7,opt,name=username,proto3" json:"username,omitempty"`
+	ClearPassword         bool                   `protobuf:"varint,8,opt,name=clear_password,json=clearPassword,proto3" json:"clear_password,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
 }
 
 func (x *UpdateUserRequest) Reset() {
@@ -456,13 +474,6 @@ func (x *UpdateUserRequest) GetId() string {
 	return ""
 }
 
-func (x *UpdateUserRequest) GetUsername() string {
-	if x != nil {
-		return x.Username
-	}
-	return ""
-}
-
 func (x *UpdateUserRequest) GetEmail() string {
 	if x != nil {
 		return x.Email
@@ -491,6 +502,27 @@ func (x *UpdateUserRequest) GetStatus() UserStatus {
 	return UserStatus_USER_STATUS_UNSPECIFIED
 }
 
+func (x *UpdateUserRequest) GetPasswordResetRequired() bool {
+	if x != nil && x.PasswordResetRequired != nil {
+		return *x.PasswordResetRequired
+	}
+	return false
+}
+
+func (x *UpdateUserRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *UpdateUserRequest) GetClearPassword() bool {
+	if x != nil {
+		return x.ClearPassword
+	}
+	return false
+}
+
 type UpdateUserResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	User          *User                  `protobuf:"bytes,1,opt,name=user,proto3" json:"user,omitempty"`
@@ -535,6 +567,294 @@ func (x *UpdateUserResponse) GetUser() *User {
 	return nil
 }
 
+type UpdateUserPasswordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateUserPasswordRequest) Reset() {
+	*x = UpdateUserPasswordRequest{}
+	mi := &file_proto_user_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserPasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserPasswordRequest) ProtoMessage() {}
+
+func (x *UpdateUserPasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_user_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserPasswordRequest.ProtoReflect.Descriptor instead.
+func (*UpdateUserPasswordRequest) Descriptor() ([]byte, []int) {
+	return file_proto_user_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateUserPasswordRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *UpdateUserPasswordRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type UpdateUserPasswordResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateUserPasswordResponse) Reset() {
+	*x = UpdateUserPasswordResponse{}
+	mi := &file_proto_user_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserPasswordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserPasswordResponse) ProtoMessage() {}
+
+func (x *UpdateUserPasswordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_user_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserPasswordResponse.ProtoReflect.Descriptor instead.
+func (*UpdateUserPasswordResponse) Descriptor() ([]byte, []int) {
+	return file_proto_user_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateUserPasswordResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+type CheckUserPasswordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckUserPasswordRequest) Reset() {
+	*x = CheckUserPasswordRequest{}
+	mi := &file_proto_user_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckUserPasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckUserPasswordRequest) ProtoMessage() {}
+
+func (x *CheckUserPasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_user_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckUserPasswordRequest.ProtoReflect.Descriptor instead.
+func (*CheckUserPasswordRequest) Descriptor() ([]byte, []int) {
+	return file_proto_user_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CheckUserPasswordRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *CheckUserPasswordRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type CheckUserPasswordResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Valid         bool                   `protobuf:"varint,1,opt,name=valid,proto3" json:"valid,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CheckUserPasswordResponse) Reset() {
+	*x = CheckUserPasswordResponse{}
+	mi := &file_proto_user_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CheckUserPasswordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CheckUserPasswordResponse) ProtoMessage() {}
+
+func (x *CheckUserPasswordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_user_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CheckUserPasswordResponse.ProtoReflect.Descriptor instead.
+func (*CheckUserPasswordResponse) Descriptor() ([]byte, []int) {
+	return file_proto_user_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CheckUserPasswordResponse) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+type RehashUserPasswordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RehashUserPasswordRequest) Reset() {
+	*x = RehashUserPasswordRequest{}
+	mi := &file_proto_user_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RehashUserPasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RehashUserPasswordRequest) ProtoMessage() {}
+
+func (x *RehashUserPasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_user_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RehashUserPasswordRequest.ProtoReflect.Descriptor instead.
+func (*RehashUserPasswordRequest) Descriptor() ([]byte, []int) {
+	return file_proto_user_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *RehashUserPasswordRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RehashUserPasswordRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+type RehashUserPasswordResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rehashed      bool                   `protobuf:"varint,1,opt,name=rehashed,proto3" json:"rehashed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RehashUserPasswordResponse) Reset() {
+	*x = RehashUserPasswordResponse{}
+	mi := &file_proto_user_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RehashUserPasswordResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RehashUserPasswordResponse) ProtoMessage() {}
+
+func (x *RehashUserPasswordResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_user_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RehashUserPasswordResponse.ProtoReflect.Descriptor instead.
+func (*RehashUserPasswordResponse) Descriptor() ([]byte, []int) {
+	return file_proto_user_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *RehashUserPasswordResponse) GetRehashed() bool {
+	if x != nil {
+		return x.Rehashed
+	}
+	return false
+}
+
 type DeleteUserRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -544,7 +864,7 @@ type DeleteUserRequest struct {
 
 func (x *DeleteUserRequest) Reset() {
 	*x = DeleteUserRequest{}
-	mi := &file_proto_user_proto_msgTypes[7]
+	mi := &file_proto_user_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -556,7 +876,7 @@ func (x *DeleteUserRequest) String() string {
 func (*DeleteUserRequest) ProtoMessage() {}
 
 func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_user_proto_msgTypes[7]
+	mi := &file_proto_user_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -569,7 +889,7 @@ func (x *DeleteUserRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteUserRequest.ProtoReflect.Descriptor instead.
 func (*DeleteUserRequest) Descriptor() ([]byte, []int) {
-	return file_proto_user_proto_rawDescGZIP(), []int{7}
+	return file_proto_user_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *DeleteUserRequest) GetId() string {
@@ -588,7 +908,7 @@ type DeleteUserResponse struct {
 
 func (x *DeleteUserResponse) Reset() {
 	*x = DeleteUserResponse{}
-	mi := &file_proto_user_proto_msgTypes[8]
+	mi := &file_proto_user_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -600,7 +920,7 @@ func (x *DeleteUserResponse) String() string {
 func (*DeleteUserResponse) ProtoMessage() {}
 
 func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_user_proto_msgTypes[8]
+	mi := &file_proto_user_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -613,7 +933,7 @@ func (x *DeleteUserResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeleteUserResponse.ProtoReflect.Descriptor instead.
 func (*DeleteUserResponse) Descriptor() ([]byte, []int) {
-	return file_proto_user_proto_rawDescGZIP(), []int{8}
+	return file_proto_user_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *DeleteUserResponse) GetSuccess() bool {
@@ -633,7 +953,7 @@ type ListUsersRequest struct {
 
 func (x *ListUsersRequest) Reset() {
 	*x = ListUsersRequest{}
-	mi := &file_proto_user_proto_msgTypes[9]
+	mi := &file_proto_user_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -645,7 +965,7 @@ func (x *ListUsersRequest) String() string {
 func (*ListUsersRequest) ProtoMessage() {}
 
 func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_user_proto_msgTypes[9]
+	mi := &file_proto_user_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -658,7 +978,7 @@ func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListUsersRequest.ProtoReflect.Descriptor instead.
 func (*ListUsersRequest) Descriptor() ([]byte, []int) {
-	return file_proto_user_proto_rawDescGZIP(), []int{9}
+	return file_proto_user_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ListUsersRequest) GetPage() int32 {
@@ -685,7 +1005,7 @@ type ListUsersResponse struct {
 
 func (x *ListUsersResponse) Reset() {
 	*x = ListUsersResponse{}
-	mi := &file_proto_user_proto_msgTypes[10]
+	mi := &file_proto_user_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -697,7 +1017,7 @@ func (x *ListUsersResponse) String() string {
 func (*ListUsersResponse) ProtoMessage() {}
 
 func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_user_proto_msgTypes[10]
+	mi := &file_proto_user_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -710,7 +1030,7 @@ func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ListUsersResponse.ProtoReflect.Descriptor instead.
 func (*ListUsersResponse) Descriptor() ([]byte, []int) {
-	return file_proto_user_proto_rawDescGZIP(), []int{10}
+	return file_proto_user_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *ListUsersResponse) GetUsers() []*User {
@@ -742,7 +1062,7 @@ type DetailError struct {
 
 func (x *DetailError) Reset() {
 	*x = DetailError{}
-	mi := &file_proto_user_proto_msgTypes[11]
+	mi := &file_proto_user_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -754,7 +1074,7 @@ func (x *DetailError) String() string {
 func (*DetailError) ProtoMessage() {}
 
 func (x *DetailError) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_user_proto_msgTypes[11]
+	mi := &file_proto_user_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -767,7 +1087,7 @@ func (x *DetailError) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DetailError.ProtoReflect.Descriptor instead.
 func (*DetailError) Descriptor() ([]byte, []int) {
-	return file_proto_user_proto_rawDescGZIP(), []int{11}
+	return file_proto_user_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *DetailError) GetId() string {
@@ -829,7 +1149,7 @@ type FindOneOption struct {
 
 func (x *FindOneOption) Reset() {
 	*x = FindOneOption{}
-	mi := &file_proto_user_proto_msgTypes[12]
+	mi := &file_proto_user_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -841,7 +1161,7 @@ func (x *FindOneOption) String() string {
 func (*FindOneOption) ProtoMessage() {}
 
 func (x *FindOneOption) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_user_proto_msgTypes[12]
+	mi := &file_proto_user_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -854,7 +1174,7 @@ func (x *FindOneOption) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use FindOneOption.ProtoReflect.Descriptor instead.
 func (*FindOneOption) Descriptor() ([]byte, []int) {
-	return file_proto_user_proto_rawDescGZIP(), []int{12}
+	return file_proto_user_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *FindOneOption) GetPreloads() []string {
@@ -877,21 +1197,21 @@ type UserFilter struct {
 	IdNe           *string                `protobuf:"bytes,2,opt,name=id_ne,json=idNe,proto3,oneof" json:"id_ne,omitempty"`
 	IdIn           []string               `protobuf:"bytes,3,rep,name=id_in,json=idIn,proto3" json:"id_in,omitempty"`
 	Email          *string                `protobuf:"bytes,4,opt,name=email,proto3,oneof" json:"email,omitempty"`
-	Username       *string                `protobuf:"bytes,5,opt,name=username,proto3,oneof" json:"username,omitempty"`
-	Active         *bool                  `protobuf:"varint,6,opt,name=active,proto3,oneof" json:"active,omitempty"`
-	Blocked        *bool                  `protobuf:"varint,7,opt,name=blocked,proto3,oneof" json:"blocked,omitempty"`
-	HasRoles       []string               `protobuf:"bytes,8,rep,name=has_roles,json=hasRoles,proto3" json:"has_roles,omitempty"`
-	SearchTerm     *string                `protobuf:"bytes,9,opt,name=search_term,json=searchTerm,proto3,oneof" json:"search_term,omitempty"`
-	SearchFields   []string               `protobuf:"bytes,10,rep,name=search_fields,json=searchFields,proto3" json:"search_fields,omitempty"`
-	IncludeDeleted *bool                  `protobuf:"varint,11,opt,name=include_deleted,json=includeDeleted,proto3,oneof" json:"include_deleted,omitempty"`
-	Option         *FindOneOption         `protobuf:"bytes,12,opt,name=option,proto3,oneof" json:"option,omitempty"`
+	Active         *bool                  `protobuf:"varint,5,opt,name=active,proto3,oneof" json:"active,omitempty"`
+	Blocked        *bool                  `protobuf:"varint,6,opt,name=blocked,proto3,oneof" json:"blocked,omitempty"`
+	HasRoles       []string               `protobuf:"bytes,7,rep,name=has_roles,json=hasRoles,proto3" json:"has_roles,omitempty"`
+	SearchTerm     *string                `protobuf:"bytes,8,opt,name=search_term,json=searchTerm,proto3,oneof" json:"search_term,omitempty"`
+	SearchFields   []string               `protobuf:"bytes,9,rep,name=search_fields,json=searchFields,proto3" json:"search_fields,omitempty"`
+	IncludeDeleted *bool                  `protobuf:"varint,10,opt,name=include_deleted,json=includeDeleted,proto3,oneof" json:"include_deleted,omitempty"`
+	Option         *FindOneOption         `protobuf:"bytes,11,opt,name=option,proto3,oneof" json:"option,omitempty"`
+	Username       *string                `protobuf:"bytes,12,opt,name=username,proto3,oneof" json:"username,omitempty"`
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
 
 func (x *UserFilter) Reset() {
 	*x = UserFilter{}
-	mi := &file_proto_user_proto_msgTypes[13]
+	mi := &file_proto_user_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -903,7 +1223,7 @@ func (x *UserFilter) String() string {
 func (*UserFilter) ProtoMessage() {}
 
 func (x *UserFilter) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_user_proto_msgTypes[13]
+	mi := &file_proto_user_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -916,7 +1236,7 @@ func (x *UserFilter) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserFilter.ProtoReflect.Descriptor instead.
 func (*UserFilter) Descriptor() ([]byte, []int) {
-	return file_proto_user_proto_rawDescGZIP(), []int{13}
+	return file_proto_user_proto_rawDescGZIP(), []int{19}
 }
 
 func (x *UserFilter) GetId() string {
@@ -947,13 +1267,6 @@ func (x *UserFilter) GetEmail() string {
 	return ""
 }
 
-func (x *UserFilter) GetUsername() string {
-	if x != nil && x.Username != nil {
-		return *x.Username
-	}
-	return ""
-}
-
 func (x *UserFilter) GetActive() bool {
 	if x != nil && x.Active != nil {
 		return *x.Active
@@ -1003,6 +1316,13 @@ func (x *UserFilter) GetOption() *FindOneOption {
 	return nil
 }
 
+func (x *UserFilter) GetUsername() string {
+	if x != nil && x.Username != nil {
+		return *x.Username
+	}
+	return ""
+}
+
 type GetUserByFilterRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Filter        *UserFilter            `protobuf:"bytes,1,opt,name=filter,proto3,oneof" json:"filter,omitempty"`
@@ -1013,7 +1333,7 @@ type GetUserByFilterRequest struct {
 
 func (x *GetUserByFilterRequest) Reset() {
 	*x = GetUserByFilterRequest{}
-	mi := &file_proto_user_proto_msgTypes[14]
+	mi := &file_proto_user_proto_msgTypes[20]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1025,7 +1345,7 @@ func (x *GetUserByFilterRequest) String() string {
 func (*GetUserByFilterRequest) ProtoMessage() {}
 
 func (x *GetUserByFilterRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_user_proto_msgTypes[14]
+	mi := &file_proto_user_proto_msgTypes[20]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1038,7 +1358,7 @@ func (x *GetUserByFilterRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetUserByFilterRequest.ProtoReflect.Descriptor instead.
 func (*GetUserByFilterRequest) Descriptor() ([]byte, []int) {
-	return file_proto_user_proto_rawDescGZIP(), []int{14}
+	return file_proto_user_proto_rawDescGZIP(), []int{20}
 }
 
 func (x *GetUserByFilterRequest) GetFilter() *UserFilter {
@@ -1065,7 +1385,7 @@ type GetUserByIDRequest struct {
 
 func (x *GetUserByIDRequest) Reset() {
 	*x = GetUserByIDRequest{}
-	mi := &file_proto_user_proto_msgTypes[15]
+	mi := &file_proto_user_proto_msgTypes[21]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1077,7 +1397,7 @@ func (x *GetUserByIDRequest) String() string {
 func (*GetUserByIDRequest) ProtoMessage() {}
 
 func (x *GetUserByIDRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_user_proto_msgTypes[15]
+	mi := &file_proto_user_proto_msgTypes[21]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1090,7 +1410,7 @@ func (x *GetUserByIDRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use GetUserByIDRequest.ProtoReflect.Descriptor instead.
 func (*GetUserByIDRequest) Descriptor() ([]byte, []int) {
-	return file_proto_user_proto_rawDescGZIP(), []int{15}
+	return file_proto_user_proto_rawDescGZIP(), []int{21}
 }
 
 func (x *GetUserByIDRequest) GetId() string {
@@ -1111,46 +1431,66 @@ var File_proto_user_proto protoreflect.FileDescriptor
 
 const file_proto_user_proto_rawDesc = "" +
 	"\n" +
-	"\x10proto/user.proto\x12\x06userpb\"\xa9\x02\n" +
+	"\x10proto/user.proto\x12\x06userpb\"\xe1\x02\n" +
 	"\x04User\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
-	"\busername\x18\x02 \x01(\tR\busername\x12\x14\n" +
-	"\x05email\x18\x03 \x01(\tR\x05email\x12\x1a\n" +
-	"\bpassword\x18\x04 \x01(\tR\bpassword\x12\x1d\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x03 \x01(\tR\bpassword\x12\x1d\n" +
 	"\n" +
-	"first_name\x18\x05 \x01(\tR\tfirstName\x12\x1b\n" +
-	"\tlast_name\x18\x06 \x01(\tR\blastName\x12*\n" +
-	"\x06status\x18\a \x01(\x0e2\x12.userpb.UserStatusR\x06status\x12\x1d\n" +
+	"first_name\x18\x04 \x01(\tR\tfirstName\x12\x1b\n" +
+	"\tlast_name\x18\x05 \x01(\tR\blastName\x12*\n" +
+	"\x06status\x18\x06 \x01(\x0e2\x12.userpb.UserStatusR\x06status\x12\x1d\n" +
 	"\n" +
-	"created_at\x18\b \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"created_at\x18\a \x01(\x03R\tcreatedAt\x12\x1d\n" +
 	"\n" +
-	"updated_at\x18\t \x01(\x03R\tupdatedAt\x12\x1d\n" +
+	"updated_at\x18\b \x01(\x03R\tupdatedAt\x12\x1d\n" +
 	"\n" +
-	"deleted_at\x18\n" +
-	" \x01(\x03R\tdeletedAt\"\x9d\x01\n" +
-	"\x11CreateUserRequest\x12\x1a\n" +
-	"\busername\x18\x01 \x01(\tR\busername\x12\x14\n" +
-	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
-	"\bpassword\x18\x03 \x01(\tR\bpassword\x12\x1d\n" +
+	"deleted_at\x18\t \x01(\x03R\tdeletedAt\x126\n" +
+	"\x17password_reset_required\x18\n" +
+	" \x01(\bR\x15passwordResetRequired\x12\x1a\n" +
+	"\busername\x18\v \x01(\tR\busername\"\xb9\x01\n" +
+	"\x11CreateUserRequest\x12\x14\n" +
+	"\x05email\x18\x01 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x1d\n" +
 	"\n" +
-	"first_name\x18\x04 \x01(\tR\tfirstName\x12\x1b\n" +
-	"\tlast_name\x18\x05 \x01(\tR\blastName\"6\n" +
+	"first_name\x18\x03 \x01(\tR\tfirstName\x12\x1b\n" +
+	"\tlast_name\x18\x04 \x01(\tR\blastName\x12\x1a\n" +
+	"\bexternal\x18\x05 \x01(\bR\bexternal\x12\x1a\n" +
+	"\busername\x18\x06 \x01(\tR\busername\"6\n" +
 	"\x12CreateUserResponse\x12 \n" +
 	"\x04user\x18\x01 \x01(\v2\f.userpb.UserR\x04user\" \n" +
 	"\x0eGetUserRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\"3\n" +
 	"\x0fGetUserResponse\x12 \n" +
-	"\x04user\x18\x01 \x01(\v2\f.userpb.UserR\x04user\"\xbd\x01\n" +
+	"\x04user\x18\x01 \x01(\v2\f.userpb.UserR\x04user\"\xbd\x02\n" +
 	"\x11UpdateUserRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
-	"\busername\x18\x02 \x01(\tR\busername\x12\x14\n" +
-	"\x05email\x18\x03 \x01(\tR\x05email\x12\x1d\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1d\n" +
 	"\n" +
-	"first_name\x18\x04 \x01(\tR\tfirstName\x12\x1b\n" +
-	"\tlast_name\x18\x05 \x01(\tR\blastName\x12*\n" +
-	"\x06status\x18\x06 \x01(\x0e2\x12.userpb.UserStatusR\x06status\"6\n" +
+	"first_name\x18\x03 \x01(\tR\tfirstName\x12\x1b\n" +
+	"\tlast_name\x18\x04 \x01(\tR\blastName\x12*\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x12.userpb.UserStatusR\x06status\x12;\n" +
+	"\x17password_reset_required\x18\x06 \x01(\bH\x00R\x15passwordResetRequired\x88\x01\x01\x12\x1a\n" +
+	"\busername\x18\a \x01(\tR\busername\x12%\n" +
+	"\x0eclear_password\x18\b \x01(\bR\rclearPasswordB\x1a\n" +
+	"\x18_password_reset_required\"6\n" +
 	"\x12UpdateUserResponse\x12 \n" +
-	"\x04user\x18\x01 \x01(\v2\f.userpb.UserR\x04user\"#\n" +
+	"\x04user\x18\x01 \x01(\v2\f.userpb.UserR\x04user\"G\n" +
+	"\x19UpdateUserPasswordRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"6\n" +
+	"\x1aUpdateUserPasswordResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\"F\n" +
+	"\x18CheckUserPasswordRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"1\n" +
+	"\x19CheckUserPasswordResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\"G\n" +
+	"\x19RehashUserPasswordRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\"8\n" +
+	"\x1aRehashUserPasswordResponse\x12\x1a\n" +
+	"\brehashed\x18\x01 \x01(\bR\brehashed\"#\n" +
 	"\x11DeleteUserRequest\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\".\n" +
 	"\x12DeleteUserResponse\x12\x18\n" +
@@ -1180,27 +1520,27 @@ const file_proto_user_proto_rawDesc = "" +
 	"\x02id\x18\x01 \x01(\tH\x00R\x02id\x88\x01\x01\x12\x18\n" +
 	"\x05id_ne\x18\x02 \x01(\tH\x01R\x04idNe\x88\x01\x01\x12\x13\n" +
 	"\x05id_in\x18\x03 \x03(\tR\x04idIn\x12\x19\n" +
-	"\x05email\x18\x04 \x01(\tH\x02R\x05email\x88\x01\x01\x12\x1f\n" +
-	"\busername\x18\x05 \x01(\tH\x03R\busername\x88\x01\x01\x12\x1b\n" +
-	"\x06active\x18\x06 \x01(\bH\x04R\x06active\x88\x01\x01\x12\x1d\n" +
-	"\ablocked\x18\a \x01(\bH\x05R\ablocked\x88\x01\x01\x12\x1b\n" +
-	"\thas_roles\x18\b \x03(\tR\bhasRoles\x12$\n" +
-	"\vsearch_term\x18\t \x01(\tH\x06R\n" +
+	"\x05email\x18\x04 \x01(\tH\x02R\x05email\x88\x01\x01\x12\x1b\n" +
+	"\x06active\x18\x05 \x01(\bH\x03R\x06active\x88\x01\x01\x12\x1d\n" +
+	"\ablocked\x18\x06 \x01(\bH\x04R\ablocked\x88\x01\x01\x12\x1b\n" +
+	"\thas_roles\x18\a \x03(\tR\bhasRoles\x12$\n" +
+	"\vsearch_term\x18\b \x01(\tH\x05R\n" +
 	"searchTerm\x88\x01\x01\x12#\n" +
-	"\rsearch_fields\x18\n" +
-	" \x03(\tR\fsearchFields\x12,\n" +
-	"\x0finclude_deleted\x18\v \x01(\bH\aR\x0eincludeDeleted\x88\x01\x01\x122\n" +
-	"\x06option\x18\f \x01(\v2\x15.userpb.FindOneOptionH\bR\x06option\x88\x01\x01B\x05\n" +
+	"\rsearch_fields\x18\t \x03(\tR\fsearchFields\x12,\n" +
+	"\x0finclude_deleted\x18\n" +
+	" \x01(\bH\x06R\x0eincludeDeleted\x88\x01\x01\x122\n" +
+	"\x06option\x18\v \x01(\v2\x15.userpb.FindOneOptionH\aR\x06option\x88\x01\x01\x12\x1f\n" +
+	"\busername\x18\f \x01(\tH\bR\busername\x88\x01\x01B\x05\n" +
 	"\x03_idB\b\n" +
 	"\x06_id_neB\b\n" +
-	"\x06_emailB\v\n" +
-	"\t_usernameB\t\n" +
+	"\x06_emailB\t\n" +
 	"\a_activeB\n" +
 	"\n" +
 	"\b_blockedB\x0e\n" +
 	"\f_search_termB\x12\n" +
 	"\x10_include_deletedB\t\n" +
-	"\a_option\"\x93\x01\n" +
+	"\a_optionB\v\n" +
+	"\t_username\"\x93\x01\n" +
 	"\x16GetUserByFilterRequest\x12/\n" +
 	"\x06filter\x18\x01 \x01(\v2\x12.userpb.UserFilterH\x00R\x06filter\x88\x01\x01\x122\n" +
 	"\x06option\x18\x02 \x01(\v2\x15.userpb.FindOneOptionH\x01R\x06option\x88\x01\x01B\t\n" +
@@ -1215,14 +1555,17 @@ const file_proto_user_proto_rawDesc = "" +
 	"\x17USER_STATUS_UNSPECIFIED\x10\x00\x12\x1e\n" +
 	"\x1aUSER_STATUS_WAITING_VERIFY\x10\x01\x12\x16\n" +
 	"\x12USER_STATUS_ACTIVE\x10\x02\x12\x16\n" +
-	"\x12USER_STATUS_BANNED\x10\x032\xae\x03\n" +
+	"\x12USER_STATUS_BANNED\x10\x032\xc2\x05\n" +
 	"\vUserService\x12C\n" +
 	"\n" +
 	"CreateUser\x12\x19.userpb.CreateUserRequest\x1a\x1a.userpb.CreateUserResponse\x12B\n" +
 	"\vGetUserByID\x12\x1a.userpb.GetUserByIDRequest\x1a\x17.userpb.GetUserResponse\x12J\n" +
 	"\x0fGetUserByFilter\x12\x1e.userpb.GetUserByFilterRequest\x1a\x17.userpb.GetUserResponse\x12C\n" +
 	"\n" +
-	"UpdateUser\x12\x19.userpb.UpdateUserRequest\x1a\x1a.userpb.UpdateUserResponse\x12C\n" +
+	"UpdateUser\x12\x19.userpb.UpdateUserRequest\x1a\x1a.userpb.UpdateUserResponse\x12[\n" +
+	"\x12UpdateUserPassword\x12!.userpb.UpdateUserPasswordRequest\x1a\".userpb.UpdateUserPasswordResponse\x12X\n" +
+	"\x11CheckUserPassword\x12 .userpb.CheckUserPasswordRequest\x1a!.userpb.CheckUserPasswordResponse\x12[\n" +
+	"\x12RehashUserPassword\x12!.userpb.RehashUserPasswordRequest\x1a\".userpb.RehashUserPasswordResponse\x12C\n" +
 	"\n" +
 	"DeleteUser\x12\x19.userpb.DeleteUserRequest\x1a\x1a.userpb.DeleteUserResponse\x12@\n" +
 	"\tListUsers\x12\x18.userpb.ListUsersRequest\x1a\x19.userpb.ListUsersResponseB\rZ\vproto/pb;pbb\x06proto3"
@@ -1240,26 +1583,32 @@ func file_proto_user_proto_rawDescGZIP() []byte {
 }
 
 var file_proto_user_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_user_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_proto_user_proto_msgTypes = make([]protoimpl.MessageInfo, 23)
 var file_proto_user_proto_goTypes = []any{
-	(UserStatus)(0),                // 0: userpb.UserStatus
-	(*User)(nil),                   // 1: userpb.User
-	(*CreateUserRequest)(nil),      // 2: userpb.CreateUserRequest
-	(*CreateUserResponse)(nil),     // 3: userpb.CreateUserResponse
-	(*GetUserRequest)(nil),         // 4: userpb.GetUserRequest
-	(*GetUserResponse)(nil),        // 5: userpb.GetUserResponse
-	(*UpdateUserRequest)(nil),      // 6: userpb.UpdateUserRequest
-	(*UpdateUserResponse)(nil),     // 7: userpb.UpdateUserResponse
-	(*DeleteUserRequest)(nil),      // 8: userpb.DeleteUserRequest
-	(*DeleteUserResponse)(nil),     // 9: userpb.DeleteUserResponse
-	(*ListUsersRequest)(nil),       // 10: userpb.ListUsersRequest
-	(*ListUsersResponse)(nil),      // 11: userpb.ListUsersResponse
-	(*DetailError)(nil),            // 12: userpb.DetailError
-	(*FindOneOption)(nil),          // 13: userpb.FindOneOption
-	(*UserFilter)(nil),             // 14: userpb.UserFilter
-	(*GetUserByFilterRequest)(nil), // 15: userpb.GetUserByFilterRequest
-	(*GetUserByIDRequest)(nil),     // 16: userpb.GetUserByIDRequest
-	nil,                            // 17: userpb.DetailError.DetailsEntry
+	(UserStatus)(0),                    // 0: userpb.UserStatus
+	(*User)(nil),                       // 1: userpb.User
+	(*CreateUserRequest)(nil),          // 2: userpb.CreateUserRequest
+	(*CreateUserResponse)(nil),         // 3: userpb.CreateUserResponse
+	(*GetUserRequest)(nil),             // 4: userpb.GetUserRequest
+	(*GetUserResponse)(nil),            // 5: userpb.GetUserResponse
+	(*UpdateUserRequest)(nil),          // 6: userpb.UpdateUserRequest
+	(*UpdateUserResponse)(nil),         // 7: userpb.UpdateUserResponse
+	(*UpdateUserPasswordRequest)(nil),  // 8: userpb.UpdateUserPasswordRequest
+	(*UpdateUserPasswordResponse)(nil), // 9: userpb.UpdateUserPasswordResponse
+	(*CheckUserPasswordRequest)(nil),   // 10: userpb.CheckUserPasswordRequest
+	(*CheckUserPasswordResponse)(nil),  // 11: userpb.CheckUserPasswordResponse
+	(*RehashUserPasswordRequest)(nil),  // 12: userpb.RehashUserPasswordRequest
+	(*RehashUserPasswordResponse)(nil), // 13: userpb.RehashUserPasswordResponse
+	(*DeleteUserRequest)(nil),          // 14: userpb.DeleteUserRequest
+	(*DeleteUserResponse)(nil),         // 15: userpb.DeleteUserResponse
+	(*ListUsersRequest)(nil),           // 16: userpb.ListUsersRequest
+	(*ListUsersResponse)(nil),          // 17: userpb.ListUsersResponse
+	(*DetailError)(nil),                // 18: userpb.DetailError
+	(*FindOneOption)(nil),              // 19: userpb.FindOneOption
+	(*UserFilter)(nil),                 // 20: userpb.UserFilter
+	(*GetUserByFilterRequest)(nil),     // 21: userpb.GetUserByFilterRequest
+	(*GetUserByIDRequest)(nil),         // 22: userpb.GetUserByIDRequest
+	nil,                                // 23: userpb.DetailError.DetailsEntry
 }
 var file_proto_user_proto_depIdxs = []int32{
 	0,  // 0: userpb.User.status:type_name -> userpb.UserStatus
@@ -1268,25 +1617,31 @@ var file_proto_user_proto_depIdxs = []int32{
 	0,  // 3: userpb.UpdateUserRequest.status:type_name -> userpb.UserStatus
 	1,  // 4: userpb.UpdateUserResponse.user:type_name -> userpb.User
 	1,  // 5: userpb.ListUsersResponse.users:type_name -> userpb.User
-	17, // 6: userpb.DetailError.details:type_name -> userpb.DetailError.DetailsEntry
-	13, // 7: userpb.UserFilter.option:type_name -> userpb.FindOneOption
-	14, // 8: userpb.GetUserByFilterRequest.filter:type_name -> userpb.UserFilter
-	13, // 9: userpb.GetUserByFilterRequest.option:type_name -> userpb.FindOneOption
-	13, // 10: userpb.GetUserByIDRequest.option:type_name -> userpb.FindOneOption
+	23, // 6: userpb.DetailError.details:type_name -> userpb.DetailError.DetailsEntry
+	19, // 7: userpb.UserFilter.option:type_name -> userpb.FindOneOption
+	20, // 8: userpb.GetUserByFilterRequest.filter:type_name -> userpb.UserFilter
+	19, // 9: userpb.GetUserByFilterRequest.option:type_name -> userpb.FindOneOption
+	19, // 10: userpb.GetUserByIDRequest.option:type_name -> userpb.FindOneOption
 	2,  // 11: userpb.UserService.CreateUser:input_type -> userpb.CreateUserRequest
-	16, // 12: userpb.UserService.GetUserByID:input_type -> userpb.GetUserByIDRequest
-	15, // 13: userpb.UserService.GetUserByFilter:input_type -> userpb.GetUserByFilterRequest
+	22, // 12: userpb.UserService.GetUserByID:input_type -> userpb.GetUserByIDRequest
+	21, // 13: userpb.UserService.GetUserByFilter:input_type -> userpb.GetUserByFilterRequest
 	6,  // 14: userpb.UserService.UpdateUser:input_type -> userpb.UpdateUserRequest
-	8,  // 15: userpb.UserService.DeleteUser:input_type -> userpb.DeleteUserRequest
-	10, // 16: userpb.UserService.ListUsers:input_type -> userpb.ListUsersRequest
-	3,  // 17: userpb.UserService.CreateUser:output_type -> userpb.CreateUserResponse
-	5,  // 18: userpb.UserService.GetUserByID:output_type -> userpb.GetUserResponse
-	5,  // 19: userpb.UserService.GetUserByFilter:output_type -> userpb.GetUserResponse
-	7,  // 20: userpb.UserService.UpdateUser:output_type -> userpb.UpdateUserResponse
-	9,  // 21: userpb.UserService.DeleteUser:output_type -> userpb.DeleteUserResponse
-	11, // 22: userpb.UserService.ListUsers:output_type -> userpb.ListUsersResponse
-	17, // [17:23] is the sub-list for method output_type
-	11, // [11:17] is the sub-list for method input_type
+	8,  // 15: userpb.UserService.UpdateUserPassword:input_type -> userpb.UpdateUserPasswordRequest
+	10, // 16: userpb.UserService.CheckUserPassword:input_type -> userpb.CheckUserPasswordRequest
+	12, // 17: userpb.UserService.RehashUserPassword:input_type -> userpb.RehashUserPasswordRequest
+	14, // 18: userpb.UserService.DeleteUser:input_type -> userpb.DeleteUserRequest
+	16, // 19: userpb.UserService.ListUsers:input_type -> userpb.ListUsersRequest
+	3,  // 20: userpb.UserService.CreateUser:output_type -> userpb.CreateUserResponse
+	5,  // 21: userpb.UserService.GetUserByID:output_type -> userpb.GetUserResponse
+	5,  // 22: userpb.UserService.GetUserByFilter:output_type -> userpb.GetUserResponse
+	7,  // 23: userpb.UserService.UpdateUser:output_type -> userpb.UpdateUserResponse
+	9,  // 24: userpb.UserService.UpdateUserPassword:output_type -> userpb.UpdateUserPasswordResponse
+	11, // 25: userpb.UserService.CheckUserPassword:output_type -> userpb.CheckUserPasswordResponse
+	13, // 26: userpb.UserService.RehashUserPassword:output_type -> userpb.RehashUserPasswordResponse
+	15, // 27: userpb.UserService.DeleteUser:output_type -> userpb.DeleteUserResponse
+	17, // 28: userpb.UserService.ListUsers:output_type -> userpb.ListUsersResponse
+	20, // [20:29] is the sub-list for method output_type
+	11, // [11:20] is the sub-list for method input_type
 	11, // [11:11] is the sub-list for extension type_name
 	11, // [11:11] is the sub-list for extension extendee
 	0,  // [0:11] is the sub-list for field type_name
@@ -1297,16 +1652,17 @@ func file_proto_user_proto_init() {
 	if File_proto_user_proto != nil {
 		return
 	}
-	file_proto_user_proto_msgTypes[13].OneofWrappers = []any{}
-	file_proto_user_proto_msgTypes[14].OneofWrappers = []any{}
-	file_proto_user_proto_msgTypes[15].OneofWrappers = []any{}
+	file_proto_user_proto_msgTypes[5].OneofWrappers = []any{}
+	file_proto_user_proto_msgTypes[19].OneofWrappers = []any{}
+	file_proto_user_proto_msgTypes[20].OneofWrappers = []any{}
+	file_proto_user_proto_msgTypes[21].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_user_proto_rawDesc), len(file_proto_user_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   17,
+			NumMessages:   23,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -26,6 +26,7 @@ func (c *UserRPCClient) Create(ctx context.Context, req *domain.UserCreateReques
 		Password:  req.Password,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
+		External:  req.External,
 	}
 	resp, err := c.client.CreateUser(ctx, pbReq)
 	if err != nil {
@@ -49,3 +50,53 @@ func (c *UserRPCClient) FindOne(ctx context.Context, filter *domain.UserFilter,
 	}
 	return common.ToDomainUser(resp.User), nil
 }
+
+func (c *UserRPCClient) Update(ctx context.Context, userID string, req *domain.UserUpdateRequest) (*domain.User, error) {
+	pbReq := &pb.UpdateUserRequest{Id: userID}
+	if req.Username != nil {
+		pbReq.Username = *req.Username
+	}
+	if req.Email != nil {
+		pbReq.Email = *req.Email
+	}
+	if req.FirstName != nil {
+		pbReq.FirstName = *req.FirstName
+	}
+	if req.LastName != nil {
+		pbReq.LastName = *req.LastName
+	}
+	if req.Status != nil {
+		pbReq.Status = common.ToPbUserStatus(*req.Status)
+	}
+	pbReq.PasswordResetRequired = req.PasswordResetRequired
+	pbReq.ClearPassword = req.ClearPassword
+	resp, err := c.client.UpdateUser(ctx, pbReq)
+	if err != nil {
+		return nil, err
+	}
+	return common.ToDomainUser(resp.User), nil
+}
+
+func (c *UserRPCClient) UpdatePassword(ctx context.Context, userID string, newPassword string) error {
+	_, err := c.client.UpdateUserPassword(ctx, &pb.UpdateUserPasswordRequest{
+		Id:       userID,
+		Password: newPassword,
+	})
+	return err
+}
+
+func (c *UserRPCClient) CheckPassword(ctx context.Context, userID string, newPassword string) error {
+	_, err := c.client.CheckUserPassword(ctx, &pb.CheckUserPasswordRequest{
+		Id:       userID,
+		Password: newPassword,
+	})
+	return err
+}
+
+func (c *UserRPCClient) RehashPassword(ctx context.Context, userID string, password string) error {
+	_, err := c.client.RehashUserPassword(ctx, &pb.RehashUserPasswordRequest{
+		Id:       userID,
+		Password: password,
+	})
+	return err
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	usecase     domain.APIKeyUsecase
+	middlewares middleware.Middlewares
+}
+
+func NewAPIKeyHandler(
+	usecase domain.APIKeyUsecase,
+	middlewares middleware.Middlewares,
+) *APIKeyHandler {
+	return &APIKeyHandler{
+		usecase:     usecase,
+		middlewares: middlewares,
+	}
+}
+
+func (h *APIKeyHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	// API keys of other services, managed by administrators
+	admin := rg.Group("/admin/api-keys")
+	admin.Use(h.middlewares.Authenticator())
+	admin.Use(h.middlewares.RequirePermissions(domain.PermissionAPIKeysManage))
+	admin.Use(h.middlewares.AdminRateLimits())
+	{
+		admin.POST("", h.CreateAPIKey)
+		admin.GET("", h.ListAPIKeys)
+		admin.GET("/:id", h.GetAPIKey)
+		admin.PUT("/:id", h.UpdateAPIKey)
+		admin.DELETE("/:id", h.DeleteAPIKey)
+	}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.OwnerID = user.ID
+	req.Principal = common.GetPrincipalFromCtx(c)
+
+	resp, err := h.usecase.CreateAPIKey(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "API key created, store the key now as it cannot be shown again")
+}
+
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	var req domain.ListAPIKeysRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+
+	keys, pagination, err := h.usecase.ListAPIKeys(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"api_keys":   keys,
+		"pagination": pagination,
+	}
+	common.ResponseOK(c, response, "API keys retrieved successfully")
+}
+
+func (h *APIKeyHandler) GetAPIKey(c *gin.Context) {
+	key, err := h.usecase.GetAPIKey(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, key, "API key retrieved successfully")
+}
+
+func (h *APIKeyHandler) UpdateAPIKey(c *gin.Context) {
+	var req domain.UpdateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.ID = c.Param("id")
+	req.Principal = common.GetPrincipalFromCtx(c)
+
+	key, err := h.usecase.UpdateAPIKey(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, key, "API key updated successfully")
+}
+
+func (h *APIKeyHandler) DeleteAPIKey(c *gin.Context) {
+	if err := h.usecase.DeleteAPIKey(c.Request.Context(), c.Param("id")); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "API key deleted")
+}
@@ -12,15 +12,18 @@ import (
 type AuthHandler struct {
 	usecase     domain.AuthUsecase
 	middlewares middleware.Middlewares
+	cookieCfg   RefreshCookieConfig
 }
 
 func NewAuthHandler(
 	usecase domain.AuthUsecase,
 	middlewares middleware.Middlewares,
+	cookieCfg RefreshCookieConfig,
 ) *AuthHandler {
 	return &AuthHandler{
 		usecase:     usecase,
 		middlewares: middlewares,
+		cookieCfg:   cookieCfg,
 	}
 }
 
@@ -30,27 +33,110 @@ func (h *AuthHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	// Public routes
 	auth.POST("/register", h.Register)
 	auth.POST("/login", h.Login)
+	auth.POST("/login/mfa", h.mfaLoginRateLimit(), h.VerifyMFALogin)
+	auth.POST("/login/mfa/passkey/begin", h.BeginPasskeyMFA)
+	auth.POST("/login/mfa/passkey", h.mfaLoginRateLimit(), h.VerifyPasskeyMFA)
 
-	// Refresh token with specific rate limiting
-	auth.POST("/refresh-token", h.refreshTokenRateLimit(), h.RefreshToken)
+	// Passwordless login with a discoverable passkey
+	auth.POST("/passkeys/login/begin", h.BeginPasskeyLogin)
+	auth.POST("/passkeys/login/finish", h.FinishPasskeyLogin)
+
+	// Social login, the frontend sends the user to the returned URL and posts back the code
+	auth.GET("/oauth/:provider/authorize", h.StartOAuthLogin)
+	auth.POST("/oauth/:provider/callback", h.CompleteOAuthLogin)
+
+	// Refresh tokens are single use, reuse is detected by the usecase
+	auth.POST("/refresh-token", h.RefreshToken)
 
 	auth.POST("/verify-email", h.VerifyEmail)
 
+	// Password reset with its own rate limiting
+	auth.POST("/forgot-password", h.forgotPasswordRateLimit(), h.ForgotPassword)
+	auth.POST("/reset-password", h.ResetPassword)
+
+	// Passwordless login, the usecase also limits the links requested per email
+	auth.POST("/magic-link", h.magicLinkRateLimit(), h.RequestMagicLink)
+	auth.POST("/magic-link/verify", h.LoginWithMagicLink)
+
+	// "This wasn't me" link of the new sign-in email
+	auth.POST("/login-alerts/deny", h.DenyLogin)
+
 	// Protected routes (authentication required)
 	protected := auth.Group("")
 	protected.Use(h.middlewares.Authenticator())
 	{
 		protected.POST("/logout", h.Logout)
 		protected.POST("/send-verification-email", h.SendVerificationEmail)
+		protected.POST("/send-verification-code", h.SendVerificationCode)
+		protected.GET("/passkeys", h.ListPasskeys)
 	}
+
+	// Second factors can only be changed by the user, not by an administrator impersonating them
+	secondFactor := auth.Group("")
+	secondFactor.Use(h.middlewares.Authenticator())
+	secondFactor.Use(h.middlewares.DenyImpersonation())
+	{
+		// Two-factor authentication management
+		secondFactor.POST("/mfa/enroll", h.EnrollMFA)
+		secondFactor.POST("/mfa/confirm", h.ConfirmMFA)
+		secondFactor.POST("/mfa/disable", h.DisableMFA)
+		secondFactor.POST("/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+
+		// Passkey management
+		secondFactor.POST("/passkeys/register/begin", h.BeginPasskeyRegistration)
+		secondFactor.POST("/passkeys/register/finish", h.FinishPasskeyRegistration)
+		secondFactor.PATCH("/passkeys/:id", h.RenamePasskey)
+		secondFactor.DELETE("/passkeys/:id", h.DeletePasskey)
+	}
+
+	// Account administration
+	admin := rg.Group("/admin/users/:id")
+	admin.Use(h.middlewares.Authenticator())
+	admin.Use(h.middlewares.RequirePermissions(domain.PermissionUsersWrite))
+	admin.Use(h.middlewares.AdminRateLimits())
+	{
+		admin.POST("/unlock", h.UnlockAccount)
+	}
+}
+
+// forgotPasswordRateLimit creates specific rate limiting for forgot password endpoint
+func (h *AuthHandler) forgotPasswordRateLimit() gin.HandlerFunc {
+	return h.middlewares.RateLimitWithLogger(middleware.RateLimitConfig{
+		WindowSize:  15 * time.Minute, // 15 minutes window
+		MaxRequests: 3,                // Max 3 reset emails per window
+		KeyPrefix:   "forgot_password:",
+		KeyGenerator: func(c *gin.Context) string {
+			// Rate limit by IP address
+			return c.ClientIP()
+		},
+		HeaderRemainingRequests: "X-RateLimit-Remaining",
+		HeaderRetryAfter:        "X-RateLimit-Retry-After",
+		HeaderRateLimit:         "X-RateLimit-Limit",
+	})
 }
 
-// refreshTokenRateLimit creates specific rate limiting for refresh token endpoint
-func (h *AuthHandler) refreshTokenRateLimit() gin.HandlerFunc {
+// magicLinkRateLimit creates specific rate limiting for login link requests
+func (h *AuthHandler) magicLinkRateLimit() gin.HandlerFunc {
 	return h.middlewares.RateLimitWithLogger(middleware.RateLimitConfig{
-		WindowSize:  1 * time.Minute, // 1 minute window
-		MaxRequests: 1,               // Max 1 refresh attempt per minute
-		KeyPrefix:   "refresh_token:",
+		WindowSize:  15 * time.Minute, // 15 minutes window
+		MaxRequests: 10,               // Max 10 links per window, across all emails
+		KeyPrefix:   "magic_link:",
+		KeyGenerator: func(c *gin.Context) string {
+			// Rate limit by IP address
+			return c.ClientIP()
+		},
+		HeaderRemainingRequests: "X-RateLimit-Remaining",
+		HeaderRetryAfter:        "X-RateLimit-Retry-After",
+		HeaderRateLimit:         "X-RateLimit-Limit",
+	})
+}
+
+// mfaLoginRateLimit creates specific rate limiting for the second login step to slow down code guessing
+func (h *AuthHandler) mfaLoginRateLimit() gin.HandlerFunc {
+	return h.middlewares.RateLimitWithLogger(middleware.RateLimitConfig{
+		WindowSize:  5 * time.Minute, // 5 minutes window
+		MaxRequests: 5,               // Max 5 code attempts per window
+		KeyPrefix:   "mfa_login:",
 		KeyGenerator: func(c *gin.Context) string {
 			// Rate limit by IP address
 			return c.ClientIP()
@@ -74,6 +160,9 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		common.ResponseError(c, err)
 		return
 	}
+	if !h.useRefreshCookie(c, resp) {
+		return
+	}
 	common.ResponseCreated(c, resp, "Register successful")
 }
 
@@ -90,6 +179,92 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		common.ResponseError(c, err)
 		return
 	}
+	if !h.useRefreshCookie(c, resp.AuthResponse) {
+		return
+	}
+	if resp.MFARequired {
+		common.ResponseOK(c, resp, "Two-factor authentication required")
+		return
+	}
+	common.ResponseOK(c, resp, "Login successful")
+}
+
+func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
+	var req domain.MagicLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	resp, err := h.usecase.RequestMagicLink(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "If the email is registered, a login link has been sent")
+}
+
+func (h *AuthHandler) LoginWithMagicLink(c *gin.Context) {
+	var req domain.MagicLinkLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	resp, err := h.usecase.LoginWithMagicLink(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	if !h.useRefreshCookie(c, resp.AuthResponse) {
+		return
+	}
+	if resp.MFARequired {
+		common.ResponseOK(c, resp, "Two-factor authentication required")
+		return
+	}
+	common.ResponseOK(c, resp, "Login successful")
+}
+
+func (h *AuthHandler) StartOAuthLogin(c *gin.Context) {
+	var req domain.StartOAuthLoginRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.Provider = c.Param("provider")
+
+	resp, err := h.usecase.StartOAuthLogin(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Authorization URL created")
+}
+
+func (h *AuthHandler) CompleteOAuthLogin(c *gin.Context) {
+	var req domain.CompleteOAuthLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.Provider = c.Param("provider")
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	resp, err := h.usecase.CompleteOAuthLogin(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	if !h.useRefreshCookie(c, resp.AuthResponse) {
+		return
+	}
+	if resp.MFARequired {
+		common.ResponseOK(c, resp, "Two-factor authentication required")
+		return
+	}
 	common.ResponseOK(c, resp, "Login successful")
 }
 
@@ -100,51 +275,172 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.usecase.Logout(c.Request.Context(), sessionID); err != nil {
+	req := &domain.LogoutRequest{SessionID: sessionID}
+	if claims := common.GetTokenClaimsFromCtx(c); claims != nil && claims.ExpiresAt != nil {
+		req.TokenID = claims.ID
+		req.TokenExpiresAt = claims.ExpiresAt.Time
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	if err := h.usecase.Logout(c.Request.Context(), req); err != nil {
 		common.ResponseError(c, err)
 		return
 	}
+	if h.cookieCfg.Enabled() {
+		h.clearRefreshCookie(c)
+	}
 	common.ResponseOK(c, true, "Logout successful")
 }
 
+// RefreshToken takes the refresh token from the body, or else from the refresh cookie, in which
+// case the new refresh token is set in the cookie as well.
 func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	var req domain.RefreshTokenRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		common.ResponseBadRequest(c, err.Error())
-		return
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ResponseBadRequest(c, err.Error())
+			return
+		}
 	}
 	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
 
+	fromCookie := false
+	if req.RefreshToken == "" {
+		refreshToken, err := h.refreshTokenFromCookie(c)
+		if err != nil {
+			common.ResponseError(c, err)
+			return
+		}
+		if refreshToken == "" {
+			common.ResponseBadRequest(c, "refresh_token is required")
+			return
+		}
+		req.RefreshToken = refreshToken
+		fromCookie = true
+	}
+
 	resp, err := h.usecase.RefreshToken(c.Request.Context(), &req)
 	if err != nil {
 		common.ResponseError(c, err)
 		return
 	}
+	if fromCookie {
+		if err := h.setRefreshCookie(c, resp); err != nil {
+			common.ResponseError(c, err)
+			return
+		}
+	} else if !h.useRefreshCookie(c, resp) {
+		return
+	}
 	common.ResponseOK(c, resp, "Token refreshed")
 }
 
 func (h *AuthHandler) SendVerificationEmail(c *gin.Context) {
-	var req domain.SendVerificationEmailRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		common.ResponseBadRequest(c, err.Error())
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
 		return
 	}
+
+	req := domain.SendVerificationEmailRequest{UserID: user.ID}
 	if err := h.usecase.SendVerificationEmail(c.Request.Context(), &req); err != nil {
-		common.ResponseBadRequest(c, err.Error())
+		common.ResponseError(c, err)
 		return
 	}
 	common.ResponseNoContent(c, "Verification email sent")
 }
 
+func (h *AuthHandler) SendVerificationCode(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.SendVerificationCodeRequest
+	// The body is optional, the code goes by email without it
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			common.ResponseBadRequest(c, err.Error())
+			return
+		}
+	}
+	req.UserID = user.ID
+
+	resp, err := h.usecase.SendVerificationCode(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Verification code sent")
+}
+
 func (h *AuthHandler) VerifyEmail(c *gin.Context) {
 	var req domain.VerifyEmailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ResponseBadRequest(c, err.Error())
 		return
 	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
 	if err := h.usecase.VerifyEmail(c.Request.Context(), &req); err != nil {
-		common.ResponseBadRequest(c, err.Error())
+		common.ResponseError(c, err)
 		return
 	}
 	common.ResponseNoContent(c, "Email verified")
 }
+
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req domain.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	if err := h.usecase.ForgotPassword(c.Request.Context(), &req); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, true, "If the email is registered, a password reset link has been sent")
+}
+
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req domain.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	if err := h.usecase.ResetPassword(c.Request.Context(), &req); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Password has been reset")
+}
+
+func (h *AuthHandler) DenyLogin(c *gin.Context) {
+	var req domain.DenyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	if err := h.usecase.DenyLogin(c.Request.Context(), &req); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, true, "The sign-in has been signed out, check your email to reset your password")
+}
+
+// UnlockAccount lifts the login lockout of a user, for administrators
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	req := &domain.UnlockAccountRequest{UserID: c.Param("id")}
+	if err := h.usecase.UnlockAccount(c.Request.Context(), req); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Account has been unlocked")
+}
@@ -0,0 +1,213 @@
+package api
+
+import (
+	"context"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testCookieConfig is the refresh cookie configuration of the tests, in cookie mode for the
+// frontend origin.
+type testCookieConfig struct {
+	enabled bool
+}
+
+func (c testCookieConfig) Enabled() bool        { return c.enabled }
+func (testCookieConfig) Name() string           { return "refresh_token" }
+func (testCookieConfig) Path() string           { return "/auth" }
+func (testCookieConfig) Domain() string         { return "" }
+func (testCookieConfig) Secure() bool           { return true }
+func (testCookieConfig) SameSite() string       { return "strict" }
+func (testCookieConfig) CSRFCookieName() string { return "csrf_token" }
+func (testCookieConfig) CSRFHeaderName() string { return "X-CSRF-Token" }
+func (testCookieConfig) Origins() []string      { return []string{"https://app.example.com"} }
+
+// fakeAuthUsecase records the refresh tokens and logouts, the methods the tests do not need are
+// left to the nil interface
+type fakeAuthUsecase struct {
+	domain.AuthUsecase
+	refreshed []string
+	loggedOut []string
+}
+
+func (u *fakeAuthUsecase) RefreshToken(_ context.Context, req *domain.RefreshTokenRequest) (*domain.AuthResponse, error) {
+	u.refreshed = append(u.refreshed, req.RefreshToken)
+	return &domain.AuthResponse{
+		AccessToken:           "access-2",
+		RefreshToken:          "refresh-2",
+		RefreshTokenExpiresAt: time.Now().Add(time.Hour).UnixMilli(),
+	}, nil
+}
+
+func (u *fakeAuthUsecase) Logout(_ context.Context, req *domain.LogoutRequest) error {
+	u.loggedOut = append(u.loggedOut, req.SessionID)
+	return nil
+}
+
+func findCookie(cookies []*http.Cookie, name string) *http.Cookie {
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+func TestAuthHandlerCookieMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		disabled  bool
+		origin    string
+		tokenMode string
+		want      bool
+	}{
+		{name: "allowed origin", origin: "https://app.example.com", want: true},
+		{name: "other origin", origin: "https://evil.example.com"},
+		{name: "no origin"},
+		{name: "cookie mode requested", tokenMode: tokenModeCookie, want: true},
+		{name: "body mode requested from an allowed origin", origin: "https://app.example.com", tokenMode: tokenModeBody},
+		{name: "unknown mode from an allowed origin", origin: "https://app.example.com", tokenMode: "header", want: true},
+		{name: "cookies disabled", disabled: true, origin: "https://app.example.com", tokenMode: tokenModeCookie},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := &AuthHandler{cookieCfg: testCookieConfig{enabled: !tt.disabled}}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+			if tt.origin != "" {
+				c.Request.Header.Set("Origin", tt.origin)
+			}
+			if tt.tokenMode != "" {
+				c.Request.Header.Set(tokenModeHeader, tt.tokenMode)
+			}
+
+			if got := h.cookieMode(c); got != tt.want {
+				t.Errorf("cookieMode() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthHandlerRefreshToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		body          string
+		refreshCookie string
+		csrfCookie    string
+		csrfHeader    string
+		wantStatus    int
+		wantRefreshed string
+		wantCookie    bool
+	}{
+		{
+			name:          "cookie with the CSRF token",
+			refreshCookie: "refresh-1",
+			csrfCookie:    "csrf-1",
+			csrfHeader:    "csrf-1",
+			wantStatus:    http.StatusOK,
+			wantRefreshed: "refresh-1",
+			wantCookie:    true,
+		},
+		{name: "cookie without the CSRF header", refreshCookie: "refresh-1", csrfCookie: "csrf-1", wantStatus: http.StatusForbidden},
+		{name: "cookie with another CSRF token", refreshCookie: "refresh-1", csrfCookie: "csrf-1", csrfHeader: "csrf-2", wantStatus: http.StatusForbidden},
+		{name: "cookie without the CSRF cookie", refreshCookie: "refresh-1", csrfHeader: "csrf-1", wantStatus: http.StatusForbidden},
+		{name: "no refresh token", wantStatus: http.StatusBadRequest},
+		{
+			name:          "token in the body",
+			body:          `{"refresh_token":"refresh-1"}`,
+			refreshCookie: "refresh-cookie",
+			wantStatus:    http.StatusOK,
+			wantRefreshed: "refresh-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := &fakeAuthUsecase{}
+			h := &AuthHandler{usecase: usecase, cookieCfg: testCookieConfig{enabled: true}}
+			router := gin.New()
+			router.POST("/auth/refresh", h.RefreshToken)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.refreshCookie != "" {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: tt.refreshCookie})
+			}
+			if tt.csrfCookie != "" {
+				req.AddCookie(&http.Cookie{Name: "csrf_token", Value: tt.csrfCookie})
+			}
+			if tt.csrfHeader != "" {
+				req.Header.Set("X-CSRF-Token", tt.csrfHeader)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantRefreshed == "" {
+				if len(usecase.refreshed) != 0 {
+					t.Errorf("refreshed = %v, want none", usecase.refreshed)
+				}
+				return
+			}
+			if len(usecase.refreshed) != 1 || usecase.refreshed[0] != tt.wantRefreshed {
+				t.Errorf("refreshed = %v, want %q", usecase.refreshed, tt.wantRefreshed)
+			}
+
+			cookies := w.Result().Cookies()
+			refresh, csrf := findCookie(cookies, "refresh_token"), findCookie(cookies, "csrf_token")
+			if !tt.wantCookie {
+				if refresh != nil || !strings.Contains(w.Body.String(), `"refresh_token":"refresh-2"`) {
+					t.Errorf("refresh cookie = %v, body = %s, want the token in the body", refresh, w.Body)
+				}
+				return
+			}
+			if refresh == nil || refresh.Value != "refresh-2" || !refresh.HttpOnly {
+				t.Fatalf("refresh cookie = %v, want the new token in an HttpOnly cookie", refresh)
+			}
+			if strings.Contains(w.Body.String(), "refresh-2") {
+				t.Errorf("body = %s, want the refresh token left out", w.Body)
+			}
+			// The frontend reads the new CSRF token from the cookie or the header
+			if csrf == nil || csrf.Value == "" || csrf.Value == tt.csrfCookie || csrf.HttpOnly || w.Header().Get("X-CSRF-Token") != csrf.Value {
+				t.Errorf("CSRF cookie = %v, header = %q, want a new readable token in both", csrf, w.Header().Get("X-CSRF-Token"))
+			}
+		})
+	}
+}
+
+func TestAuthHandlerLogoutClearsCookies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	usecase := &fakeAuthUsecase{}
+	h := &AuthHandler{usecase: usecase, cookieCfg: testCookieConfig{enabled: true}}
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(common.SessionIDContextKey, "session-1")
+	})
+	router.POST("/auth/logout", h.Logout)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/auth/logout", nil))
+
+	if w.Code != http.StatusOK || len(usecase.loggedOut) != 1 {
+		t.Fatalf("status = %d, logged out = %v, want the session logged out", w.Code, usecase.loggedOut)
+	}
+	for _, name := range []string{"refresh_token", "csrf_token"} {
+		if cookie := findCookie(w.Result().Cookies(), name); cookie == nil || cookie.MaxAge >= 0 {
+			t.Errorf("%s cookie = %v, want it expired", name, cookie)
+		}
+	}
+}
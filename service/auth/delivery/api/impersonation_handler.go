@@ -0,0 +1,125 @@
+package api
+
+import (
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImpersonationHandler struct {
+	usecase     domain.ImpersonationUsecase
+	middlewares middleware.Middlewares
+}
+
+func NewImpersonationHandler(
+	usecase domain.ImpersonationUsecase,
+	middlewares middleware.Middlewares,
+) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		usecase:     usecase,
+		middlewares: middlewares,
+	}
+}
+
+func (h *ImpersonationHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	// Support access to user accounts, for administrators allowed to impersonate acting as themselves
+	admin := rg.Group("/admin")
+	admin.Use(h.middlewares.Authenticator())
+	admin.Use(h.middlewares.RequirePermissions(domain.PermissionUsersImpersonate))
+	admin.Use(h.middlewares.DenyImpersonation())
+	admin.Use(h.middlewares.AdminRateLimits())
+	{
+		admin.POST("/users/:id/impersonate", h.StartImpersonation)
+		admin.GET("/impersonations", h.ListImpersonations)
+		admin.POST("/impersonations/:id/end", h.EndImpersonation)
+	}
+
+	// Called with the impersonation token to hand the account back
+	impersonation := rg.Group("/auth/impersonation")
+	impersonation.Use(h.middlewares.Authenticator())
+	{
+		impersonation.POST("/stop", h.StopImpersonation)
+	}
+}
+
+func (h *ImpersonationHandler) StartImpersonation(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.StartImpersonationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.ActorID = user.ID
+	req.TargetUserID = c.Param("id")
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	resp, err := h.usecase.StartImpersonation(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Impersonation started")
+}
+
+func (h *ImpersonationHandler) StopImpersonation(c *gin.Context) {
+	actor := common.GetActorFromCtx(c)
+	if actor == nil {
+		common.ResponseError(c, domain.ErrNotImpersonating)
+		return
+	}
+
+	err := h.usecase.StopImpersonation(c.Request.Context(), &domain.StopImpersonationRequest{
+		SessionID: common.GetSessionIDFromCtx(c),
+		ActorID:   actor.ID,
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Impersonation stopped")
+}
+
+func (h *ImpersonationHandler) EndImpersonation(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	impersonation, err := h.usecase.EndImpersonation(c.Request.Context(), &domain.EndImpersonationRequest{
+		ID:      c.Param("id"),
+		ActorID: user.ID,
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, impersonation, "Impersonation ended")
+}
+
+func (h *ImpersonationHandler) ListImpersonations(c *gin.Context) {
+	var req domain.ListImpersonationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+
+	impersonations, pagination, err := h.usecase.ListImpersonations(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"impersonations": impersonations,
+		"pagination":     pagination,
+	}
+	common.ResponseOK(c, response, "Impersonations retrieved successfully")
+}
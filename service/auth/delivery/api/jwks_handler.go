@@ -0,0 +1,36 @@
+package api
+
+import (
+	"go-clean-arch/domain"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KeySetProvider interface {
+	JWKS() *domain.JSONWebKeySet
+}
+
+// JWKSHandler publishes the public keys that verify our access tokens, so other services can
+// check tokens without sharing a secret.
+type JWKSHandler struct {
+	keySet KeySetProvider
+}
+
+func NewJWKSHandler(keySet KeySetProvider) *JWKSHandler {
+	return &JWKSHandler{
+		keySet: keySet,
+	}
+}
+
+// RegisterRoutes registers the well-known endpoint on the root router, outside the API prefix
+func (h *JWKSHandler) RegisterRoutes(r gin.IRouter) {
+	r.GET("/.well-known/jwks.json", h.GetJWKS)
+}
+
+// GetJWKS answers with a plain RFC 7517 key set instead of the API response envelope, as JWKS
+// clients expect
+func (h *JWKSHandler) GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.keySet.JWKS())
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *AuthHandler) VerifyMFALogin(c *gin.Context) {
+	var req domain.VerifyMFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	resp, err := h.usecase.VerifyMFALogin(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	if !h.useRefreshCookie(c, resp) {
+		return
+	}
+	common.ResponseOK(c, resp, "Login successful")
+}
+
+func (h *AuthHandler) EnrollMFA(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	resp, err := h.usecase.EnrollMFA(c.Request.Context(), &domain.EnrollMFARequest{UserID: user.ID})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Scan the secret with your authenticator app and confirm with a code")
+}
+
+func (h *AuthHandler) ConfirmMFA(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.ConfirmMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.UserID = user.ID
+
+	resp, err := h.usecase.ConfirmMFA(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Two-factor authentication enabled")
+}
+
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.DisableMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.UserID = user.ID
+
+	if err := h.usecase.DisableMFA(c.Request.Context(), &req); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Two-factor authentication disabled")
+}
+
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.RegenerateRecoveryCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.UserID = user.ID
+
+	resp, err := h.usecase.RegenerateRecoveryCodes(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Recovery codes regenerated")
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (h *AuthHandler) BeginPasskeyRegistration(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	resp, err := h.usecase.BeginPasskeyRegistration(c.Request.Context(), &domain.BeginPasskeyRegistrationRequest{UserID: user.ID})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Create the passkey with these options and send back the credential")
+}
+
+func (h *AuthHandler) FinishPasskeyRegistration(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.FinishPasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.UserID = user.ID
+
+	credential, err := h.usecase.FinishPasskeyRegistration(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseCreated(c, credential, "Passkey registered")
+}
+
+func (h *AuthHandler) BeginPasskeyLogin(c *gin.Context) {
+	resp, err := h.usecase.BeginPasskeyLogin(c.Request.Context())
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Sign the challenge with a passkey and send back the credential")
+}
+
+func (h *AuthHandler) FinishPasskeyLogin(c *gin.Context) {
+	var req domain.FinishPasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	resp, err := h.usecase.FinishPasskeyLogin(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	if !h.useRefreshCookie(c, resp) {
+		return
+	}
+	common.ResponseOK(c, resp, "Login successful")
+}
+
+func (h *AuthHandler) BeginPasskeyMFA(c *gin.Context) {
+	var req domain.BeginPasskeyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+
+	resp, err := h.usecase.BeginPasskeyMFA(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Sign the challenge with one of your passkeys and send back the credential")
+}
+
+func (h *AuthHandler) VerifyPasskeyMFA(c *gin.Context) {
+	var req domain.VerifyPasskeyMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	resp, err := h.usecase.VerifyPasskeyMFA(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	if !h.useRefreshCookie(c, resp) {
+		return
+	}
+	common.ResponseOK(c, resp, "Login successful")
+}
+
+func (h *AuthHandler) ListPasskeys(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	passkeys, err := h.usecase.ListPasskeys(c.Request.Context(), user.ID)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, passkeys, "Passkeys retrieved successfully")
+}
+
+func (h *AuthHandler) RenamePasskey(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.UserID = user.ID
+	req.ID = c.Param("id")
+
+	passkey, err := h.usecase.RenamePasskey(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, passkey, "Passkey renamed")
+}
+
+func (h *AuthHandler) DeletePasskey(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	err := h.usecase.DeletePasskey(c.Request.Context(), &domain.DeletePasskeyRequest{
+		UserID: user.ID,
+		ID:     c.Param("id"),
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Passkey deleted")
+}
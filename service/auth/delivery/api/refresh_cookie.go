@@ -0,0 +1,131 @@
+package api
+
+import (
+	"crypto/subtle"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenModeHeader lets a client choose where the refresh token goes for a single request, either
+// tokenModeCookie or tokenModeBody. Without it, the configured origins are in cookie mode.
+const (
+	tokenModeHeader = "X-Token-Mode"
+	tokenModeCookie = "cookie"
+	tokenModeBody   = "body"
+)
+
+type RefreshCookieConfig interface {
+	Enabled() bool
+	Name() string
+	Path() string
+	Domain() string
+	Secure() bool
+	SameSite() string
+	CSRFCookieName() string
+	CSRFHeaderName() string
+	Origins() []string
+}
+
+// cookieMode tells whether the refresh token of this request is exchanged in a cookie rather
+// than in the JSON body.
+func (h *AuthHandler) cookieMode(c *gin.Context) bool {
+	if !h.cookieCfg.Enabled() {
+		return false
+	}
+	switch c.GetHeader(tokenModeHeader) {
+	case tokenModeCookie:
+		return true
+	case tokenModeBody:
+		return false
+	}
+	origin := c.GetHeader("Origin")
+	return origin != "" && slices.Contains(h.cookieCfg.Origins(), origin)
+}
+
+// setRefreshCookie moves the refresh token of the response into an HttpOnly cookie, together
+// with a new CSRF token in a cookie the frontend can read. The CSRF token is also returned in the
+// CSRF header for frontends on another site, which cannot read the cookie.
+func (h *AuthHandler) setRefreshCookie(c *gin.Context, resp *domain.AuthResponse) error {
+	csrfToken, err := common.GenerateSecureToken(32)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	maxAge := int(time.Until(time.UnixMilli(resp.RefreshTokenExpiresAt)).Seconds())
+	h.setCookie(c, h.cookieCfg.Name(), resp.RefreshToken, h.cookieCfg.Path(), maxAge, true)
+	h.setCookie(c, h.cookieCfg.CSRFCookieName(), csrfToken, "/", maxAge, false)
+	c.Header(h.cookieCfg.CSRFHeaderName(), csrfToken)
+
+	resp.RefreshToken = ""
+	return nil
+}
+
+// clearRefreshCookie expires the refresh and CSRF cookies.
+func (h *AuthHandler) clearRefreshCookie(c *gin.Context) {
+	h.setCookie(c, h.cookieCfg.Name(), "", h.cookieCfg.Path(), -1, true)
+	h.setCookie(c, h.cookieCfg.CSRFCookieName(), "", "/", -1, false)
+}
+
+// refreshTokenFromCookie returns the refresh token of the cookie after checking the CSRF header
+// against the CSRF cookie, empty when the request has no refresh cookie.
+func (h *AuthHandler) refreshTokenFromCookie(c *gin.Context) (string, error) {
+	if !h.cookieCfg.Enabled() {
+		return "", nil
+	}
+	refreshToken, err := c.Cookie(h.cookieCfg.Name())
+	if err != nil || refreshToken == "" {
+		return "", nil
+	}
+
+	csrfCookie, err := c.Cookie(h.cookieCfg.CSRFCookieName())
+	if err != nil || csrfCookie == "" {
+		return "", domain.ErrInvalidCSRFToken
+	}
+	csrfHeader := c.GetHeader(h.cookieCfg.CSRFHeaderName())
+	if subtle.ConstantTimeCompare([]byte(csrfHeader), []byte(csrfCookie)) != 1 {
+		return "", domain.ErrInvalidCSRFToken
+	}
+	return refreshToken, nil
+}
+
+func (h *AuthHandler) setCookie(c *gin.Context, name, value, path string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.cookieCfg.Domain(),
+		MaxAge:   maxAge,
+		Secure:   h.cookieCfg.Secure(),
+		HttpOnly: httpOnly,
+		SameSite: sameSiteMode(h.cookieCfg.SameSite()),
+	})
+}
+
+func sameSiteMode(sameSite string) http.SameSite {
+	switch sameSite {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+// useRefreshCookie moves the refresh token of the response into the cookie when the request is in
+// cookie mode. When that fails it answers with the error and returns false.
+func (h *AuthHandler) useRefreshCookie(c *gin.Context, resp *domain.AuthResponse) bool {
+	if resp == nil || !h.cookieMode(c) {
+		return true
+	}
+	if err := h.setRefreshCookie(c, resp); err != nil {
+		common.ResponseError(c, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SecurityEventHandler struct {
+	usecase     domain.SecurityEventUsecase
+	middlewares middleware.Middlewares
+}
+
+func NewSecurityEventHandler(
+	usecase domain.SecurityEventUsecase,
+	middlewares middleware.Middlewares,
+) *SecurityEventHandler {
+	return &SecurityEventHandler{
+		usecase:     usecase,
+		middlewares: middlewares,
+	}
+}
+
+func (h *SecurityEventHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	// Security history of the authenticated user
+	me := rg.Group("/auth/me/security-events")
+	me.Use(h.middlewares.Authenticator())
+	{
+		me.GET("", h.ListMySecurityEvents)
+	}
+
+	// Security events of all users, for administrators
+	admin := rg.Group("/admin/security-events")
+	admin.Use(h.middlewares.Authenticator())
+	admin.Use(h.middlewares.RequirePermissions(domain.PermissionSecurityEventsRead))
+	admin.Use(h.middlewares.AdminRateLimits())
+	{
+		admin.GET("", h.ListSecurityEvents)
+	}
+}
+
+func (h *SecurityEventHandler) ListMySecurityEvents(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.ListSecurityEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.UserID = &user.ID
+
+	h.listSecurityEvents(c, &req)
+}
+
+func (h *SecurityEventHandler) ListSecurityEvents(c *gin.Context) {
+	var req domain.ListSecurityEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+
+	h.listSecurityEvents(c, &req)
+}
+
+func (h *SecurityEventHandler) listSecurityEvents(c *gin.Context, req *domain.ListSecurityEventsRequest) {
+	events, pagination, err := h.usecase.ListSecurityEvents(c.Request.Context(), req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"security_events": events,
+		"pagination":      pagination,
+	}
+	common.ResponseOK(c, response, "Security events retrieved successfully")
+}
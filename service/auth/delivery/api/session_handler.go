@@ -0,0 +1,176 @@
+package api
+
+import (
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/middleware"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	usecase     domain.SessionUsecase
+	middlewares middleware.Middlewares
+}
+
+func NewSessionHandler(
+	usecase domain.SessionUsecase,
+	middlewares middleware.Middlewares,
+) *SessionHandler {
+	return &SessionHandler{
+		usecase:     usecase,
+		middlewares: middlewares,
+	}
+}
+
+func (h *SessionHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	// Sessions of the authenticated user
+	sessions := rg.Group("/auth/sessions")
+	sessions.Use(h.middlewares.Authenticator())
+	{
+		sessions.GET("", h.ListMySessions)
+		sessions.GET("/:id", h.GetMySession)
+		sessions.DELETE("/:id", h.RevokeMySession)
+		sessions.DELETE("", h.RevokeMyOtherSessions) // Revoke all sessions except the current one
+	}
+
+	// Sessions of any user, for administrators
+	admin := rg.Group("/admin/users/:id/sessions")
+	admin.Use(h.middlewares.Authenticator())
+	admin.Use(h.middlewares.RequirePermissions(domain.PermissionSessionsManage))
+	admin.Use(h.middlewares.AdminRateLimits())
+	{
+		admin.GET("", h.ListUserSessions)
+		admin.GET("/:session_id", h.GetUserSession)
+		admin.DELETE("/:session_id", h.RevokeUserSession)
+		admin.DELETE("", h.RevokeAllUserSessions)
+	}
+}
+
+func (h *SessionHandler) ListMySessions(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+	h.listSessions(c, user.ID, common.GetSessionIDFromCtx(c))
+}
+
+func (h *SessionHandler) GetMySession(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	session, err := h.usecase.GetSession(c.Request.Context(), &domain.GetSessionRequest{
+		UserID:           user.ID,
+		SessionID:        c.Param("id"),
+		CurrentSessionID: common.GetSessionIDFromCtx(c),
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, session, "Session retrieved successfully")
+}
+
+func (h *SessionHandler) RevokeMySession(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	if err := h.usecase.RevokeSession(c.Request.Context(), &domain.RevokeSessionRequest{
+		UserID:    user.ID,
+		SessionID: c.Param("id"),
+	}); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Session revoked")
+}
+
+func (h *SessionHandler) RevokeMyOtherSessions(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	revoked, err := h.usecase.RevokeOtherSessions(c.Request.Context(), &domain.RevokeOtherSessionsRequest{
+		UserID:           user.ID,
+		CurrentSessionID: common.GetSessionIDFromCtx(c),
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, &domain.RevokeSessionsResponse{Revoked: revoked}, "Other sessions revoked")
+}
+
+func (h *SessionHandler) ListUserSessions(c *gin.Context) {
+	h.listSessions(c, c.Param("id"), common.GetSessionIDFromCtx(c))
+}
+
+func (h *SessionHandler) GetUserSession(c *gin.Context) {
+	session, err := h.usecase.GetSession(c.Request.Context(), &domain.GetSessionRequest{
+		UserID:           c.Param("id"),
+		SessionID:        c.Param("session_id"),
+		CurrentSessionID: common.GetSessionIDFromCtx(c),
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, session, "Session retrieved successfully")
+}
+
+func (h *SessionHandler) RevokeUserSession(c *gin.Context) {
+	if err := h.usecase.RevokeSession(c.Request.Context(), &domain.RevokeSessionRequest{
+		UserID:    c.Param("id"),
+		SessionID: c.Param("session_id"),
+	}); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Session revoked")
+}
+
+func (h *SessionHandler) RevokeAllUserSessions(c *gin.Context) {
+	// Keep the administrator's own session alive when acting on their own account
+	revoked, err := h.usecase.RevokeOtherSessions(c.Request.Context(), &domain.RevokeOtherSessionsRequest{
+		UserID:           c.Param("id"),
+		CurrentSessionID: common.GetSessionIDFromCtx(c),
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, &domain.RevokeSessionsResponse{Revoked: revoked}, "Sessions revoked")
+}
+
+func (h *SessionHandler) listSessions(c *gin.Context, userID, currentSessionID string) {
+	// Parse pagination parameters
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "10"))
+
+	sessions, pagination, err := h.usecase.ListSessions(c.Request.Context(), &domain.ListSessionsRequest{
+		UserID:           userID,
+		CurrentSessionID: currentSessionID,
+		Page:             page,
+		PerPage:          perPage,
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"sessions":   sessions,
+		"pagination": pagination,
+	}
+	common.ResponseOK(c, response, "Sessions retrieved successfully")
+}
@@ -0,0 +1,28 @@
+package event
+
+import (
+	"context"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/log"
+)
+
+// LoggerEmitter writes security events to the application log.
+type LoggerEmitter struct {
+	logger log.Logger
+}
+
+func NewLoggerEmitter(logger log.Logger) *LoggerEmitter {
+	return &LoggerEmitter{logger: logger}
+}
+
+func (e *LoggerEmitter) Emit(ctx context.Context, event *domain.SecurityEvent) {
+	e.logger.Warn("Security event",
+		log.String("type", string(event.Type)),
+		log.UserID(event.UserID),
+		log.String("session_id", event.SessionID),
+		log.String("ip_address", event.IPAddress),
+		log.String("user_agent", event.UserAgent),
+		log.Any("metadata", event.Metadata),
+		log.Int64("occurred_at", event.OccurredAt),
+	)
+}
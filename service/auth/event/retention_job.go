@@ -0,0 +1,54 @@
+package event
+
+import (
+	"context"
+	"go-clean-arch/pkg/log"
+	"time"
+)
+
+type SecurityEventPurger interface {
+	PurgeSecurityEvents(ctx context.Context) (int64, error)
+}
+
+// RetentionJob deletes the security events older than the retention period, once at start and
+// then at every interval. Running it on several instances is harmless.
+type RetentionJob struct {
+	purger   SecurityEventPurger
+	interval time.Duration
+	logger   log.Logger
+}
+
+func NewRetentionJob(purger SecurityEventPurger, interval time.Duration, logger log.Logger) *RetentionJob {
+	return &RetentionJob{
+		purger:   purger,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run purges until the context is done.
+func (j *RetentionJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.purge(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (j *RetentionJob) purge(ctx context.Context) {
+	deleted, err := j.purger.PurgeSecurityEvents(ctx)
+	if err != nil {
+		j.logger.Error("Failed to purge security events", log.Error(err))
+		return
+	}
+	if deleted > 0 {
+		j.logger.Info("Purged security events", log.Int64("deleted", deleted))
+	}
+}
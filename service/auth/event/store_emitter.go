@@ -0,0 +1,48 @@
+package event
+
+import (
+	"context"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/log"
+	"go-clean-arch/pkg/utils"
+	"time"
+)
+
+// storeTimeout bounds the write of a single event, which outlives the request that emitted it
+const storeTimeout = 10 * time.Second
+
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event *domain.SecurityEvent) error
+}
+
+// StoreEmitter records security events in the database for the security event history. Events
+// are written in the background, the operation emitting them neither waits for nor fails with
+// the write, which is logged when it fails.
+type StoreEmitter struct {
+	repo   SecurityEventRepository
+	logger log.Logger
+}
+
+func NewStoreEmitter(repo SecurityEventRepository, logger log.Logger) *StoreEmitter {
+	return &StoreEmitter{repo: repo, logger: logger}
+}
+
+func (e *StoreEmitter) Emit(ctx context.Context, event *domain.SecurityEvent) {
+	if event.OccurredAt == 0 {
+		event.OccurredAt = utils.NowUnixMillis()
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), storeTimeout)
+		defer cancel()
+
+		if err := e.repo.Create(ctx, event); err != nil {
+			e.logger.Error("Failed to store security event",
+				log.String("type", string(event.Type)),
+				log.UserID(event.UserID),
+				log.String("session_id", event.SessionID),
+				log.Error(err),
+			)
+		}
+	}()
+}
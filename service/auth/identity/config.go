@@ -0,0 +1,73 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"go-clean-arch/domain"
+	"slices"
+)
+
+const (
+	ProviderTypeGoogle = "google"
+	ProviderTypeGitHub = "github"
+	ProviderTypeOIDC   = "oidc"
+)
+
+// Config describes an identity provider. The endpoints of Google and GitHub have defaults, they only
+// need to be set to point the provider at another server, such as a fake one in tests.
+type Config struct {
+	Name         string // Used in the login URLs, e.g. /auth/oauth/<name>/authorize
+	Type         string // google, github or oidc
+	ClientID     string
+	ClientSecret string
+	Issuer       string // Expected "iss" claim of ID tokens, OIDC only
+	AuthURL      string
+	TokenURL     string
+	JWKSURL      string // OIDC only
+	APIURL       string // GitHub only
+	Scopes       []string
+	RedirectURIs []string // Frontend URLs allowed to receive the authorization code
+}
+
+// Provider authenticates users with an external identity provider through the authorization code
+// flow with PKCE.
+type Provider interface {
+	Name() string
+	AllowsRedirectURI(uri string) bool
+	DefaultRedirectURI() string
+	AuthCodeURL(req AuthCodeRequest) string
+	// Exchange redeems the authorization code and returns the authenticated account
+	Exchange(ctx context.Context, req ExchangeRequest) (*domain.ExternalProfile, error)
+}
+
+// New builds the provider described by the config.
+func New(cfg Config) (Provider, error) {
+	if cfg.Name == "" || cfg.ClientID == "" {
+		return nil, fmt.Errorf("identity provider needs a name and a client ID")
+	}
+	if len(cfg.RedirectURIs) == 0 {
+		return nil, fmt.Errorf("identity provider %s needs at least one redirect URI", cfg.Name)
+	}
+
+	switch cfg.Type {
+	case ProviderTypeGoogle:
+		return NewOIDCProvider(withGoogleDefaults(cfg))
+	case ProviderTypeGitHub:
+		return NewGitHubProvider(cfg), nil
+	case ProviderTypeOIDC:
+		return NewOIDCProvider(cfg)
+	default:
+		return nil, fmt.Errorf("identity provider %s has unknown type %q", cfg.Name, cfg.Type)
+	}
+}
+
+// redirectURIs is shared by providers to check the redirect URI of a login against the registered ones
+type redirectURIs []string
+
+func (r redirectURIs) AllowsRedirectURI(uri string) bool {
+	return slices.Contains(r, uri)
+}
+
+func (r redirectURIs) DefaultRedirectURI() string {
+	return r[0]
+}
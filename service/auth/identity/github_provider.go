@@ -0,0 +1,112 @@
+package identity
+
+import (
+	"context"
+	"fmt"
+	"go-clean-arch/domain"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var githubDefaults = Config{
+	AuthURL:  "https://github.com/login/oauth/authorize",
+	TokenURL: "https://github.com/login/oauth/access_token",
+	APIURL:   "https://api.github.com",
+}
+
+type githubUser struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type githubEmail struct {
+	Email    string `json:"email"`
+	Primary  bool   `json:"primary"`
+	Verified bool   `json:"verified"`
+}
+
+// GitHubProvider logs users in with GitHub. GitHub does not implement OpenID Connect, the account is
+// read from its REST API with the access token instead of an ID token.
+type GitHubProvider struct {
+	redirectURIs
+	cfg    Config
+	client *http.Client
+}
+
+func NewGitHubProvider(cfg Config) *GitHubProvider {
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = githubDefaults.AuthURL
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = githubDefaults.TokenURL
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = githubDefaults.APIURL
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"read:user", "user:email"}
+	}
+	cfg.APIURL = strings.TrimSuffix(cfg.APIURL, "/")
+
+	return &GitHubProvider{
+		redirectURIs: cfg.RedirectURIs,
+		cfg:          cfg,
+		client:       newHTTPClient(),
+	}
+}
+
+func (p *GitHubProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *GitHubProvider) AuthCodeURL(req AuthCodeRequest) string {
+	// GitHub has no ID token, the nonce is not sent
+	req.Nonce = ""
+	return authCodeURL(p.cfg.AuthURL, p.cfg.ClientID, p.cfg.Scopes, req)
+}
+
+func (p *GitHubProvider) Exchange(ctx context.Context, req ExchangeRequest) (*domain.ExternalProfile, error) {
+	token, err := exchangeCode(ctx, p.client, p.cfg.TokenURL, p.cfg.ClientID, p.cfg.ClientSecret, req)
+	if err != nil {
+		return nil, domain.ErrOAuthExchangeFailed.WithWrap(err)
+	}
+
+	var user githubUser
+	if err := p.get(ctx, token.AccessToken, "/user", &user); err != nil {
+		return nil, domain.ErrOAuthExchangeFailed.WithWrap(err)
+	}
+	if user.ID == 0 {
+		return nil, domain.ErrOAuthExchangeFailed.WithError("GitHub returned no user ID")
+	}
+
+	// The public email of the profile may be unverified, only the primary verified one is trusted
+	var emails []githubEmail
+	if err := p.get(ctx, token.AccessToken, "/user/emails", &emails); err != nil {
+		return nil, domain.ErrOAuthExchangeFailed.WithWrap(err)
+	}
+
+	profile := &domain.ExternalProfile{Subject: strconv.FormatInt(user.ID, 10)}
+	for _, email := range emails {
+		if email.Primary {
+			profile.Email = strings.ToLower(email.Email)
+			profile.EmailVerified = email.Verified
+		}
+	}
+	profile.FirstName, profile.LastName, _ = strings.Cut(strings.TrimSpace(user.Name), " ")
+	return profile, nil
+}
+
+func (p *GitHubProvider) get(ctx context.Context, accessToken, path string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.cfg.APIURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if err := doJSON(p.client, req, dest); err != nil {
+		return fmt.Errorf("github api: %w", err)
+	}
+	return nil
+}
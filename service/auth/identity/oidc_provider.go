@@ -0,0 +1,155 @@
+package identity
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"go-clean-arch/domain"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var googleDefaults = Config{
+	Issuer:   "https://accounts.google.com",
+	AuthURL:  "https://accounts.google.com/o/oauth2/v2/auth",
+	TokenURL: "https://oauth2.googleapis.com/token",
+	JWKSURL:  "https://www.googleapis.com/oauth2/v3/certs",
+}
+
+func withGoogleDefaults(cfg Config) Config {
+	if cfg.Issuer == "" {
+		cfg.Issuer = googleDefaults.Issuer
+	}
+	if cfg.AuthURL == "" {
+		cfg.AuthURL = googleDefaults.AuthURL
+	}
+	if cfg.TokenURL == "" {
+		cfg.TokenURL = googleDefaults.TokenURL
+	}
+	if cfg.JWKSURL == "" {
+		cfg.JWKSURL = googleDefaults.JWKSURL
+	}
+	return cfg
+}
+
+type oidcClaims struct {
+	jwt.RegisteredClaims
+	Nonce         string `json:"nonce"`
+	Email         string `json:"email"`
+	EmailVerified any    `json:"email_verified"` // Some providers send the boolean as a string
+	GivenName     string `json:"given_name"`
+	FamilyName    string `json:"family_name"`
+}
+
+func (c *oidcClaims) emailVerified() bool {
+	switch v := c.EmailVerified.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	}
+	return false
+}
+
+// OIDCProvider logs users in with an OpenID Connect provider, identifying them by the ID token
+// returned with the access token.
+type OIDCProvider struct {
+	redirectURIs
+	cfg     Config
+	client  *http.Client
+	keySet  *remoteKeySet
+	parser  *jwt.Parser
+	issuers []string
+}
+
+func NewOIDCProvider(cfg Config) (*OIDCProvider, error) {
+	if cfg.Issuer == "" || cfg.AuthURL == "" || cfg.TokenURL == "" || cfg.JWKSURL == "" {
+		return nil, fmt.Errorf("identity provider %s needs an issuer, auth, token and JWKS URL", cfg.Name)
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+
+	// Google issues tokens with and without the scheme in "iss"
+	issuers := []string{cfg.Issuer}
+	if host, ok := strings.CutPrefix(cfg.Issuer, "https://"); ok {
+		issuers = append(issuers, host)
+	}
+
+	client := newHTTPClient()
+	return &OIDCProvider{
+		redirectURIs: cfg.RedirectURIs,
+		cfg:          cfg,
+		client:       client,
+		keySet:       newRemoteKeySet(cfg.JWKSURL, client),
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}),
+			jwt.WithAudience(cfg.ClientID),
+			jwt.WithExpirationRequired(),
+			jwt.WithIssuedAt(),
+		),
+		issuers: issuers,
+	}, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *OIDCProvider) AuthCodeURL(req AuthCodeRequest) string {
+	return authCodeURL(p.cfg.AuthURL, p.cfg.ClientID, p.cfg.Scopes, req)
+}
+
+func (p *OIDCProvider) Exchange(ctx context.Context, req ExchangeRequest) (*domain.ExternalProfile, error) {
+	token, err := exchangeCode(ctx, p.client, p.cfg.TokenURL, p.cfg.ClientID, p.cfg.ClientSecret, req)
+	if err != nil {
+		return nil, domain.ErrOAuthExchangeFailed.WithWrap(err)
+	}
+	if token.IDToken == "" {
+		return nil, domain.ErrInvalidIDToken.WithError("token response has no ID token")
+	}
+
+	claims, err := p.verifyIDToken(ctx, token.IDToken, req.Nonce)
+	if err != nil {
+		return nil, domain.ErrInvalidIDToken.WithWrap(err)
+	}
+
+	return &domain.ExternalProfile{
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(claims.Email),
+		EmailVerified: claims.emailVerified(),
+		FirstName:     claims.GivenName,
+		LastName:      claims.FamilyName,
+	}, nil
+}
+
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, rawToken, nonce string) (*oidcClaims, error) {
+	claims := &oidcClaims{}
+	_, err := p.parser.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keySet.key(ctx, kid)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	issuerValid := false
+	for _, issuer := range p.issuers {
+		if claims.Issuer == issuer {
+			issuerValid = true
+		}
+	}
+	if !issuerValid {
+		return nil, fmt.Errorf("unexpected issuer %q", claims.Issuer)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("ID token has no subject")
+	}
+	// The nonce ties the ID token to the login started by this browser
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("nonce mismatch")
+	}
+	return claims, nil
+}
@@ -0,0 +1,253 @@
+package identity
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"go-clean-arch/domain"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID    = "client-1"
+	testCode        = "code-1"
+	testNonce       = "nonce-1"
+	testKeyID       = "key-1"
+	testRedirectURI = "https://app.example.com/callback"
+)
+
+// fakeOIDCServer is an identity provider that redeems a single authorization code, checking the
+// PKCE verifier like a real provider does, and answers with an ID token built from claims.
+type fakeOIDCServer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	challenge string
+	claims    jwt.MapClaims
+}
+
+func newFakeOIDCServer(t *testing.T, codeVerifier string) *fakeOIDCServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(codeVerifier))
+	s := &fakeOIDCServer{
+		key:       key,
+		challenge: base64.RawURLEncoding.EncodeToString(sum[:]),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if r.PostForm.Get("code") != testCode || base64.RawURLEncoding.EncodeToString(sum[:]) != s.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, s.claims)
+		token.Header["kid"] = testKeyID
+		idToken, err := token.SignedString(s.key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "access-1",
+			"token_type":   "Bearer",
+			"id_token":     idToken,
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(domain.JSONWebKeySet{Keys: []domain.JSONWebKey{{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: testKeyID,
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *fakeOIDCServer) validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            s.URL,
+		"aud":            testClientID,
+		"sub":            "subject-1",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          testNonce,
+		"email":          "Jane@Example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+	}
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	const codeVerifier = "verifier-1"
+
+	tests := []struct {
+		name         string
+		claims       func(c jwt.MapClaims)
+		codeVerifier string
+		nonce        string
+		want         *domain.ExternalProfile
+		wantErr      error
+	}{
+		{
+			name: "valid ID token",
+			want: &domain.ExternalProfile{
+				Subject:       "subject-1",
+				Email:         "jane@example.com",
+				EmailVerified: true,
+				FirstName:     "Jane",
+				LastName:      "Doe",
+			},
+		},
+		{
+			name:   "email verified sent as a string",
+			claims: func(c jwt.MapClaims) { c["email_verified"] = "true" },
+			want: &domain.ExternalProfile{
+				Subject:       "subject-1",
+				Email:         "jane@example.com",
+				EmailVerified: true,
+				FirstName:     "Jane",
+				LastName:      "Doe",
+			},
+		},
+		{
+			name:   "unverified email",
+			claims: func(c jwt.MapClaims) { c["email_verified"] = false },
+			want: &domain.ExternalProfile{
+				Subject:   "subject-1",
+				Email:     "jane@example.com",
+				FirstName: "Jane",
+				LastName:  "Doe",
+			},
+		},
+		{
+			name:         "PKCE verifier mismatch",
+			codeVerifier: "another-verifier",
+			wantErr:      domain.ErrOAuthExchangeFailed,
+		},
+		{
+			name:    "nonce mismatch",
+			nonce:   "another-nonce",
+			wantErr: domain.ErrInvalidIDToken,
+		},
+		{
+			name:    "ID token without nonce",
+			claims:  func(c jwt.MapClaims) { delete(c, "nonce") },
+			wantErr: domain.ErrInvalidIDToken,
+		},
+		{
+			name:    "another audience",
+			claims:  func(c jwt.MapClaims) { c["aud"] = "client-2" },
+			wantErr: domain.ErrInvalidIDToken,
+		},
+		{
+			name:    "another issuer",
+			claims:  func(c jwt.MapClaims) { c["iss"] = "https://issuer.example.com" },
+			wantErr: domain.ErrInvalidIDToken,
+		},
+		{
+			name:    "expired ID token",
+			claims:  func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() },
+			wantErr: domain.ErrInvalidIDToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeOIDCServer(t, codeVerifier)
+			server.claims = server.validClaims()
+			if tt.claims != nil {
+				tt.claims(server.claims)
+			}
+			provider, err := NewOIDCProvider(Config{
+				Name:         "oidc",
+				ClientID:     testClientID,
+				Issuer:       server.URL,
+				AuthURL:      server.URL + "/authorize",
+				TokenURL:     server.URL + "/token",
+				JWKSURL:      server.URL + "/jwks",
+				RedirectURIs: []string{testRedirectURI},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := ExchangeRequest{
+				Code:         testCode,
+				CodeVerifier: codeVerifier,
+				RedirectURI:  testRedirectURI,
+				Nonce:        testNonce,
+			}
+			if tt.codeVerifier != "" {
+				req.CodeVerifier = tt.codeVerifier
+			}
+			if tt.nonce != "" {
+				req.Nonce = tt.nonce
+			}
+
+			profile, err := provider.Exchange(context.Background(), req)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Exchange() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange() error = %v", err)
+			}
+			if *profile != *tt.want {
+				t.Errorf("Exchange() = %+v, want %+v", profile, tt.want)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderAuthCodeURL(t *testing.T) {
+	provider, err := NewOIDCProvider(Config{
+		Name:         "oidc",
+		ClientID:     testClientID,
+		Issuer:       "https://issuer.example.com",
+		AuthURL:      "https://issuer.example.com/authorize",
+		TokenURL:     "https://issuer.example.com/token",
+		JWKSURL:      "https://issuer.example.com/jwks",
+		RedirectURIs: []string{testRedirectURI},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := provider.AuthCodeURL(AuthCodeRequest{
+		State:         "state-1",
+		Nonce:         testNonce,
+		CodeChallenge: "challenge-1",
+		RedirectURI:   testRedirectURI,
+	})
+	want := "https://issuer.example.com/authorize?client_id=client-1&code_challenge=challenge-1" +
+		"&code_challenge_method=S256&nonce=nonce-1&redirect_uri=https%3A%2F%2Fapp.example.com%2Fcallback" +
+		"&response_type=code&scope=openid+email+profile&state=state-1"
+	if got != want {
+		t.Errorf("AuthCodeURL() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,49 @@
+// Package loginrisk compares a new login with the previous logins of the same user and reports what
+// looks unfamiliar about it. Each check is a Rule, more of them can be plugged in, e.g. one looking
+// the country of the IP address up in a local geo-IP database.
+package loginrisk
+
+import "context"
+
+// Reason tells what is unfamiliar about a login.
+type Reason string
+
+const (
+	ReasonNewDevice  Reason = "new_device"
+	ReasonNewNetwork Reason = "new_network"
+)
+
+// Login is what is known about a login, the new one or a previous one.
+type Login struct {
+	IPAddress string
+	UserAgent string
+}
+
+// Rule decides whether a login differs from the previous ones in a way the user should hear about.
+type Rule interface {
+	Evaluate(ctx context.Context, current Login, history []Login) (Reason, bool)
+}
+
+type Detector struct {
+	rules []Rule
+}
+
+func NewDetector(rules ...Rule) *Detector {
+	return &Detector{rules: rules}
+}
+
+// Detect returns the reasons reported by the rules, none for the first login of a user as there is
+// nothing to compare it with.
+func (d *Detector) Detect(ctx context.Context, current Login, history []Login) []Reason {
+	if len(history) == 0 {
+		return nil
+	}
+
+	var reasons []Reason
+	for _, rule := range d.rules {
+		if reason, ok := rule.Evaluate(ctx, current, history); ok {
+			reasons = append(reasons, reason)
+		}
+	}
+	return reasons
+}
@@ -0,0 +1,83 @@
+package loginrisk
+
+import (
+	"context"
+	"go-clean-arch/pkg/utils"
+	"net"
+	"strings"
+	"unicode"
+)
+
+// DeviceRule flags logins from a browser, operating system and device type combination the user
+// never logged in with. Browser versions are ignored so updates do not look like a new device.
+type DeviceRule struct{}
+
+func NewDeviceRule() *DeviceRule {
+	return &DeviceRule{}
+}
+
+func (r *DeviceRule) Evaluate(_ context.Context, current Login, history []Login) (Reason, bool) {
+	fingerprint := deviceFingerprint(current.UserAgent)
+	for _, login := range history {
+		if deviceFingerprint(login.UserAgent) == fingerprint {
+			return "", false
+		}
+	}
+	return ReasonNewDevice, true
+}
+
+func deviceFingerprint(userAgent string) string {
+	info := utils.ParseUserAgent(userAgent)
+	return browserFamily(info.Browser) + "|" + info.OS + "|" + info.Device
+}
+
+// browserFamily drops the trailing version of a browser name, e.g. "Microsoft Edge 120.0"
+func browserFamily(browser string) string {
+	if i := strings.LastIndexByte(browser, ' '); i > 0 {
+		if version := browser[i+1:]; version != "" && unicode.IsDigit(rune(version[0])) {
+			return browser[:i]
+		}
+	}
+	return browser
+}
+
+// NetworkRule flags logins from an IP range the user never logged in from. Addresses are compared by
+// network prefix, as the last bits of an address change often for the same user.
+type NetworkRule struct {
+	ipv4Mask net.IPMask
+	ipv6Mask net.IPMask
+}
+
+// NewNetworkRule compares IPv4 addresses by their first ipv4PrefixLen bits and IPv6 addresses by
+// their first ipv6PrefixLen bits, e.g. 24 and 48.
+func NewNetworkRule(ipv4PrefixLen, ipv6PrefixLen int) *NetworkRule {
+	return &NetworkRule{
+		ipv4Mask: net.CIDRMask(ipv4PrefixLen, 8*net.IPv4len),
+		ipv6Mask: net.CIDRMask(ipv6PrefixLen, 8*net.IPv6len),
+	}
+}
+
+func (r *NetworkRule) Evaluate(_ context.Context, current Login, history []Login) (Reason, bool) {
+	network := r.network(current.IPAddress)
+	if network == "" {
+		// Nothing to compare without a valid address
+		return "", false
+	}
+	for _, login := range history {
+		if r.network(login.IPAddress) == network {
+			return "", false
+		}
+	}
+	return ReasonNewNetwork, true
+}
+
+func (r *NetworkRule) network(address string) string {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return ""
+	}
+	if ipv4 := ip.To4(); ipv4 != nil {
+		return ipv4.Mask(r.ipv4Mask).String()
+	}
+	return ip.Mask(r.ipv6Mask).String()
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository struct {
+	sqlHandler *database.SQLHandler[domain.APIKey, domain.APIKeyFilter]
+}
+
+func NewPgAPIKeyRepo(db *gorm.DB) *APIKeyRepository {
+	sqlHandler := database.NewSQLHandler[domain.APIKey](db, applyAPIKeyFilter, database.WithoutTenantScope())
+	return &APIKeyRepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyAPIKeyFilter(qb *gorm.DB, filter *domain.APIKeyFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if filter.Prefix != nil {
+		qb = qb.Where("prefix = ?", *filter.Prefix)
+	}
+	if filter.OwnerID != nil {
+		qb = qb.Where("owner_id = ?", *filter.OwnerID)
+	}
+
+	return qb
+}
+
+func (r *APIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	return r.sqlHandler.Create(ctx, key)
+}
+
+func (r *APIKeyRepository) FindByID(ctx context.Context, id string) (*domain.APIKey, error) {
+	return r.sqlHandler.FindByID(ctx, id, nil)
+}
+
+func (r *APIKeyRepository) FindByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.APIKeyFilter{
+		Prefix: &prefix,
+	}, nil)
+}
+
+func (r *APIKeyRepository) FindPage(ctx context.Context, filter *domain.APIKeyFilter, option *domain.FindPageOption) ([]*domain.APIKey, *domain.Pagination, error) {
+	return r.sqlHandler.FindPage(ctx, filter, option)
+}
+
+func (r *APIKeyRepository) Update(ctx context.Context, key *domain.APIKey) error {
+	return r.sqlHandler.Update(ctx, key)
+}
+
+func (r *APIKeyRepository) TouchLastUsed(ctx context.Context, id string, at int64) error {
+	return r.sqlHandler.UpdateFields(ctx, id, map[string]any{
+		"last_used_at": at,
+	})
+}
+
+// Delete removes the key for good, so its prefix can never match again
+func (r *APIKeyRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.sqlHandler.DeleteMany(ctx, &domain.APIKeyFilter{ID: &id})
+	return err
+}
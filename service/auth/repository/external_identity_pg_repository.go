@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type ExternalIdentityRepository struct {
+	sqlHandler *database.SQLHandler[domain.ExternalIdentity, domain.ExternalIdentityFilter]
+}
+
+func NewPgExternalIdentityRepo(db *gorm.DB) *ExternalIdentityRepository {
+	sqlHandler := database.NewSQLHandler[domain.ExternalIdentity](db, applyExternalIdentityFilter, database.WithoutTenantScope())
+	return &ExternalIdentityRepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyExternalIdentityFilter(qb *gorm.DB, filter *domain.ExternalIdentityFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if filter.UserID != nil {
+		qb = qb.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Provider != nil {
+		qb = qb.Where("provider = ?", *filter.Provider)
+	}
+	if filter.Subject != nil {
+		qb = qb.Where("subject = ?", *filter.Subject)
+	}
+
+	return qb
+}
+
+func (r *ExternalIdentityRepository) Create(ctx context.Context, identity *domain.ExternalIdentity) error {
+	return r.sqlHandler.Create(ctx, identity)
+}
+
+func (r *ExternalIdentityRepository) FindBySubject(ctx context.Context, provider, subject string) (*domain.ExternalIdentity, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.ExternalIdentityFilter{
+		Provider: &provider,
+		Subject:  &subject,
+	}, nil)
+}
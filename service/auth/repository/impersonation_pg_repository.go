@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type ImpersonationRepository struct {
+	sqlHandler *database.SQLHandler[domain.Impersonation, domain.ImpersonationFilter]
+}
+
+func NewPgImpersonationRepo(db *gorm.DB) *ImpersonationRepository {
+	sqlHandler := database.NewSQLHandler[domain.Impersonation](db, applyImpersonationFilter, database.WithoutTenantScope())
+	return &ImpersonationRepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyImpersonationFilter(qb *gorm.DB, filter *domain.ImpersonationFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if filter.ActorID != nil {
+		qb = qb.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.TargetUserID != nil {
+		qb = qb.Where("target_user_id = ?", *filter.TargetUserID)
+	}
+	if filter.SessionID != nil {
+		qb = qb.Where("session_id = ?", *filter.SessionID)
+	}
+	if filter.NotEnded != nil {
+		if *filter.NotEnded {
+			qb = qb.Where("ended_at = 0")
+		} else {
+			qb = qb.Where("ended_at > 0")
+		}
+	}
+
+	return qb
+}
+
+func (r *ImpersonationRepository) Create(ctx context.Context, impersonation *domain.Impersonation) error {
+	return r.sqlHandler.Create(ctx, impersonation)
+}
+
+func (r *ImpersonationRepository) FindByID(ctx context.Context, id string) (*domain.Impersonation, error) {
+	return r.sqlHandler.FindByID(ctx, id, nil)
+}
+
+func (r *ImpersonationRepository) FindBySessionID(ctx context.Context, sessionID string) (*domain.Impersonation, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.ImpersonationFilter{
+		SessionID: &sessionID,
+	}, nil)
+}
+
+func (r *ImpersonationRepository) FindPage(ctx context.Context, filter *domain.ImpersonationFilter, option *domain.FindPageOption) ([]*domain.Impersonation, *domain.Pagination, error) {
+	return r.sqlHandler.FindPage(ctx, filter, option)
+}
+
+// MarkEnded records the end of the impersonation, false when it had already ended
+func (r *ImpersonationRepository) MarkEnded(ctx context.Context, id, endedBy string, endedAt int64) (bool, error) {
+	notEnded := true
+	affected, err := r.sqlHandler.UpdateMany(ctx, &domain.ImpersonationFilter{
+		ID:       &id,
+		NotEnded: &notEnded,
+	}, map[string]any{
+		"ended_at": endedAt,
+		"ended_by": endedBy,
+	})
+	return affected == 1, err
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type MFARecoveryCodeRepository struct {
+	db         *gorm.DB
+	sqlHandler *database.SQLHandler[domain.MFARecoveryCode, domain.MFARecoveryCodeFilter]
+}
+
+func NewPgMFARecoveryCodeRepo(db *gorm.DB) *MFARecoveryCodeRepository {
+	sqlHandler := database.NewSQLHandler[domain.MFARecoveryCode](db, applyMFARecoveryCodeFilter, database.WithoutTenantScope())
+	return &MFARecoveryCodeRepository{
+		db:         db,
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyMFARecoveryCodeFilter(qb *gorm.DB, filter *domain.MFARecoveryCodeFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if filter.UserID != nil {
+		qb = qb.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.CodeHash != nil {
+		qb = qb.Where("code_hash = ?", *filter.CodeHash)
+	}
+	if filter.Used != nil {
+		if *filter.Used {
+			qb = qb.Where("used_at > 0")
+		} else {
+			qb = qb.Where("used_at = 0")
+		}
+	}
+
+	return qb
+}
+
+// ReplaceForUser deletes every recovery code of the user and stores the given ones in a single transaction.
+func (r *MFARecoveryCodeRepository) ReplaceForUser(ctx context.Context, userID string, codes []*domain.MFARecoveryCode) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := r.sqlHandler.DeleteMany(ctx, &domain.MFARecoveryCodeFilter{
+			UserID: &userID,
+		}, database.WithTx(tx)); err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return r.sqlHandler.CreateMany(ctx, codes, database.WithTx(tx))
+	})
+}
+
+func (r *MFARecoveryCodeRepository) FindUnusedByHash(ctx context.Context, userID, codeHash string) (*domain.MFARecoveryCode, error) {
+	used := false
+	return r.sqlHandler.FindOne(ctx, &domain.MFARecoveryCodeFilter{
+		UserID:   &userID,
+		CodeHash: &codeHash,
+		Used:     &used,
+	}, nil)
+}
+
+// MarkUsed consumes the code. It returns false when the code had already been used.
+func (r *MFARecoveryCodeRepository) MarkUsed(ctx context.Context, codeID string) (bool, error) {
+	used := false
+	affected, err := r.sqlHandler.UpdateMany(ctx, &domain.MFARecoveryCodeFilter{
+		ID:   &codeID,
+		Used: &used,
+	}, map[string]any{
+		"used_at": utils.NowUnixMillis(),
+	})
+	return affected > 0, err
+}
+
+func (r *MFARecoveryCodeRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	_, err := r.sqlHandler.DeleteMany(ctx, &domain.MFARecoveryCodeFilter{
+		UserID: &userID,
+	})
+	return err
+}
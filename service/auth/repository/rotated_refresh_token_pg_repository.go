@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type RotatedRefreshTokenRepository struct {
+	sqlHandler *database.SQLHandler[domain.RotatedRefreshToken, domain.RotatedRefreshTokenFilter]
+}
+
+func NewPgRotatedRefreshTokenRepo(db *gorm.DB) *RotatedRefreshTokenRepository {
+	sqlHandler := database.NewSQLHandler[domain.RotatedRefreshToken](db, applyRotatedRefreshTokenFilter, database.WithoutTenantScope())
+	return &RotatedRefreshTokenRepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyRotatedRefreshTokenFilter(qb *gorm.DB, filter *domain.RotatedRefreshTokenFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if filter.SessionID != nil {
+		qb = qb.Where("session_id = ?", *filter.SessionID)
+	}
+	if filter.TokenHash != nil {
+		qb = qb.Where("token_hash = ?", *filter.TokenHash)
+	}
+
+	return qb
+}
+
+func (r *RotatedRefreshTokenRepository) Create(ctx context.Context, token *domain.RotatedRefreshToken) error {
+	return r.sqlHandler.Create(ctx, token)
+}
+
+func (r *RotatedRefreshTokenRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*domain.RotatedRefreshToken, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.RotatedRefreshTokenFilter{
+		TokenHash: &tokenHash,
+	}, nil)
+}
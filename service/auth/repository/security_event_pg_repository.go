@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type SecurityEventRepository struct {
+	sqlHandler *database.SQLHandler[domain.SecurityEvent, domain.SecurityEventFilter]
+}
+
+func NewPgSecurityEventRepo(db *gorm.DB) *SecurityEventRepository {
+	sqlHandler := database.NewSQLHandler[domain.SecurityEvent](db, applySecurityEventFilter, database.WithoutTenantScope())
+	return &SecurityEventRepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applySecurityEventFilter(qb *gorm.DB, filter *domain.SecurityEventFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.UserID != nil {
+		qb = qb.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.ActorID != nil {
+		qb = qb.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Type != nil {
+		qb = qb.Where("type = ?", *filter.Type)
+	}
+	if filter.IPAddress != nil {
+		qb = qb.Where("ip_address = ?", *filter.IPAddress)
+	}
+	if filter.OccurredAfter != nil {
+		qb = qb.Where("occurred_at >= ?", *filter.OccurredAfter)
+	}
+	if filter.OccurredBefore != nil {
+		qb = qb.Where("occurred_at < ?", *filter.OccurredBefore)
+	}
+
+	return qb
+}
+
+func (r *SecurityEventRepository) Create(ctx context.Context, event *domain.SecurityEvent) error {
+	return r.sqlHandler.Create(ctx, event)
+}
+
+func (r *SecurityEventRepository) FindPage(ctx context.Context, filter *domain.SecurityEventFilter, option *domain.FindPageOption) ([]*domain.SecurityEvent, *domain.Pagination, error) {
+	return r.sqlHandler.FindPage(ctx, filter, option)
+}
+
+// DeleteOccurredBefore removes the events older than the given milli timestamp, for good
+func (r *SecurityEventRepository) DeleteOccurredBefore(ctx context.Context, before int64) (int64, error) {
+	return r.sqlHandler.DeleteMany(ctx, &domain.SecurityEventFilter{
+		OccurredBefore: &before,
+	})
+}
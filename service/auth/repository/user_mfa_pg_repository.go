@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type UserMFARepository struct {
+	sqlHandler *database.SQLHandler[domain.UserMFA, domain.UserMFAFilter]
+}
+
+func NewPgUserMFARepo(db *gorm.DB) *UserMFARepository {
+	sqlHandler := database.NewSQLHandler[domain.UserMFA](db, applyUserMFAFilter, database.WithoutTenantScope())
+	return &UserMFARepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyUserMFAFilter(qb *gorm.DB, filter *domain.UserMFAFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if filter.UserID != nil {
+		qb = qb.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Enabled != nil {
+		qb = qb.Where("enabled = ?", *filter.Enabled)
+	}
+	if filter.LastUsedStepBefore != nil {
+		qb = qb.Where("last_used_step < ?", *filter.LastUsedStepBefore)
+	}
+
+	return qb
+}
+
+func (r *UserMFARepository) Create(ctx context.Context, mfa *domain.UserMFA) error {
+	return r.sqlHandler.Create(ctx, mfa)
+}
+
+func (r *UserMFARepository) FindByUserID(ctx context.Context, userID string) (*domain.UserMFA, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.UserMFAFilter{
+		UserID: &userID,
+	}, nil)
+}
+
+func (r *UserMFARepository) Update(ctx context.Context, mfa *domain.UserMFA) error {
+	return r.sqlHandler.Update(ctx, mfa)
+}
+
+// UseStep records step as the last accepted TOTP step. It returns false when a code of the
+// same or a later step was already accepted, so a code cannot be replayed.
+func (r *UserMFARepository) UseStep(ctx context.Context, id string, step int64) (bool, error) {
+	affected, err := r.sqlHandler.UpdateMany(ctx, &domain.UserMFAFilter{
+		ID:                 &id,
+		LastUsedStepBefore: &step,
+	}, map[string]any{
+		"last_used_step": step,
+	})
+	return affected > 0, err
+}
@@ -13,7 +13,7 @@ type UserSessionRepository struct {
 }
 
 func NewPgUserSessionRepo(db *gorm.DB) *UserSessionRepository {
-	sqlHandler := database.NewSQLHandler[domain.UserSession](db, applyFilter)
+	sqlHandler := database.NewSQLHandler[domain.UserSession](db, applyFilter, database.WithoutTenantScope())
 	return &UserSessionRepository{
 		sqlHandler: sqlHandler,
 	}
@@ -27,6 +27,9 @@ func applyFilter(qb *gorm.DB, filter *domain.UserSessionFilter) *gorm.DB {
 	if filter.ID != nil {
 		qb = qb.Where("id = ?", *filter.ID)
 	}
+	if filter.IDNe != nil {
+		qb = qb.Where("id <> ?", *filter.IDNe)
+	}
 	if filter.UserID != nil {
 		qb = qb.Where("user_id = ?", *filter.UserID)
 	}
@@ -48,6 +51,9 @@ func applyFilter(qb *gorm.DB, filter *domain.UserSessionFilter) *gorm.DB {
 	if filter.ExpiresBefore != nil {
 		qb = qb.Where("expires_at < ?", *filter.ExpiresBefore)
 	}
+	if filter.ValidAt != nil {
+		qb = qb.Where("(expires_at = 0 OR expires_at > ?)", *filter.ValidAt)
+	}
 	if filter.CreatedAfter != nil {
 		qb = qb.Where("created_at >= ?", *filter.CreatedAfter)
 	}
@@ -96,8 +102,73 @@ func (r *UserSessionRepository) FindByRefreshToken(ctx context.Context, refreshT
 	}, option)
 }
 
-func (r *UserSessionRepository) InvalidateRefreshToken(ctx context.Context, sessionID string) error {
+// RevokeAllByUserID deactivates every session of the user and clears their refresh tokens
+func (r *UserSessionRepository) RevokeAllByUserID(ctx context.Context, userID string) error {
+	_, err := r.sqlHandler.UpdateMany(ctx, &domain.UserSessionFilter{
+		UserID: &userID,
+	}, map[string]any{
+		"active":        false,
+		"refresh_token": "",
+	})
+	return err
+}
+
+// Revoke deactivates a single session and clears its refresh token
+func (r *UserSessionRepository) Revoke(ctx context.Context, sessionID string) error {
 	return r.sqlHandler.UpdateFields(ctx, sessionID, map[string]any{
+		"active":        false,
+		"refresh_token": "",
+	})
+}
+
+// RevokeAllByUserIDExcept deactivates every active session of the user but the given one and
+// returns how many sessions were revoked
+func (r *UserSessionRepository) RevokeAllByUserIDExcept(ctx context.Context, userID, exceptSessionID string) (int64, error) {
+	active := true
+	filter := &domain.UserSessionFilter{
+		UserID: &userID,
+		Active: &active,
+	}
+	if exceptSessionID != "" {
+		filter.IDNe = &exceptSessionID
+	}
+	return r.sqlHandler.UpdateMany(ctx, filter, map[string]any{
+		"active":        false,
 		"refresh_token": "",
 	})
 }
+
+// SetOrganization changes the organization the tokens of the session act in
+func (r *UserSessionRepository) SetOrganization(ctx context.Context, sessionID, organizationID string) error {
+	return r.sqlHandler.UpdateFields(ctx, sessionID, map[string]any{
+		"organization_id": organizationID,
+	})
+}
+
+// TouchLastActivity records the time the session was last used
+func (r *UserSessionRepository) TouchLastActivity(ctx context.Context, sessionID string, at int64) error {
+	return r.sqlHandler.UpdateFields(ctx, sessionID, map[string]any{
+		"last_activity_at": at,
+	})
+}
+
+// RotateRefreshToken replaces the refresh token of an active session, together with its expiry and
+// client info, only if oldRefreshToken is still the current one. It returns false when another
+// request rotated the token first or the session was revoked in the meantime.
+func (r *UserSessionRepository) RotateRefreshToken(ctx context.Context, session *domain.UserSession, oldRefreshToken string) (bool, error) {
+	active := true
+	affected, err := r.sqlHandler.UpdateMany(ctx, &domain.UserSessionFilter{
+		ID:           &session.ID,
+		RefreshToken: &oldRefreshToken,
+		Active:       &active,
+	}, map[string]any{
+		"refresh_token": session.RefreshToken,
+		"expires_at":    session.ExpiresAt,
+		"ip_address":    session.IPAddress,
+		"user_agent":    session.UserAgent,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
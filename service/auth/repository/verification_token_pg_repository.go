@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+type VerificationTokenRepository struct {
+	sqlHandler *database.SQLHandler[domain.VerificationToken, domain.VerificationTokenFilter]
+}
+
+func NewPgVerificationTokenRepo(db *gorm.DB) *VerificationTokenRepository {
+	sqlHandler := database.NewSQLHandler[domain.VerificationToken](db, applyVerificationTokenFilter, database.WithoutTenantScope())
+	return &VerificationTokenRepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyVerificationTokenFilter(qb *gorm.DB, filter *domain.VerificationTokenFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if filter.UserID != nil {
+		qb = qb.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Purpose != nil {
+		qb = qb.Where("purpose = ?", *filter.Purpose)
+	}
+	if filter.TokenHash != nil {
+		qb = qb.Where("token_hash = ?", *filter.TokenHash)
+	}
+	if filter.Used != nil {
+		if *filter.Used {
+			qb = qb.Where("used_at > 0")
+		} else {
+			qb = qb.Where("used_at = 0")
+		}
+	}
+
+	return qb
+}
+
+func (r *VerificationTokenRepository) Create(ctx context.Context, token *domain.VerificationToken) error {
+	return r.sqlHandler.Create(ctx, token)
+}
+
+func (r *VerificationTokenRepository) FindOne(ctx context.Context, filter *domain.VerificationTokenFilter, option *domain.FindOneOption) (*domain.VerificationToken, error) {
+	return r.sqlHandler.FindOne(ctx, filter, option)
+}
+
+func (r *VerificationTokenRepository) FindByTokenHash(ctx context.Context, purpose domain.VerificationPurpose, tokenHash string) (*domain.VerificationToken, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.VerificationTokenFilter{
+		Purpose:   &purpose,
+		TokenHash: &tokenHash,
+	}, nil)
+}
+
+// MarkUsed consumes the token. It returns false when the token had already been used,
+// so concurrent requests cannot redeem the same token twice.
+func (r *VerificationTokenRepository) MarkUsed(ctx context.Context, tokenID string) (bool, error) {
+	used := false
+	affected, err := r.sqlHandler.UpdateMany(ctx, &domain.VerificationTokenFilter{
+		ID:   &tokenID,
+		Used: &used,
+	}, map[string]any{
+		"used_at": utils.NowUnixMillis(),
+	})
+	return affected > 0, err
+}
+
+// InvalidateByUser consumes every outstanding token of the given purpose for a user.
+func (r *VerificationTokenRepository) InvalidateByUser(ctx context.Context, userID string, purpose domain.VerificationPurpose) error {
+	used := false
+	_, err := r.sqlHandler.UpdateMany(ctx, &domain.VerificationTokenFilter{
+		UserID:  &userID,
+		Purpose: &purpose,
+		Used:    &used,
+	}, map[string]any{
+		"used_at": utils.NowUnixMillis(),
+	})
+	return err
+}
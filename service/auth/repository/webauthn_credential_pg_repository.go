@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type WebAuthnCredentialRepository struct {
+	sqlHandler *database.SQLHandler[domain.WebAuthnCredential, domain.WebAuthnCredentialFilter]
+}
+
+func NewPgWebAuthnCredentialRepo(db *gorm.DB) *WebAuthnCredentialRepository {
+	sqlHandler := database.NewSQLHandler[domain.WebAuthnCredential](db, applyWebAuthnCredentialFilter, database.WithoutTenantScope())
+	return &WebAuthnCredentialRepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyWebAuthnCredentialFilter(qb *gorm.DB, filter *domain.WebAuthnCredentialFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if filter.UserID != nil {
+		qb = qb.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.CredentialID != nil {
+		qb = qb.Where("credential_id = ?", *filter.CredentialID)
+	}
+	if filter.SignCountBefore != nil {
+		qb = qb.Where("sign_count < ?", *filter.SignCountBefore)
+	}
+
+	return qb
+}
+
+func (r *WebAuthnCredentialRepository) Create(ctx context.Context, credential *domain.WebAuthnCredential) error {
+	return r.sqlHandler.Create(ctx, credential)
+}
+
+func (r *WebAuthnCredentialRepository) FindByCredentialID(ctx context.Context, credentialID string) (*domain.WebAuthnCredential, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.WebAuthnCredentialFilter{
+		CredentialID: &credentialID,
+	}, nil)
+}
+
+func (r *WebAuthnCredentialRepository) FindByUserID(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error) {
+	return r.sqlHandler.FindMany(ctx, &domain.WebAuthnCredentialFilter{
+		UserID: &userID,
+	}, &domain.FindManyOption{
+		Sort: []string{"created_at ASC"},
+	})
+}
+
+func (r *WebAuthnCredentialRepository) CountByUserID(ctx context.Context, userID string) (int64, error) {
+	return r.sqlHandler.Count(ctx, &domain.WebAuthnCredentialFilter{
+		UserID: &userID,
+	})
+}
+
+func (r *WebAuthnCredentialRepository) Update(ctx context.Context, credential *domain.WebAuthnCredential) error {
+	return r.sqlHandler.Update(ctx, credential)
+}
+
+// RecordUse stores the sign counter of an accepted assertion. It returns false when an assertion
+// with the same or a higher counter was already accepted, so a signature cannot be replayed.
+// Authenticators without a counter always report 0, their use is recorded unconditionally.
+func (r *WebAuthnCredentialRepository) RecordUse(ctx context.Context, id string, signCount, usedAt int64) (bool, error) {
+	filter := &domain.WebAuthnCredentialFilter{ID: &id}
+	if signCount > 0 {
+		filter.SignCountBefore = &signCount
+	}
+	affected, err := r.sqlHandler.UpdateMany(ctx, filter, map[string]any{
+		"sign_count":   signCount,
+		"last_used_at": usedAt,
+	})
+	return affected > 0, err
+}
+
+// Delete removes the credential for good, so it can be registered again
+func (r *WebAuthnCredentialRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.sqlHandler.DeleteMany(ctx, &domain.WebAuthnCredentialFilter{ID: &id})
+	return err
+}
@@ -0,0 +1,239 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+	"strings"
+	"time"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *domain.APIKey) error
+	FindByID(ctx context.Context, id string) (*domain.APIKey, error)
+	FindByPrefix(ctx context.Context, prefix string) (*domain.APIKey, error)
+	FindPage(ctx context.Context, filter *domain.APIKeyFilter, option *domain.FindPageOption) ([]*domain.APIKey, *domain.Pagination, error)
+	Update(ctx context.Context, key *domain.APIKey) error
+	TouchLastUsed(ctx context.Context, id string, at int64) error
+	Delete(ctx context.Context, id string) error
+}
+
+const (
+	// Raw keys look like gca_<prefix>_<secret>, the prefix is stored in clear to find the key
+	apiKeyTag          = "gca"
+	apiKeyPrefixBytes  = 6
+	apiKeySecretBytes  = 32
+	apiKeyNameMaxChars = 100
+)
+
+// apiKeyUsageInterval throttles LastUsedAt writes to at most one per key per interval
+const apiKeyUsageInterval = time.Minute
+
+type apiKeyUsecase struct {
+	apiKeyRepo APIKeyRepository
+	userRepo   UserRepository
+}
+
+func NewAPIKeyUsecase(apiKeyRepo APIKeyRepository, userRepo UserRepository) domain.APIKeyUsecase {
+	return &apiKeyUsecase{apiKeyRepo: apiKeyRepo, userRepo: userRepo}
+}
+
+func (a *apiKeyUsecase) CreateAPIKey(ctx context.Context, req *domain.CreateAPIKeyRequest) (*domain.CreateAPIKeyResponse, error) {
+	if err := validateAPIKeyName(req.Name); err != nil {
+		return nil, err
+	}
+	scopes, err := validateScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeAPIKeyScopes(req.Principal, req.Scopes); err != nil {
+		return nil, err
+	}
+	if req.ExpiresAt != 0 && req.ExpiresAt <= utils.NowUnixMillis() {
+		return nil, domain.ErrAPIKeyValidationFailed.WithError("expires_at must be in the future")
+	}
+
+	prefix, err := common.GenerateSecureToken(apiKeyPrefixBytes)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	secret, err := common.GenerateSecureToken(apiKeySecretBytes)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	rawKey := apiKeyTag + "_" + prefix + "_" + secret
+
+	key := &domain.APIKey{
+		Name:       req.Name,
+		Prefix:     prefix,
+		SecretHash: common.HashToken(rawKey),
+		OwnerID:    req.OwnerID,
+		Scopes:     scopes,
+		ExpiresAt:  req.ExpiresAt,
+	}
+	if err := a.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return &domain.CreateAPIKeyResponse{APIKey: key, Key: rawKey}, nil
+}
+
+func (a *apiKeyUsecase) ListAPIKeys(ctx context.Context, req *domain.ListAPIKeysRequest) ([]*domain.APIKey, *domain.Pagination, error) {
+	keys, pagination, err := a.apiKeyRepo.FindPage(ctx, &domain.APIKeyFilter{
+		OwnerID: req.OwnerID,
+	}, &domain.FindPageOption{
+		Sort:    []string{"created_at DESC"},
+		Page:    req.Page,
+		PerPage: req.PerPage,
+	})
+	if err != nil {
+		return nil, nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return keys, pagination, nil
+}
+
+func (a *apiKeyUsecase) GetAPIKey(ctx context.Context, id string) (*domain.APIKey, error) {
+	return a.findAPIKey(ctx, id)
+}
+
+func (a *apiKeyUsecase) UpdateAPIKey(ctx context.Context, req *domain.UpdateAPIKeyRequest) (*domain.APIKey, error) {
+	key, err := a.findAPIKey(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if err := validateAPIKeyName(*req.Name); err != nil {
+			return nil, err
+		}
+		key.Name = *req.Name
+	}
+	if req.Scopes != nil {
+		scopes, err := validateScopes(req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		if err := domain.AuthorizeAPIKeyScopes(req.Principal, req.Scopes); err != nil {
+			return nil, err
+		}
+		key.Scopes = scopes
+	}
+	if req.ExpiresAt != nil {
+		if *req.ExpiresAt != 0 && *req.ExpiresAt <= utils.NowUnixMillis() {
+			return nil, domain.ErrAPIKeyValidationFailed.WithError("expires_at must be in the future")
+		}
+		key.ExpiresAt = *req.ExpiresAt
+	}
+
+	if err := a.apiKeyRepo.Update(ctx, key); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return key, nil
+}
+
+func (a *apiKeyUsecase) DeleteAPIKey(ctx context.Context, id string) error {
+	key, err := a.findAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := a.apiKeyRepo.Delete(ctx, key.ID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return nil
+}
+
+// Authenticate returns the API key matching the raw key, unless it is unknown or expired, or its
+// owner was banned or deleted.
+func (a *apiKeyUsecase) Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error) {
+	tag, rest, _ := strings.Cut(rawKey, "_")
+	prefix, secret, _ := strings.Cut(rest, "_")
+	if tag != apiKeyTag || prefix == "" || secret == "" {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := a.apiKeyRepo.FindByPrefix(ctx, prefix)
+	if err != nil {
+		if common.IsRecordNotFound(err) {
+			return nil, domain.ErrInvalidAPIKey
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if subtle.ConstantTimeCompare([]byte(common.HashToken(rawKey)), []byte(key.SecretHash)) != 1 {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	now := utils.NowUnixMillis()
+	if key.IsExpired(now) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err := a.checkOwner(ctx, key); err != nil {
+		return nil, err
+	}
+
+	// Usage tracking is best effort, it must not fail the request
+	if now-key.LastUsedAt >= apiKeyUsageInterval.Milliseconds() {
+		if err := a.apiKeyRepo.TouchLastUsed(ctx, key.ID, now); err == nil {
+			key.LastUsedAt = now
+		}
+	}
+	return key, nil
+}
+
+// checkOwner rejects the keys of a user who cannot act anymore, a key never outlives the access of
+// its owner
+func (a *apiKeyUsecase) checkOwner(ctx context.Context, key *domain.APIKey) error {
+	owner, err := a.userRepo.FindByID(ctx, key.OwnerID, nil)
+	if err != nil {
+		if common.IsRecordNotFound(err) {
+			return domain.ErrInvalidAPIKey
+		}
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if owner.DeletedAt > 0 || owner.IsBanned() {
+		return domain.ErrInvalidAPIKey
+	}
+	return nil
+}
+
+func (a *apiKeyUsecase) findAPIKey(ctx context.Context, id string) (*domain.APIKey, error) {
+	key, err := a.apiKeyRepo.FindByID(ctx, id)
+	if err != nil {
+		if common.IsRecordNotFound(err) {
+			return nil, domain.ErrAPIKeyNotFound
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return key, nil
+}
+
+func validateAPIKeyName(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return domain.ErrAPIKeyValidationFailed.WithError("name must be not empty")
+	}
+	if len([]rune(name)) > apiKeyNameMaxChars {
+		return domain.ErrAPIKeyValidationFailed.WithErrorf("name must be at most %d characters long", apiKeyNameMaxChars)
+	}
+	return nil
+}
+
+// validateScopes rejects unknown scopes and returns the given ones without duplicates
+func validateScopes(scopes []domain.Scope) (domain.StringSlice, error) {
+	if len(scopes) == 0 {
+		return nil, domain.ErrAPIKeyValidationFailed.WithError("at least one scope is required")
+	}
+	seen := make(map[domain.Scope]struct{}, len(scopes))
+	result := make(domain.StringSlice, 0, len(scopes))
+	for _, scope := range scopes {
+		if !scope.IsValid() {
+			return nil, domain.ErrAPIKeyValidationFailed.WithErrorf("unknown scope %s", scope)
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		result = append(result, string(scope))
+	}
+	return result, nil
+}
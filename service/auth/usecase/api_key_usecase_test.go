@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeAPIKeyRepo keeps the keys by ID, the methods the tests do not need are left to the nil interface
+type fakeAPIKeyRepo struct {
+	APIKeyRepository
+	keys    map[string]*domain.APIKey
+	touched []string
+}
+
+func newFakeAPIKeyRepo() *fakeAPIKeyRepo {
+	return &fakeAPIKeyRepo{keys: map[string]*domain.APIKey{}}
+}
+
+func (r *fakeAPIKeyRepo) Create(_ context.Context, key *domain.APIKey) error {
+	key.ID = fmt.Sprintf("key-%d", len(r.keys)+1)
+	r.keys[key.ID] = key
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) FindByID(_ context.Context, id string) (*domain.APIKey, error) {
+	if key, ok := r.keys[id]; ok {
+		return key, nil
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *fakeAPIKeyRepo) FindByPrefix(_ context.Context, prefix string) (*domain.APIKey, error) {
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *fakeAPIKeyRepo) Update(_ context.Context, key *domain.APIKey) error {
+	r.keys[key.ID] = key
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) TouchLastUsed(_ context.Context, id string, _ int64) error {
+	r.touched = append(r.touched, id)
+	return nil
+}
+
+// fakeUserRepo serves the users by ID, deleted ones included like the repository
+type fakeUserRepo struct {
+	users map[string]*domain.User
+	err   error
+}
+
+func (r *fakeUserRepo) FindByID(_ context.Context, userID string, _ *domain.FindOneOption) (*domain.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	if user, ok := r.users[userID]; ok {
+		return user, nil
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+// hasErrorID reports whether err is the given error, or nil for a nil want. Validation errors
+// carry their own message, so they are matched by ID.
+func hasErrorID(err error, want *domain.DetailedError) bool {
+	if want == nil {
+		return err == nil
+	}
+	var de *domain.DetailedError
+	return errors.As(err, &de) && de.IDField == want.IDField
+}
+
+func newAPIKeyTestPrincipal(permissions ...domain.PermissionID) *domain.Principal {
+	return &domain.Principal{UserID: "admin-1", Roles: []domain.RoleID{domain.RoleIDAdmin}, Permissions: permissions}
+}
+
+func TestCreateAPIKeyScopes(t *testing.T) {
+	admin := newAPIKeyTestPrincipal(domain.PermissionAPIKeysManage, domain.PermissionID(domain.ScopeEmailsSend), domain.PermissionID(domain.ScopeUsersRead))
+
+	tests := []struct {
+		name       string
+		principal  *domain.Principal
+		scopes     []domain.Scope
+		wantScopes domain.StringSlice
+		wantErr    *domain.DetailedError
+	}{
+		{
+			name:       "scopes the creator holds",
+			principal:  admin,
+			scopes:     []domain.Scope{domain.ScopeEmailsSend, domain.ScopeUsersRead, domain.ScopeEmailsSend},
+			wantScopes: domain.StringSlice{"emails:send", "users:read"},
+		},
+		{
+			name:      "scope beyond the permissions of the creator",
+			principal: admin,
+			scopes:    []domain.Scope{domain.ScopeEmailsSend, domain.ScopeUsersWrite},
+			wantErr:   &domain.ErrForbidden,
+		},
+		{
+			name:    "no creator",
+			scopes:  []domain.Scope{domain.ScopeEmailsSend},
+			wantErr: &domain.ErrForbidden,
+		},
+		{
+			name:      "unknown scope",
+			principal: admin,
+			scopes:    []domain.Scope{"emails:delete"},
+			wantErr:   domain.ErrAPIKeyValidationFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeAPIKeyRepo()
+			a := NewAPIKeyUsecase(repo, &fakeUserRepo{})
+
+			resp, err := a.CreateAPIKey(context.Background(), &domain.CreateAPIKeyRequest{
+				OwnerID:   "admin-1",
+				Principal: tt.principal,
+				Name:      "mailer",
+				Scopes:    tt.scopes,
+			})
+			if !hasErrorID(err, tt.wantErr) {
+				t.Fatalf("CreateAPIKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if len(repo.keys) != 0 {
+					t.Errorf("keys = %v, want none created", repo.keys)
+				}
+				return
+			}
+			if !reflect.DeepEqual(resp.APIKey.Scopes, tt.wantScopes) {
+				t.Errorf("scopes = %v, want %v", resp.APIKey.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
+
+func TestUpdateAPIKeyScopes(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeAPIKeyRepo()
+	repo.keys["key-1"] = &domain.APIKey{Name: "mailer", OwnerID: "admin-1", Scopes: domain.StringSlice{"emails:send"}}
+	repo.keys["key-1"].ID = "key-1"
+	a := NewAPIKeyUsecase(repo, &fakeUserRepo{})
+	admin := newAPIKeyTestPrincipal(domain.PermissionAPIKeysManage, domain.PermissionID(domain.ScopeEmailsSend))
+
+	_, err := a.UpdateAPIKey(ctx, &domain.UpdateAPIKeyRequest{ID: "key-1", Principal: admin, Scopes: []domain.Scope{domain.ScopeUsersWrite}})
+	if !errors.Is(err, domain.ErrForbidden) {
+		t.Fatalf("UpdateAPIKey() error = %v, want %v", err, domain.ErrForbidden)
+	}
+	if got := repo.keys["key-1"].Scopes; !reflect.DeepEqual(got, domain.StringSlice{"emails:send"}) {
+		t.Errorf("scopes = %v, want them unchanged", got)
+	}
+
+	// Renaming a key does not grant anything
+	name := "newsletter"
+	if _, err := a.UpdateAPIKey(ctx, &domain.UpdateAPIKeyRequest{ID: "key-1", Principal: newAPIKeyTestPrincipal(), Name: &name}); err != nil {
+		t.Errorf("UpdateAPIKey() error = %v", err)
+	}
+}
+
+func TestAPIKeyAuthenticate(t *testing.T) {
+	now := utils.NowUnixMillis()
+	owner := &domain.User{Status: domain.UserSTTActive}
+	owner.ID = "admin-1"
+	banned := &domain.User{Status: domain.UserSTTBanned}
+	banned.ID = "admin-1"
+	deleted := &domain.User{Status: domain.UserSTTActive}
+	deleted.ID = "admin-1"
+	deleted.DeletedAt = now
+
+	tests := []struct {
+		name    string
+		key     func(raw string) string
+		users   *fakeUserRepo
+		expires int64
+		wantErr error
+	}{
+		{name: "valid key", users: &fakeUserRepo{users: map[string]*domain.User{"admin-1": owner}}},
+		{
+			name:    "key that did not expire yet",
+			users:   &fakeUserRepo{users: map[string]*domain.User{"admin-1": owner}},
+			expires: now + time.Hour.Milliseconds(),
+		},
+		{
+			name:    "malformed key",
+			key:     func(string) string { return "not-a-key" },
+			users:   &fakeUserRepo{users: map[string]*domain.User{"admin-1": owner}},
+			wantErr: domain.ErrInvalidAPIKey,
+		},
+		{
+			name:    "unknown prefix",
+			key:     func(string) string { return "gca_unknown_secret" },
+			users:   &fakeUserRepo{users: map[string]*domain.User{"admin-1": owner}},
+			wantErr: domain.ErrInvalidAPIKey,
+		},
+		{
+			name:    "wrong secret",
+			key:     func(raw string) string { return raw + "x" },
+			users:   &fakeUserRepo{users: map[string]*domain.User{"admin-1": owner}},
+			wantErr: domain.ErrInvalidAPIKey,
+		},
+		{
+			name:    "expired key",
+			users:   &fakeUserRepo{users: map[string]*domain.User{"admin-1": owner}},
+			expires: now - 1,
+			wantErr: domain.ErrInvalidAPIKey,
+		},
+		{name: "banned owner", users: &fakeUserRepo{users: map[string]*domain.User{"admin-1": banned}}, wantErr: domain.ErrInvalidAPIKey},
+		{name: "deleted owner", users: &fakeUserRepo{users: map[string]*domain.User{"admin-1": deleted}}, wantErr: domain.ErrInvalidAPIKey},
+		{name: "missing owner", users: &fakeUserRepo{}, wantErr: domain.ErrInvalidAPIKey},
+		{name: "owner lookup failure", users: &fakeUserRepo{err: errors.New("database unavailable")}, wantErr: domain.ErrInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			repo := newFakeAPIKeyRepo()
+			a := NewAPIKeyUsecase(repo, tt.users)
+			resp, err := NewAPIKeyUsecase(repo, &fakeUserRepo{}).CreateAPIKey(ctx, &domain.CreateAPIKeyRequest{
+				OwnerID:   "admin-1",
+				Principal: newAPIKeyTestPrincipal(domain.PermissionID(domain.ScopeEmailsSend)),
+				Name:      "mailer",
+				Scopes:    []domain.Scope{domain.ScopeEmailsSend},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Set after the creation, which refuses expiries in the past
+			resp.APIKey.ExpiresAt = tt.expires
+
+			raw := resp.Key
+			if tt.key != nil {
+				raw = tt.key(raw)
+			}
+			key, err := a.Authenticate(ctx, raw)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Authenticate() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (key.ID != resp.APIKey.ID || len(repo.touched) != 1) {
+				t.Errorf("Authenticate() = %v, touched %v, want the key with its use recorded", key, repo.touched)
+			}
+		})
+	}
+}
@@ -2,19 +2,38 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go-clean-arch/common"
 	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+	"go-clean-arch/service/auth/identity"
+	"net/url"
+	"strings"
 	"time"
 )
 
+type Cache interface {
+	Delete(ctx context.Context, key string) error
+	Increment(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	GetJSON(ctx context.Context, key string, dest interface{}) error
+}
+
 type Hasher interface {
 	Hash(password string) (string, error)
 	Compare(hashed, password string) bool
+	NeedsRehash(hashed string) bool
+}
+
+type SecretCipher interface {
+	Encrypt(plaintext string) (string, error)
+	Decrypt(value string) (string, error)
 }
 
 type JWTProvider interface {
 	Generate(tokenType domain.TokenType, userID string, sessionID string) (string, error)
+	GenerateAccessToken(userID, sessionID, organizationID string) (string, error)
 	Verify(tokenType domain.TokenType, tokenStr string) (*domain.JwtClaims, error)
 }
 
@@ -26,41 +45,191 @@ type UserSessionRepository interface {
 	FindMany(ctx context.Context, filter *domain.UserSessionFilter, option *domain.FindManyOption) ([]*domain.UserSession, error)
 	FindPage(ctx context.Context, filter *domain.UserSessionFilter, option *domain.FindPageOption) ([]*domain.UserSession, *domain.Pagination, error)
 	Update(ctx context.Context, session *domain.UserSession) error
-	InvalidateRefreshToken(ctx context.Context, sessionID string) error
+	RotateRefreshToken(ctx context.Context, session *domain.UserSession, oldRefreshToken string) (bool, error)
+	RevokeAllByUserID(ctx context.Context, userID string) error
+	RevokeAllByUserIDExcept(ctx context.Context, userID, exceptSessionID string) (int64, error)
+	Revoke(ctx context.Context, sessionID string) error
 	Delete(ctx context.Context, sessionID string) error
 	Count(ctx context.Context, filter *domain.UserSessionFilter) (int64, error)
 }
 
+type TokenRevocationList interface {
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	RevokeSession(ctx context.Context, sessionID string) error
+	IsSessionRevoked(ctx context.Context, session *domain.UserSession) (bool, error)
+}
+
+type RotatedRefreshTokenRepository interface {
+	Create(ctx context.Context, token *domain.RotatedRefreshToken) error
+	FindByTokenHash(ctx context.Context, tokenHash string) (*domain.RotatedRefreshToken, error)
+}
+
+type VerificationTokenRepository interface {
+	Create(ctx context.Context, token *domain.VerificationToken) error
+	FindByTokenHash(ctx context.Context, purpose domain.VerificationPurpose, tokenHash string) (*domain.VerificationToken, error)
+	MarkUsed(ctx context.Context, tokenID string) (bool, error)
+	InvalidateByUser(ctx context.Context, userID string, purpose domain.VerificationPurpose) error
+}
+
+type UserMFARepository interface {
+	Create(ctx context.Context, mfa *domain.UserMFA) error
+	FindByUserID(ctx context.Context, userID string) (*domain.UserMFA, error)
+	Update(ctx context.Context, mfa *domain.UserMFA) error
+	UseStep(ctx context.Context, id string, step int64) (bool, error)
+}
+
+type MFARecoveryCodeRepository interface {
+	ReplaceForUser(ctx context.Context, userID string, codes []*domain.MFARecoveryCode) error
+	FindUnusedByHash(ctx context.Context, userID, codeHash string) (*domain.MFARecoveryCode, error)
+	MarkUsed(ctx context.Context, codeID string) (bool, error)
+	DeleteByUserID(ctx context.Context, userID string) error
+}
+
 type UserClient interface {
 	Create(ctx context.Context, req *domain.UserCreateRequest) (*domain.User, error)
 	FindOne(ctx context.Context, filter *domain.UserFilter, option *domain.FindOneOption) (*domain.User, error)
+	Update(ctx context.Context, userID string, req *domain.UserUpdateRequest) (*domain.User, error)
+	UpdatePassword(ctx context.Context, userID string, newPassword string) error
+	CheckPassword(ctx context.Context, userID string, newPassword string) error
+	RehashPassword(ctx context.Context, userID string, password string) error
 }
 
 type EmailClient interface {
 	SendEmailWithTemplate(ctx context.Context, req *domain.SendEmailWithTemplateRequest) (*domain.EmailLog, error)
 }
 
+type OTPUsecase interface {
+	Generate(ctx context.Context, req *domain.GenerateOTPRequest) (*domain.OTPCode, error)
+	Send(ctx context.Context, req *domain.SendOTPRequest) (*domain.OTPSendResponse, error)
+	Verify(ctx context.Context, req *domain.VerifyOTPRequest) error
+}
+
+type ExternalIdentityRepository interface {
+	Create(ctx context.Context, identity *domain.ExternalIdentity) error
+	FindBySubject(ctx context.Context, provider, subject string) (*domain.ExternalIdentity, error)
+}
+
+type IdentityProvider interface {
+	Name() string
+	AllowsRedirectURI(uri string) bool
+	DefaultRedirectURI() string
+	AuthCodeURL(req identity.AuthCodeRequest) string
+	Exchange(ctx context.Context, req identity.ExchangeRequest) (*domain.ExternalProfile, error)
+}
+
+type AppConfig interface {
+	Name() string
+	EmailVerificationTokenExpiresIn() time.Duration
+	PasswordResetTokenExpiresIn() time.Duration
+	MFAChallengeExpiresIn() time.Duration
+	LoginMaxFailedAttempts() int
+	LoginIPMaxFailedAttempts() int
+	LoginFailureWindow() time.Duration
+	LoginFailureBaseDelay() time.Duration
+	LoginLockoutDuration() time.Duration
+	MagicLinkExpiresIn() time.Duration
+	MagicLinkMaxRequests() int
+	MagicLinkRequestWindow() time.Duration
+	MagicLinkRequireSameDevice() bool
+	RefreshTokenExpiresIn() time.Duration
+	RefreshTokenReuseGracePeriod() time.Duration
+	SessionMaxLifetime() time.Duration
+	SessionLimitPerUser() int
+	UserSessionLimitEnabled() bool
+	SessionLimitPolicy() string
+}
+
+type ServerConfig interface {
+	Domain() string
+}
+
+type OAuthConfig interface {
+	StateExpiresIn() time.Duration
+}
+
 type authUsecase struct {
-	sessionRepo    UserSessionRepository
-	userClient     UserClient
-	emailRPCClient EmailClient
-	jwtProvider    JWTProvider
-	hasher         Hasher
+	sessionRepo            UserSessionRepository
+	rotatedTokenRepo       RotatedRefreshTokenRepository
+	verificationTokenRepo  VerificationTokenRepository
+	userMFARepo            UserMFARepository
+	recoveryCodeRepo       MFARecoveryCodeRepository
+	externalIdentityRepo   ExternalIdentityRepository
+	identityProviders      map[string]IdentityProvider
+	webAuthnCredentialRepo WebAuthnCredentialRepository
+	relyingParty           RelyingParty
+	loginRiskDetector      LoginRiskDetector
+	userClient             UserClient
+	emailRPCClient         EmailClient
+	otpUsecase             OTPUsecase
+	securityEvents         domain.SecurityEventEmitter
+	revocationList         TokenRevocationList
+	cache                  Cache
+	jwtProvider            JWTProvider
+	hasher                 Hasher
+	secretCipher           SecretCipher
+	appCfg                 AppConfig
+	srvCfg                 ServerConfig
+	oauthCfg               OAuthConfig
+	webAuthnCfg            WebAuthnConfig
+	loginAlertCfg          LoginAlertConfig
 }
 
 func NewAuthUsecase(
 	sessionRepo UserSessionRepository,
+	rotatedTokenRepo RotatedRefreshTokenRepository,
+	verificationTokenRepo VerificationTokenRepository,
+	userMFARepo UserMFARepository,
+	recoveryCodeRepo MFARecoveryCodeRepository,
+	externalIdentityRepo ExternalIdentityRepository,
+	identityProviders []IdentityProvider,
+	webAuthnCredentialRepo WebAuthnCredentialRepository,
+	relyingParty RelyingParty,
+	loginRiskDetector LoginRiskDetector,
 	userClient UserClient,
 	emailRPCClient EmailClient,
+	otpUsecase OTPUsecase,
+	securityEvents domain.SecurityEventEmitter,
+	revocationList TokenRevocationList,
+	cache Cache,
 	jwtProvider JWTProvider,
 	hasher Hasher,
+	secretCipher SecretCipher,
+	appCfg AppConfig,
+	srvCfg ServerConfig,
+	oauthCfg OAuthConfig,
+	webAuthnCfg WebAuthnConfig,
+	loginAlertCfg LoginAlertConfig,
 ) domain.AuthUsecase {
+	providers := make(map[string]IdentityProvider, len(identityProviders))
+	for _, provider := range identityProviders {
+		providers[provider.Name()] = provider
+	}
+
 	return &authUsecase{
-		sessionRepo:    sessionRepo,
-		userClient:     userClient,
-		emailRPCClient: emailRPCClient,
-		jwtProvider:    jwtProvider,
-		hasher:         hasher,
+		sessionRepo:            sessionRepo,
+		rotatedTokenRepo:       rotatedTokenRepo,
+		verificationTokenRepo:  verificationTokenRepo,
+		userMFARepo:            userMFARepo,
+		recoveryCodeRepo:       recoveryCodeRepo,
+		externalIdentityRepo:   externalIdentityRepo,
+		identityProviders:      providers,
+		webAuthnCredentialRepo: webAuthnCredentialRepo,
+		relyingParty:           relyingParty,
+		loginRiskDetector:      loginRiskDetector,
+		userClient:             userClient,
+		emailRPCClient:         emailRPCClient,
+		otpUsecase:             otpUsecase,
+		securityEvents:         securityEvents,
+		revocationList:         revocationList,
+		cache:                  cache,
+		jwtProvider:            jwtProvider,
+		hasher:                 hasher,
+		secretCipher:           secretCipher,
+		appCfg:                 appCfg,
+		srvCfg:                 srvCfg,
+		oauthCfg:               oauthCfg,
+		webAuthnCfg:            webAuthnCfg,
+		loginAlertCfg:          loginAlertCfg,
 	}
 }
 
@@ -79,85 +248,160 @@ func (a *authUsecase) Register(ctx context.Context, req *domain.RegisterRequest)
 		return nil, domain.ErrUserCreationFailed.WithWrap(err)
 	}
 
-	// Generate refresh token
-	refreshToken, err := a.jwtProvider.Generate(domain.TokenTypeRefresh, "", "")
+	resp, err := a.createSession(ctx, user, req.IPAddress, req.UserAgent)
 	if err != nil {
-		return nil, domain.ErrInternalServerError.WithWrap(err)
+		return nil, err
 	}
 
-	session := &domain.UserSession{
-		UserID:       user.ID,
-		RefreshToken: refreshToken,
-		IPAddress:    req.IPAddress,
-		UserAgent:    req.UserAgent,
-		Active:       true,
+	// Send verification email asynchronously - don't block registration if email fails,
+	// the user can request a new one through the send-verification-email endpoint
+	go func() {
+		_ = a.sendVerificationEmail(context.Background(), user)
+	}()
+
+	return resp, nil
+}
+
+// isUserNotFound reports whether the user service found no user, as opposed to failing to look
+func isUserNotFound(err error) bool {
+	if errors.Is(err, domain.ErrRecordNotFound) {
+		return true
 	}
-	if err := a.sessionRepo.Create(ctx, session); err != nil {
-		return nil, domain.ErrCannotCreateSession.WithWrap(err)
+	de, ok := common.IsDetailError(err)
+	return ok && errors.Is(de, domain.ErrUserNotFound)
+}
+
+// rehashPasswordTimeout bounds the background rehash of a password after a login
+const rehashPasswordTimeout = 10 * time.Second
+
+func (a *authUsecase) Login(ctx context.Context, req *domain.LoginRequest) (*domain.LoginResponse, error) {
+	if req.IPAddress != "" {
+		if err := a.checkLoginLock(ctx, loginScopeIP, req.IPAddress); err != nil {
+			return nil, err
+		}
 	}
 
-	accessToken, err := a.jwtProvider.Generate(domain.TokenTypeAccess, user.ID, session.ID)
+	var identifier string
+	filter := &domain.UserFilter{}
+	switch {
+	case req.Email != "" && req.Username != "":
+		return nil, domain.ErrBadRequest.WithError("only one of email and username must be set")
+	case req.Email != "":
+		email := domain.NormalizeEmail(req.Email)
+		filter.Email = &email
+		identifier = "email:" + email
+	case req.Username != "":
+		username := domain.NormalizeUsername(req.Username)
+		filter.Username = &username
+		identifier = "username:" + strings.ToLower(username)
+	default:
+		return nil, domain.ErrBadRequest.WithError("email or username must be not empty")
+	}
+
+	user, err := a.userClient.FindOne(ctx, filter, &domain.FindOneOption{})
 	if err != nil {
-		return nil, domain.ErrInternalServerError.WithWrap(err)
+		// Only an unknown identifier counts as a failed login, an outage must not lock accounts
+		if !isUserNotFound(err) {
+			return nil, domain.ErrInternalServerError.WithWrap(err)
+		}
+		user = nil
 	}
 
-	// Send verification email after successful registration
-	verificationToken, err := a.jwtProvider.Generate(domain.TokenTypeAccess, user.ID, "")
-	if err != nil {
-		return &domain.AuthResponse{
-			User:         user,
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
-		}, nil
+	// Unknown identifiers go through the same lock, delay and failure counting as accounts
+	subject := loginSubject(user, identifier)
+	if err := a.checkLoginLock(ctx, loginScopeUser, subject); err != nil {
+		return nil, err
+	}
+	if err := a.checkLoginDelay(ctx, subject); err != nil {
+		return nil, err
 	}
 
-	// Prepare template data for verification email
-	templateData := map[string]any{
-		"app_name":         "Go Clean Arch",
-		"user_name":        user.FirstName + " " + user.LastName,
-		"verification_url": "https://your-domain.com/verify-email?token=" + verificationToken,
-		"user_email":       user.Email,
-		"current_time":     time.Now().Format("2006-01-02 15:04:05"),
+	if user == nil || !a.hasher.Compare(user.Password, req.Password) {
+		return nil, a.recordLoginFailure(ctx, user, subject, req.IPAddress, req.UserAgent)
+	}
+	if err := a.clearLoginFailures(ctx, user.ID); err != nil {
+		return nil, err
 	}
 
-	// Send verification email using email RPC client
-	emailReq := &domain.SendEmailWithTemplateRequest{
-		To:           []string{user.Email},
-		TemplateCode: domain.EmailCodeVerification,
-		Locale:       "en", // Default locale
-		Data:         templateData,
-		RequestID:    common.GenerateUUID(),
+	resp, err := a.completeLogin(ctx, user, false, req.IPAddress, req.UserAgent)
+	if err != nil {
+		return nil, err
 	}
 
-	// Send email asynchronously - don't block registration if email fails
-	go func() {
-		if _, err := a.emailRPCClient.SendEmailWithTemplate(context.Background(), emailReq); err != nil {
-			// Log error but don't fail the registration process
-			// In a production environment, you might want to queue this for retry
-		}
-	}()
+	// Move the password to the current hashing algorithm and cost while its plain text is at hand.
+	// A failure is retried on the next login, so it does not block this one
+	if a.hasher.NeedsRehash(user.Password) {
+		rehashCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rehashPasswordTimeout)
+		go func() {
+			defer cancel()
+			_ = a.userClient.RehashPassword(rehashCtx, user.ID, req.Password)
+		}()
+	}
 
-	return &domain.AuthResponse{
-		User:         user,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	}, nil
+	return resp, nil
 }
 
-func (a *authUsecase) Login(ctx context.Context, req *domain.LoginRequest) (*domain.AuthResponse, error) {
-	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
-		Email: &req.Email,
-	}, &domain.FindOneOption{})
-	if err != nil || user == nil {
-		return nil, domain.ErrInvalidCredentials
+// completeLogin starts a session for a user whose first factor was verified, or returns an MFA
+// challenge when the account has two-factor authentication enabled or registered passkeys. Every
+// login ends here, mfaVerified is set by the logins which already proved a second factor.
+func (a *authUsecase) completeLogin(ctx context.Context, user *domain.User, mfaVerified bool, ipAddress, userAgent string) (*domain.LoginResponse, error) {
+	if user.Status != domain.UserSTTActive {
+		return nil, domain.ErrUserInactive
+	}
+	// Set when the user reported a sign-in as not theirs, or when the password was chosen by someone
+	// who may not own the account. No login method works until the password is reset by email.
+	if user.PasswordResetRequired {
+		return nil, domain.ErrPasswordResetRequired
+	}
+	if mfaVerified {
+		resp, err := a.createSession(ctx, user, ipAddress, userAgent)
+		if err != nil {
+			return nil, err
+		}
+		return &domain.LoginResponse{AuthResponse: resp}, nil
 	}
 
-	if !a.hasher.Compare(user.Password, req.Password) {
-		return nil, domain.ErrInvalidCredentials
+	var methods []domain.MFAMethod
+	mfa, err := a.userMFARepo.FindByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if mfa != nil && mfa.Enabled {
+		methods = append(methods, domain.MFAMethodTOTP)
+	}
+	passkeys, err := a.webAuthnCredentialRepo.CountByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if passkeys > 0 {
+		methods = append(methods, domain.MFAMethodPasskey)
 	}
 
-	if user.Status != domain.UserSTTActive {
-		return nil, domain.ErrUserInactive
+	// Accounts with a second factor only get a challenge token at this point
+	if len(methods) > 0 {
+		rawToken, token, err := a.issueVerificationToken(ctx, user.ID, domain.VerificationPurposeMFAChallenge, a.appCfg.MFAChallengeExpiresIn())
+		if err != nil {
+			return nil, err
+		}
+		return &domain.LoginResponse{
+			MFARequired:       true,
+			MFAToken:          rawToken,
+			MFATokenExpiresAt: token.ExpiresAt,
+			MFAMethods:        methods,
+		}, nil
+	}
+
+	resp, err := a.createSession(ctx, user, ipAddress, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.LoginResponse{AuthResponse: resp}, nil
+}
+
+// createSession starts a new session for the user and issues its access and refresh tokens.
+func (a *authUsecase) createSession(ctx context.Context, user *domain.User, ipAddress, userAgent string) (*domain.AuthResponse, error) {
+	if err := a.enforceSessionLimit(ctx, user.ID); err != nil {
+		return nil, err
 	}
 
 	// Generate refresh token
@@ -166,12 +410,16 @@ func (a *authUsecase) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 		return nil, domain.ErrInternalServerError.WithWrap(err)
 	}
 
+	// Only the hash is stored, a leaked session table cannot be used to refresh tokens
+	now := time.Now()
 	session := &domain.UserSession{
-		UserID:       user.ID,
-		RefreshToken: refreshToken,
-		IPAddress:    req.IPAddress,
-		UserAgent:    req.UserAgent,
-		Active:       true,
+		UserID:         user.ID,
+		RefreshToken:   common.HashToken(refreshToken),
+		IPAddress:      ipAddress,
+		UserAgent:      userAgent,
+		Active:         true,
+		ExpiresAt:      now.Add(a.appCfg.RefreshTokenExpiresIn()).UnixMilli(),
+		LastActivityAt: now.UnixMilli(),
 	}
 	if err := a.sessionRepo.Create(ctx, session); err != nil {
 		return nil, domain.ErrCannotCreateSession.WithWrap(err)
@@ -182,43 +430,135 @@ func (a *authUsecase) Login(ctx context.Context, req *domain.LoginRequest) (*dom
 		return nil, domain.ErrInternalServerError.WithWrap(err)
 	}
 
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventLoginSucceeded,
+		UserID:     user.ID,
+		SessionID:  session.ID,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
+	// The alert is best effort, a failure to send it does not fail the login
+	go func() {
+		_ = a.alertNewLogin(context.Background(), user, session)
+	}()
+
 	return &domain.AuthResponse{
-		User:         user,
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
+		User:                  user,
+		AccessToken:           accessToken,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
 	}, nil
 }
 
-func (a *authUsecase) Logout(ctx context.Context, sessionID string) error {
-	session, err := a.sessionRepo.FindByID(ctx, sessionID, nil)
+// enforceSessionLimit makes room for a new session when the per-user limit is enabled, either by
+// revoking the oldest active sessions or by rejecting the login, depending on the configured policy.
+func (a *authUsecase) enforceSessionLimit(ctx context.Context, userID string) error {
+	if !a.appCfg.UserSessionLimitEnabled() {
+		return nil
+	}
+
+	active := true
+	now := utils.NowUnixMillis()
+	sessions, err := a.sessionRepo.FindMany(ctx, &domain.UserSessionFilter{
+		UserID:  &userID,
+		Active:  &active,
+		ValidAt: &now,
+	}, &domain.FindManyOption{
+		Sort: []string{"created_at ASC"},
+	})
+	if err != nil {
+		return domain.ErrSessionFindFailed.WithWrap(err)
+	}
+
+	limit := a.appCfg.SessionLimitPerUser()
+	if len(sessions) < limit {
+		return nil
+	}
+
+	if domain.SessionLimitPolicy(a.appCfg.SessionLimitPolicy()) == domain.SessionLimitPolicyReject {
+		return domain.ErrSessionLimitReached
+	}
+
+	// Keep the newest limit-1 sessions so the new one fits
+	for _, session := range sessions[:len(sessions)-limit+1] {
+		if err := a.revokeSession(ctx, session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeSession ends a session and revokes the access tokens already issued for it.
+func (a *authUsecase) revokeSession(ctx context.Context, sessionID string) error {
+	if err := a.sessionRepo.Revoke(ctx, sessionID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if err := a.revocationList.RevokeSession(ctx, sessionID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return nil
+}
+
+func (a *authUsecase) Logout(ctx context.Context, req *domain.LogoutRequest) error {
+	session, err := a.sessionRepo.FindByID(ctx, req.SessionID, nil)
 	if err != nil || session == nil {
 		return domain.ErrSessionExpired.WithWrap(err)
 	}
-	if !session.Active {
-		return nil // Already logged out
+	if session.Active {
+		if err := a.revokeSession(ctx, session.ID); err != nil {
+			return err
+		}
 	}
-	session.Active = false
-	if err := a.sessionRepo.Update(ctx, session); err != nil {
+	if err := a.revocationList.RevokeToken(ctx, req.TokenID, req.TokenExpiresAt); err != nil {
 		return domain.ErrInternalServerError.WithWrap(err)
 	}
+
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventLogout,
+		UserID:     session.UserID,
+		SessionID:  session.ID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
 	return nil
 }
 
 func (a *authUsecase) RefreshToken(ctx context.Context, req *domain.RefreshTokenRequest) (*domain.AuthResponse, error) {
+	tokenHash := common.HashToken(req.RefreshToken)
+
 	// Find session by refresh token
-	session, err := a.sessionRepo.FindByRefreshToken(ctx, req.RefreshToken, nil)
-	if err != nil || session == nil {
-		return nil, domain.ErrInvalidToken.WithError("invalid refresh token")
+	session, err := a.sessionRepo.FindByRefreshToken(ctx, tokenHash, nil)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, a.handleRotatedRefreshToken(ctx, tokenHash, req)
+		}
+		return nil, domain.ErrSessionFindFailed.WithWrap(err)
 	}
 
 	if !session.IsActive() {
 		return nil, domain.ErrSessionExpired
 	}
 
-	// Invalidate the current refresh token (one-time use)
-	if err := a.sessionRepo.InvalidateRefreshToken(ctx, session.ID); err != nil {
+	// Sessions started before a password change or a ban cannot issue new tokens
+	revoked, err := a.revocationList.IsSessionRevoked(ctx, session)
+	if err != nil {
 		return nil, domain.ErrInternalServerError.WithWrap(err)
 	}
+	if revoked {
+		if err := a.revokeSession(ctx, session.ID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrSessionExpired
+	}
+
+	// Sessions cannot be extended past their absolute lifetime
+	maxExpiresAt := time.UnixMilli(session.CreatedAt).Add(a.appCfg.SessionMaxLifetime()).UnixMilli()
+	if maxExpiresAt <= utils.NowUnixMillis() {
+		return nil, domain.ErrSessionExpired
+	}
 
 	// Get user information
 	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
@@ -238,8 +578,9 @@ func (a *authUsecase) RefreshToken(ctx context.Context, req *domain.RefreshToken
 		return nil, domain.ErrInternalServerError.WithWrap(err)
 	}
 
-	// Update session with new refresh token and client info
-	session.RefreshToken = newRefreshToken
+	// Rotate the refresh token (one-time use) and update client info, sliding the expiry forward
+	session.RefreshToken = common.HashToken(newRefreshToken)
+	session.ExpiresAt = min(time.Now().Add(a.appCfg.RefreshTokenExpiresIn()).UnixMilli(), maxExpiresAt)
 	if req.IPAddress != "" {
 		session.IPAddress = req.IPAddress
 	}
@@ -247,23 +588,85 @@ func (a *authUsecase) RefreshToken(ctx context.Context, req *domain.RefreshToken
 		session.UserAgent = req.UserAgent
 	}
 
-	if err := a.sessionRepo.Update(ctx, session); err != nil {
+	rotated, err := a.sessionRepo.RotateRefreshToken(ctx, session, tokenHash)
+	if err != nil {
 		return nil, domain.ErrInternalServerError.WithWrap(err)
 	}
+	if !rotated {
+		// A concurrent request of the same client won the rotation
+		return nil, domain.ErrRefreshTokenAlreadyRotated
+	}
 
-	// Generate new access token
-	accessToken, err := a.jwtProvider.Generate(domain.TokenTypeAccess, user.ID, session.ID)
+	// Keep the replaced token in the session lineage so a later reuse can be detected
+	if err := a.rotatedTokenRepo.Create(ctx, &domain.RotatedRefreshToken{
+		SessionID: session.ID,
+		UserID:    session.UserID,
+		TokenHash: tokenHash,
+		RotatedAt: utils.NowUnixMillis(),
+	}); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	// Generate new access token, in the organization the session switched to
+	accessToken, err := a.jwtProvider.GenerateAccessToken(user.ID, session.ID, session.OrganizationID)
 	if err != nil {
 		return nil, domain.ErrInternalServerError.WithWrap(err)
 	}
 
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventTokenRefreshed,
+		UserID:     user.ID,
+		SessionID:  session.ID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
 	return &domain.AuthResponse{
-		User:         user,
-		AccessToken:  accessToken,
-		RefreshToken: newRefreshToken,
+		User:                  user,
+		AccessToken:           accessToken,
+		RefreshToken:          newRefreshToken,
+		RefreshTokenExpiresAt: session.ExpiresAt,
 	}, nil
 }
 
+// handleRotatedRefreshToken decides what to answer for a refresh token that is not the current
+// token of any session. A token that was rotated moments ago is most likely a concurrent refresh
+// of the same client and only needs a retry. Any older token of the lineage being presented again
+// means it was copied, so the whole session is revoked and a security event is emitted.
+func (a *authUsecase) handleRotatedRefreshToken(ctx context.Context, tokenHash string, req *domain.RefreshTokenRequest) error {
+	rotated, err := a.rotatedTokenRepo.FindByTokenHash(ctx, tokenHash)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return domain.ErrInvalidToken.WithError("invalid refresh token")
+		}
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	gracePeriod := a.appCfg.RefreshTokenReuseGracePeriod()
+	if time.Since(time.UnixMilli(rotated.RotatedAt)) <= gracePeriod {
+		return domain.ErrRefreshTokenAlreadyRotated
+	}
+
+	if err := a.revokeSession(ctx, rotated.SessionID); err != nil {
+		return err
+	}
+
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:      domain.SecurityEventRefreshTokenReuse,
+		UserID:    rotated.UserID,
+		SessionID: rotated.SessionID,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+		Metadata: map[string]any{
+			"rotated_at": rotated.RotatedAt,
+		},
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
+	return domain.ErrRefreshTokenReused
+}
+
 func (a *authUsecase) SendVerificationEmail(ctx context.Context, req *domain.SendVerificationEmailRequest) error {
 	// Get user information
 	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
@@ -273,12 +676,145 @@ func (a *authUsecase) SendVerificationEmail(ctx context.Context, req *domain.Sen
 		return domain.ErrUserNotFound.WithWrap(err)
 	}
 
+	if user.Status != domain.UserSTTWaitingVerify {
+		return domain.ErrEmailAlreadyVerified
+	}
+
+	return a.sendVerificationEmail(ctx, user)
+}
+
+// SendVerificationCode delivers only the 6 digit code through the OTP senders, VerifyEmail
+// accepts it like the emailed one.
+func (a *authUsecase) SendVerificationCode(ctx context.Context, req *domain.SendVerificationCodeRequest) (*domain.OTPSendResponse, error) {
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		ID: &req.UserID,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound.WithWrap(err)
+	}
+
+	if user.Status != domain.UserSTTWaitingVerify {
+		return nil, domain.ErrEmailAlreadyVerified
+	}
+
+	method := req.DeliveryMethod
+	if method == "" {
+		method = domain.OTPDeliveryEmail
+	}
+	return a.otpUsecase.Send(ctx, &domain.SendOTPRequest{
+		Action:         domain.ActionVerifyEmail,
+		Recipient:      user.Email,
+		DeliveryMethod: method,
+	})
+}
+
+func (a *authUsecase) VerifyEmail(ctx context.Context, req *domain.VerifyEmailRequest) error {
+	email := domain.NormalizeEmail(req.Email)
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		Email: &email,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return domain.ErrInvalidVerificationToken
+	}
+
+	// The emailed 6 digit code and the link token are both accepted
+	if isOTPCode(req.Code) {
+		if err := a.otpUsecase.Verify(ctx, &domain.VerifyOTPRequest{
+			Action:    domain.ActionVerifyEmail,
+			Recipient: user.Email,
+			Code:      req.Code,
+		}); err != nil {
+			return err
+		}
+		if err := a.verificationTokenRepo.InvalidateByUser(ctx, user.ID, domain.VerificationPurposeEmail); err != nil {
+			return domain.ErrInternalServerError.WithWrap(err)
+		}
+	} else if err := a.consumeEmailVerificationToken(ctx, user.ID, req.Code); err != nil {
+		return err
+	}
+
+	switch user.Status {
+	case domain.UserSTTActive:
+		return nil
+	case domain.UserSTTBanned:
+		return domain.ErrAccountBanned
+	}
+
+	status := domain.UserSTTActive
+	if _, err := a.userClient.Update(ctx, user.ID, &domain.UserUpdateRequest{
+		Status: &status,
+	}); err != nil {
+		if de, ok := common.IsDetailError(err); ok {
+			return de
+		}
+		return domain.ErrUserUpdateFailed.WithWrap(err)
+	}
+
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventEmailVerified,
+		UserID:     user.ID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
+	return nil
+}
+
+// consumeEmailVerificationToken redeems a verification link token issued to the user.
+func (a *authUsecase) consumeEmailVerificationToken(ctx context.Context, userID, rawToken string) error {
+	token, err := a.verificationTokenRepo.FindByTokenHash(ctx, domain.VerificationPurposeEmail, common.HashToken(rawToken))
+	if err != nil || token == nil {
+		return domain.ErrInvalidVerificationToken
+	}
+	if token.UserID != userID || !token.IsUsable() {
+		return domain.ErrInvalidVerificationToken
+	}
+
+	// Consume the token before activating the account so it can only be redeemed once
+	consumed, err := a.verificationTokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !consumed {
+		return domain.ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+// sendVerificationEmail issues a new one-time code and email verification token for the user,
+// revoking any previously issued ones, and mails both the code and the verification link.
+func (a *authUsecase) sendVerificationEmail(ctx context.Context, user *domain.User) error {
+	// The OTP backoff also throttles verification email resends
+	otp, err := a.otpUsecase.Generate(ctx, &domain.GenerateOTPRequest{
+		Action:    domain.ActionVerifyEmail,
+		Recipient: user.Email,
+	})
+	if err != nil {
+		return err
+	}
+
+	rawToken, token, err := a.issueVerificationToken(ctx, user.ID, domain.VerificationPurposeEmail, a.appCfg.EmailVerificationTokenExpiresIn())
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("email", user.Email)
+	query.Set("code", rawToken)
+	verificationURL := common.JoinURLPath(a.srvCfg.Domain(), "verify-email") + "?" + query.Encode()
+
 	// Prepare template data
-	templateData := map[string]interface{}{
-		"user_name":        user.FirstName + " " + user.LastName,
-		"verification_url": "https://your-domain.com/verify-email?token=" + req.Token,
-		"user_email":       user.Email,
-		"current_time":     time.Now().Format("2006-01-02 15:04:05"),
+	templateData := map[string]any{
+		"app_name":          a.appCfg.Name(),
+		"user_name":         user.FirstName + " " + user.LastName,
+		"verification_code": otp.Code,
+		"verification_url":  verificationURL,
+		"code_expires_in":   utils.FormatDuration(otp.ExpiresIn),
+		"expires_in":        utils.FormatDuration(a.appCfg.EmailVerificationTokenExpiresIn()),
+		"user_email":        user.Email,
+		"current_time":      time.Now().Format("2006-01-02 15:04:05"),
+		"current_year":      time.Now().Year(),
 	}
 
 	// Send verification email using email template
@@ -287,22 +823,183 @@ func (a *authUsecase) SendVerificationEmail(ctx context.Context, req *domain.Sen
 		TemplateCode: domain.EmailCodeVerification,
 		Locale:       "en", // Default locale
 		Data:         templateData,
-		RequestID:    fmt.Sprintf("auth_verify_%s", req.UserID),
+		RequestID:    fmt.Sprintf("auth_verify_%s", token.ID),
 	}
 
-	_, err = a.emailRPCClient.SendEmailWithTemplate(ctx, emailReq)
-	if err != nil {
+	if _, err := a.emailRPCClient.SendEmailWithTemplate(ctx, emailReq); err != nil {
 		return domain.ErrEmailSendFailed.WithError("failed to send verification email").WithWrap(err)
 	}
 
 	return nil
 }
 
-func (a *authUsecase) VerifyEmail(ctx context.Context, req *domain.VerifyEmailRequest) error {
-	// TODO: Implement email verification logic
-	// This would typically:
-	// 1. Validate the verification code/token
-	// 2. Update user's email_verified status
-	// 3. Invalidate the verification token
-	return domain.ErrNotImplemented
+func (a *authUsecase) ForgotPassword(ctx context.Context, req *domain.ForgotPasswordRequest) error {
+	email := domain.NormalizeEmail(req.Email)
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		Email: &email,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil || user.IsBanned() {
+		// Do not reveal whether the email is registered
+		return nil
+	}
+
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventPasswordResetRequested,
+		UserID:     user.ID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
+	// Send asynchronously so the response time does not depend on whether the email exists
+	go func() {
+		_ = a.sendPasswordResetEmail(context.Background(), user, req.IPAddress)
+	}()
+
+	return nil
+}
+
+func (a *authUsecase) ResetPassword(ctx context.Context, req *domain.ResetPasswordRequest) error {
+	token, err := a.verificationTokenRepo.FindByTokenHash(ctx, domain.VerificationPurposePasswordReset, common.HashToken(req.Token))
+	if err != nil || token == nil || !token.IsUsable() {
+		return domain.ErrInvalidPasswordResetToken
+	}
+
+	// The password is checked before the token is consumed, so a password rejected by the password
+	// policy or the password history does not burn the reset link
+	if err := a.userClient.CheckPassword(ctx, token.UserID, req.NewPassword); err != nil {
+		if de, ok := common.IsDetailError(err); ok {
+			return de
+		}
+		return domain.ErrUserUpdateFailed.WithWrap(err)
+	}
+
+	// Only the request that consumes the token sets the password, a concurrent one with the same
+	// link is rejected
+	consumed, err := a.verificationTokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !consumed {
+		return domain.ErrInvalidPasswordResetToken
+	}
+
+	if err := a.userClient.UpdatePassword(ctx, token.UserID, req.NewPassword); err != nil {
+		if de, ok := common.IsDetailError(err); ok {
+			return de
+		}
+		return domain.ErrUserUpdateFailed.WithWrap(err)
+	}
+
+	// Sign out everywhere, the old password may have been compromised
+	if err := a.sessionRepo.RevokeAllByUserID(ctx, token.UserID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	// Other reset links requested before this one are no longer valid
+	if err := a.verificationTokenRepo.InvalidateByUser(ctx, token.UserID, domain.VerificationPurposePasswordReset); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventPasswordReset,
+		UserID:     token.UserID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
+	return nil
+}
+
+// sendPasswordResetEmail issues a new password reset token for the user and mails the reset link.
+func (a *authUsecase) sendPasswordResetEmail(ctx context.Context, user *domain.User, ipAddress string) error {
+	rawToken, token, err := a.issueVerificationToken(ctx, user.ID, domain.VerificationPurposePasswordReset, a.appCfg.PasswordResetTokenExpiresIn())
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("token", rawToken)
+	resetURL := common.JoinURLPath(a.srvCfg.Domain(), "reset-password") + "?" + query.Encode()
+
+	templateData := map[string]any{
+		"app_name":     a.appCfg.Name(),
+		"user_name":    user.FirstName + " " + user.LastName,
+		"user_email":   user.Email,
+		"reset_url":    resetURL,
+		"ip_address":   ipAddress,
+		"request_time": time.Now().UTC().Format("2006-01-02 15:04:05 UTC"),
+		"expires_in":   utils.FormatDuration(a.appCfg.PasswordResetTokenExpiresIn()),
+		"current_year": time.Now().Year(),
+	}
+
+	emailReq := &domain.SendEmailWithTemplateRequest{
+		To:           []string{user.Email},
+		TemplateCode: domain.EmailCodePasswordReset,
+		Locale:       "en", // Default locale
+		Data:         templateData,
+		RequestID:    fmt.Sprintf("auth_reset_%s", token.ID),
+	}
+
+	if _, err := a.emailRPCClient.SendEmailWithTemplate(ctx, emailReq); err != nil {
+		return domain.ErrEmailSendFailed.WithError("failed to send password reset email").WithWrap(err)
+	}
+
+	return nil
+}
+
+// issueVerificationToken revokes the user's outstanding tokens of the given purpose and stores a new one.
+// It returns the raw token, which is only ever sent to the user, together with the stored record.
+func (a *authUsecase) issueVerificationToken(
+	ctx context.Context,
+	userID string,
+	purpose domain.VerificationPurpose,
+	ttl time.Duration,
+) (string, *domain.VerificationToken, error) {
+	return a.issueBoundVerificationToken(ctx, userID, purpose, ttl, "")
+}
+
+// issueBoundVerificationToken is issueVerificationToken for a token that can only be redeemed by the
+// client holding the secret hashed to bindingHash, an empty hash leaves the token unbound.
+func (a *authUsecase) issueBoundVerificationToken(
+	ctx context.Context,
+	userID string,
+	purpose domain.VerificationPurpose,
+	ttl time.Duration,
+	bindingHash string,
+) (string, *domain.VerificationToken, error) {
+	if err := a.verificationTokenRepo.InvalidateByUser(ctx, userID, purpose); err != nil {
+		return "", nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	rawToken, err := common.GenerateSecureToken(32)
+	if err != nil {
+		return "", nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	token := &domain.VerificationToken{
+		UserID:      userID,
+		Purpose:     purpose,
+		TokenHash:   common.HashToken(rawToken),
+		ExpiresAt:   time.Now().Add(ttl).UnixMilli(),
+		BindingHash: bindingHash,
+	}
+	if err := a.verificationTokenRepo.Create(ctx, token); err != nil {
+		return "", nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	return rawToken, token, nil
+}
+
+func isOTPCode(code string) bool {
+	if len(code) != domain.OTPCodeLength {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/cache"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCompleteLoginAccountChecks(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		user    *domain.User
+		wantErr error
+	}{
+		{
+			name:    "unverified user",
+			user:    &domain.User{Status: domain.UserSTTWaitingVerify},
+			wantErr: domain.ErrUserInactive,
+		},
+		{
+			name:    "banned user",
+			user:    &domain.User{Status: domain.UserSTTBanned},
+			wantErr: domain.ErrUserInactive,
+		},
+		{
+			name:    "password reset required",
+			user:    &domain.User{Status: domain.UserSTTActive, PasswordResetRequired: true},
+			wantErr: domain.ErrPasswordResetRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		for _, mfaVerified := range []bool{false, true} {
+			t.Run(tt.name, func(t *testing.T) {
+				a := &authUsecase{}
+				if _, err := a.completeLogin(ctx, tt.user, mfaVerified, "", ""); !errors.Is(err, tt.wantErr) {
+					t.Errorf("completeLogin(mfaVerified=%v) error = %v, want %v", mfaVerified, err, tt.wantErr)
+				}
+			})
+		}
+	}
+}
+
+func TestCompleteOAuthLoginRequiresPasswordReset(t *testing.T) {
+	ctx := context.Background()
+	users := newFakeUserClient(newTestUser("user-1", "jane@example.com", domain.UserSTTWaitingVerify))
+	a := newOAuthTestUsecase(users, &fakeExternalIdentityRepo{}, &fakeIdentityProvider{
+		name:    "google",
+		profile: &domain.ExternalProfile{Subject: "subject-1", Email: "jane@example.com", EmailVerified: true},
+	})
+
+	resp, err := a.StartOAuthLogin(ctx, &domain.StartOAuthLoginRequest{Provider: "google"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The account was linked with its password cleared, it has to be reset before any login
+	_, err = a.CompleteOAuthLogin(ctx, &domain.CompleteOAuthLoginRequest{
+		Provider: "google",
+		State:    resp.State,
+		Code:     "code",
+	})
+	if !errors.Is(err, domain.ErrPasswordResetRequired) {
+		t.Fatalf("CompleteOAuthLogin() error = %v, want %v", err, domain.ErrPasswordResetRequired)
+	}
+}
+
+func TestIsUserNotFound(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "user not found", err: domain.ErrUserNotFound.WithWrap(domain.ErrRecordNotFound), want: true},
+		{name: "user not found from the user service", err: common.ToGRPCError(domain.ErrUserNotFound), want: true},
+		{name: "record not found", err: domain.ErrRecordNotFound, want: true},
+		{name: "internal error from the user service", err: common.ToGRPCError(domain.ErrInternalServerError)},
+		{name: "unavailable user service", err: status.Error(codes.Unavailable, "connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isUserNotFound(tt.err); got != tt.want {
+				t.Errorf("isUserNotFound(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginLookupFailure(t *testing.T) {
+	ctx := context.Background()
+	users := newFakeUserClient()
+	users.findErr = status.Error(codes.Unavailable, "connection refused")
+	a := newOAuthTestUsecase(users, &fakeExternalIdentityRepo{})
+
+	// The failure is not counted against the identifier, the security events emitter is not even set
+	_, err := a.Login(ctx, &domain.LoginRequest{Email: "jane@example.com", Password: "password", IPAddress: "192.0.2.1"})
+	if !errors.Is(err, domain.ErrInternalServerError) {
+		t.Fatalf("Login() error = %v, want %v", err, domain.ErrInternalServerError)
+	}
+	if err := a.checkLoginLock(ctx, loginScopeIP, "192.0.2.1"); err != nil {
+		t.Errorf("checkLoginLock() error = %v, want the IP unlocked", err)
+	}
+	if err := a.checkLoginDelay(ctx, loginSubject(nil, "email:jane@example.com")); err != nil {
+		t.Errorf("checkLoginDelay() error = %v, want no delay", err)
+	}
+}
+
+func TestEmailLookupsNormalizeEmail(t *testing.T) {
+	ctx := context.Background()
+	const email = "  Jane.Doe@Example.COM "
+
+	tests := []struct {
+		name   string
+		lookup func(a *authUsecase) error
+	}{
+		{
+			name: "verify email",
+			lookup: func(a *authUsecase) error {
+				err := a.VerifyEmail(ctx, &domain.VerifyEmailRequest{Email: email, Code: "123456"})
+				if !errors.Is(err, domain.ErrInvalidVerificationToken) {
+					return err
+				}
+				return nil
+			},
+		},
+		{
+			name: "forgot password",
+			lookup: func(a *authUsecase) error {
+				return a.ForgotPassword(ctx, &domain.ForgotPasswordRequest{Email: email})
+			},
+		},
+		{
+			name: "magic link",
+			lookup: func(a *authUsecase) error {
+				_, err := a.RequestMagicLink(ctx, &domain.MagicLinkRequest{Email: email})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUserClient()
+			a := &authUsecase{
+				userClient: users,
+				cache:      cache.NewMemoryCache(&cache.Config{}, nopCacheLogger{}),
+				appCfg:     testAppConfig{},
+			}
+			if err := tt.lookup(a); err != nil {
+				t.Fatal(err)
+			}
+			if len(users.lookups) != 1 || users.lookups[0].Email == nil || *users.lookups[0].Email != "jane.doe@example.com" {
+				t.Errorf("lookups = %v, want one by the normalized email", users.lookups)
+			}
+		})
+	}
+}
+
+func TestForgotPasswordMixedCaseEmail(t *testing.T) {
+	// Stored as typed before emails were normalized
+	users := newFakeUserClient(newTestUser("user-1", "Jane.Doe@Example.com", domain.UserSTTActive))
+	emails := newFakeEmailClient()
+	a := &authUsecase{
+		userClient:            users,
+		verificationTokenRepo: &fakeVerificationTokenRepo{},
+		emailRPCClient:        emails,
+		securityEvents:        nopSecurityEvents{},
+		appCfg:                testAppConfig{},
+		srvCfg:                testServerConfig{},
+	}
+
+	if err := a.ForgotPassword(context.Background(), &domain.ForgotPasswordRequest{Email: "jane.doe@example.com"}); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+	sent := emails.waitForEmail()
+	if sent == nil || len(sent.To) != 1 || sent.To[0] != "Jane.Doe@Example.com" {
+		t.Fatalf("sent %v, want the reset link to the account", sent)
+	}
+}
@@ -0,0 +1,207 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+	"strings"
+	"time"
+)
+
+type ImpersonationRepository interface {
+	Create(ctx context.Context, impersonation *domain.Impersonation) error
+	FindByID(ctx context.Context, id string) (*domain.Impersonation, error)
+	FindBySessionID(ctx context.Context, sessionID string) (*domain.Impersonation, error)
+	FindPage(ctx context.Context, filter *domain.ImpersonationFilter, option *domain.FindPageOption) ([]*domain.Impersonation, *domain.Pagination, error)
+	MarkEnded(ctx context.Context, id, endedBy string, endedAt int64) (bool, error)
+}
+
+// UserRepository reads users with their roles, which the user RPC does not carry.
+type UserRepository interface {
+	FindByID(ctx context.Context, userID string, option *domain.FindOneOption) (*domain.User, error)
+}
+
+type ImpersonationTokenIssuer interface {
+	GenerateImpersonationToken(userID, sessionID, actorID string, expiresAt time.Time) (*domain.JwtClaims, string, error)
+}
+
+type ImpersonationConfig interface {
+	ImpersonationExpiresIn() time.Duration
+}
+
+type impersonationUsecase struct {
+	impersonationRepo ImpersonationRepository
+	sessionRepo       UserSessionRepository
+	userRepo          UserRepository
+	tokenIssuer       ImpersonationTokenIssuer
+	revocationList    TokenRevocationList
+	cfg               ImpersonationConfig
+}
+
+func NewImpersonationUsecase(
+	impersonationRepo ImpersonationRepository,
+	sessionRepo UserSessionRepository,
+	userRepo UserRepository,
+	tokenIssuer ImpersonationTokenIssuer,
+	revocationList TokenRevocationList,
+	cfg ImpersonationConfig,
+) domain.ImpersonationUsecase {
+	return &impersonationUsecase{
+		impersonationRepo: impersonationRepo,
+		sessionRepo:       sessionRepo,
+		userRepo:          userRepo,
+		tokenIssuer:       tokenIssuer,
+		revocationList:    revocationList,
+		cfg:               cfg,
+	}
+}
+
+// StartImpersonation opens a time-boxed session for the target user on behalf of the actor and
+// records it in the audit log. The session has no refresh token, it ends with its access token.
+func (i *impersonationUsecase) StartImpersonation(ctx context.Context, req *domain.StartImpersonationRequest) (*domain.StartImpersonationResponse, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, domain.ErrBadRequest.WithError("reason must be not empty")
+	}
+	if req.TargetUserID == req.ActorID {
+		return nil, domain.ErrImpersonationNotAllowed.WithReason("you cannot impersonate yourself")
+	}
+
+	target, err := i.userRepo.FindByID(ctx, req.TargetUserID, &domain.FindOneOption{
+		Preloads: []string{common.FieldRoles},
+	})
+	if err != nil {
+		if common.IsRecordNotFound(err) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	// Acting as another super admin would hide who did what with the highest privileges
+	if target.HasAnyRole(domain.RoleIDSuperAdmin) {
+		return nil, domain.ErrImpersonationNotAllowed.WithReason("super admins cannot be impersonated")
+	}
+	if target.IsBanned() {
+		return nil, domain.ErrAccountBanned
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(i.cfg.ImpersonationExpiresIn())
+	session := &domain.UserSession{
+		UserID:         target.ID,
+		ImpersonatorID: req.ActorID,
+		IPAddress:      req.IPAddress,
+		UserAgent:      req.UserAgent,
+		Active:         true,
+		ExpiresAt:      expiresAt.UnixMilli(),
+		LastActivityAt: now.UnixMilli(),
+	}
+	if err := i.sessionRepo.Create(ctx, session); err != nil {
+		return nil, domain.ErrCannotCreateSession.WithWrap(err)
+	}
+
+	claims, accessToken, err := i.tokenIssuer.GenerateImpersonationToken(target.ID, session.ID, req.ActorID, expiresAt)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	impersonation := &domain.Impersonation{
+		ActorID:      req.ActorID,
+		TargetUserID: target.ID,
+		SessionID:    session.ID,
+		TokenID:      claims.ID,
+		Reason:       reason,
+		IPAddress:    req.IPAddress,
+		UserAgent:    req.UserAgent,
+		StartedAt:    now.UnixMilli(),
+		ExpiresAt:    expiresAt.UnixMilli(),
+	}
+	if err := i.impersonationRepo.Create(ctx, impersonation); err != nil {
+		// An impersonation missing from the audit log must not be usable
+		_ = i.sessionRepo.Revoke(ctx, session.ID)
+		_ = i.revocationList.RevokeSession(ctx, session.ID)
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	return &domain.StartImpersonationResponse{
+		Impersonation: impersonation,
+		User:          target,
+		AccessToken:   accessToken,
+	}, nil
+}
+
+// StopImpersonation ends the impersonation of the current session, for the administrator using it.
+func (i *impersonationUsecase) StopImpersonation(ctx context.Context, req *domain.StopImpersonationRequest) error {
+	impersonation, err := i.impersonationRepo.FindBySessionID(ctx, req.SessionID)
+	if err != nil {
+		if common.IsRecordNotFound(err) {
+			return domain.ErrNotImpersonating
+		}
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if impersonation.ActorID != req.ActorID {
+		return domain.ErrNotImpersonating
+	}
+	return i.endImpersonation(ctx, impersonation, req.ActorID)
+}
+
+// EndImpersonation ends any running impersonation, e.g. one an administrator forgot to stop.
+func (i *impersonationUsecase) EndImpersonation(ctx context.Context, req *domain.EndImpersonationRequest) (*domain.Impersonation, error) {
+	impersonation, err := i.impersonationRepo.FindByID(ctx, req.ID)
+	if err != nil {
+		if common.IsRecordNotFound(err) {
+			return nil, domain.ErrImpersonationNotFound
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if err := i.endImpersonation(ctx, impersonation, req.ActorID); err != nil {
+		return nil, err
+	}
+	return impersonation, nil
+}
+
+func (i *impersonationUsecase) ListImpersonations(ctx context.Context, req *domain.ListImpersonationsRequest) ([]*domain.Impersonation, *domain.Pagination, error) {
+	impersonations, pagination, err := i.impersonationRepo.FindPage(ctx, &domain.ImpersonationFilter{
+		ActorID:      req.ActorID,
+		TargetUserID: req.TargetUserID,
+	}, &domain.FindPageOption{
+		Sort:    []string{"started_at DESC"},
+		Page:    req.Page,
+		PerPage: req.PerPage,
+	})
+	if err != nil {
+		return nil, nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return impersonations, pagination, nil
+}
+
+// endImpersonation records the end of a running impersonation and revokes its session and token.
+// Impersonations that already ended are left untouched.
+func (i *impersonationUsecase) endImpersonation(ctx context.Context, impersonation *domain.Impersonation, endedBy string) error {
+	now := utils.NowUnixMillis()
+	if !impersonation.IsRunning(now) {
+		return nil
+	}
+
+	ended, err := i.impersonationRepo.MarkEnded(ctx, impersonation.ID, endedBy, now)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !ended {
+		return nil
+	}
+	impersonation.EndedAt = now
+	impersonation.EndedBy = endedBy
+
+	if err := i.sessionRepo.Revoke(ctx, impersonation.SessionID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if err := i.revocationList.RevokeSession(ctx, impersonation.SessionID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	// The token can outlive the session revocation entry, it is revoked until it expires
+	if err := i.revocationList.RevokeToken(ctx, impersonation.TokenID, time.UnixMilli(impersonation.ExpiresAt)); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return nil
+}
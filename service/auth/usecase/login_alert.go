@@ -0,0 +1,185 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+	"go-clean-arch/service/auth/loginrisk"
+	"net/url"
+	"time"
+)
+
+type LoginRiskDetector interface {
+	Detect(ctx context.Context, current loginrisk.Login, history []loginrisk.Login) []loginrisk.Reason
+}
+
+type LoginAlertConfig interface {
+	Enabled() bool
+	HistorySize() int
+	DenyLinkExpiresIn() time.Duration
+}
+
+var loginRiskReasonTexts = map[loginrisk.Reason]string{
+	loginrisk.ReasonNewDevice:  "a device or browser you have not signed in with before",
+	loginrisk.ReasonNewNetwork: "a network you have not signed in from before",
+}
+
+// alertNewLogin compares a new session with the previous sessions of the user and emails a new
+// sign-in alert when the detector finds it unfamiliar. The alert carries a link to deny the login.
+func (a *authUsecase) alertNewLogin(ctx context.Context, user *domain.User, session *domain.UserSession) error {
+	if !a.loginAlertCfg.Enabled() {
+		return nil
+	}
+
+	limit := a.loginAlertCfg.HistorySize()
+	previous, err := a.sessionRepo.FindMany(ctx, &domain.UserSessionFilter{
+		UserID: &user.ID,
+		IDNe:   &session.ID,
+	}, &domain.FindManyOption{
+		Sort:  []string{"created_at DESC"},
+		Limit: &limit,
+	})
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	// Sessions opened by an impersonating administrator are not logins of the user, and denied
+	// logins are deleted so they do not make the device or network of an attacker look known
+	history := make([]loginrisk.Login, 0, len(previous))
+	for _, s := range previous {
+		if s.ImpersonatorID != "" || s.DeletedAt != 0 {
+			continue
+		}
+		history = append(history, loginrisk.Login{IPAddress: s.IPAddress, UserAgent: s.UserAgent})
+	}
+
+	reasons := a.loginRiskDetector.Detect(ctx, loginrisk.Login{
+		IPAddress: session.IPAddress,
+		UserAgent: session.UserAgent,
+	}, history)
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:      domain.SecurityEventNewLogin,
+		UserID:    user.ID,
+		SessionID: session.ID,
+		IPAddress: session.IPAddress,
+		UserAgent: session.UserAgent,
+		Metadata: map[string]any{
+			"reasons": reasons,
+		},
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
+	return a.sendLoginAlertEmail(ctx, user, session, reasons)
+}
+
+func (a *authUsecase) sendLoginAlertEmail(ctx context.Context, user *domain.User, session *domain.UserSession, reasons []loginrisk.Reason) error {
+	// Not issued through issueVerificationToken, an alert must not void the links of earlier alerts
+	rawToken, err := common.GenerateSecureToken(32)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	token := &domain.VerificationToken{
+		UserID:    user.ID,
+		Purpose:   domain.VerificationPurposeLoginAlert,
+		TokenHash: common.HashToken(rawToken),
+		SessionID: session.ID,
+		ExpiresAt: time.Now().Add(a.loginAlertCfg.DenyLinkExpiresIn()).UnixMilli(),
+	}
+	if err := a.verificationTokenRepo.Create(ctx, token); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	query := url.Values{}
+	query.Set("token", rawToken)
+	denyURL := common.JoinURLPath(a.srvCfg.Domain(), "deny-login") + "?" + query.Encode()
+
+	reasonTexts := make([]string, 0, len(reasons))
+	for _, reason := range reasons {
+		if text, ok := loginRiskReasonTexts[reason]; ok {
+			reasonTexts = append(reasonTexts, text)
+		}
+	}
+
+	agent := utils.ParseUserAgent(session.UserAgent)
+	templateData := map[string]any{
+		"app_name":     a.appCfg.Name(),
+		"user_name":    user.FirstName + " " + user.LastName,
+		"user_email":   user.Email,
+		"deny_url":     denyURL,
+		"ip_address":   session.IPAddress,
+		"browser":      agent.Browser,
+		"os":           agent.OS,
+		"device":       agent.Device,
+		"login_time":   time.UnixMilli(session.CreatedAt).UTC().Format("2006-01-02 15:04:05 UTC"),
+		"reasons":      reasonTexts,
+		"expires_in":   utils.FormatDuration(a.loginAlertCfg.DenyLinkExpiresIn()),
+		"current_year": time.Now().Year(),
+	}
+
+	emailReq := &domain.SendEmailWithTemplateRequest{
+		To:           []string{user.Email},
+		TemplateCode: domain.EmailCodeNewLogin,
+		Locale:       "en", // Default locale
+		Data:         templateData,
+		RequestID:    fmt.Sprintf("auth_login_alert_%s", token.ID),
+	}
+
+	if _, err := a.emailRPCClient.SendEmailWithTemplate(ctx, emailReq); err != nil {
+		return domain.ErrEmailSendFailed.WithError("failed to send new sign-in email").WithWrap(err)
+	}
+
+	return nil
+}
+
+// DenyLogin handles the "this wasn't me" link of a new sign-in alert. It signs the reported session
+// out and requires a password reset before the next password login, sending the reset link.
+func (a *authUsecase) DenyLogin(ctx context.Context, req *domain.DenyLoginRequest) error {
+	token, err := a.verificationTokenRepo.FindByTokenHash(ctx, domain.VerificationPurposeLoginAlert, common.HashToken(req.Token))
+	if err != nil || token == nil || !token.IsUsable() {
+		return domain.ErrInvalidLoginAlertToken
+	}
+
+	consumed, err := a.verificationTokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !consumed {
+		return domain.ErrInvalidLoginAlertToken
+	}
+
+	if err := a.revokeSession(ctx, token.SessionID); err != nil {
+		return err
+	}
+	// Deleted sessions are left out of the history the next logins are compared with
+	if err := a.sessionRepo.Delete(ctx, token.SessionID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	required := true
+	user, err := a.userClient.Update(ctx, token.UserID, &domain.UserUpdateRequest{
+		PasswordResetRequired: &required,
+	})
+	if err != nil {
+		if de, ok := common.IsDetailError(err); ok {
+			return de
+		}
+		return domain.ErrUserUpdateFailed.WithWrap(err)
+	}
+
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventLoginDenied,
+		UserID:     token.UserID,
+		SessionID:  token.SessionID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
+	return a.sendPasswordResetEmail(ctx, user, req.IPAddress)
+}
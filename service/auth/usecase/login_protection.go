@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/cache"
+	"go-clean-arch/pkg/utils"
+	"time"
+)
+
+const (
+	loginScopeUser = "user"
+	loginScopeIP   = "ip"
+)
+
+// loginLock is stored while an account or IP is locked out, or has to wait before its next attempt
+type loginLock struct {
+	Until int64 `json:"until"`
+}
+
+func loginFailuresKey(scope, id string) string {
+	return "login_failures:" + scope + ":" + id
+}
+
+func loginLockKey(scope, id string) string {
+	return "login_lock:" + scope + ":" + id
+}
+
+func loginDelayKey(subject string) string {
+	return "login_delay:" + subject
+}
+
+// loginSubject is the ID the failures of a login are counted under in the user scope: the account
+// when the identifier matches one, the identifier otherwise. Unknown identifiers are delayed and
+// locked like accounts so the answer does not tell whether an account exists.
+func loginSubject(user *domain.User, identifier string) string {
+	if user != nil {
+		return user.ID
+	}
+	return "unknown:" + identifier
+}
+
+// checkLoginLock rejects a login attempt while the account or IP is locked out.
+func (a *authUsecase) checkLoginLock(ctx context.Context, scope, id string) error {
+	var lock loginLock
+	if err := a.cache.GetJSON(ctx, loginLockKey(scope, id), &lock); err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return nil
+		}
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if scope == loginScopeUser {
+		return domain.ErrAccountLocked.WithDetail("locked_until", lock.Until)
+	}
+	return domain.ErrTooManyLoginAttempts.WithDetail("retry_at", lock.Until)
+}
+
+// checkLoginDelay rejects a login attempt made before the delay of the last failure elapsed.
+func (a *authUsecase) checkLoginDelay(ctx context.Context, subject string) error {
+	var delay loginLock
+	if err := a.cache.GetJSON(ctx, loginDelayKey(subject), &delay); err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return nil
+		}
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return domain.ErrTooManyLoginAttempts.WithDetail("retry_at", delay.Until)
+}
+
+// recordLoginFailure counts a failed login for the IP and for its subject, see loginSubject, and
+// returns the error to answer with. Every failure doubles the wait before the next attempt of the
+// subject, until the threshold locks it and the owner of the account, if any, is notified.
+func (a *authUsecase) recordLoginFailure(ctx context.Context, user *domain.User, subject, ipAddress, userAgent string) error {
+	event := &domain.SecurityEvent{
+		Type:       domain.SecurityEventLoginFailed,
+		IPAddress:  ipAddress,
+		UserAgent:  userAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	}
+	if user != nil {
+		event.UserID = user.ID
+	}
+	a.securityEvents.Emit(ctx, event)
+
+	if ipAddress != "" {
+		failures, err := a.cache.Increment(ctx, loginFailuresKey(loginScopeIP, ipAddress), 1, a.appCfg.LoginFailureWindow())
+		if err != nil {
+			return domain.ErrInternalServerError.WithWrap(err)
+		}
+		if failures >= int64(a.appCfg.LoginIPMaxFailedAttempts()) {
+			if _, err := a.lockLogin(ctx, loginScopeIP, ipAddress); err != nil {
+				return err
+			}
+		}
+	}
+
+	failures, err := a.cache.Increment(ctx, loginFailuresKey(loginScopeUser, subject), 1, a.appCfg.LoginFailureWindow())
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	if failures >= int64(a.appCfg.LoginMaxFailedAttempts()) {
+		lockedUntil, err := a.lockLogin(ctx, loginScopeUser, subject)
+		if err != nil {
+			return err
+		}
+		_ = a.cache.Delete(ctx, loginDelayKey(subject))
+		if user == nil {
+			return domain.ErrAccountLocked.WithDetail("locked_until", lockedUntil)
+		}
+
+		a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+			Type:      domain.SecurityEventAccountLocked,
+			UserID:    user.ID,
+			IPAddress: ipAddress,
+			UserAgent: userAgent,
+			Metadata: map[string]any{
+				"failed_attempts": failures,
+				"locked_until":    lockedUntil,
+			},
+			OccurredAt: utils.NowUnixMillis(),
+		})
+
+		// Notify the owner asynchronously, the lockout must not depend on the email service
+		go func() {
+			_ = a.sendAccountLockedEmail(context.Background(), user, failures, ipAddress, lockedUntil)
+		}()
+		return domain.ErrAccountLocked.WithDetail("locked_until", lockedUntil)
+	}
+
+	delay := min(a.appCfg.LoginFailureBaseDelay()<<min(failures-1, 16), a.appCfg.LoginLockoutDuration())
+	if delay > 0 {
+		retryAt := time.Now().Add(delay).UnixMilli()
+		if err := a.cache.SetJSON(ctx, loginDelayKey(subject), &loginLock{Until: retryAt}, delay); err != nil {
+			return domain.ErrInternalServerError.WithWrap(err)
+		}
+	}
+	return domain.ErrInvalidCredentials
+}
+
+// lockLogin locks the account or IP out for the lockout duration and starts counting its failures anew.
+func (a *authUsecase) lockLogin(ctx context.Context, scope, id string) (int64, error) {
+	lockoutDuration := a.appCfg.LoginLockoutDuration()
+	lockedUntil := time.Now().Add(lockoutDuration).UnixMilli()
+	if err := a.cache.SetJSON(ctx, loginLockKey(scope, id), &loginLock{Until: lockedUntil}, lockoutDuration); err != nil {
+		return 0, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if err := a.cache.Delete(ctx, loginFailuresKey(scope, id)); err != nil {
+		return 0, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return lockedUntil, nil
+}
+
+// clearLoginFailures forgets the failed attempts of an account after a successful login. The IP
+// counter is kept, a valid login to one account must not reset the guessing budget for others.
+func (a *authUsecase) clearLoginFailures(ctx context.Context, userID string) error {
+	if err := a.cache.Delete(ctx, loginFailuresKey(loginScopeUser, userID)); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if err := a.cache.Delete(ctx, loginDelayKey(userID)); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return nil
+}
+
+func (a *authUsecase) UnlockAccount(ctx context.Context, req *domain.UnlockAccountRequest) error {
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		ID: &req.UserID,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return domain.ErrUserNotFound.WithWrap(err)
+	}
+
+	if err := a.cache.Delete(ctx, loginLockKey(loginScopeUser, user.ID)); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return a.clearLoginFailures(ctx, user.ID)
+}
+
+// sendAccountLockedEmail tells the account owner that the account was locked and how to recover it.
+func (a *authUsecase) sendAccountLockedEmail(ctx context.Context, user *domain.User, failedAttempts int64, ipAddress string, lockedUntil int64) error {
+	templateData := map[string]any{
+		"app_name":        a.appCfg.Name(),
+		"user_name":       user.FirstName + " " + user.LastName,
+		"user_email":      user.Email,
+		"failed_attempts": failedAttempts,
+		"ip_address":      ipAddress,
+		"attempt_time":    time.Now().UTC().Format("2006-01-02 15:04:05 UTC"),
+		"locked_until":    time.UnixMilli(lockedUntil).UTC().Format("2006-01-02 15:04:05 UTC"),
+		"reset_url":       common.JoinURLPath(a.srvCfg.Domain(), "forgot-password"),
+		"current_year":    time.Now().Year(),
+	}
+
+	emailReq := &domain.SendEmailWithTemplateRequest{
+		To:           []string{user.Email},
+		TemplateCode: domain.EmailCodeAccountLocked,
+		Locale:       "en", // Default locale
+		Data:         templateData,
+		RequestID:    fmt.Sprintf("auth_locked_%s_%d", user.ID, utils.NowUnixMillis()),
+	}
+
+	if _, err := a.emailRPCClient.SendEmailWithTemplate(ctx, emailReq); err != nil {
+		return domain.ErrEmailSendFailed.WithError("failed to send account locked email").WithWrap(err)
+	}
+
+	return nil
+}
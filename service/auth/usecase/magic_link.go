@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+	"net/url"
+	"time"
+)
+
+func magicLinkRequestsKey(email string) string {
+	return "magic_link_requests:" + email
+}
+
+// RequestMagicLink emails a single-use login link to the user. The answer is the same whether the
+// email is registered or not, and carries the device token the link is bound to.
+func (a *authUsecase) RequestMagicLink(ctx context.Context, req *domain.MagicLinkRequest) (*domain.MagicLinkResponse, error) {
+	email := domain.NormalizeEmail(req.Email)
+
+	// Counted before the lookup so the limit does not reveal whether the email exists
+	requests, err := a.cache.Increment(ctx, magicLinkRequestsKey(email), 1, a.appCfg.MagicLinkRequestWindow())
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if requests > int64(a.appCfg.MagicLinkMaxRequests()) {
+		return nil, domain.ErrTooManyMagicLinkRequests
+	}
+
+	deviceToken, err := common.GenerateSecureToken(32)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	resp := &domain.MagicLinkResponse{
+		DeviceToken: deviceToken,
+		ExpiresAt:   time.Now().Add(a.appCfg.MagicLinkExpiresIn()).UnixMilli(),
+	}
+
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		Email: &email,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil || user.IsBanned() {
+		return resp, nil
+	}
+
+	// Send asynchronously so the response time does not depend on whether the email exists
+	go func() {
+		_ = a.sendMagicLinkEmail(context.Background(), user, common.HashToken(deviceToken), req.IPAddress)
+	}()
+
+	return resp, nil
+}
+
+// LoginWithMagicLink redeems the token of a login link and starts a session like a password login.
+func (a *authUsecase) LoginWithMagicLink(ctx context.Context, req *domain.MagicLinkLoginRequest) (*domain.LoginResponse, error) {
+	token, err := a.verificationTokenRepo.FindByTokenHash(ctx, domain.VerificationPurposeMagicLink, common.HashToken(req.Token))
+	if err != nil || token == nil || !token.IsUsable() {
+		return nil, domain.ErrInvalidMagicLink
+	}
+
+	// A link opened on another device has no device token, it is only accepted when allowed
+	if token.BindingHash != "" {
+		if req.DeviceToken == "" {
+			if a.appCfg.MagicLinkRequireSameDevice() {
+				return nil, domain.ErrInvalidMagicLink.WithError("the login link must be opened on the device which requested it")
+			}
+		} else if subtle.ConstantTimeCompare([]byte(common.HashToken(req.DeviceToken)), []byte(token.BindingHash)) != 1 {
+			return nil, domain.ErrInvalidMagicLink
+		}
+	}
+
+	consumed, err := a.verificationTokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !consumed {
+		return nil, domain.ErrInvalidMagicLink
+	}
+
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		ID: &token.UserID,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound.WithWrap(err)
+	}
+
+	switch user.Status {
+	case domain.UserSTTBanned:
+		return nil, domain.ErrAccountBanned
+	case domain.UserSTTWaitingVerify:
+		// Opening the link proves the user owns the email address
+		status := domain.UserSTTActive
+		user, err = a.userClient.Update(ctx, user.ID, &domain.UserUpdateRequest{
+			Status: &status,
+		})
+		if err != nil {
+			if de, ok := common.IsDetailError(err); ok {
+				return nil, de
+			}
+			return nil, domain.ErrUserUpdateFailed.WithWrap(err)
+		}
+	}
+
+	return a.completeLogin(ctx, user, false, req.IPAddress, req.UserAgent)
+}
+
+// sendMagicLinkEmail issues a new login token for the user, revoking the previous ones, and mails the login link.
+func (a *authUsecase) sendMagicLinkEmail(ctx context.Context, user *domain.User, bindingHash, ipAddress string) error {
+	rawToken, token, err := a.issueBoundVerificationToken(ctx, user.ID, domain.VerificationPurposeMagicLink, a.appCfg.MagicLinkExpiresIn(), bindingHash)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{}
+	query.Set("token", rawToken)
+	loginURL := common.JoinURLPath(a.srvCfg.Domain(), "magic-login") + "?" + query.Encode()
+
+	templateData := map[string]any{
+		"app_name":     a.appCfg.Name(),
+		"user_name":    user.FirstName + " " + user.LastName,
+		"user_email":   user.Email,
+		"login_url":    loginURL,
+		"ip_address":   ipAddress,
+		"request_time": time.Now().UTC().Format("2006-01-02 15:04:05 UTC"),
+		"expires_in":   utils.FormatDuration(a.appCfg.MagicLinkExpiresIn()),
+		"current_year": time.Now().Year(),
+	}
+
+	emailReq := &domain.SendEmailWithTemplateRequest{
+		To:           []string{user.Email},
+		TemplateCode: domain.EmailCodeMagicLink,
+		Locale:       "en", // Default locale
+		Data:         templateData,
+		RequestID:    fmt.Sprintf("auth_magic_link_%s", token.ID),
+	}
+
+	if _, err := a.emailRPCClient.SendEmailWithTemplate(ctx, emailReq); err != nil {
+		return domain.ErrEmailSendFailed.WithError("failed to send login link email").WithWrap(err)
+	}
+
+	return nil
+}
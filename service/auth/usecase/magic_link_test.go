@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/cache"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testAppConfig sets the settings of the email links, the others are left to the nil interface
+type testAppConfig struct {
+	AppConfig
+}
+
+func (testAppConfig) Name() string                               { return "Test" }
+func (testAppConfig) MagicLinkExpiresIn() time.Duration          { return 15 * time.Minute }
+func (testAppConfig) MagicLinkMaxRequests() int                  { return 2 }
+func (testAppConfig) MagicLinkRequestWindow() time.Duration      { return time.Hour }
+func (testAppConfig) PasswordResetTokenExpiresIn() time.Duration { return time.Hour }
+
+type nopSecurityEvents struct{}
+
+func (nopSecurityEvents) Emit(context.Context, *domain.SecurityEvent) {}
+
+type testServerConfig struct{}
+
+func (testServerConfig) Domain() string { return "https://app.example.com" }
+
+// fakeVerificationTokenRepo keeps the issued tokens, the methods the tests do not need are left to
+// the nil interface
+type fakeVerificationTokenRepo struct {
+	VerificationTokenRepository
+	mu     sync.Mutex
+	tokens []*domain.VerificationToken
+}
+
+func (r *fakeVerificationTokenRepo) Create(_ context.Context, token *domain.VerificationToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.ID = fmt.Sprintf("token-%d", len(r.tokens)+1)
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakeVerificationTokenRepo) InvalidateByUser(context.Context, string, domain.VerificationPurpose) error {
+	return nil
+}
+
+// fakeEmailClient hands the emails over to the test, they are sent in the background
+type fakeEmailClient struct {
+	sent chan *domain.SendEmailWithTemplateRequest
+}
+
+func newFakeEmailClient() *fakeEmailClient {
+	return &fakeEmailClient{sent: make(chan *domain.SendEmailWithTemplateRequest, 1)}
+}
+
+func (c *fakeEmailClient) SendEmailWithTemplate(_ context.Context, req *domain.SendEmailWithTemplateRequest) (*domain.EmailLog, error) {
+	c.sent <- req
+	return &domain.EmailLog{}, nil
+}
+
+// waitForEmail returns the next email sent, or nil when none is sent in time
+func (c *fakeEmailClient) waitForEmail() *domain.SendEmailWithTemplateRequest {
+	select {
+	case req := <-c.sent:
+		return req
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+func TestRequestMagicLinkNormalizesEmail(t *testing.T) {
+	ctx := context.Background()
+	users := newFakeUserClient()
+	a := &authUsecase{
+		userClient: users,
+		cache:      cache.NewMemoryCache(&cache.Config{}, nopCacheLogger{}),
+		appCfg:     testAppConfig{},
+	}
+
+	emails := []string{"  Jane@Example.com ", "jane@example.com", "JANE@EXAMPLE.COM"}
+	for i, email := range emails {
+		_, err := a.RequestMagicLink(ctx, &domain.MagicLinkRequest{Email: email})
+		if i < 2 {
+			if err != nil {
+				t.Fatalf("RequestMagicLink(%q) error = %v", email, err)
+			}
+			if got := *users.lookups[i].Email; got != "jane@example.com" {
+				t.Errorf("RequestMagicLink(%q) looked up %q, want jane@example.com", email, got)
+			}
+			continue
+		}
+		// Every spelling of the address counts toward the same limit
+		if !errors.Is(err, domain.ErrTooManyMagicLinkRequests) {
+			t.Errorf("RequestMagicLink(%q) error = %v, want %v", email, err, domain.ErrTooManyMagicLinkRequests)
+		}
+	}
+}
+
+func TestRequestMagicLinkMixedCaseEmail(t *testing.T) {
+	ctx := context.Background()
+	// Stored as typed before emails were normalized
+	users := newFakeUserClient(newTestUser("user-1", "Jane.Doe@Example.com", domain.UserSTTActive))
+	emails := newFakeEmailClient()
+	a := &authUsecase{
+		userClient:            users,
+		verificationTokenRepo: &fakeVerificationTokenRepo{},
+		emailRPCClient:        emails,
+		cache:                 cache.NewMemoryCache(&cache.Config{}, nopCacheLogger{}),
+		appCfg:                testAppConfig{},
+		srvCfg:                testServerConfig{},
+	}
+
+	if _, err := a.RequestMagicLink(ctx, &domain.MagicLinkRequest{Email: "jane.doe@example.com"}); err != nil {
+		t.Fatalf("RequestMagicLink() error = %v", err)
+	}
+	sent := emails.waitForEmail()
+	if sent == nil {
+		t.Fatal("no login link was sent")
+	}
+	if sent.TemplateCode != domain.EmailCodeMagicLink || len(sent.To) != 1 || sent.To[0] != "Jane.Doe@Example.com" {
+		t.Errorf("sent %s to %v, want the login link to the account", sent.TemplateCode, sent.To)
+	}
+}
@@ -0,0 +1,333 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/totp"
+	"go-clean-arch/pkg/utils"
+	"strings"
+	"time"
+)
+
+// totpSkew is the number of 30 second steps accepted on each side of the current one to tolerate clock drift
+const totpSkew = 1
+
+func (a *authUsecase) VerifyMFALogin(ctx context.Context, req *domain.VerifyMFALoginRequest) (*domain.AuthResponse, error) {
+	token, err := a.verificationTokenRepo.FindByTokenHash(ctx, domain.VerificationPurposeMFAChallenge, common.HashToken(req.MFAToken))
+	if err != nil || token == nil || !token.IsUsable() {
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		ID: &token.UserID,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound.WithWrap(err)
+	}
+	// Wrong codes count like wrong passwords, a locked account cannot keep guessing codes
+	if err := a.checkLoginLock(ctx, loginScopeUser, user.ID); err != nil {
+		return nil, err
+	}
+	if err := a.checkLoginDelay(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	mfa, err := a.findEnabledMFA(ctx, token.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	// A wrong code keeps the challenge alive so the user can retry, up to MFAChallengeMaxAttempts
+	if err := a.verifyMFACode(ctx, mfa, req.Code); err != nil {
+		if errors.Is(err, domain.ErrInvalidMFACode) {
+			return nil, a.recordMFAFailure(ctx, token, user, domain.ErrInvalidMFACode, req.IPAddress, req.UserAgent)
+		}
+		return nil, err
+	}
+
+	consumed, err := a.verificationTokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !consumed {
+		return nil, domain.ErrInvalidMFAToken
+	}
+	if err := a.clearMFAFailures(ctx, token.ID, user.ID); err != nil {
+		return nil, err
+	}
+
+	resp, err := a.completeLogin(ctx, user, true, req.IPAddress, req.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	return resp.AuthResponse, nil
+}
+
+func mfaChallengeFailuresKey(tokenID string) string {
+	return "mfa_challenge_failures:" + tokenID
+}
+
+// recordMFAFailure counts a failed second factor for the challenge and for the account, and returns
+// the error to answer with, failure unless the challenge or the account got locked. The challenge is
+// discarded after MFAChallengeMaxAttempts failures, the user has to sign in with the password again
+// to get a new one.
+func (a *authUsecase) recordMFAFailure(ctx context.Context, token *domain.VerificationToken, user *domain.User, failure error, ipAddress, userAgent string) error {
+	failures, err := a.cache.Increment(ctx, mfaChallengeFailuresKey(token.ID), 1, a.appCfg.MFAChallengeExpiresIn())
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	discarded := failures >= domain.MFAChallengeMaxAttempts
+	if discarded {
+		if _, err := a.verificationTokenRepo.MarkUsed(ctx, token.ID); err != nil {
+			return domain.ErrInternalServerError.WithWrap(err)
+		}
+		_ = a.cache.Delete(ctx, mfaChallengeFailuresKey(token.ID))
+	}
+
+	if err := a.recordLoginFailure(ctx, user, user.ID, ipAddress, userAgent); !errors.Is(err, domain.ErrInvalidCredentials) {
+		return err
+	}
+	if discarded {
+		return domain.ErrInvalidMFAToken
+	}
+	return failure
+}
+
+// clearMFAFailures forgets the wrong codes of a challenge and of the account after it was completed.
+func (a *authUsecase) clearMFAFailures(ctx context.Context, tokenID, userID string) error {
+	if err := a.cache.Delete(ctx, mfaChallengeFailuresKey(tokenID)); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return a.clearLoginFailures(ctx, userID)
+}
+
+// EnrollMFA generates a new TOTP secret for the user. The secret only protects logins once
+// ConfirmMFA proves that the authenticator app produces valid codes for it.
+func (a *authUsecase) EnrollMFA(ctx context.Context, req *domain.EnrollMFARequest) (*domain.EnrollMFAResponse, error) {
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		ID: &req.UserID,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound.WithWrap(err)
+	}
+
+	mfa, err := a.userMFARepo.FindByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if mfa != nil && mfa.Enabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	encryptedSecret, err := a.secretCipher.Encrypt(secret)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	if mfa == nil {
+		mfa = &domain.UserMFA{UserID: user.ID, Secret: encryptedSecret}
+		err = a.userMFARepo.Create(ctx, mfa)
+	} else {
+		// Restarting an unfinished enrollment replaces the pending secret
+		mfa.Secret = encryptedSecret
+		mfa.LastUsedStep = 0
+		err = a.userMFARepo.Update(ctx, mfa)
+	}
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	return &domain.EnrollMFAResponse{
+		Secret:     secret,
+		OTPAuthURI: totp.KeyURI(a.appCfg.Name(), user.Email, secret),
+	}, nil
+}
+
+func (a *authUsecase) ConfirmMFA(ctx context.Context, req *domain.ConfirmMFARequest) (*domain.MFARecoveryCodesResponse, error) {
+	mfa, err := a.userMFARepo.FindByUserID(ctx, req.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, domain.ErrMFANotEnrolled
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if mfa.Enabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+	if mfa.Secret == "" {
+		return nil, domain.ErrMFANotEnrolled
+	}
+
+	if err := a.verifyTOTP(ctx, mfa, req.Code); err != nil {
+		return nil, err
+	}
+
+	mfa.Enabled = true
+	mfa.EnabledAt = utils.NowUnixMillis()
+	if err := a.userMFARepo.Update(ctx, mfa); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventMFAEnabled,
+		UserID:     mfa.UserID,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
+	return a.issueRecoveryCodes(ctx, mfa.UserID)
+}
+
+func (a *authUsecase) DisableMFA(ctx context.Context, req *domain.DisableMFARequest) error {
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		ID: &req.UserID,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return domain.ErrUserNotFound.WithWrap(err)
+	}
+	if !a.hasher.Compare(user.Password, req.Password) {
+		return domain.ErrInvalidCredentials.WithError("password is incorrect")
+	}
+
+	mfa, err := a.findEnabledMFA(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if err := a.verifyMFACode(ctx, mfa, req.Code); err != nil {
+		return err
+	}
+
+	mfa.Enabled = false
+	mfa.EnabledAt = 0
+	mfa.Secret = ""
+	if err := a.userMFARepo.Update(ctx, mfa); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if err := a.recoveryCodeRepo.DeleteByUserID(ctx, user.ID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if err := a.verificationTokenRepo.InvalidateByUser(ctx, user.ID, domain.VerificationPurposeMFAChallenge); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	a.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventMFADisabled,
+		UserID:     user.ID,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+	return nil
+}
+
+func (a *authUsecase) RegenerateRecoveryCodes(ctx context.Context, req *domain.RegenerateRecoveryCodesRequest) (*domain.MFARecoveryCodesResponse, error) {
+	mfa, err := a.findEnabledMFA(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.verifyTOTP(ctx, mfa, req.Code); err != nil {
+		return nil, err
+	}
+	return a.issueRecoveryCodes(ctx, mfa.UserID)
+}
+
+func (a *authUsecase) findEnabledMFA(ctx context.Context, userID string) (*domain.UserMFA, error) {
+	mfa, err := a.userMFARepo.FindByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, domain.ErrMFANotEnabled
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !mfa.Enabled {
+		return nil, domain.ErrMFANotEnabled
+	}
+	return mfa, nil
+}
+
+// verifyMFACode accepts either a TOTP code or one of the user's unused recovery codes.
+func (a *authUsecase) verifyMFACode(ctx context.Context, mfa *domain.UserMFA, code string) error {
+	code = strings.TrimSpace(code)
+	if isOTPCode(code) {
+		return a.verifyTOTP(ctx, mfa, code)
+	}
+
+	recoveryCode, err := a.recoveryCodeRepo.FindUnusedByHash(ctx, mfa.UserID, hashRecoveryCode(code))
+	if err != nil || recoveryCode == nil {
+		return domain.ErrInvalidMFACode
+	}
+	consumed, err := a.recoveryCodeRepo.MarkUsed(ctx, recoveryCode.ID)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !consumed {
+		return domain.ErrInvalidMFACode
+	}
+	return nil
+}
+
+// verifyTOTP checks a TOTP code and records its time step so the same code cannot be used twice.
+func (a *authUsecase) verifyTOTP(ctx context.Context, mfa *domain.UserMFA, code string) error {
+	// Secrets enrolled before they were encrypted are still stored in plain text, Decrypt returns them as is
+	secret, err := a.secretCipher.Decrypt(mfa.Secret)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	step, ok := totp.Validate(secret, code, time.Now(), totpSkew)
+	if !ok {
+		return domain.ErrInvalidMFACode
+	}
+	accepted, err := a.userMFARepo.UseStep(ctx, mfa.ID, step)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !accepted {
+		return domain.ErrInvalidMFACode
+	}
+	mfa.LastUsedStep = step
+
+	// Encrypt a plain text secret now that it is known to be the right one. A failure is retried
+	// on the next use, so it does not fail this one
+	if !common.IsEncryptedSecret(mfa.Secret) {
+		if encryptedSecret, err := a.secretCipher.Encrypt(secret); err == nil {
+			mfa.Secret = encryptedSecret
+			_ = a.userMFARepo.Update(ctx, mfa)
+		}
+	}
+	return nil
+}
+
+// issueRecoveryCodes replaces the user's recovery codes with a fresh set. The raw codes are
+// returned once and only their hashes are stored.
+func (a *authUsecase) issueRecoveryCodes(ctx context.Context, userID string) (*domain.MFARecoveryCodesResponse, error) {
+	rawCodes := make([]string, 0, domain.MFARecoveryCodeCount)
+	codes := make([]*domain.MFARecoveryCode, 0, domain.MFARecoveryCodeCount)
+	for i := 0; i < domain.MFARecoveryCodeCount; i++ {
+		token, err := common.GenerateSecureToken(5)
+		if err != nil {
+			return nil, domain.ErrInternalServerError.WithWrap(err)
+		}
+		rawCode := token[:5] + "-" + token[5:]
+		rawCodes = append(rawCodes, rawCode)
+		codes = append(codes, &domain.MFARecoveryCode{
+			UserID:   userID,
+			CodeHash: hashRecoveryCode(rawCode),
+		})
+	}
+
+	if err := a.recoveryCodeRepo.ReplaceForUser(ctx, userID, codes); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	return &domain.MFARecoveryCodesResponse{RecoveryCodes: rawCodes}, nil
+}
+
+// hashRecoveryCode hashes a recovery code ignoring case, spaces and dashes.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return common.HashToken(normalized)
+}
@@ -0,0 +1,223 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/service/auth/identity"
+	"strings"
+	"time"
+)
+
+// oauthSecretBytes is the entropy of the state, the nonce and the PKCE code verifier
+const oauthSecretBytes = 32
+
+func oauthStateKey(state string) string {
+	return "oauth_state:" + state
+}
+
+func oauthStateUsedKey(state string) string {
+	return "oauth_state_used:" + state
+}
+
+// StartOAuthLogin returns the URL of the identity provider the user is sent to. The state, nonce and
+// PKCE code verifier bound to this login are kept in the cache until the user comes back.
+func (a *authUsecase) StartOAuthLogin(ctx context.Context, req *domain.StartOAuthLoginRequest) (*domain.StartOAuthLoginResponse, error) {
+	provider, ok := a.identityProviders[req.Provider]
+	if !ok {
+		return nil, domain.ErrOAuthProviderNotFound
+	}
+
+	redirectURI := req.RedirectURI
+	if redirectURI == "" {
+		redirectURI = provider.DefaultRedirectURI()
+	} else if !provider.AllowsRedirectURI(redirectURI) {
+		return nil, domain.ErrInvalidOAuthRedirectURI
+	}
+
+	state, err := common.GenerateSecureToken(oauthSecretBytes)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	nonce, err := common.GenerateSecureToken(oauthSecretBytes)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	codeVerifier, err := common.GenerateSecureToken(oauthSecretBytes)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	ttl := a.oauthCfg.StateExpiresIn()
+	if err := a.cache.SetJSON(ctx, oauthStateKey(state), &domain.OAuthState{
+		Provider:     provider.Name(),
+		RedirectURI:  redirectURI,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+	}, ttl); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	authURL := provider.AuthCodeURL(identity.AuthCodeRequest{
+		State:         state,
+		Nonce:         nonce,
+		CodeChallenge: base64.RawURLEncoding.EncodeToString(challenge[:]),
+		RedirectURI:   redirectURI,
+	})
+
+	return &domain.StartOAuthLoginResponse{
+		AuthorizationURL: authURL,
+		State:            state,
+		ExpiresAt:        time.Now().Add(ttl).UnixMilli(),
+	}, nil
+}
+
+// CompleteOAuthLogin redeems the authorization code the identity provider sent back and logs in
+// the user owning the external account. Unknown accounts are linked to the user with the same
+// verified email, or to a new user when there is none.
+func (a *authUsecase) CompleteOAuthLogin(ctx context.Context, req *domain.CompleteOAuthLoginRequest) (*domain.LoginResponse, error) {
+	provider, ok := a.identityProviders[req.Provider]
+	if !ok {
+		return nil, domain.ErrOAuthProviderNotFound
+	}
+
+	state, err := a.consumeOAuthState(ctx, req.State)
+	if err != nil {
+		return nil, err
+	}
+	if state.Provider != provider.Name() {
+		return nil, domain.ErrInvalidOAuthState
+	}
+
+	profile, err := provider.Exchange(ctx, identity.ExchangeRequest{
+		Code:         req.Code,
+		CodeVerifier: state.CodeVerifier,
+		RedirectURI:  state.RedirectURI,
+		Nonce:        state.Nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.findOrLinkExternalUser(ctx, provider.Name(), profile)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsBanned() {
+		return nil, domain.ErrAccountBanned
+	}
+
+	return a.completeLogin(ctx, user, false, req.IPAddress, req.UserAgent)
+}
+
+// consumeOAuthState loads the state of a login and makes sure it is only redeemed once.
+func (a *authUsecase) consumeOAuthState(ctx context.Context, rawState string) (*domain.OAuthState, error) {
+	var state domain.OAuthState
+	if err := a.cache.GetJSON(ctx, oauthStateKey(rawState), &state); err != nil {
+		return nil, domain.ErrInvalidOAuthState
+	}
+
+	// The increment is atomic, only the first of concurrent callbacks with the same state gets 1
+	used, err := a.cache.Increment(ctx, oauthStateUsedKey(rawState), 1, a.oauthCfg.StateExpiresIn())
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if used != 1 {
+		return nil, domain.ErrInvalidOAuthState
+	}
+	_ = a.cache.Delete(ctx, oauthStateKey(rawState))
+
+	return &state, nil
+}
+
+func (a *authUsecase) findOrLinkExternalUser(ctx context.Context, providerName string, profile *domain.ExternalProfile) (*domain.User, error) {
+	linked, err := a.externalIdentityRepo.FindBySubject(ctx, providerName, profile.Subject)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if linked != nil {
+		user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+			ID: &linked.UserID,
+		}, &domain.FindOneOption{})
+		if err != nil || user == nil {
+			return nil, domain.ErrUserNotFound.WithWrap(err)
+		}
+		return user, nil
+	}
+
+	// Linking by email is only safe when the provider proved the user owns the address, otherwise
+	// anyone could take over an account by registering its email at the provider
+	if profile.Email == "" || !profile.EmailVerified {
+		return nil, domain.ErrExternalEmailNotVerified
+	}
+
+	// A lookup failure falls through to creating the user, which fails if the email is taken
+	email := domain.NormalizeEmail(profile.Email)
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		Email: &email,
+	}, &domain.FindOneOption{})
+
+	switch {
+	case err != nil || user == nil:
+		user, err = a.createExternalUser(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+	case user.Status == domain.UserSTTWaitingVerify:
+		// The provider verified the email, the account does not need the verification email anymore.
+		// Whoever registered it never proved to own the address though, so the password they chose
+		// and their sessions must not survive the link.
+		status := domain.UserSTTActive
+		resetRequired := true
+		user, err = a.userClient.Update(ctx, user.ID, &domain.UserUpdateRequest{
+			Status:                &status,
+			PasswordResetRequired: &resetRequired,
+			ClearPassword:         true,
+		})
+		if err != nil {
+			if de, ok := common.IsDetailError(err); ok {
+				return nil, de
+			}
+			return nil, domain.ErrUserUpdateFailed.WithWrap(err)
+		}
+	}
+
+	if err := a.externalIdentityRepo.Create(ctx, &domain.ExternalIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  profile.Subject,
+		Email:    email,
+	}); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return user, nil
+}
+
+func (a *authUsecase) createExternalUser(ctx context.Context, profile *domain.ExternalProfile) (*domain.User, error) {
+	// Providers do not always share the name of the user, the local part of the email stands in
+	firstName, lastName := profile.FirstName, profile.LastName
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(profile.Email, "@")
+	}
+	if lastName == "" {
+		lastName = "-"
+	}
+
+	user, err := a.userClient.Create(ctx, &domain.UserCreateRequest{
+		Email:     profile.Email,
+		FirstName: firstName,
+		LastName:  lastName,
+		External:  true,
+	})
+	if err != nil {
+		if de, ok := common.IsDetailError(err); ok {
+			return nil, de
+		}
+		return nil, domain.ErrUserCreationFailed.WithWrap(err)
+	}
+	return user, nil
+}
@@ -0,0 +1,335 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/cache"
+	"go-clean-arch/service/auth/identity"
+	"strings"
+	"testing"
+	"time"
+)
+
+type nopCacheLogger struct{}
+
+func (nopCacheLogger) Info(string, ...interface{})   {}
+func (nopCacheLogger) Error(string, ...interface{})  {}
+func (nopCacheLogger) Debug(string, ...interface{})  {}
+func (nopCacheLogger) Infof(string, ...interface{})  {}
+func (nopCacheLogger) Errorf(string, ...interface{}) {}
+func (nopCacheLogger) Debugf(string, ...interface{}) {}
+
+type testOAuthConfig struct{}
+
+func (testOAuthConfig) StateExpiresIn() time.Duration { return time.Minute }
+
+// fakeIdentityProvider records the requests of a login and answers the exchange with profile
+type fakeIdentityProvider struct {
+	name        string
+	profile     *domain.ExternalProfile
+	authCodeReq identity.AuthCodeRequest
+	exchangeReq identity.ExchangeRequest
+}
+
+func (p *fakeIdentityProvider) Name() string { return p.name }
+func (p *fakeIdentityProvider) AllowsRedirectURI(uri string) bool {
+	return uri == p.DefaultRedirectURI()
+}
+func (p *fakeIdentityProvider) DefaultRedirectURI() string { return "https://app.example.com/callback" }
+
+func (p *fakeIdentityProvider) AuthCodeURL(req identity.AuthCodeRequest) string {
+	p.authCodeReq = req
+	return "https://idp.example.com/authorize"
+}
+
+func (p *fakeIdentityProvider) Exchange(_ context.Context, req identity.ExchangeRequest) (*domain.ExternalProfile, error) {
+	p.exchangeReq = req
+	profile := *p.profile
+	return &profile, nil
+}
+
+// fakeUserClient keeps users by ID and matches emails regardless of case, as the user repository
+// does. The methods the tests do not need are left to the nil interface
+type fakeUserClient struct {
+	UserClient
+	users   map[string]*domain.User
+	lookups []*domain.UserFilter
+	created []*domain.UserCreateRequest
+	updates map[string]*domain.UserUpdateRequest
+	findErr error
+}
+
+func newFakeUserClient(users ...*domain.User) *fakeUserClient {
+	c := &fakeUserClient{
+		users:   map[string]*domain.User{},
+		updates: map[string]*domain.UserUpdateRequest{},
+	}
+	for _, user := range users {
+		c.users[user.ID] = user
+	}
+	return c
+}
+
+func (c *fakeUserClient) FindOne(_ context.Context, filter *domain.UserFilter, _ *domain.FindOneOption) (*domain.User, error) {
+	c.lookups = append(c.lookups, filter)
+	if c.findErr != nil {
+		return nil, c.findErr
+	}
+	for _, user := range c.users {
+		if (filter.ID == nil || *filter.ID == user.ID) && (filter.Email == nil || strings.EqualFold(*filter.Email, user.Email)) {
+			return user, nil
+		}
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (c *fakeUserClient) Create(_ context.Context, req *domain.UserCreateRequest) (*domain.User, error) {
+	c.created = append(c.created, req)
+	user := &domain.User{Email: req.Email, Status: domain.UserSTTActive}
+	user.ID = "created-user"
+	c.users[user.ID] = user
+	return user, nil
+}
+
+func (c *fakeUserClient) Update(_ context.Context, userID string, req *domain.UserUpdateRequest) (*domain.User, error) {
+	c.updates[userID] = req
+	user := c.users[userID]
+	if req.Status != nil {
+		user.Status = *req.Status
+	}
+	if req.PasswordResetRequired != nil {
+		user.PasswordResetRequired = *req.PasswordResetRequired
+	}
+	if req.ClearPassword {
+		user.Password = ""
+	}
+	return user, nil
+}
+
+type fakeExternalIdentityRepo struct {
+	identities []*domain.ExternalIdentity
+}
+
+func (r *fakeExternalIdentityRepo) Create(_ context.Context, identity *domain.ExternalIdentity) error {
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+func (r *fakeExternalIdentityRepo) FindBySubject(_ context.Context, provider, subject string) (*domain.ExternalIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func newTestUser(id, email string, status domain.UserStatus) *domain.User {
+	user := &domain.User{Email: email, Password: "hash", Status: status}
+	user.ID = id
+	return user
+}
+
+func newOAuthTestUsecase(userClient UserClient, identities ExternalIdentityRepository, providers ...IdentityProvider) *authUsecase {
+	return NewAuthUsecase(
+		nil, nil, nil, nil, nil, identities, providers, nil, nil, nil, userClient, nil, nil, nil, nil,
+		cache.NewMemoryCache(&cache.Config{}, nopCacheLogger{}), nil, nil, nil, nil, nil, testOAuthConfig{}, nil, nil,
+	).(*authUsecase)
+}
+
+func TestCompleteOAuthLoginState(t *testing.T) {
+	ctx := context.Background()
+	unverified := &domain.ExternalProfile{Subject: "subject-1", Email: "jane@example.com"}
+
+	tests := []struct {
+		name    string
+		state   func(a *authUsecase) string
+		wantErr error
+	}{
+		{
+			name:    "unknown state",
+			state:   func(*authUsecase) string { return "unknown" },
+			wantErr: domain.ErrInvalidOAuthState,
+		},
+		{
+			name: "state of another provider",
+			state: func(a *authUsecase) string {
+				resp, _ := a.StartOAuthLogin(ctx, &domain.StartOAuthLoginRequest{Provider: "other"})
+				return resp.State
+			},
+			wantErr: domain.ErrInvalidOAuthState,
+		},
+		{
+			name: "state redeemed twice",
+			state: func(a *authUsecase) string {
+				resp, _ := a.StartOAuthLogin(ctx, &domain.StartOAuthLoginRequest{Provider: "google"})
+				_, _ = a.CompleteOAuthLogin(ctx, &domain.CompleteOAuthLoginRequest{
+					Provider: "google",
+					State:    resp.State,
+					Code:     "code",
+				})
+				return resp.State
+			},
+			wantErr: domain.ErrInvalidOAuthState,
+		},
+		{
+			// The state is valid, the login then stops at the unverified email of the profile
+			name: "valid state",
+			state: func(a *authUsecase) string {
+				resp, _ := a.StartOAuthLogin(ctx, &domain.StartOAuthLoginRequest{Provider: "google"})
+				return resp.State
+			},
+			wantErr: domain.ErrExternalEmailNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newOAuthTestUsecase(newFakeUserClient(), &fakeExternalIdentityRepo{},
+				&fakeIdentityProvider{name: "google", profile: unverified},
+				&fakeIdentityProvider{name: "other", profile: unverified},
+			)
+			_, err := a.CompleteOAuthLogin(ctx, &domain.CompleteOAuthLoginRequest{
+				Provider: "google",
+				State:    tt.state(a),
+				Code:     "code",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CompleteOAuthLogin() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompleteOAuthLoginBindsNonceAndPKCE(t *testing.T) {
+	ctx := context.Background()
+	provider := &fakeIdentityProvider{
+		name:    "google",
+		profile: &domain.ExternalProfile{Subject: "subject-1", Email: "jane@example.com"},
+	}
+	a := newOAuthTestUsecase(newFakeUserClient(), &fakeExternalIdentityRepo{}, provider)
+
+	resp, err := a.StartOAuthLogin(ctx, &domain.StartOAuthLoginRequest{Provider: "google"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = a.CompleteOAuthLogin(ctx, &domain.CompleteOAuthLoginRequest{
+		Provider: "google",
+		State:    resp.State,
+		Code:     "code",
+	})
+
+	challenge := sha256.Sum256([]byte(provider.exchangeReq.CodeVerifier))
+	if got := base64.RawURLEncoding.EncodeToString(challenge[:]); got != provider.authCodeReq.CodeChallenge {
+		t.Errorf("code verifier hashes to %s, want the challenge %s", got, provider.authCodeReq.CodeChallenge)
+	}
+	if provider.exchangeReq.Nonce == "" || provider.exchangeReq.Nonce != provider.authCodeReq.Nonce {
+		t.Errorf("exchange nonce = %q, want the authorization nonce %q", provider.exchangeReq.Nonce, provider.authCodeReq.Nonce)
+	}
+	if provider.exchangeReq.RedirectURI != provider.authCodeReq.RedirectURI {
+		t.Errorf("exchange redirect URI = %q, want %q", provider.exchangeReq.RedirectURI, provider.authCodeReq.RedirectURI)
+	}
+	if provider.authCodeReq.State != resp.State {
+		t.Errorf("authorization state = %q, want %q", provider.authCodeReq.State, resp.State)
+	}
+}
+
+func TestFindOrLinkExternalUser(t *testing.T) {
+	ctx := context.Background()
+	verified := &domain.ExternalProfile{Subject: "subject-1", Email: "jane@example.com", EmailVerified: true}
+
+	tests := []struct {
+		name       string
+		users      []*domain.User
+		identities []*domain.ExternalIdentity
+		profile    *domain.ExternalProfile
+		wantUserID string
+		wantErr    error
+		check      func(t *testing.T, users *fakeUserClient)
+	}{
+		{
+			name:       "linked account",
+			users:      []*domain.User{newTestUser("user-1", "another@example.com", domain.UserSTTActive)},
+			identities: []*domain.ExternalIdentity{{UserID: "user-1", Provider: "google", Subject: "subject-1"}},
+			profile:    verified,
+			wantUserID: "user-1",
+		},
+		{
+			name:    "unverified email",
+			users:   []*domain.User{newTestUser("user-1", "jane@example.com", domain.UserSTTActive)},
+			profile: &domain.ExternalProfile{Subject: "subject-1", Email: "jane@example.com"},
+			wantErr: domain.ErrExternalEmailNotVerified,
+		},
+		{
+			name:       "active account with the email",
+			users:      []*domain.User{newTestUser("user-1", "jane@example.com", domain.UserSTTActive)},
+			profile:    verified,
+			wantUserID: "user-1",
+			check: func(t *testing.T, users *fakeUserClient) {
+				if len(users.updates) != 0 || users.users["user-1"].Password != "hash" {
+					t.Error("the verified account must be linked unchanged")
+				}
+			},
+		},
+		{
+			name:       "unverified account with the email",
+			users:      []*domain.User{newTestUser("user-1", "jane@example.com", domain.UserSTTWaitingVerify)},
+			profile:    verified,
+			wantUserID: "user-1",
+			check: func(t *testing.T, users *fakeUserClient) {
+				req := users.updates["user-1"]
+				if req == nil || !req.ClearPassword || req.PasswordResetRequired == nil || !*req.PasswordResetRequired {
+					t.Fatalf("update = %+v, want the password cleared and a reset required", req)
+				}
+				if user := users.users["user-1"]; user.Status != domain.UserSTTActive || user.Password != "" {
+					t.Errorf("user status = %s, password = %q, want an active user without password", user.Status, user.Password)
+				}
+			},
+		},
+		{
+			name:       "no account with the email",
+			profile:    verified,
+			wantUserID: "created-user",
+			check: func(t *testing.T, users *fakeUserClient) {
+				if len(users.created) != 1 || !users.created[0].External {
+					t.Errorf("created = %+v, want one external user", users.created)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUserClient(tt.users...)
+			identities := &fakeExternalIdentityRepo{identities: tt.identities}
+			a := newOAuthTestUsecase(users, identities)
+
+			user, err := a.findOrLinkExternalUser(ctx, "google", tt.profile)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("findOrLinkExternalUser() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(identities.identities) != len(tt.identities) {
+					t.Error("no identity must be linked")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("findOrLinkExternalUser() error = %v", err)
+			}
+			if user.ID != tt.wantUserID {
+				t.Errorf("findOrLinkExternalUser() user = %s, want %s", user.ID, tt.wantUserID)
+			}
+			linked, _ := identities.FindBySubject(ctx, "google", tt.profile.Subject)
+			if linked == nil || linked.UserID != tt.wantUserID {
+				t.Errorf("identity linked to %+v, want %s", linked, tt.wantUserID)
+			}
+			if tt.check != nil {
+				tt.check(t, users)
+			}
+		})
+	}
+}
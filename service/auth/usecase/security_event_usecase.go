@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch/domain"
+	"time"
+)
+
+type SecurityEventRepository interface {
+	FindPage(ctx context.Context, filter *domain.SecurityEventFilter, option *domain.FindPageOption) ([]*domain.SecurityEvent, *domain.Pagination, error)
+	DeleteOccurredBefore(ctx context.Context, before int64) (int64, error)
+}
+
+type SecurityEventConfig interface {
+	Retention() time.Duration
+}
+
+type securityEventUsecase struct {
+	securityEventRepo SecurityEventRepository
+	cfg               SecurityEventConfig
+}
+
+func NewSecurityEventUsecase(securityEventRepo SecurityEventRepository, cfg SecurityEventConfig) domain.SecurityEventUsecase {
+	return &securityEventUsecase{
+		securityEventRepo: securityEventRepo,
+		cfg:               cfg,
+	}
+}
+
+// ListSecurityEvents returns the matching events, the most recent first.
+func (s *securityEventUsecase) ListSecurityEvents(ctx context.Context, req *domain.ListSecurityEventsRequest) ([]*domain.SecurityEvent, *domain.Pagination, error) {
+	if req.From != nil && req.To != nil && *req.From >= *req.To {
+		return nil, nil, domain.ErrBadRequest.WithError("from must be before to")
+	}
+
+	events, pagination, err := s.securityEventRepo.FindPage(ctx, &domain.SecurityEventFilter{
+		UserID:         req.UserID,
+		ActorID:        req.ActorID,
+		Type:           req.Type,
+		IPAddress:      req.IPAddress,
+		OccurredAfter:  req.From,
+		OccurredBefore: req.To,
+	}, &domain.FindPageOption{
+		Sort:    []string{"occurred_at DESC"},
+		Page:    req.Page,
+		PerPage: req.PerPage,
+	})
+	if err != nil {
+		return nil, nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return events, pagination, nil
+}
+
+// PurgeSecurityEvents deletes the events older than the retention period and returns how many.
+func (s *securityEventUsecase) PurgeSecurityEvents(ctx context.Context) (int64, error) {
+	before := time.Now().Add(-s.cfg.Retention()).UnixMilli()
+	deleted, err := s.securityEventRepo.DeleteOccurredBefore(ctx, before)
+	if err != nil {
+		return 0, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+)
+
+type sessionUsecase struct {
+	sessionRepo    UserSessionRepository
+	revocationList TokenRevocationList
+}
+
+func NewSessionUsecase(sessionRepo UserSessionRepository, revocationList TokenRevocationList) domain.SessionUsecase {
+	return &sessionUsecase{sessionRepo: sessionRepo, revocationList: revocationList}
+}
+
+// ListSessions returns the user's active sessions, most recently used first.
+func (s *sessionUsecase) ListSessions(ctx context.Context, req *domain.ListSessionsRequest) ([]*domain.SessionInfo, *domain.Pagination, error) {
+	active := true
+	now := utils.NowUnixMillis()
+	sessions, pagination, err := s.sessionRepo.FindPage(ctx, &domain.UserSessionFilter{
+		UserID:  &req.UserID,
+		Active:  &active,
+		ValidAt: &now,
+	}, &domain.FindPageOption{
+		Sort:    []string{"last_activity_at DESC", "created_at DESC"},
+		Page:    req.Page,
+		PerPage: req.PerPage,
+	})
+	if err != nil {
+		return nil, nil, domain.ErrSessionFindFailed.WithWrap(err)
+	}
+
+	infos := make([]*domain.SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		infos = append(infos, toSessionInfo(session, req.CurrentSessionID))
+	}
+	return infos, pagination, nil
+}
+
+func (s *sessionUsecase) GetSession(ctx context.Context, req *domain.GetSessionRequest) (*domain.SessionInfo, error) {
+	session, err := s.findUserSession(ctx, req.UserID, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	return toSessionInfo(session, req.CurrentSessionID), nil
+}
+
+func (s *sessionUsecase) RevokeSession(ctx context.Context, req *domain.RevokeSessionRequest) error {
+	session, err := s.findUserSession(ctx, req.UserID, req.SessionID)
+	if err != nil {
+		return err
+	}
+	if err := s.sessionRepo.Revoke(ctx, session.ID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if err := s.revocationList.RevokeSession(ctx, session.ID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return nil
+}
+
+func (s *sessionUsecase) RevokeOtherSessions(ctx context.Context, req *domain.RevokeOtherSessionsRequest) (int64, error) {
+	// Collect the sessions first, their access tokens are revoked once the sessions are ended
+	active := true
+	filter := &domain.UserSessionFilter{
+		UserID: &req.UserID,
+		Active: &active,
+	}
+	if req.CurrentSessionID != "" {
+		filter.IDNe = &req.CurrentSessionID
+	}
+	sessions, err := s.sessionRepo.FindMany(ctx, filter, nil)
+	if err != nil {
+		return 0, domain.ErrSessionFindFailed.WithWrap(err)
+	}
+
+	revoked, err := s.sessionRepo.RevokeAllByUserIDExcept(ctx, req.UserID, req.CurrentSessionID)
+	if err != nil {
+		return 0, domain.ErrInternalServerError.WithWrap(err)
+	}
+	for _, session := range sessions {
+		if err := s.revocationList.RevokeSession(ctx, session.ID); err != nil {
+			return 0, domain.ErrInternalServerError.WithWrap(err)
+		}
+	}
+	return revoked, nil
+}
+
+// findUserSession loads an active session and makes sure it belongs to the user, so a session
+// ID of another account is reported as not found.
+func (s *sessionUsecase) findUserSession(ctx context.Context, userID, sessionID string) (*domain.UserSession, error) {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID, nil)
+	if err != nil {
+		if common.IsRecordNotFound(err) {
+			return nil, domain.ErrSessionNotFound
+		}
+		return nil, domain.ErrSessionFindFailed.WithWrap(err)
+	}
+	if session == nil || session.UserID != userID || !session.IsActive() {
+		return nil, domain.ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func toSessionInfo(session *domain.UserSession, currentSessionID string) *domain.SessionInfo {
+	ua := utils.ParseUserAgent(session.UserAgent)
+	return &domain.SessionInfo{
+		ID:             session.ID,
+		IPAddress:      session.IPAddress,
+		UserAgent:      session.UserAgent,
+		Browser:        ua.Browser,
+		OS:             ua.OS,
+		Device:         ua.Device,
+		Current:        currentSessionID != "" && session.ID == currentSessionID,
+		Impersonated:   session.ImpersonatorID != "",
+		CreatedAt:      session.CreatedAt,
+		LastActivityAt: session.LastActivityAt,
+		ExpiresAt:      session.ExpiresAt,
+	}
+}
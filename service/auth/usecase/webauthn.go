@@ -0,0 +1,417 @@
+package usecase
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+	"go-clean-arch/pkg/webauthn"
+	"strings"
+	"time"
+)
+
+type WebAuthnCredentialRepository interface {
+	Create(ctx context.Context, credential *domain.WebAuthnCredential) error
+	FindByCredentialID(ctx context.Context, credentialID string) (*domain.WebAuthnCredential, error)
+	FindByUserID(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error)
+	CountByUserID(ctx context.Context, userID string) (int64, error)
+	Update(ctx context.Context, credential *domain.WebAuthnCredential) error
+	RecordUse(ctx context.Context, id string, signCount, usedAt int64) (bool, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type RelyingParty interface {
+	CreationOptions(challenge string, user webauthn.User, exclude []webauthn.CredentialDescriptor) *webauthn.CreationOptions
+	RequestOptions(challenge string, allow []webauthn.CredentialDescriptor, userVerification string) *webauthn.RequestOptions
+	VerifyRegistration(resp *webauthn.RegistrationResponse, challenge string) (*webauthn.Credential, error)
+	VerifyAssertion(resp *webauthn.AssertionResponse, challenge, userVerification string, coseKey []byte, storedSignCount uint32) (*webauthn.Assertion, error)
+}
+
+type WebAuthnConfig interface {
+	ChallengeExpiresIn() time.Duration
+}
+
+const (
+	webAuthnChallengeIDBytes = 16
+	defaultPasskeyName       = "Passkey"
+)
+
+func webAuthnChallengeKey(id string) string {
+	return "webauthn_challenge:" + id
+}
+
+func webAuthnChallengeUsedKey(id string) string {
+	return "webauthn_challenge_used:" + id
+}
+
+// BeginPasskeyRegistration starts the registration of a new passkey for a logged in user.
+func (a *authUsecase) BeginPasskeyRegistration(ctx context.Context, req *domain.BeginPasskeyRegistrationRequest) (*domain.PasskeyOptionsResponse, error) {
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		ID: &req.UserID,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound.WithWrap(err)
+	}
+
+	credentials, err := a.webAuthnCredentialRepo.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	challengeID, challenge, err := a.storeWebAuthnChallenge(ctx, domain.WebAuthnPurposeRegistration, user.ID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	// Authenticators already holding a passkey of the user refuse to create a second one
+	displayName := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if displayName == "" {
+		displayName = user.Email
+	}
+	options := a.relyingParty.CreationOptions(challenge, webauthn.User{
+		ID:          user.ID,
+		Name:        user.Email,
+		DisplayName: displayName,
+	}, credentialDescriptors(credentials))
+
+	return &domain.PasskeyOptionsResponse{ChallengeID: challengeID, PublicKey: options}, nil
+}
+
+// FinishPasskeyRegistration verifies the credential created by the authenticator and stores it.
+func (a *authUsecase) FinishPasskeyRegistration(ctx context.Context, req *domain.FinishPasskeyRegistrationRequest) (*domain.WebAuthnCredential, error) {
+	challenge, err := a.consumeWebAuthnChallenge(ctx, req.ChallengeID, domain.WebAuthnPurposeRegistration)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID != req.UserID {
+		return nil, domain.ErrInvalidWebAuthnChallenge
+	}
+
+	var resp webauthn.RegistrationResponse
+	if err := json.Unmarshal(req.Credential, &resp); err != nil {
+		return nil, domain.ErrPasskeyValidationFailed.WithError("credential is malformed")
+	}
+	verified, err := a.relyingParty.VerifyRegistration(&resp, challenge.Challenge)
+	if err != nil {
+		return nil, domain.ErrWebAuthnVerificationFailed.WithWrap(err)
+	}
+
+	credentialID := webauthn.EncodeID(verified.ID)
+	if _, err := a.webAuthnCredentialRepo.FindByCredentialID(ctx, credentialID); err == nil {
+		return nil, domain.ErrPasskeyAlreadyRegistered
+	} else if !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = defaultPasskeyName
+	}
+	credential := &domain.WebAuthnCredential{
+		UserID:         req.UserID,
+		CredentialID:   credentialID,
+		PublicKey:      verified.PublicKey,
+		Algorithm:      verified.Algorithm,
+		SignCount:      int64(verified.SignCount),
+		AAGUID:         formatAAGUID(verified.AAGUID),
+		Transports:     verified.Transports,
+		Name:           name,
+		BackupEligible: verified.BackupEligible,
+	}
+	if err := a.webAuthnCredentialRepo.Create(ctx, credential); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return credential, nil
+}
+
+// BeginPasskeyLogin starts a passwordless login. No user is known yet, the authenticator lets the
+// user pick one of the discoverable credentials it holds for the site.
+func (a *authUsecase) BeginPasskeyLogin(ctx context.Context) (*domain.PasskeyOptionsResponse, error) {
+	// A passkey replaces both the password and the second factor, so the user must be verified
+	challengeID, challenge, err := a.storeWebAuthnChallenge(ctx, domain.WebAuthnPurposeLogin, "", webauthn.UserVerificationRequired)
+	if err != nil {
+		return nil, err
+	}
+
+	options := a.relyingParty.RequestOptions(challenge, nil, webauthn.UserVerificationRequired)
+	return &domain.PasskeyOptionsResponse{ChallengeID: challengeID, PublicKey: options}, nil
+}
+
+// FinishPasskeyLogin verifies the assertion of a discoverable credential and logs in its owner.
+func (a *authUsecase) FinishPasskeyLogin(ctx context.Context, req *domain.FinishPasskeyLoginRequest) (*domain.AuthResponse, error) {
+	challenge, err := a.consumeWebAuthnChallenge(ctx, req.ChallengeID, domain.WebAuthnPurposeLogin)
+	if err != nil {
+		return nil, err
+	}
+
+	credential, err := a.verifyPasskeyAssertion(ctx, req.Credential, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		ID: &credential.UserID,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return nil, domain.ErrWebAuthnVerificationFailed
+	}
+
+	// The authenticator verified the user, the passkey is both factors
+	resp, err := a.completeLogin(ctx, user, true, req.IPAddress, req.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	return resp.AuthResponse, nil
+}
+
+// BeginPasskeyMFA starts the second step of a login with one of the passkeys of the user. The MFA
+// token stays valid until the assertion is verified.
+func (a *authUsecase) BeginPasskeyMFA(ctx context.Context, req *domain.BeginPasskeyMFARequest) (*domain.PasskeyOptionsResponse, error) {
+	token, err := a.verificationTokenRepo.FindByTokenHash(ctx, domain.VerificationPurposeMFAChallenge, common.HashToken(req.MFAToken))
+	if err != nil || token == nil || !token.IsUsable() {
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	credentials, err := a.webAuthnCredentialRepo.FindByUserID(ctx, token.UserID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if len(credentials) == 0 {
+		return nil, domain.ErrPasskeyNotFound
+	}
+
+	// The password was already checked, the passkey only has to prove possession
+	challengeID, challenge, err := a.storeWebAuthnChallenge(ctx, domain.WebAuthnPurposeMFA, token.UserID, webauthn.UserVerificationDiscouraged)
+	if err != nil {
+		return nil, err
+	}
+
+	options := a.relyingParty.RequestOptions(challenge, credentialDescriptors(credentials), webauthn.UserVerificationDiscouraged)
+	return &domain.PasskeyOptionsResponse{ChallengeID: challengeID, PublicKey: options}, nil
+}
+
+// VerifyPasskeyMFA completes a login with a passkey as the second factor.
+func (a *authUsecase) VerifyPasskeyMFA(ctx context.Context, req *domain.VerifyPasskeyMFARequest) (*domain.AuthResponse, error) {
+	token, err := a.verificationTokenRepo.FindByTokenHash(ctx, domain.VerificationPurposeMFAChallenge, common.HashToken(req.MFAToken))
+	if err != nil || token == nil || !token.IsUsable() {
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	user, err := a.userClient.FindOne(ctx, &domain.UserFilter{
+		ID: &token.UserID,
+	}, &domain.FindOneOption{})
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound.WithWrap(err)
+	}
+	// Failed assertions count like wrong MFA codes
+	if err := a.checkLoginLock(ctx, loginScopeUser, user.ID); err != nil {
+		return nil, err
+	}
+	if err := a.checkLoginDelay(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	challenge, err := a.consumeWebAuthnChallenge(ctx, req.ChallengeID, domain.WebAuthnPurposeMFA)
+	if err != nil {
+		return nil, err
+	}
+	if challenge.UserID != token.UserID {
+		return nil, domain.ErrInvalidWebAuthnChallenge
+	}
+
+	if _, err := a.verifyPasskeyAssertion(ctx, req.Credential, challenge); err != nil {
+		if errors.Is(err, domain.ErrWebAuthnVerificationFailed) {
+			return nil, a.recordMFAFailure(ctx, token, user, err, req.IPAddress, req.UserAgent)
+		}
+		return nil, err
+	}
+
+	consumed, err := a.verificationTokenRepo.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !consumed {
+		return nil, domain.ErrInvalidMFAToken
+	}
+	if err := a.clearMFAFailures(ctx, token.ID, user.ID); err != nil {
+		return nil, err
+	}
+
+	resp, err := a.completeLogin(ctx, user, true, req.IPAddress, req.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	return resp.AuthResponse, nil
+}
+
+func (a *authUsecase) ListPasskeys(ctx context.Context, userID string) ([]*domain.WebAuthnCredential, error) {
+	credentials, err := a.webAuthnCredentialRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return credentials, nil
+}
+
+func (a *authUsecase) RenamePasskey(ctx context.Context, req *domain.RenamePasskeyRequest) (*domain.WebAuthnCredential, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, domain.ErrPasskeyValidationFailed.WithError("name must be not empty")
+	}
+
+	credential, err := a.findUserPasskey(ctx, req.UserID, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	credential.Name = name
+	if err := a.webAuthnCredentialRepo.Update(ctx, credential); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return credential, nil
+}
+
+func (a *authUsecase) DeletePasskey(ctx context.Context, req *domain.DeletePasskeyRequest) error {
+	credential, err := a.findUserPasskey(ctx, req.UserID, req.ID)
+	if err != nil {
+		return err
+	}
+	if err := a.webAuthnCredentialRepo.Delete(ctx, credential.ID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return nil
+}
+
+// findUserPasskey returns the passkey with the given ID, as long as it belongs to the user.
+func (a *authUsecase) findUserPasskey(ctx context.Context, userID, id string) (*domain.WebAuthnCredential, error) {
+	credentials, err := a.webAuthnCredentialRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	for _, credential := range credentials {
+		if credential.ID == id {
+			return credential, nil
+		}
+	}
+	return nil, domain.ErrPasskeyNotFound
+}
+
+// verifyPasskeyAssertion checks an assertion against the stored credential it was made with and
+// records the new sign counter. Credentials of other users than the one of the challenge are rejected.
+func (a *authUsecase) verifyPasskeyAssertion(ctx context.Context, rawCredential json.RawMessage, challenge *domain.WebAuthnChallenge) (*domain.WebAuthnCredential, error) {
+	var resp webauthn.AssertionResponse
+	if err := json.Unmarshal(rawCredential, &resp); err != nil {
+		return nil, domain.ErrPasskeyValidationFailed.WithError("credential is malformed")
+	}
+	rawID, err := webauthn.DecodeID(resp.RawID)
+	if err != nil || len(rawID) == 0 {
+		return nil, domain.ErrPasskeyValidationFailed.WithError("credential id is malformed")
+	}
+
+	credential, err := a.webAuthnCredentialRepo.FindByCredentialID(ctx, webauthn.EncodeID(rawID))
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, domain.ErrWebAuthnVerificationFailed
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if challenge.UserID != "" && credential.UserID != challenge.UserID {
+		return nil, domain.ErrWebAuthnVerificationFailed
+	}
+
+	assertion, err := a.relyingParty.VerifyAssertion(&resp, challenge.Challenge, challenge.UserVerification, credential.PublicKey, uint32(credential.SignCount))
+	if err != nil {
+		return nil, domain.ErrWebAuthnVerificationFailed.WithWrap(err)
+	}
+	// Discoverable credentials name their owner, it must be the one the credential was registered for
+	if assertion.UserHandle != "" && assertion.UserHandle != credential.UserID {
+		return nil, domain.ErrWebAuthnVerificationFailed
+	}
+
+	// Two concurrent assertions with the same counter cannot both succeed
+	now := utils.NowUnixMillis()
+	recorded, err := a.webAuthnCredentialRepo.RecordUse(ctx, credential.ID, int64(assertion.SignCount), now)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !recorded {
+		return nil, domain.ErrWebAuthnVerificationFailed
+	}
+	credential.SignCount = int64(assertion.SignCount)
+	credential.LastUsedAt = now
+	return credential, nil
+}
+
+// storeWebAuthnChallenge creates a challenge for a ceremony and keeps it in the cache until the
+// client sends back the credential. It returns the ID the client must send back with it.
+func (a *authUsecase) storeWebAuthnChallenge(ctx context.Context, purpose domain.WebAuthnPurpose, userID, userVerification string) (string, string, error) {
+	challengeID, err := common.GenerateSecureToken(webAuthnChallengeIDBytes)
+	if err != nil {
+		return "", "", domain.ErrInternalServerError.WithWrap(err)
+	}
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", "", domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	if err := a.cache.SetJSON(ctx, webAuthnChallengeKey(challengeID), &domain.WebAuthnChallenge{
+		Challenge:        challenge,
+		Purpose:          purpose,
+		UserID:           userID,
+		UserVerification: userVerification,
+	}, a.webAuthnCfg.ChallengeExpiresIn()); err != nil {
+		return "", "", domain.ErrInternalServerError.WithWrap(err)
+	}
+	return challengeID, challenge, nil
+}
+
+// consumeWebAuthnChallenge loads a challenge and makes sure it is only redeemed once, even when the
+// verification fails afterwards.
+func (a *authUsecase) consumeWebAuthnChallenge(ctx context.Context, challengeID string, purpose domain.WebAuthnPurpose) (*domain.WebAuthnChallenge, error) {
+	var challenge domain.WebAuthnChallenge
+	if err := a.cache.GetJSON(ctx, webAuthnChallengeKey(challengeID), &challenge); err != nil {
+		return nil, domain.ErrInvalidWebAuthnChallenge
+	}
+	if challenge.Purpose != purpose {
+		return nil, domain.ErrInvalidWebAuthnChallenge
+	}
+
+	used, err := a.cache.Increment(ctx, webAuthnChallengeUsedKey(challengeID), 1, a.webAuthnCfg.ChallengeExpiresIn())
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if used != 1 {
+		return nil, domain.ErrInvalidWebAuthnChallenge
+	}
+	_ = a.cache.Delete(ctx, webAuthnChallengeKey(challengeID))
+
+	return &challenge, nil
+}
+
+func credentialDescriptors(credentials []*domain.WebAuthnCredential) []webauthn.CredentialDescriptor {
+	descriptors := make([]webauthn.CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		descriptors = append(descriptors, webauthn.CredentialDescriptor{
+			Type:       "public-key",
+			ID:         credential.CredentialID,
+			Transports: credential.Transports,
+		})
+	}
+	return descriptors
+}
+
+// formatAAGUID renders the authenticator model in the usual UUID form, an empty string when the
+// authenticator does not disclose it.
+func formatAAGUID(aaguid []byte) string {
+	if len(aaguid) != 16 {
+		return ""
+	}
+	for _, b := range aaguid {
+		if b != 0 {
+			h := hex.EncodeToString(aaguid)
+			return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+		}
+	}
+	return ""
+}
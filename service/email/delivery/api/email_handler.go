@@ -36,35 +36,39 @@ func NewEmailHandler(usecase domain.EmailUsecase, templateRenderer TemplateRende
 func (h *EmailHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	email := rg.Group("/emails")
 
-	// Apply authentication middleware for all email routes
-	email.Use(h.middlewares.Authenticator())
-	// Require admin or super admin roles for all email operations
-	email.Use(h.middlewares.RequireAnyRoles(domain.RoleIDAdmin, domain.RoleIDSuperAdmin))
+	// Email routes are called by administrators and by other services with an API key,
+	// access is granted by scope for both
+	email.Use(h.middlewares.UserOrAPIKeyAuthenticator())
 	// Apply admin-specific rate limiting
 	email.Use(h.middlewares.AdminRateLimits())
 
 	// Email sending operations
+	sending := email.Group("")
+	sending.Use(h.middlewares.RequireScopes(domain.ScopeEmailsSend))
 	{
-		email.POST("", h.SendEmail)
-		email.POST("/template", h.SendEmailWithTemplate)
-		email.POST("/bulk", h.SendBulkEmail)
-		email.POST("/resend/:id", h.ResendEmail)
+		sending.POST("", h.SendEmail)
+		sending.POST("/template", h.SendEmailWithTemplate)
+		sending.POST("/bulk", h.SendBulkEmail)
+		sending.POST("/resend/:id", h.ResendEmail)
 	}
 
 	// Email template operations
+	templateReadScope := h.middlewares.RequireScopes(domain.ScopeEmailTemplatesRead)
+	templateWriteScope := h.middlewares.RequireScopes(domain.ScopeEmailTemplatesWrite)
 	templates := email.Group("/templates")
 	{
-		templates.POST("", h.CreateTemplate)
-		templates.GET("/:id", h.GetTemplateByID)
-		templates.GET("", h.ListTemplates)
-		templates.PUT("/:id", h.UpdateTemplate)
-		templates.DELETE("/:id", h.DeleteTemplate)
-		templates.GET("/code/:code", h.GetTemplateByCode)
-		templates.POST("/preview", h.PreviewTemplate)
+		templates.POST("", templateWriteScope, h.CreateTemplate)
+		templates.GET("/:id", templateReadScope, h.GetTemplateByID)
+		templates.GET("", templateReadScope, h.ListTemplates)
+		templates.PUT("/:id", templateWriteScope, h.UpdateTemplate)
+		templates.DELETE("/:id", templateWriteScope, h.DeleteTemplate)
+		templates.GET("/code/:code", templateReadScope, h.GetTemplateByCode)
+		templates.POST("/preview", templateReadScope, h.PreviewTemplate)
 	}
 
 	// Email log operations
 	logs := email.Group("/logs")
+	logs.Use(h.middlewares.RequireScopes(domain.ScopeEmailsRead))
 	{
 		logs.GET("/:id", h.GetEmailLog)
 		logs.GET("", h.GetEmailLogs)
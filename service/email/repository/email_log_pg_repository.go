@@ -14,7 +14,7 @@ type EmailLogRepository struct {
 }
 
 func NewEmailLogRepository(db *gorm.DB) *EmailLogRepository {
-	sqlHandler := database.NewSQLHandler[domain.EmailLog](db, applyEmailLogFilter)
+	sqlHandler := database.NewSQLHandler[domain.EmailLog](db, applyEmailLogFilter, database.WithoutTenantScope())
 	return &EmailLogRepository{
 		db:         db,
 		sqlHandler: sqlHandler,
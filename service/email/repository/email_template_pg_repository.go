@@ -13,7 +13,7 @@ type EmailTemplateRepository struct {
 }
 
 func NewEmailTemplateRepository(db *gorm.DB) *EmailTemplateRepository {
-	sqlHandler := database.NewSQLHandler[domain.EmailTemplate](db, applyEmailTemplateFilter)
+	sqlHandler := database.NewSQLHandler[domain.EmailTemplate](db, applyEmailTemplateFilter, database.WithoutTenantScope())
 	return &EmailTemplateRepository{
 		sqlHandler: sqlHandler,
 	}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationHandler struct {
+	usecase     domain.OrganizationUsecase
+	middlewares middleware.Middlewares
+}
+
+func NewOrganizationHandler(usecase domain.OrganizationUsecase, middlewares middleware.Middlewares) *OrganizationHandler {
+	return &OrganizationHandler{
+		usecase:     usecase,
+		middlewares: middlewares,
+	}
+}
+
+func (h *OrganizationHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	// Organizations of the authenticated user, across organizations
+	organizations := rg.Group("/organizations")
+	organizations.Use(h.middlewares.Authenticator())
+	organizations.Use(h.middlewares.APIRateLimits())
+	{
+		organizations.POST("", h.CreateOrganization)
+		organizations.GET("", h.ListMyOrganizations)
+		// Impersonated sessions keep the organization they were started in, the new token would
+		// lose the act claim
+		organizations.POST("/switch", h.middlewares.DenyImpersonation(), h.SwitchOrganization)
+		organizations.POST("/invitations/accept", h.AcceptInvitation)
+	}
+
+	// The organization selected by the X-Organization-ID header or the token
+	organization := rg.Group("/organization")
+	organization.Use(h.middlewares.Authenticator())
+	organization.Use(h.middlewares.Tenant())
+	organization.Use(h.middlewares.APIRateLimits())
+	{
+		organization.GET("", h.GetOrganization)
+		organization.PUT("", h.UpdateOrganization)
+		organization.GET("/members", h.ListMembers)
+		organization.PUT("/members/:user_id", h.UpdateMemberRole)
+		organization.DELETE("/members/:user_id", h.RemoveMember)
+		organization.GET("/invitations", h.ListInvitations)
+		organization.POST("/invitations", h.InviteMember)
+		organization.DELETE("/invitations/:id", h.RevokeInvitation)
+	}
+}
+
+func (h *OrganizationHandler) CreateOrganization(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.ActorID = user.ID
+
+	organization, err := h.usecase.CreateOrganization(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseCreated(c, organization, "Organization created successfully")
+}
+
+func (h *OrganizationHandler) ListMyOrganizations(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	members, err := h.usecase.ListMyOrganizations(c.Request.Context(), user.ID)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, members, "Organizations retrieved successfully")
+}
+
+func (h *OrganizationHandler) SwitchOrganization(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.SwitchOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.UserID = user.ID
+	req.SessionID = common.GetSessionIDFromCtx(c)
+
+	resp, err := h.usecase.SwitchOrganization(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, resp, "Organization switched successfully")
+}
+
+func (h *OrganizationHandler) AcceptInvitation(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.AcceptInvitationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	if req.Token == "" {
+		common.ResponseBadRequest(c, "token must be not empty")
+		return
+	}
+	req.UserID = user.ID
+
+	member, err := h.usecase.AcceptInvitation(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, member, "Invitation accepted successfully")
+}
+
+func (h *OrganizationHandler) GetOrganization(c *gin.Context) {
+	organization, err := h.usecase.GetOrganization(c.Request.Context())
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, organization, "Organization retrieved successfully")
+}
+
+func (h *OrganizationHandler) UpdateOrganization(c *gin.Context) {
+	var req domain.UpdateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.Member = common.GetMembershipFromCtx(c)
+
+	organization, err := h.usecase.UpdateOrganization(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, organization, "Organization updated successfully")
+}
+
+func (h *OrganizationHandler) ListMembers(c *gin.Context) {
+	members, err := h.usecase.ListMembers(c.Request.Context())
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, members, "Members retrieved successfully")
+}
+
+func (h *OrganizationHandler) UpdateMemberRole(c *gin.Context) {
+	var req domain.UpdateMemberRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.UserID = c.Param("user_id")
+	req.Member = common.GetMembershipFromCtx(c)
+
+	member, err := h.usecase.UpdateMemberRole(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, member, "Member role updated successfully")
+}
+
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	if err := h.usecase.RemoveMember(c.Request.Context(), &domain.RemoveMemberRequest{
+		UserID: c.Param("user_id"),
+		Member: common.GetMembershipFromCtx(c),
+	}); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Member removed")
+}
+
+func (h *OrganizationHandler) ListInvitations(c *gin.Context) {
+	var req domain.ListInvitationsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.Member = common.GetMembershipFromCtx(c)
+
+	invitations, err := h.usecase.ListInvitations(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, invitations, "Invitations retrieved successfully")
+}
+
+func (h *OrganizationHandler) InviteMember(c *gin.Context) {
+	var req domain.InviteMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	if req.Email == "" {
+		common.ResponseBadRequest(c, "email must be not empty")
+		return
+	}
+	req.Member = common.GetMembershipFromCtx(c)
+
+	invitation, err := h.usecase.InviteMember(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseCreated(c, invitation, "Invitation sent successfully")
+}
+
+func (h *OrganizationHandler) RevokeInvitation(c *gin.Context) {
+	if err := h.usecase.RevokeInvitation(c.Request.Context(), &domain.RevokeInvitationRequest{
+		ID:     c.Param("id"),
+		Member: common.GetMembershipFromCtx(c),
+	}); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Invitation revoked")
+}
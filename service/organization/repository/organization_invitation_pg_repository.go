@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+// OrganizationInvitationRepository reads and writes the invitations of the organization of the
+// tenant context. Only the lookup by token crosses organizations, for invitees who are not
+// members yet.
+type OrganizationInvitationRepository struct {
+	sqlHandler      *database.SQLHandler[domain.OrganizationInvitation, domain.OrganizationInvitationFilter]
+	tokenSQLHandler *database.SQLHandler[domain.OrganizationInvitation, domain.OrganizationInvitationFilter]
+}
+
+func NewOrganizationInvitationRepository(db *gorm.DB) *OrganizationInvitationRepository {
+	return &OrganizationInvitationRepository{
+		sqlHandler:      database.NewSQLHandler[domain.OrganizationInvitation](db, applyInvitationFilter, database.WithTenantScope("organization_id")),
+		tokenSQLHandler: database.NewSQLHandler[domain.OrganizationInvitation](db, applyInvitationFilter, database.WithoutTenantScope()),
+	}
+}
+
+func applyInvitationFilter(qb *gorm.DB, filter *domain.OrganizationInvitationFilter) *gorm.DB {
+	qb = qb.Where("deleted_at = 0")
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if filter.Email != nil {
+		qb = qb.Where("email = ?", *filter.Email)
+	}
+	if filter.TokenHash != nil {
+		qb = qb.Where("token_hash = ?", *filter.TokenHash)
+	}
+	if filter.Pending != nil {
+		if *filter.Pending {
+			qb = qb.Where("accepted_at = 0 AND expires_at > ?", utils.NowUnixMillis())
+		} else {
+			qb = qb.Where("(accepted_at > 0 OR expires_at <= ?)", utils.NowUnixMillis())
+		}
+	}
+
+	return qb
+}
+
+func (r *OrganizationInvitationRepository) Create(ctx context.Context, invitation *domain.OrganizationInvitation) error {
+	return r.sqlHandler.Create(ctx, invitation)
+}
+
+func (r *OrganizationInvitationRepository) FindByID(ctx context.Context, id string) (*domain.OrganizationInvitation, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.OrganizationInvitationFilter{ID: &id}, nil)
+}
+
+func (r *OrganizationInvitationRepository) FindMany(ctx context.Context, filter *domain.OrganizationInvitationFilter, option *domain.FindManyOption) ([]*domain.OrganizationInvitation, error) {
+	return r.sqlHandler.FindMany(ctx, filter, option)
+}
+
+// RevokePending cancels the pending invitations of the email, so a new invitation replaces them
+func (r *OrganizationInvitationRepository) RevokePending(ctx context.Context, email string) error {
+	pending := true
+	_, err := r.sqlHandler.UpdateMany(ctx, &domain.OrganizationInvitationFilter{
+		Email:   &email,
+		Pending: &pending,
+	}, map[string]any{
+		"deleted_at": utils.NowUnixMillis(),
+	})
+	return err
+}
+
+func (r *OrganizationInvitationRepository) Delete(ctx context.Context, id string) error {
+	return r.sqlHandler.DeleteByID(ctx, id)
+}
+
+// MarkAccepted records that the user joined with the invitation, only if it is still pending. It
+// returns false when the invitation was used, revoked or expired in the meantime.
+func (r *OrganizationInvitationRepository) MarkAccepted(ctx context.Context, id, userID string) (bool, error) {
+	pending := true
+	rows, err := r.sqlHandler.UpdateMany(ctx, &domain.OrganizationInvitationFilter{
+		ID:      &id,
+		Pending: &pending,
+	}, map[string]any{
+		"accepted_at": utils.NowUnixMillis(),
+		"accepted_by": userID,
+	})
+	return rows > 0, err
+}
+
+// FindByTokenHash returns the invitation sent with the token, in any organization
+func (r *OrganizationInvitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*domain.OrganizationInvitation, error) {
+	return r.tokenSQLHandler.FindOne(ctx, &domain.OrganizationInvitationFilter{TokenHash: &tokenHash}, nil)
+}
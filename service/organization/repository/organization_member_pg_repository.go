@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+// OrganizationMemberRepository reads and writes the members of the organization of the tenant
+// context. Only the lookups by user cross organizations.
+type OrganizationMemberRepository struct {
+	db             *gorm.DB
+	sqlHandler     *database.SQLHandler[domain.OrganizationMember, domain.OrganizationMemberFilter]
+	userSQLHandler *database.SQLHandler[domain.OrganizationMember, domain.OrganizationMemberFilter]
+}
+
+func NewOrganizationMemberRepository(db *gorm.DB) *OrganizationMemberRepository {
+	return &OrganizationMemberRepository{
+		db:             db,
+		sqlHandler:     database.NewSQLHandler[domain.OrganizationMember](db, applyMemberFilter, database.WithTenantScope("organization_id")),
+		userSQLHandler: database.NewSQLHandler[domain.OrganizationMember](db, applyMemberFilter, database.WithoutTenantScope()),
+	}
+}
+
+func applyMemberFilter(qb *gorm.DB, filter *domain.OrganizationMemberFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.UserID != nil {
+		qb = qb.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Role != nil {
+		qb = qb.Where("role = ?", *filter.Role)
+	}
+
+	return qb
+}
+
+func (r *OrganizationMemberRepository) Create(ctx context.Context, member *domain.OrganizationMember) error {
+	return r.sqlHandler.Create(ctx, member)
+}
+
+func (r *OrganizationMemberRepository) FindByUserID(ctx context.Context, userID string, option *domain.FindOneOption) (*domain.OrganizationMember, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.OrganizationMemberFilter{UserID: &userID}, option)
+}
+
+func (r *OrganizationMemberRepository) FindMany(ctx context.Context, filter *domain.OrganizationMemberFilter, option *domain.FindManyOption) ([]*domain.OrganizationMember, error) {
+	return r.sqlHandler.FindMany(ctx, filter, option)
+}
+
+// UpdateRole changes the role of the member. Taking the owner role from the last owner fails with
+// ErrLastOwner.
+func (r *OrganizationMemberRepository) UpdateRole(ctx context.Context, member *domain.OrganizationMember, role domain.OrgRole) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if role != domain.OrgRoleOwner {
+			if err := r.ensureAnotherOwner(ctx, tx, member.UserID); err != nil {
+				return err
+			}
+		}
+		return r.sqlHandler.UpdateFields(ctx, member.ID, map[string]any{
+			"role": role,
+		}, database.WithTx(tx))
+	})
+}
+
+// DeleteByUserID removes the user from the organization, unless they are its last owner.
+// Memberships are deleted for good, so the user can be invited again.
+func (r *OrganizationMemberRepository) DeleteByUserID(ctx context.Context, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.ensureAnotherOwner(ctx, tx, userID); err != nil {
+			return err
+		}
+		deleted, err := r.sqlHandler.DeleteMany(ctx, &domain.OrganizationMemberFilter{UserID: &userID}, database.WithTx(tx))
+		if err != nil {
+			return err
+		}
+		if deleted == 0 {
+			return domain.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// ensureAnotherOwner fails with ErrLastOwner when the user is the only owner of the organization.
+// The owners stay locked until the transaction ends, so two concurrent changes cannot each see
+// the other owner and leave the organization without any.
+func (r *OrganizationMemberRepository) ensureAnotherOwner(ctx context.Context, tx *gorm.DB, userID string) error {
+	owner := domain.OrgRoleOwner
+	owners, err := r.sqlHandler.FindMany(ctx, &domain.OrganizationMemberFilter{Role: &owner}, nil,
+		database.WithTx(tx), database.WithLockForUpdate())
+	if err != nil {
+		return err
+	}
+	if len(owners) == 1 && owners[0].UserID == userID {
+		return domain.ErrLastOwner
+	}
+	return nil
+}
+
+// FindMembership returns the membership of the user in the organization, outside of any tenant
+// context
+func (r *OrganizationMemberRepository) FindMembership(ctx context.Context, organizationID, userID string) (*domain.OrganizationMember, error) {
+	return r.FindByUserID(domain.ContextWithTenant(ctx, organizationID), userID, nil)
+}
+
+// ListByUserID returns the memberships of the user in every organization, with the organizations
+func (r *OrganizationMemberRepository) ListByUserID(ctx context.Context, userID string) ([]*domain.OrganizationMember, error) {
+	return r.userSQLHandler.FindMany(ctx, &domain.OrganizationMemberFilter{UserID: &userID}, &domain.FindManyOption{
+		Sort:     []string{"created_at ASC"},
+		Preloads: []string{"Organization"},
+	})
+}
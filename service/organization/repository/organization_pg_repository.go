@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository struct {
+	db               *gorm.DB
+	sqlHandler       *database.SQLHandler[domain.Organization, domain.OrganizationFilter]
+	memberSQLHandler *database.SQLHandler[domain.OrganizationMember, domain.OrganizationMemberFilter]
+}
+
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{
+		db:               db,
+		sqlHandler:       database.NewSQLHandler[domain.Organization](db, applyOrganizationFilter, database.WithoutTenantScope()),
+		memberSQLHandler: database.NewSQLHandler[domain.OrganizationMember](db, applyMemberFilter, database.WithTenantScope("organization_id")),
+	}
+}
+
+func applyOrganizationFilter(qb *gorm.DB, filter *domain.OrganizationFilter) *gorm.DB {
+	qb = qb.Where("deleted_at = 0")
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if len(filter.IDIn) > 0 {
+		qb = qb.Where("id IN (?)", filter.IDIn)
+	}
+	if filter.Slug != nil {
+		qb = qb.Where("slug = ?", *filter.Slug)
+	}
+
+	return qb
+}
+
+// Create stores the organization together with the membership of its first owner in a single
+// transaction
+func (r *OrganizationRepository) Create(ctx context.Context, organization *domain.Organization, owner *domain.OrganizationMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := r.sqlHandler.Create(ctx, organization, database.WithTx(tx)); err != nil {
+			return err
+		}
+		tenantCtx := domain.ContextWithTenant(ctx, organization.ID)
+		return r.memberSQLHandler.Create(tenantCtx, owner, database.WithTx(tx))
+	})
+}
+
+func (r *OrganizationRepository) FindByID(ctx context.Context, id string, option *domain.FindOneOption) (*domain.Organization, error) {
+	return r.sqlHandler.FindOne(ctx, &domain.OrganizationFilter{ID: &id}, option)
+}
+
+func (r *OrganizationRepository) FindOne(ctx context.Context, filter *domain.OrganizationFilter, option *domain.FindOneOption) (*domain.Organization, error) {
+	return r.sqlHandler.FindOne(ctx, filter, option)
+}
+
+func (r *OrganizationRepository) UpdateFields(ctx context.Context, id string, fields map[string]any) error {
+	return r.sqlHandler.UpdateFields(ctx, id, fields)
+}
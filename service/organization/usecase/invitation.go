@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func (o *organizationUsecase) ListInvitations(ctx context.Context, req *domain.ListInvitationsRequest) ([]*domain.OrganizationInvitation, error) {
+	if err := domain.AuthorizeInvitationManagement(req.Member); err != nil {
+		return nil, err
+	}
+	invitations, err := o.invitationRepo.FindMany(ctx, &domain.OrganizationInvitationFilter{
+		Pending: req.Pending,
+	}, &domain.FindManyOption{
+		Sort: []string{"created_at DESC"},
+	})
+	if err != nil {
+		return nil, wrapTenantError(err)
+	}
+	return invitations, nil
+}
+
+// InviteMember emails an invitation to join the organization with the role. It replaces the
+// pending invitations of the same email address.
+func (o *organizationUsecase) InviteMember(ctx context.Context, req *domain.InviteMemberRequest) (*domain.OrganizationInvitation, error) {
+	if !req.Role.IsValid() {
+		return nil, domain.ErrInvalidOrgRole
+	}
+	if err := domain.AuthorizeOrgRoleGrant(req.Member, req.Role); err != nil {
+		return nil, err
+	}
+	organization, err := o.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	email := domain.NormalizeEmail(req.Email)
+
+	invitee, err := o.userRepo.FindOne(ctx, &domain.UserFilter{Email: &email}, nil)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if invitee != nil {
+		if _, err := o.findMember(ctx, invitee.ID); err == nil {
+			return nil, domain.ErrAlreadyMember
+		} else if !errors.Is(err, domain.ErrMemberNotFound) {
+			return nil, err
+		}
+	}
+
+	if err := o.invitationRepo.RevokePending(ctx, email); err != nil {
+		return nil, wrapTenantError(err)
+	}
+	rawToken, err := common.GenerateSecureToken(32)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	invitation := &domain.OrganizationInvitation{
+		Email:     email,
+		Role:      req.Role,
+		TokenHash: common.HashToken(rawToken),
+		InvitedBy: req.Member.UserID,
+		ExpiresAt: time.Now().Add(o.orgCfg.InvitationExpiresIn()).UnixMilli(),
+	}
+	if err := o.invitationRepo.Create(ctx, invitation); err != nil {
+		return nil, wrapTenantError(err)
+	}
+
+	if err := o.sendInvitationEmail(ctx, organization, invitation, rawToken); err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (o *organizationUsecase) sendInvitationEmail(ctx context.Context, organization *domain.Organization, invitation *domain.OrganizationInvitation, rawToken string) error {
+	inviterName := "A member"
+	inviter, err := o.userRepo.FindByID(ctx, invitation.InvitedBy, nil)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if inviter != nil {
+		inviterName = strings.TrimSpace(inviter.FirstName + " " + inviter.LastName)
+	}
+
+	query := url.Values{}
+	query.Set("token", rawToken)
+	acceptURL := common.JoinURLPath(o.srvCfg.Domain(), "accept-invitation") + "?" + query.Encode()
+
+	templateData := map[string]any{
+		"app_name":          o.appCfg.Name(),
+		"user_email":        invitation.Email,
+		"inviter_name":      inviterName,
+		"organization_name": organization.Name,
+		"role":              string(invitation.Role),
+		"accept_url":        acceptURL,
+		"expires_in":        utils.FormatDuration(o.orgCfg.InvitationExpiresIn()),
+		"current_year":      time.Now().Year(),
+	}
+
+	emailReq := &domain.SendEmailWithTemplateRequest{
+		To:           []string{invitation.Email},
+		TemplateCode: domain.EmailCodeOrganizationInvitation,
+		Locale:       "en", // Default locale
+		Data:         templateData,
+		RequestID:    fmt.Sprintf("organization_invitation_%s", invitation.ID),
+	}
+
+	if _, err := o.emailClient.SendEmailWithTemplate(ctx, emailReq); err != nil {
+		return domain.ErrEmailSendFailed.WithError("failed to send invitation email").WithWrap(err)
+	}
+	return nil
+}
+
+// RevokeInvitation cancels a pending invitation, its link stops working
+func (o *organizationUsecase) RevokeInvitation(ctx context.Context, req *domain.RevokeInvitationRequest) error {
+	if err := domain.AuthorizeInvitationManagement(req.Member); err != nil {
+		return err
+	}
+	invitation, err := o.invitationRepo.FindByID(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return domain.ErrInvitationNotFound
+		}
+		return wrapTenantError(err)
+	}
+	if err := domain.AuthorizeOrgRoleGrant(req.Member, invitation.Role); err != nil {
+		return err
+	}
+
+	if err := o.invitationRepo.Delete(ctx, invitation.ID); err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return domain.ErrInvitationNotFound
+		}
+		return wrapTenantError(err)
+	}
+	return nil
+}
+
+// AcceptInvitation makes the user a member of the organization of the invitation. Invitations are
+// bound to the email address they were sent to, only the account with this address can use them.
+func (o *organizationUsecase) AcceptInvitation(ctx context.Context, req *domain.AcceptInvitationRequest) (*domain.OrganizationMember, error) {
+	invitation, err := o.invitationRepo.FindByTokenHash(ctx, common.HashToken(req.Token))
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if invitation == nil || !invitation.IsPending() {
+		return nil, domain.ErrInvalidInvitation
+	}
+
+	user, err := o.userRepo.FindByID(ctx, req.UserID, nil)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !strings.EqualFold(user.Email, invitation.Email) {
+		return nil, domain.ErrForbidden.WithReason("the invitation was sent to another email address")
+	}
+
+	// The invitee is not a member yet, the rest happens in the organization of the invitation
+	tenantCtx := domain.ContextWithTenant(ctx, invitation.OrganizationID)
+	if _, err := o.findMember(tenantCtx, user.ID); err == nil {
+		return nil, domain.ErrAlreadyMember
+	} else if !errors.Is(err, domain.ErrMemberNotFound) {
+		return nil, err
+	}
+
+	accepted, err := o.invitationRepo.MarkAccepted(tenantCtx, invitation.ID, user.ID)
+	if err != nil {
+		return nil, wrapTenantError(err)
+	}
+	if !accepted {
+		// Another request used the invitation first, or it was revoked in the meantime
+		return nil, domain.ErrInvalidInvitation
+	}
+
+	member := &domain.OrganizationMember{
+		UserID: user.ID,
+		Role:   invitation.Role,
+	}
+	if err := o.memberRepo.Create(tenantCtx, member); err != nil {
+		return nil, wrapTenantError(err)
+	}
+	member.Organization, err = o.findOrganization(ctx, invitation.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	return member, nil
+}
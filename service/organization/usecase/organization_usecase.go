@@ -0,0 +1,331 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/domain"
+	"strings"
+	"time"
+)
+
+type OrganizationRepository interface {
+	Create(ctx context.Context, organization *domain.Organization, owner *domain.OrganizationMember) error
+	FindByID(ctx context.Context, id string, option *domain.FindOneOption) (*domain.Organization, error)
+	FindOne(ctx context.Context, filter *domain.OrganizationFilter, option *domain.FindOneOption) (*domain.Organization, error)
+	UpdateFields(ctx context.Context, id string, fields map[string]any) error
+}
+
+// OrganizationMemberRepository acts in the organization of the tenant context, but for
+// FindMembership and ListByUserID
+type OrganizationMemberRepository interface {
+	Create(ctx context.Context, member *domain.OrganizationMember) error
+	FindByUserID(ctx context.Context, userID string, option *domain.FindOneOption) (*domain.OrganizationMember, error)
+	FindMany(ctx context.Context, filter *domain.OrganizationMemberFilter, option *domain.FindManyOption) ([]*domain.OrganizationMember, error)
+	UpdateRole(ctx context.Context, member *domain.OrganizationMember, role domain.OrgRole) error
+	DeleteByUserID(ctx context.Context, userID string) error
+	FindMembership(ctx context.Context, organizationID, userID string) (*domain.OrganizationMember, error)
+	ListByUserID(ctx context.Context, userID string) ([]*domain.OrganizationMember, error)
+}
+
+// OrganizationInvitationRepository acts in the organization of the tenant context, but for
+// FindByTokenHash
+type OrganizationInvitationRepository interface {
+	Create(ctx context.Context, invitation *domain.OrganizationInvitation) error
+	FindByID(ctx context.Context, id string) (*domain.OrganizationInvitation, error)
+	FindMany(ctx context.Context, filter *domain.OrganizationInvitationFilter, option *domain.FindManyOption) ([]*domain.OrganizationInvitation, error)
+	RevokePending(ctx context.Context, email string) error
+	Delete(ctx context.Context, id string) error
+	MarkAccepted(ctx context.Context, id, userID string) (bool, error)
+	FindByTokenHash(ctx context.Context, tokenHash string) (*domain.OrganizationInvitation, error)
+}
+
+type UserRepository interface {
+	FindByID(ctx context.Context, userID string, option *domain.FindOneOption) (*domain.User, error)
+	FindOne(ctx context.Context, filter *domain.UserFilter, option *domain.FindOneOption) (*domain.User, error)
+}
+
+type SessionRepository interface {
+	FindByID(ctx context.Context, sessionID string, option *domain.FindOneOption) (*domain.UserSession, error)
+	SetOrganization(ctx context.Context, sessionID, organizationID string) error
+}
+
+// TokenIssuer issues the access tokens of a session in the organization it switched to
+type TokenIssuer interface {
+	GenerateAccessToken(userID, sessionID, organizationID string) (string, error)
+}
+
+type EmailClient interface {
+	SendEmailWithTemplate(ctx context.Context, req *domain.SendEmailWithTemplateRequest) (*domain.EmailLog, error)
+}
+
+type AppConfig interface {
+	Name() string
+}
+
+type ServerConfig interface {
+	Domain() string
+}
+
+type OrganizationsConfig interface {
+	InvitationExpiresIn() time.Duration
+}
+
+type organizationUsecase struct {
+	orgRepo        OrganizationRepository
+	memberRepo     OrganizationMemberRepository
+	invitationRepo OrganizationInvitationRepository
+	userRepo       UserRepository
+	sessionRepo    SessionRepository
+	tokenIssuer    TokenIssuer
+	emailClient    EmailClient
+	appCfg         AppConfig
+	srvCfg         ServerConfig
+	orgCfg         OrganizationsConfig
+}
+
+func NewOrganizationUsecase(
+	orgRepo OrganizationRepository,
+	memberRepo OrganizationMemberRepository,
+	invitationRepo OrganizationInvitationRepository,
+	userRepo UserRepository,
+	sessionRepo SessionRepository,
+	tokenIssuer TokenIssuer,
+	emailClient EmailClient,
+	appCfg AppConfig,
+	srvCfg ServerConfig,
+	orgCfg OrganizationsConfig,
+) domain.OrganizationUsecase {
+	return &organizationUsecase{
+		orgRepo:        orgRepo,
+		memberRepo:     memberRepo,
+		invitationRepo: invitationRepo,
+		userRepo:       userRepo,
+		sessionRepo:    sessionRepo,
+		tokenIssuer:    tokenIssuer,
+		emailClient:    emailClient,
+		appCfg:         appCfg,
+		srvCfg:         srvCfg,
+		orgCfg:         orgCfg,
+	}
+}
+
+// CreateOrganization creates the organization with the actor as its first owner
+func (o *organizationUsecase) CreateOrganization(ctx context.Context, req *domain.CreateOrganizationRequest) (*domain.Organization, error) {
+	organization := &domain.Organization{
+		Name:      strings.TrimSpace(req.Name),
+		Slug:      strings.ToLower(strings.TrimSpace(req.Slug)),
+		CreatedBy: req.ActorID,
+	}
+	if err := organization.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := o.orgRepo.FindOne(ctx, &domain.OrganizationFilter{Slug: &organization.Slug}, nil)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if existing != nil {
+		return nil, domain.ErrOrganizationAlreadyExists
+	}
+
+	if err := o.orgRepo.Create(ctx, organization, &domain.OrganizationMember{
+		UserID: req.ActorID,
+		Role:   domain.OrgRoleOwner,
+	}); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return organization, nil
+}
+
+// ListMyOrganizations returns the memberships of the user, with their organizations
+func (o *organizationUsecase) ListMyOrganizations(ctx context.Context, userID string) ([]*domain.OrganizationMember, error) {
+	members, err := o.memberRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return members, nil
+}
+
+// SwitchOrganization makes the session act in the organization, or in none when the request has no
+// organization. The access token returned carries the organization, and so do the ones issued when
+// the session is refreshed.
+func (o *organizationUsecase) SwitchOrganization(ctx context.Context, req *domain.SwitchOrganizationRequest) (*domain.SwitchOrganizationResponse, error) {
+	if req.OrganizationID != "" {
+		if _, err := o.findMembership(ctx, req.OrganizationID, req.UserID); err != nil {
+			return nil, err
+		}
+	}
+
+	session, err := o.sessionRepo.FindByID(ctx, req.SessionID, nil)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if session == nil || session.UserID != req.UserID || !session.IsActive() {
+		return nil, domain.ErrSessionExpired
+	}
+
+	if err := o.sessionRepo.SetOrganization(ctx, session.ID, req.OrganizationID); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	accessToken, err := o.tokenIssuer.GenerateAccessToken(req.UserID, session.ID, req.OrganizationID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	return &domain.SwitchOrganizationResponse{
+		AccessToken:    accessToken,
+		OrganizationID: req.OrganizationID,
+	}, nil
+}
+
+func (o *organizationUsecase) GetOrganization(ctx context.Context) (*domain.Organization, error) {
+	organizationID, err := tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return o.findOrganization(ctx, organizationID)
+}
+
+func (o *organizationUsecase) UpdateOrganization(ctx context.Context, req *domain.UpdateOrganizationRequest) (*domain.Organization, error) {
+	if err := domain.AuthorizeOrganizationUpdate(req.Member); err != nil {
+		return nil, err
+	}
+	organization, err := o.GetOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == nil {
+		return organization, nil
+	}
+
+	organization.Name = strings.TrimSpace(*req.Name)
+	if err := organization.Validate(); err != nil {
+		return nil, err
+	}
+	if err := o.orgRepo.UpdateFields(ctx, organization.ID, map[string]any{
+		"name": organization.Name,
+	}); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return organization, nil
+}
+
+func (o *organizationUsecase) ListMembers(ctx context.Context) ([]*domain.OrganizationMember, error) {
+	members, err := o.memberRepo.FindMany(ctx, nil, &domain.FindManyOption{
+		Sort:     []string{"created_at ASC"},
+		Preloads: []string{"User"},
+	})
+	if err != nil {
+		return nil, wrapTenantError(err)
+	}
+	return members, nil
+}
+
+// UpdateMemberRole changes the role of a member. Organizations always keep an owner.
+func (o *organizationUsecase) UpdateMemberRole(ctx context.Context, req *domain.UpdateMemberRoleRequest) (*domain.OrganizationMember, error) {
+	if !req.Role.IsValid() {
+		return nil, domain.ErrInvalidOrgRole
+	}
+	target, err := o.findMember(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeMemberManagement(req.Member, target); err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeOrgRoleGrant(req.Member, req.Role); err != nil {
+		return nil, err
+	}
+	if target.Role == req.Role {
+		return target, nil
+	}
+
+	if err := o.memberRepo.UpdateRole(ctx, target, req.Role); err != nil {
+		return nil, wrapMemberError(err)
+	}
+	target.Role = req.Role
+	return target, nil
+}
+
+// RemoveMember removes a member from the organization. Members can always leave on their own.
+func (o *organizationUsecase) RemoveMember(ctx context.Context, req *domain.RemoveMemberRequest) error {
+	target, err := o.findMember(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+	if req.Member == nil || req.Member.UserID != target.UserID {
+		if err := domain.AuthorizeMemberManagement(req.Member, target); err != nil {
+			return err
+		}
+	}
+
+	if err := o.memberRepo.DeleteByUserID(ctx, target.UserID); err != nil {
+		return wrapMemberError(err)
+	}
+	return nil
+}
+
+func (o *organizationUsecase) findOrganization(ctx context.Context, organizationID string) (*domain.Organization, error) {
+	organization, err := o.orgRepo.FindByID(ctx, organizationID, nil)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, domain.ErrOrganizationNotFound
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return organization, nil
+}
+
+// findMember returns the member of the organization of the tenant context
+func (o *organizationUsecase) findMember(ctx context.Context, userID string) (*domain.OrganizationMember, error) {
+	member, err := o.memberRepo.FindByUserID(ctx, userID, &domain.FindOneOption{
+		Preloads: []string{"User"},
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, domain.ErrMemberNotFound
+		}
+		return nil, wrapTenantError(err)
+	}
+	return member, nil
+}
+
+func (o *organizationUsecase) findMembership(ctx context.Context, organizationID, userID string) (*domain.OrganizationMember, error) {
+	member, err := o.memberRepo.FindMembership(ctx, organizationID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, domain.ErrNotOrganizationMember
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return member, nil
+}
+
+func tenantID(ctx context.Context) (string, error) {
+	organizationID, ok := domain.TenantFromContext(ctx)
+	if !ok {
+		return "", domain.ErrTenantRequired
+	}
+	return organizationID, nil
+}
+
+// wrapTenantError keeps the errors of the tenant scope as they are, they tell the client what to
+// fix, and hides the others behind an internal error
+func wrapTenantError(err error) error {
+	if errors.Is(err, domain.ErrTenantRequired) || errors.Is(err, domain.ErrTenantMismatch) {
+		return err
+	}
+	return domain.ErrInternalServerError.WithWrap(err)
+}
+
+// wrapMemberError keeps the refusal to remove the last owner, and reports a member that left the
+// organization in the meantime as not found
+func wrapMemberError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrLastOwner):
+		return err
+	case errors.Is(err, domain.ErrRecordNotFound):
+		return domain.ErrMemberNotFound
+	}
+	return wrapTenantError(err)
+}
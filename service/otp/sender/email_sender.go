@@ -0,0 +1,73 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+)
+
+type EmailClient interface {
+	SendEmailWithTemplate(ctx context.Context, req *domain.SendEmailWithTemplateRequest) (*domain.EmailLog, error)
+}
+
+type AppConfig interface {
+	Name() string
+}
+
+// EmailSender delivers OTP codes using the "otp" email template.
+type EmailSender struct {
+	emailClient EmailClient
+	appCfg      AppConfig
+}
+
+func NewEmailSender(emailClient EmailClient, appCfg AppConfig) *EmailSender {
+	return &EmailSender{emailClient: emailClient, appCfg: appCfg}
+}
+
+func (s *EmailSender) Method() domain.OTPDeliveryMethod {
+	return domain.OTPDeliveryEmail
+}
+
+func (s *EmailSender) Send(ctx context.Context, msg *domain.OTPMessage) error {
+	templateData := map[string]any{
+		"app_name":     s.appCfg.Name(),
+		"otp_code":     msg.Code,
+		"action":       actionDescription(msg.Action),
+		"expires_in":   utils.FormatDuration(msg.ExpiresIn),
+		"user_email":   msg.Recipient,
+		"current_year": time.Now().Year(),
+	}
+
+	emailReq := &domain.SendEmailWithTemplateRequest{
+		To:           []string{msg.Recipient},
+		TemplateCode: domain.EmailCodeOTP,
+		Locale:       "en", // Default locale
+		Data:         templateData,
+		RequestID:    fmt.Sprintf("otp_%s_%d", msg.Action, utils.NowUnixMillis()),
+	}
+
+	if _, err := s.emailClient.SendEmailWithTemplate(ctx, emailReq); err != nil {
+		return err
+	}
+	return nil
+}
+
+func actionDescription(action domain.Action) string {
+	switch action {
+	case domain.ActionRegister:
+		return "complete your registration"
+	case domain.ActionVerifyEmail:
+		return "verify your email address"
+	case domain.ActionLogin:
+		return "sign in to your account"
+	case domain.ActionResetPassword:
+		return "reset your password"
+	case domain.ActionDeleteAccount:
+		return "delete your account"
+	default:
+		return "continue"
+	}
+}
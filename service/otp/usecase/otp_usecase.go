@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/cache"
+)
+
+type Cache interface {
+	Delete(ctx context.Context, key string) error
+	Increment(ctx context.Context, key string, delta int64, ttl time.Duration) (int64, error)
+	Lock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, key string) error
+	SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	GetJSON(ctx context.Context, key string, dest interface{}) error
+}
+
+type Config interface {
+	ExpiresIn() time.Duration
+	RetryBaseWaitTime() time.Duration
+	RetryMaxWaitTime() time.Duration
+	MaxAttempts() int
+}
+
+// Sender delivers an OTP code to its recipient through a single delivery method.
+type Sender interface {
+	Method() domain.OTPDeliveryMethod
+	Send(ctx context.Context, msg *domain.OTPMessage) error
+}
+
+const generateLockTTL = 10 * time.Second
+
+type otpUsecase struct {
+	cache   Cache
+	cfg     Config
+	senders map[domain.OTPDeliveryMethod]Sender
+}
+
+func NewOTPUsecase(cache Cache, cfg Config, senders ...Sender) domain.OTPUsecase {
+	u := &otpUsecase{
+		cache:   cache,
+		cfg:     cfg,
+		senders: make(map[domain.OTPDeliveryMethod]Sender, len(senders)),
+	}
+	for _, s := range senders {
+		u.senders[s.Method()] = s
+	}
+	return u
+}
+
+// Generate issues a new code for the action/recipient pair, replacing any previous one.
+// Consecutive codes are spaced out with an exponential backoff bounded by the configured waits.
+func (u *otpUsecase) Generate(ctx context.Context, req *domain.GenerateOTPRequest) (*domain.OTPCode, error) {
+	if !req.Action.IsValid() {
+		return nil, domain.ErrOTPInvalidAction
+	}
+	recipient := normalizeRecipient(req.Recipient)
+	resendKey := otpResendKey(req.Action, recipient)
+
+	// Serialize concurrent requests so two codes cannot be issued inside one backoff window
+	locked, err := u.cache.Lock(ctx, resendKey, generateLockTTL)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !locked {
+		return nil, domain.ErrOTPResendTooSoon
+	}
+	defer func() { _ = u.cache.Unlock(ctx, resendKey) }()
+
+	now := time.Now()
+	var state domain.OTPResendState
+	if err := u.cache.GetJSON(ctx, resendKey, &state); err != nil && !errors.Is(err, cache.ErrKeyNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if state.NextSendAt > now.UnixMilli() {
+		return nil, domain.ErrOTPResendTooSoon.WithDetail("next_send_at", state.NextSendAt)
+	}
+
+	code, err := common.GenerateNumericCode(domain.OTPCodeLength)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	expiresIn := u.cfg.ExpiresIn()
+	otp := &domain.OTP{
+		Action:    req.Action,
+		Recipient: recipient,
+		CodeHash:  common.HashToken(code),
+		ExpiresAt: now.Add(expiresIn).UnixMilli(),
+	}
+	if err := u.cache.SetJSON(ctx, otpKey(req.Action, recipient), otp, expiresIn); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	// A fresh code gets a fresh attempt budget
+	_ = u.cache.Delete(ctx, otpAttemptsKey(req.Action, recipient))
+
+	state.SendCount++
+	wait := u.backoff(state.SendCount)
+	state.NextSendAt = now.Add(wait).UnixMilli()
+	// Forget the send count once the recipient has been quiet for a full max wait
+	if err := u.cache.SetJSON(ctx, resendKey, &state, wait+u.cfg.RetryMaxWaitTime()); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	return &domain.OTPCode{
+		Code:       code,
+		ExpiresIn:  expiresIn,
+		ExpiresAt:  otp.ExpiresAt,
+		NextSendAt: state.NextSendAt,
+	}, nil
+}
+
+func (u *otpUsecase) Send(ctx context.Context, req *domain.SendOTPRequest) (*domain.OTPSendResponse, error) {
+	sender, ok := u.senders[req.DeliveryMethod]
+	if !ok {
+		return nil, domain.ErrOTPDeliveryMethodNotSupported
+	}
+
+	otp, err := u.Generate(ctx, &domain.GenerateOTPRequest{
+		Action:    req.Action,
+		Recipient: req.Recipient,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &domain.OTPMessage{
+		Action:    req.Action,
+		Recipient: normalizeRecipient(req.Recipient),
+		Code:      otp.Code,
+		ExpiresIn: otp.ExpiresIn,
+	}
+	if err := sender.Send(ctx, msg); err != nil {
+		return nil, domain.ErrOTPSendFailed.WithWrap(err)
+	}
+
+	return &domain.OTPSendResponse{
+		ExpiresAt:  otp.ExpiresAt,
+		NextSendAt: otp.NextSendAt,
+	}, nil
+}
+
+// Verify checks a code against the one issued for the action/recipient pair. Every call
+// counts as an attempt; once the configured limit is reached the code is discarded.
+// A successful verification consumes the code and resets the resend backoff.
+func (u *otpUsecase) Verify(ctx context.Context, req *domain.VerifyOTPRequest) error {
+	recipient := normalizeRecipient(req.Recipient)
+	key := otpKey(req.Action, recipient)
+	attemptsKey := otpAttemptsKey(req.Action, recipient)
+
+	var otp domain.OTP
+	if err := u.cache.GetJSON(ctx, key, &otp); err != nil {
+		if errors.Is(err, cache.ErrKeyNotFound) {
+			return domain.ErrOTPInvalid
+		}
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	remaining := time.Until(time.UnixMilli(otp.ExpiresAt))
+	if remaining <= 0 {
+		return domain.ErrOTPInvalid
+	}
+
+	attempts, err := u.cache.Increment(ctx, attemptsKey, 1, remaining)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if attempts > int64(u.cfg.MaxAttempts()) {
+		_ = u.cache.Delete(ctx, key)
+		_ = u.cache.Delete(ctx, attemptsKey)
+		return domain.ErrOTPTooManyAttempts
+	}
+
+	if subtle.ConstantTimeCompare([]byte(common.HashToken(req.Code)), []byte(otp.CodeHash)) != 1 {
+		return domain.ErrOTPInvalid
+	}
+
+	// Claim the code so concurrent verifications of the same code cannot both succeed
+	claimed, err := u.cache.Lock(ctx, key+":"+otp.CodeHash, remaining)
+	if err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if !claimed {
+		return domain.ErrOTPInvalid
+	}
+
+	_ = u.cache.Delete(ctx, key)
+	_ = u.cache.Delete(ctx, attemptsKey)
+	_ = u.cache.Delete(ctx, otpResendKey(req.Action, recipient))
+	return nil
+}
+
+// backoff returns the wait before the next code can be sent after sendCount codes,
+// doubling from the base wait and capped at the max wait.
+func (u *otpUsecase) backoff(sendCount int) time.Duration {
+	wait, maxWait := u.cfg.RetryBaseWaitTime(), u.cfg.RetryMaxWaitTime()
+	for i := 1; i < sendCount && wait < maxWait; i++ {
+		wait *= 2
+	}
+	return min(wait, maxWait)
+}
+
+func normalizeRecipient(recipient string) string {
+	return strings.ToLower(strings.TrimSpace(recipient))
+}
+
+func otpKey(action domain.Action, recipient string) string {
+	return fmt.Sprintf("otp:%s:%s", action, recipient)
+}
+
+func otpAttemptsKey(action domain.Action, recipient string) string {
+	return fmt.Sprintf("otp_attempts:%s:%s", action, recipient)
+}
+
+func otpResendKey(action domain.Action, recipient string) string {
+	return fmt.Sprintf("otp_resend:%s:%s", action, recipient)
+}
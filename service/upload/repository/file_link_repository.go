@@ -33,7 +33,7 @@ func NewFileLinkPgRepository(
 
 ) *FileLinkPgRepository {
 	return &FileLinkPgRepository{
-		sqlHandler:   database.NewSQLHandler[domain.FileLink](db, applyFileLinkFilter),
+		sqlHandler:   database.NewSQLHandler[domain.FileLink](db, applyFileLinkFilter, database.WithoutTenantScope()),
 		baseURL:      srvCfg.Domain(),
 		presignTTL:   uploadCfg.S3PresignUrlTTL(),
 		uploadClient: client,
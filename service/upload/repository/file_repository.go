@@ -27,7 +27,7 @@ type UploadConfig interface {
 
 func NewFilePgRepository(db *gorm.DB, srvCfg ServerConfig, uploadCfg UploadConfig, client upload.Client) *FilePgRepository {
 	return &FilePgRepository{
-		handler:      database.NewSQLHandler[domain.File](db, applyFileFilter),
+		handler:      database.NewSQLHandler[domain.File](db, applyFileFilter, database.WithoutTenantScope()),
 		baseURL:      srvCfg.Domain(),
 		presignTTL:   uploadCfg.S3PresignUrlTTL(),
 		uploadClient: client,
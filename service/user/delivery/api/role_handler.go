@@ -0,0 +1,182 @@
+package api
+
+import (
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	usecase     domain.RoleUsecase
+	middlewares middleware.Middlewares
+}
+
+func NewRoleHandler(usecase domain.RoleUsecase, middlewares middleware.Middlewares) *RoleHandler {
+	return &RoleHandler{
+		usecase:     usecase,
+		middlewares: middlewares,
+	}
+}
+
+func (h *RoleHandler) RegisterRoutes(rg *gin.RouterGroup) {
+	// Roles, permissions and role assignments, managed by administrators acting as themselves
+	admin := rg.Group("/admin")
+	admin.Use(h.middlewares.Authenticator())
+	admin.Use(h.middlewares.DenyImpersonation())
+	admin.Use(h.middlewares.AdminRateLimits())
+
+	read := admin.Group("")
+	read.Use(h.middlewares.RequirePermissions(domain.PermissionRolesRead))
+	{
+		read.GET("/roles", h.ListRoles)
+		read.GET("/roles/:id", h.GetRole)
+		read.GET("/permissions", h.ListPermissions)
+	}
+
+	write := admin.Group("")
+	write.Use(h.middlewares.RequirePermissions(domain.PermissionRolesWrite))
+	{
+		write.POST("/roles", h.CreateRole)
+		write.PUT("/roles/:id", h.UpdateRole)
+		write.DELETE("/roles/:id", h.DeleteRole)
+		write.POST("/users/:id/roles", h.AssignRoles)
+		write.DELETE("/users/:id/roles/:role_id", h.RevokeRole)
+	}
+}
+
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.usecase.ListRoles(c.Request.Context())
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, roles, "Roles retrieved successfully")
+}
+
+func (h *RoleHandler) GetRole(c *gin.Context) {
+	role, err := h.usecase.GetRole(c.Request.Context(), domain.RoleID(c.Param("id")))
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, role, "Role retrieved successfully")
+}
+
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.usecase.ListPermissions(c.Request.Context())
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, permissions, "Permissions retrieved successfully")
+}
+
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.ActorID = user.ID
+
+	role, err := h.usecase.CreateRole(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseCreated(c, role, "Role created successfully")
+}
+
+func (h *RoleHandler) UpdateRole(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.ActorID = user.ID
+	req.ID = domain.RoleID(c.Param("id"))
+
+	role, err := h.usecase.UpdateRole(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, role, "Role updated successfully")
+}
+
+func (h *RoleHandler) DeleteRole(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	if err := h.usecase.DeleteRole(c.Request.Context(), &domain.DeleteRoleRequest{
+		ActorID: user.ID,
+		ID:      domain.RoleID(c.Param("id")),
+	}); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "Role deleted")
+}
+
+func (h *RoleHandler) AssignRoles(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.AssignRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	if len(req.RoleIDs) == 0 {
+		common.ResponseBadRequest(c, "role_ids must be not empty")
+		return
+	}
+	req.ActorID = user.ID
+	req.UserID = c.Param("id")
+
+	target, err := h.usecase.AssignRoles(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, target, "Roles assigned successfully")
+}
+
+func (h *RoleHandler) RevokeRole(c *gin.Context) {
+	user := common.GetUserFromCtx(c)
+	if user == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	target, err := h.usecase.RevokeRole(c.Request.Context(), &domain.RevokeRoleRequest{
+		ActorID: user.ID,
+		UserID:  c.Param("id"),
+		RoleID:  domain.RoleID(c.Param("role_id")),
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, target, "Role revoked successfully")
+}
@@ -27,18 +27,52 @@ func (h *UserHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	user.Use(h.middlewares.Authenticator())
 	user.Use(h.middlewares.APIRateLimits())
 
+	// Only administrators create accounts here, the policy is applied by the usecase
 	user.POST("", h.Create)
+	// Shortcuts to the account of the authenticated user
+	user.GET("/me", h.GetByID)
+	user.PUT("/me", h.Update)
+	user.PUT("/me/password", h.middlewares.DenyImpersonation(), h.ChangePassword)
 	user.GET("/:id", h.GetByID)
 	user.PUT("/:id", h.Update)
-	user.PUT("/:id/password", h.ChangePassword)
+	user.PUT("/:id/password", h.middlewares.DenyImpersonation(), h.ChangePassword)
+
+	// Management of user accounts, by administrators acting as themselves
+	admin := rg.Group("/admin/users")
+	admin.Use(h.middlewares.Authenticator())
+	admin.Use(h.middlewares.DenyImpersonation())
+	admin.Use(h.middlewares.AdminRateLimits())
+
+	read := admin.Group("")
+	read.Use(h.middlewares.RequirePermissions(domain.PermissionUsersRead))
+	{
+		read.GET("", h.ListUsers)
+		read.GET("/:id", h.AdminGetByID)
+	}
+
+	write := admin.Group("")
+	write.Use(h.middlewares.RequirePermissions(domain.PermissionUsersWrite))
+	{
+		write.DELETE("/:id", h.Delete)
+		write.POST("/:id/restore", h.Restore)
+		write.POST("/:id/ban", h.Ban)
+		write.POST("/:id/unban", h.Unban)
+		write.POST("/:id/logout", h.ForceLogout)
+	}
 }
 
 func (h *UserHandler) Create(c *gin.Context) {
+	principal := common.GetPrincipalFromCtx(c)
+	if principal == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
 	var req domain.UserCreateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ResponseBadRequest(c, err.Error())
 		return
 	}
+	req.Principal = principal
 	user, err := h.usecase.Create(c.Request.Context(), &req)
 	if err != nil {
 		common.ResponseError(c, err)
@@ -48,23 +82,49 @@ func (h *UserHandler) Create(c *gin.Context) {
 }
 
 func (h *UserHandler) GetByID(c *gin.Context) {
-	id := c.Param("id")
-	user, err := h.usecase.FindByID(c.Request.Context(), id, nil)
+	principal := common.GetPrincipalFromCtx(c)
+	if principal == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+	// Authorized before the lookup, the answer must not tell whether the user exists
+	userID := targetUserID(c, principal)
+	if err := domain.AuthorizeUserLookup(principal, domain.UserActionView, userID); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	user, err := h.usecase.FindByID(c.Request.Context(), userID, nil)
 	if err != nil || user == nil {
 		common.ResponseNotFound(c, "user not found")
 		return
 	}
+	if err := domain.AuthorizeUserAction(principal, domain.UserActionView, user); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
 	common.ResponseOK(c, user, "User found")
 }
 
 func (h *UserHandler) Update(c *gin.Context) {
-	id := c.Param("id")
+	principal := common.GetPrincipalFromCtx(c)
+	if principal == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
 	var req domain.UserUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ResponseBadRequest(c, err.Error())
 		return
 	}
-	if err := h.usecase.Update(c.Request.Context(), id, &req); err != nil {
+	// The email and the username identify the account at login, like the password only the user
+	// may change them. The other profile fields stay open to impersonating administrators.
+	if (req.Email != nil || req.Username != nil) && common.GetActorFromCtx(c) != nil {
+		common.ResponseError(c, domain.ErrImpersonationForbidden)
+		return
+	}
+	req.Principal = principal
+
+	if err := h.usecase.Update(c.Request.Context(), targetUserID(c, principal), &req); err != nil {
 		common.ResponseError(c, err)
 		return
 	}
@@ -72,16 +132,158 @@ func (h *UserHandler) Update(c *gin.Context) {
 }
 
 func (h *UserHandler) ChangePassword(c *gin.Context) {
-	id := c.Param("id")
+	principal := common.GetPrincipalFromCtx(c)
+	if principal == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
 	var req domain.UserChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		common.ResponseBadRequest(c, err.Error())
 		return
 	}
-	req.UserID = id
+	req.UserID = targetUserID(c, principal)
+	req.Principal = principal
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
 	if err := h.usecase.ChangePassword(c.Request.Context(), &req); err != nil {
 		common.ResponseError(c, err)
 		return
 	}
 	common.ResponseNoContent(c, "Password changed successfully")
 }
+
+// targetUserID returns the user of the path, or the authenticated user on the /users/me routes
+func targetUserID(c *gin.Context, principal *domain.Principal) string {
+	if id := c.Param("id"); id != "" {
+		return id
+	}
+	return principal.UserID
+}
+
+func (h *UserHandler) ListUsers(c *gin.Context) {
+	var req domain.ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+
+	users, pagination, err := h.usecase.ListUsers(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"users":      users,
+		"pagination": pagination,
+	}
+	common.ResponseOK(c, response, "Users retrieved successfully")
+}
+
+// AdminGetByID returns the user with its roles and ban, deleted users included
+func (h *UserHandler) AdminGetByID(c *gin.Context) {
+	id := c.Param("id")
+	includeDeleted := true
+	user, err := h.usecase.FindOne(c.Request.Context(), &domain.UserFilter{
+		ID:             &id,
+		IncludeDeleted: &includeDeleted,
+	}, &domain.FindOneOption{
+		Preloads: []string{common.FieldRoles},
+	})
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, user, "User found")
+}
+
+func (h *UserHandler) Delete(c *gin.Context) {
+	req, ok := h.bindAdminAction(c)
+	if !ok {
+		return
+	}
+	if err := h.usecase.Delete(c.Request.Context(), req); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "User deleted")
+}
+
+func (h *UserHandler) Restore(c *gin.Context) {
+	req, ok := h.bindAdminAction(c)
+	if !ok {
+		return
+	}
+	user, err := h.usecase.Restore(c.Request.Context(), req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, user, "User restored successfully")
+}
+
+func (h *UserHandler) Ban(c *gin.Context) {
+	principal := common.GetPrincipalFromCtx(c)
+	if principal == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return
+	}
+
+	var req domain.BanUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.ResponseBadRequest(c, err.Error())
+		return
+	}
+	req.UserID = c.Param("id")
+	req.Principal = principal
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+
+	user, err := h.usecase.Ban(c.Request.Context(), &req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, user, "User banned successfully")
+}
+
+func (h *UserHandler) Unban(c *gin.Context) {
+	req, ok := h.bindAdminAction(c)
+	if !ok {
+		return
+	}
+	user, err := h.usecase.Unban(c.Request.Context(), req)
+	if err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseOK(c, user, "User unbanned successfully")
+}
+
+func (h *UserHandler) ForceLogout(c *gin.Context) {
+	req, ok := h.bindAdminAction(c)
+	if !ok {
+		return
+	}
+	if err := h.usecase.ForceLogout(c.Request.Context(), req); err != nil {
+		common.ResponseError(c, err)
+		return
+	}
+	common.ResponseNoContent(c, "User signed out everywhere")
+}
+
+// bindAdminAction builds the request of an administrator acting on the user of the path
+func (h *UserHandler) bindAdminAction(c *gin.Context) (*domain.UserAdminActionRequest, bool) {
+	principal := common.GetPrincipalFromCtx(c)
+	if principal == nil {
+		common.ResponseError(c, domain.ErrUnauthorized)
+		return nil, false
+	}
+
+	req := &domain.UserAdminActionRequest{
+		UserID:    c.Param("id"),
+		Principal: principal,
+	}
+	common.PopulateClientInfo(c, &req.IPAddress, &req.UserAgent)
+	return req, true
+}
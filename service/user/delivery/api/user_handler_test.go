@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeUserUsecase serves the users by ID and records the lookups and updates, the methods the
+// tests do not need are left to the nil interface
+type fakeUserUsecase struct {
+	domain.UserUsecase
+	users   map[string]*domain.User
+	found   []string
+	updated []string
+}
+
+func (u *fakeUserUsecase) FindByID(_ context.Context, userID string, _ *domain.FindOneOption) (*domain.User, error) {
+	u.found = append(u.found, userID)
+	if user, ok := u.users[userID]; ok {
+		return user, nil
+	}
+	return nil, domain.ErrUserNotFound
+}
+
+func (u *fakeUserUsecase) Update(_ context.Context, userID string, _ *domain.UserUpdateRequest) error {
+	u.updated = append(u.updated, userID)
+	return nil
+}
+
+func TestUserHandlerUpdateImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &domain.User{}
+	user.ID = "user-1"
+	admin := &domain.User{}
+	admin.ID = "admin-1"
+
+	tests := []struct {
+		name         string
+		path         string
+		body         string
+		impersonated bool
+		wantStatus   int
+	}{
+		{name: "user changes their email", path: "/users/me", body: `{"email":"jane@example.com"}`, wantStatus: http.StatusNoContent},
+		{name: "impersonated email change", path: "/users/me", body: `{"email":"jane@example.com"}`, impersonated: true, wantStatus: http.StatusForbidden},
+		{name: "impersonated username change", path: "/users/me", body: `{"username":"jane"}`, impersonated: true, wantStatus: http.StatusForbidden},
+		{name: "impersonated email change by ID", path: "/users/user-1", body: `{"email":"jane@example.com"}`, impersonated: true, wantStatus: http.StatusForbidden},
+		{name: "impersonated name change", path: "/users/me", body: `{"first_name":"Jane"}`, impersonated: true, wantStatus: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := &fakeUserUsecase{}
+			h := &UserHandler{usecase: usecase}
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set(common.UserContextKey, user)
+				if tt.impersonated {
+					c.Set(common.ActorContextKey, admin)
+				}
+			})
+			router.PUT("/users/me", h.Update)
+			router.PUT("/users/:id", h.Update)
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPut, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("PUT %s status = %d, want %d", tt.path, w.Code, tt.wantStatus)
+			}
+			if updated := len(usecase.updated) == 1; updated != (tt.wantStatus == http.StatusNoContent) {
+				t.Errorf("usecase updated = %v, want %v", usecase.updated, tt.wantStatus == http.StatusNoContent)
+			}
+		})
+	}
+}
+
+func TestUserHandlerGetByID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	user := &domain.User{}
+	user.ID = "user-1"
+	other := &domain.User{}
+	other.ID = "user-2"
+
+	tests := []struct {
+		name        string
+		path        string
+		permissions []domain.PermissionID
+		wantStatus  int
+		wantLookup  bool
+	}{
+		{name: "own account", path: "/users/me", wantStatus: http.StatusOK, wantLookup: true},
+		{name: "own account by ID", path: "/users/user-1", wantStatus: http.StatusOK, wantLookup: true},
+		// Refused the same way whether the user exists or not
+		{name: "existing user without users:read", path: "/users/user-2", wantStatus: http.StatusForbidden},
+		{name: "missing user without users:read", path: "/users/user-3", wantStatus: http.StatusForbidden},
+		{name: "existing user with users:read", path: "/users/user-2", permissions: []domain.PermissionID{domain.PermissionUsersRead}, wantStatus: http.StatusOK, wantLookup: true},
+		{name: "missing user with users:read", path: "/users/user-3", permissions: []domain.PermissionID{domain.PermissionUsersRead}, wantStatus: http.StatusNotFound, wantLookup: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase := &fakeUserUsecase{users: map[string]*domain.User{"user-1": user, "user-2": other}}
+			h := &UserHandler{usecase: usecase}
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set(common.UserContextKey, user)
+				c.Set(common.PermissionsContextKey, tt.permissions)
+			})
+			router.GET("/users/me", h.GetByID)
+			router.GET("/users/:id", h.GetByID)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("GET %s status = %d, want %d", tt.path, w.Code, tt.wantStatus)
+			}
+			if looked := len(usecase.found) > 0; looked != tt.wantLookup {
+				t.Errorf("lookups = %v, want a lookup %v", usecase.found, tt.wantLookup)
+			}
+		})
+	}
+}
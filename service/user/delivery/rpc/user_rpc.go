@@ -23,6 +23,7 @@ func (s *UserRPC) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*p
 		Password:  req.Password,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
+		External:  req.External,
 	}
 	user, err := s.usecase.Create(ctx, createReq)
 	if err != nil {
@@ -43,8 +44,8 @@ func (s *UserRPC) GetUserByFilter(ctx context.Context, req *pb.GetUserByFilterRe
 	filter := common.ToDomainUserFilter(req.Filter)
 	option := common.ToDomainFindOneOption(req.Option)
 	user, err := s.usecase.FindOne(ctx, filter, option)
-	if err != nil || user == nil {
-		return nil, err
+	if err != nil {
+		return nil, common.ToGRPCError(err)
 	}
 	return &pb.GetUserResponse{User: common.ToPbUser(user)}, nil
 }
@@ -63,18 +64,42 @@ func (s *UserRPC) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*p
 	if req.LastName != "" {
 		updateReq.LastName = &req.LastName
 	}
-	st := domain.UserStatus(req.Status.String())
 	if req.Status != pb.UserStatus_USER_STATUS_UNSPECIFIED {
+		st := common.ToDomainUserStatus(req.Status)
 		updateReq.Status = &st
 	}
+	updateReq.PasswordResetRequired = req.PasswordResetRequired
+	updateReq.ClearPassword = req.ClearPassword
 	err := s.usecase.Update(ctx, req.Id, updateReq)
 	if err != nil {
-		return nil, err
+		return nil, common.ToGRPCError(err)
 	}
 	user, _ := s.usecase.FindByID(ctx, req.Id, nil)
 	return &pb.UpdateUserResponse{User: common.ToPbUser(user)}, nil
 }
 
+func (s *UserRPC) UpdateUserPassword(ctx context.Context, req *pb.UpdateUserPasswordRequest) (*pb.UpdateUserPasswordResponse, error) {
+	if err := s.usecase.UpdatePassword(ctx, req.Id, req.Password); err != nil {
+		return nil, common.ToGRPCError(err)
+	}
+	return &pb.UpdateUserPasswordResponse{Success: true}, nil
+}
+
+func (s *UserRPC) CheckUserPassword(ctx context.Context, req *pb.CheckUserPasswordRequest) (*pb.CheckUserPasswordResponse, error) {
+	if err := s.usecase.CheckPassword(ctx, req.Id, req.Password); err != nil {
+		return nil, common.ToGRPCError(err)
+	}
+	return &pb.CheckUserPasswordResponse{Valid: true}, nil
+}
+
+func (s *UserRPC) RehashUserPassword(ctx context.Context, req *pb.RehashUserPasswordRequest) (*pb.RehashUserPasswordResponse, error) {
+	rehashed, err := s.usecase.RehashPassword(ctx, req.Id, req.Password)
+	if err != nil {
+		return nil, common.ToGRPCError(err)
+	}
+	return &pb.RehashUserPasswordResponse{Rehashed: rehashed}, nil
+}
+
 func (s *UserRPC) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
 	filter := &domain.UserFilter{}
 	option := &domain.FindPageOption{
@@ -0,0 +1,55 @@
+package job
+
+import (
+	"context"
+	"go-clean-arch/pkg/log"
+	"time"
+)
+
+type BanLifter interface {
+	LiftExpiredBans(ctx context.Context) (int64, error)
+}
+
+// BanLiftJob gives the users whose temporary ban expired their status back, once at start and then
+// at every interval. Expired bans no longer block the user in the meantime, and running it on
+// several instances is harmless.
+type BanLiftJob struct {
+	lifter   BanLifter
+	interval time.Duration
+	logger   log.Logger
+}
+
+func NewBanLiftJob(lifter BanLifter, interval time.Duration, logger log.Logger) *BanLiftJob {
+	return &BanLiftJob{
+		lifter:   lifter,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Run lifts expired bans until the context is done.
+func (j *BanLiftJob) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		j.lift(ctx)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (j *BanLiftJob) lift(ctx context.Context) {
+	lifted, err := j.lifter.LiftExpiredBans(ctx)
+	if err != nil {
+		j.logger.Error("Failed to lift expired bans", log.Error(err))
+		return
+	}
+	if lifted > 0 {
+		j.logger.Info("Lifted expired bans", log.Int64("lifted", lifted))
+	}
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type PasswordHistoryRepository struct {
+	sqlHandler *database.SQLHandler[domain.PasswordHistory, domain.PasswordHistoryFilter]
+}
+
+func NewPasswordHistoryRepository(db *gorm.DB) *PasswordHistoryRepository {
+	sqlHandler := database.NewSQLHandler[domain.PasswordHistory](db, applyPasswordHistoryFilter, database.WithoutTenantScope())
+	return &PasswordHistoryRepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyPasswordHistoryFilter(qb *gorm.DB, filter *domain.PasswordHistoryFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if len(filter.IDIn) > 0 {
+		qb = qb.Where("id IN (?)", filter.IDIn)
+	}
+	if filter.UserID != nil {
+		qb = qb.Where("user_id = ?", *filter.UserID)
+	}
+
+	return qb
+}
+
+func (r *PasswordHistoryRepository) Create(ctx context.Context, history *domain.PasswordHistory) error {
+	return r.sqlHandler.Create(ctx, history)
+}
+
+// FindRecentByUserID returns the latest password hashes of the user, newest first
+func (r *PasswordHistoryRepository) FindRecentByUserID(ctx context.Context, userID string, limit int) ([]*domain.PasswordHistory, error) {
+	return r.sqlHandler.FindMany(ctx, &domain.PasswordHistoryFilter{
+		UserID: &userID,
+	}, &domain.FindManyOption{
+		Sort:  []string{"created_at DESC"},
+		Limit: &limit,
+	})
+}
+
+// Prune deletes every entry of the user but the newest keep ones
+func (r *PasswordHistoryRepository) Prune(ctx context.Context, userID string, keep int) error {
+	stale, err := r.sqlHandler.FindMany(ctx, &domain.PasswordHistoryFilter{
+		UserID: &userID,
+	}, &domain.FindManyOption{
+		Sort:   []string{"created_at DESC"},
+		Offset: &keep,
+	})
+	if err != nil || len(stale) == 0 {
+		return err
+	}
+
+	ids := make([]string, 0, len(stale))
+	for _, history := range stale {
+		ids = append(ids, history.ID)
+	}
+	_, err = r.sqlHandler.DeleteMany(ctx, &domain.PasswordHistoryFilter{IDIn: ids})
+	return err
+}
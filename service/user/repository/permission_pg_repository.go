@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+)
+
+type PermissionRepository struct {
+	sqlHandler *database.SQLHandler[domain.Permission, domain.PermissionFilter]
+}
+
+func NewPermissionRepository(db *gorm.DB) *PermissionRepository {
+	sqlHandler := database.NewSQLHandler[domain.Permission](db, applyPermissionFilter, database.WithoutTenantScope())
+	return &PermissionRepository{
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyPermissionFilter(qb *gorm.DB, filter *domain.PermissionFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if len(filter.IDIn) > 0 {
+		qb = qb.Where("id IN (?)", filter.IDIn)
+	}
+
+	return qb
+}
+
+func (r *PermissionRepository) CreateMany(ctx context.Context, permissions []*domain.Permission) error {
+	return r.sqlHandler.CreateMany(ctx, permissions)
+}
+
+func (r *PermissionRepository) FindMany(ctx context.Context, filter *domain.PermissionFilter, option *domain.FindManyOption) ([]*domain.Permission, error) {
+	return r.sqlHandler.FindMany(ctx, filter, option)
+}
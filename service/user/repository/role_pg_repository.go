@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"go-clean-arch/database"
+	"go-clean-arch/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Join tables of the many2many associations of roles
+const (
+	rolePermissionsTable = "role_permissions"
+	userRolesTable       = "user_roles"
+)
+
+type RoleRepository struct {
+	db         *gorm.DB
+	sqlHandler *database.SQLHandler[domain.Role, domain.RoleFilter]
+}
+
+func NewRoleRepository(db *gorm.DB) *RoleRepository {
+	sqlHandler := database.NewSQLHandler[domain.Role](db, applyRoleFilter, database.WithoutTenantScope())
+	return &RoleRepository{
+		db:         db,
+		sqlHandler: sqlHandler,
+	}
+}
+
+func applyRoleFilter(qb *gorm.DB, filter *domain.RoleFilter) *gorm.DB {
+	if filter == nil {
+		return qb
+	}
+
+	if filter.ID != nil {
+		qb = qb.Where("id = ?", *filter.ID)
+	}
+	if len(filter.IDIn) > 0 {
+		qb = qb.Where("id IN (?)", filter.IDIn)
+	}
+
+	return qb
+}
+
+// Create stores the role together with its permissions, which must already exist.
+func (r *RoleRepository) Create(ctx context.Context, role *domain.Role) error {
+	return r.sqlHandler.Create(ctx, role, database.WithOmit("Permissions.*"))
+}
+
+func (r *RoleRepository) FindByID(ctx context.Context, id domain.RoleID, option *domain.FindOneOption) (*domain.Role, error) {
+	return r.sqlHandler.FindByID(ctx, id, option)
+}
+
+func (r *RoleRepository) FindMany(ctx context.Context, filter *domain.RoleFilter, option *domain.FindManyOption) ([]*domain.Role, error) {
+	return r.sqlHandler.FindMany(ctx, filter, option)
+}
+
+func (r *RoleRepository) UpdateFields(ctx context.Context, id domain.RoleID, fields map[string]any) error {
+	return r.sqlHandler.UpdateFields(ctx, id, fields)
+}
+
+// ReplacePermissions sets the permissions of the role to exactly the given ones in a single transaction.
+func (r *RoleRepository) ReplacePermissions(ctx context.Context, id domain.RoleID, permissionIDs []domain.PermissionID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM "+rolePermissionsTable+" WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		return addRolePermissions(tx, id, permissionIDs)
+	})
+}
+
+// AddPermissions grants the given permissions to the role, keeping the ones it already has.
+func (r *RoleRepository) AddPermissions(ctx context.Context, id domain.RoleID, permissionIDs []domain.PermissionID) error {
+	return addRolePermissions(r.db.WithContext(ctx), id, permissionIDs)
+}
+
+func addRolePermissions(db *gorm.DB, id domain.RoleID, permissionIDs []domain.PermissionID) error {
+	if len(permissionIDs) == 0 {
+		return nil
+	}
+	rows := make([]map[string]any, 0, len(permissionIDs))
+	for _, permissionID := range permissionIDs {
+		rows = append(rows, map[string]any{"role_id": id, "permission_id": permissionID})
+	}
+	return db.Table(rolePermissionsTable).Clauses(clause.OnConflict{DoNothing: true}).Create(rows).Error
+}
+
+// Delete removes the role and takes it away from every user holding it.
+func (r *RoleRepository) Delete(ctx context.Context, id domain.RoleID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM "+rolePermissionsTable+" WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM "+userRolesTable+" WHERE role_id = ?", id).Error; err != nil {
+			return err
+		}
+		_, err := r.sqlHandler.DeleteMany(ctx, &domain.RoleFilter{ID: &id}, database.WithTx(tx))
+		return err
+	})
+}
+
+// AddUserRoles assigns the roles to the user, keeping the roles the user already has.
+func (r *RoleRepository) AddUserRoles(ctx context.Context, userID string, ids []domain.RoleID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	rows := make([]map[string]any, 0, len(ids))
+	for _, id := range ids {
+		rows = append(rows, map[string]any{"user_id": userID, "role_id": id})
+	}
+	return r.db.WithContext(ctx).Table(userRolesTable).Clauses(clause.OnConflict{DoNothing: true}).Create(rows).Error
+}
+
+// RemoveUserRole takes the role away from the user. It returns false when the user did not have it.
+func (r *RoleRepository) RemoveUserRole(ctx context.Context, userID string, id domain.RoleID) (bool, error) {
+	result := r.db.WithContext(ctx).Exec("DELETE FROM "+userRolesTable+" WHERE user_id = ? AND role_id = ?", userID, id)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// CountUsers returns the number of users holding the role, deleted users excluded.
+func (r *RoleRepository) CountUsers(ctx context.Context, id domain.RoleID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table(userRolesTable).
+		Joins("JOIN users ON users.id = "+userRolesTable+".user_id").
+		Where(userRolesTable+".role_id = ? AND users.deleted_at = 0", id).
+		Count(&count).Error
+	return count, err
+}
@@ -10,6 +10,7 @@ import (
 )
 
 var userSearchableFields = map[string]string{ // map[StructField]DBColumn]
+	"username":   "username",
 	"first_name": "first_name",
 	"last_name":  "last_name",
 	"email":      "email",
@@ -20,7 +21,7 @@ type UserRepository struct {
 }
 
 func NewUserRepository(db *gorm.DB) *UserRepository {
-	sqlHandler := database.NewSQLHandler[domain.User](db, applyFilter)
+	sqlHandler := database.NewSQLHandler[domain.User](db, applyFilter, database.WithoutTenantScope())
 	return &UserRepository{
 		sqlHandler: sqlHandler,
 	}
@@ -41,10 +42,12 @@ func applyFilter(qb *gorm.DB, filter *domain.UserFilter) *gorm.DB {
 		qb = qb.Where("id IN (?)", filter.IDIn)
 	}
 	if filter.Email != nil {
-		qb = qb.Where("email = ?", *filter.Email)
+		// Matches the case-insensitive unique index on emails, some were stored before normalization
+		qb = qb.Where("LOWER(email) = LOWER(?)", *filter.Email)
 	}
 	if filter.Username != nil {
-		qb = qb.Where("username = ?", *filter.Username)
+		// Matches the case-insensitive unique index on usernames
+		qb = qb.Where("LOWER(username) = LOWER(?)", *filter.Username)
 	}
 	if filter.Active != nil {
 		if *filter.Active {
@@ -60,13 +63,21 @@ func applyFilter(qb *gorm.DB, filter *domain.UserFilter) *gorm.DB {
 			qb = qb.Where("status != ?", domain.UserSTTBanned)
 		}
 	}
+	if len(filter.HasRoles) > 0 {
+		qb = qb.Where("id IN (SELECT user_id FROM user_roles WHERE role_id IN (?))", filter.HasRoles)
+	}
+	if filter.BannedUntilBefore != nil {
+		qb = qb.Where("status = ? AND banned_until > 0 AND banned_until <= ?", domain.UserSTTBanned, *filter.BannedUntilBefore)
+	}
 	if filter.SearchTerm != nil && *filter.SearchTerm != "" {
 		searchTerm := strings.TrimSpace(*filter.SearchTerm)
 		if searchTerm != "" {
 			qb = database.ApplySearch(qb, searchTerm, filter.SearchFields, userSearchableFields)
 		}
 	}
-	if filter.IncludeDeleted == nil || !*filter.IncludeDeleted {
+	if filter.OnlyDeleted != nil && *filter.OnlyDeleted {
+		qb = qb.Where("deleted_at > 0")
+	} else if filter.IncludeDeleted == nil || !*filter.IncludeDeleted {
 		qb = qb.Where("deleted_at = 0")
 	}
 
@@ -93,22 +104,82 @@ func (r *UserRepository) FindPage(ctx context.Context, filter *domain.UserFilter
 	return r.sqlHandler.FindPage(ctx, filter, option)
 }
 
-// Update updates user with omitting password field
+// Update updates user with omitting password field and roles, which are assigned on their own
 func (r *UserRepository) Update(ctx context.Context, user *domain.User) error {
-	return r.sqlHandler.Update(ctx, user, database.WithOmit("Password"))
+	return r.sqlHandler.Update(ctx, user, database.WithOmit("Password", "Roles"))
 }
 
-// UpdatePassword updates only password field of the user
+// UpdatePassword updates only password field of the user, which also fulfills a required reset
 func (r *UserRepository) UpdatePassword(ctx context.Context, userID string, newPassword string) error {
 	return r.sqlHandler.UpdateFields(ctx, userID, map[string]any{
-		"password": newPassword,
+		"password":                newPassword,
+		"password_reset_required": false,
+	})
+}
+
+// ReplacePasswordHash swaps the password hash of the user only if it still is oldHash, so a password
+// changed in the meantime is never overwritten. It returns false when the hash was not replaced.
+func (r *UserRepository) ReplacePasswordHash(ctx context.Context, userID, oldHash, newHash string) (bool, error) {
+	affected, err := r.sqlHandler.UpdateMany(ctx, &domain.UserFilter{
+		ID: &userID,
+	}, map[string]any{
+		"password": newHash,
+	}, func(db *gorm.DB) *gorm.DB {
+		return db.Where("password = ?", oldHash)
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
+// UpdateFields updates only the given columns of the user
+func (r *UserRepository) UpdateFields(ctx context.Context, userID string, fields map[string]any) error {
+	return r.sqlHandler.UpdateFields(ctx, userID, fields)
+}
+
+// LiftBan gives the user its status from before the ban back, unless the ban was changed in the
+// meantime. It returns false when the ban was not lifted.
+func (r *UserRepository) LiftBan(ctx context.Context, userID string, bannedAt int64) (bool, error) {
+	includeDeleted := true
+	affected, err := r.sqlHandler.UpdateMany(ctx, &domain.UserFilter{
+		ID:             &userID,
+		IncludeDeleted: &includeDeleted,
+	}, map[string]any{
+		"status":            gorm.Expr("COALESCE(NULLIF(status_before_ban, ''), ?)", domain.UserSTTActive),
+		"ban_reason":        "",
+		"banned_by":         "",
+		"banned_at":         0,
+		"banned_until":      0,
+		"status_before_ban": "",
+	}, func(db *gorm.DB) *gorm.DB {
+		return db.Where("status = ? AND banned_at = ?", domain.UserSTTBanned, bannedAt)
 	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
 }
 
 func (r *UserRepository) Delete(ctx context.Context, userID string) error {
 	return r.sqlHandler.DeleteByID(ctx, userID)
 }
 
+// Restore brings a soft deleted user back and returns false when the user was not deleted
+func (r *UserRepository) Restore(ctx context.Context, userID string) (bool, error) {
+	onlyDeleted := true
+	affected, err := r.sqlHandler.UpdateMany(ctx, &domain.UserFilter{
+		ID:          &userID,
+		OnlyDeleted: &onlyDeleted,
+	}, map[string]any{
+		"deleted_at": 0,
+	})
+	if err != nil {
+		return false, err
+	}
+	return affected == 1, nil
+}
+
 func (r *UserRepository) Count(ctx context.Context, filter *domain.UserFilter) (int64, error) {
 	return r.sqlHandler.Count(ctx, filter)
 }
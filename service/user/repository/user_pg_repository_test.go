@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"go-clean-arch/domain"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newDryRunDB opens a database that builds the statements without running them
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost dbname=test sslmode=disable"}), &gorm.Config{
+		DryRun:                 true,
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestApplyFilterMatchesIdentifiersRegardlessOfCase(t *testing.T) {
+	email, username := "Jane.Doe@Example.com", "Jane"
+
+	tests := []struct {
+		name    string
+		filter  *domain.UserFilter
+		wantSQL string
+		wantVar string
+	}{
+		{name: "email", filter: &domain.UserFilter{Email: &email}, wantSQL: "LOWER(email) = LOWER($1)", wantVar: email},
+		{name: "username", filter: &domain.UserFilter{Username: &username}, wantSQL: "LOWER(username) = LOWER($1)", wantVar: username},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var users []*domain.User
+			statement := applyFilter(newDryRunDB(t).Model(&domain.User{}), tt.filter).Find(&users).Statement
+			if sql := statement.SQL.String(); !strings.Contains(sql, tt.wantSQL) {
+				t.Errorf("SQL = %q, want it to contain %q", sql, tt.wantSQL)
+			}
+			if len(statement.Vars) == 0 || statement.Vars[0] != tt.wantVar {
+				t.Errorf("vars = %v, want %q first", statement.Vars, tt.wantVar)
+			}
+		})
+	}
+}
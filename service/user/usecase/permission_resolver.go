@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/cache"
+	"time"
+)
+
+// permissionCacheTTL bounds how long a role keeps stale permissions when an invalidation was lost
+const permissionCacheTTL = 10 * time.Minute
+
+type PermissionCache interface {
+	GetMultiple(ctx context.Context, keys []string) (map[string][]byte, error)
+	SetMultiple(ctx context.Context, items map[string]cache.Item) error
+	DeleteMultiple(ctx context.Context, keys []string) error
+}
+
+// PermissionResolver resolves the permissions granted by roles. The permissions of each role are
+// cached, and invalidated whenever the permissions of a role change. Roles of users are read with
+// the user on every request, so assigning a role takes effect at once.
+type PermissionResolver struct {
+	roleRepo RoleRepository
+	cache    PermissionCache
+}
+
+func NewPermissionResolver(roleRepo RoleRepository, cache PermissionCache) *PermissionResolver {
+	return &PermissionResolver{
+		roleRepo: roleRepo,
+		cache:    cache,
+	}
+}
+
+func rolePermissionsKey(roleID domain.RoleID) string {
+	return "role_permissions:" + string(roleID)
+}
+
+// PermissionsForRoles returns every permission granted by the given roles, without duplicates.
+func (r *PermissionResolver) PermissionsForRoles(ctx context.Context, roles []*domain.Role) ([]domain.PermissionID, error) {
+	keys := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if role != nil {
+			keys = append(keys, rolePermissionsKey(role.ID))
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	// The database is the source of truth, a cache failure only costs a query
+	cached, err := r.cache.GetMultiple(ctx, keys)
+	if err != nil {
+		cached = nil
+	}
+
+	seen := make(map[domain.PermissionID]struct{})
+	var permissions []domain.PermissionID
+	add := func(ids []domain.PermissionID) {
+		for _, id := range ids {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				permissions = append(permissions, id)
+			}
+		}
+	}
+
+	var missing []domain.RoleID
+	for _, role := range roles {
+		if role == nil {
+			continue
+		}
+		var ids []domain.PermissionID
+		if data, ok := cached[rolePermissionsKey(role.ID)]; ok && json.Unmarshal(data, &ids) == nil {
+			add(ids)
+			continue
+		}
+		missing = append(missing, role.ID)
+	}
+	if len(missing) == 0 {
+		return permissions, nil
+	}
+
+	loaded, err := r.roleRepo.FindMany(ctx, &domain.RoleFilter{
+		IDIn: missing,
+	}, &domain.FindManyOption{
+		Preloads: []string{common.FieldPermissions},
+	})
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	// Roles that no longer exist are cached without permissions as well
+	loadedIDs := make(map[domain.RoleID][]domain.PermissionID, len(missing))
+	for _, role := range loaded {
+		loadedIDs[role.ID] = role.PermissionIDs()
+	}
+	items := make(map[string]cache.Item, len(missing))
+	for _, roleID := range missing {
+		ids := loadedIDs[roleID]
+		add(ids)
+		if data, err := json.Marshal(ids); err == nil {
+			key := rolePermissionsKey(roleID)
+			items[key] = cache.Item{Key: key, Value: data, TTL: permissionCacheTTL}
+		}
+	}
+	_ = r.cache.SetMultiple(ctx, items)
+
+	return permissions, nil
+}
+
+// Invalidate drops the cached permissions of the roles, after their permissions changed.
+func (r *PermissionResolver) Invalidate(ctx context.Context, roleIDs ...domain.RoleID) error {
+	keys := make([]string, 0, len(roleIDs))
+	for _, roleID := range roleIDs {
+		keys = append(keys, rolePermissionsKey(roleID))
+	}
+	if err := r.cache.DeleteMultiple(ctx, keys); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/cache"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+type nopCacheLogger struct{}
+
+func (nopCacheLogger) Info(string, ...interface{})   {}
+func (nopCacheLogger) Error(string, ...interface{})  {}
+func (nopCacheLogger) Debug(string, ...interface{})  {}
+func (nopCacheLogger) Infof(string, ...interface{})  {}
+func (nopCacheLogger) Errorf(string, ...interface{}) {}
+func (nopCacheLogger) Debugf(string, ...interface{}) {}
+
+// fakeRoleRepo serves roles from memory, the methods the tests do not need are left to the nil interface
+type fakeRoleRepo struct {
+	RoleRepository
+	roles   map[domain.RoleID][]domain.PermissionID
+	queries [][]domain.RoleID
+	err     error
+}
+
+func (r *fakeRoleRepo) FindMany(_ context.Context, filter *domain.RoleFilter, _ *domain.FindManyOption) ([]*domain.Role, error) {
+	r.queries = append(r.queries, filter.IDIn)
+	if r.err != nil {
+		return nil, r.err
+	}
+	var roles []*domain.Role
+	for _, id := range filter.IDIn {
+		permissions, ok := r.roles[id]
+		if !ok {
+			continue
+		}
+		role := &domain.Role{ID: id}
+		for _, permission := range permissions {
+			role.Permissions = append(role.Permissions, &domain.Permission{ID: permission})
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+// failingPermissionCache fails every call, as when the cache server is down
+type failingPermissionCache struct{}
+
+func (failingPermissionCache) GetMultiple(context.Context, []string) (map[string][]byte, error) {
+	return nil, errors.New("cache unavailable")
+}
+
+func (failingPermissionCache) SetMultiple(context.Context, map[string]cache.Item) error {
+	return errors.New("cache unavailable")
+}
+
+func (failingPermissionCache) DeleteMultiple(context.Context, []string) error {
+	return errors.New("cache unavailable")
+}
+
+func roles(ids ...domain.RoleID) []*domain.Role {
+	roles := make([]*domain.Role, 0, len(ids))
+	for _, id := range ids {
+		roles = append(roles, &domain.Role{ID: id})
+	}
+	return roles
+}
+
+func newTestRoleRepo() *fakeRoleRepo {
+	return &fakeRoleRepo{roles: map[domain.RoleID][]domain.PermissionID{
+		domain.RoleIDAdmin: {domain.PermissionUsersRead, domain.PermissionUsersWrite, domain.PermissionRolesRead},
+		"support":          {domain.PermissionUsersRead, domain.PermissionSessionsManage},
+		domain.RoleIDGuest: {},
+	}}
+}
+
+func TestPermissionResolverPermissionsForRoles(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []*domain.Role
+		want  []domain.PermissionID
+	}{
+		{
+			name: "no roles",
+		},
+		{
+			name:  "nil role",
+			roles: []*domain.Role{nil},
+		},
+		{
+			name:  "single role",
+			roles: roles("support"),
+			want:  []domain.PermissionID{domain.PermissionUsersRead, domain.PermissionSessionsManage},
+		},
+		{
+			name:  "shared permissions are listed once",
+			roles: roles(domain.RoleIDAdmin, "support"),
+			want: []domain.PermissionID{
+				domain.PermissionUsersRead, domain.PermissionUsersWrite, domain.PermissionRolesRead, domain.PermissionSessionsManage,
+			},
+		},
+		{
+			name:  "role without permissions",
+			roles: roles(domain.RoleIDGuest),
+		},
+		{
+			name:  "deleted role",
+			roles: roles("former", "support"),
+			want:  []domain.PermissionID{domain.PermissionUsersRead, domain.PermissionSessionsManage},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			resolver := NewPermissionResolver(newTestRoleRepo(), cache.NewMemoryCache(&cache.Config{}, nopCacheLogger{}))
+
+			// The second call is served by the cache and must agree with the first
+			for i := 0; i < 2; i++ {
+				got, err := resolver.PermissionsForRoles(ctx, tt.roles)
+				if err != nil {
+					t.Fatalf("PermissionsForRoles() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("PermissionsForRoles() call %d = %v, want %v", i+1, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPermissionResolverCache(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRoleRepo()
+	resolver := NewPermissionResolver(repo, cache.NewMemoryCache(&cache.Config{}, nopCacheLogger{}))
+
+	if _, err := resolver.PermissionsForRoles(ctx, roles("support")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolver.PermissionsForRoles(ctx, roles("support", "former")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resolver.PermissionsForRoles(ctx, roles("support", "former")); err != nil {
+		t.Fatal(err)
+	}
+	// Only the roles missing from the cache are queried, deleted roles are cached as well
+	wantQueries := [][]domain.RoleID{{"support"}, {"former"}}
+	if !reflect.DeepEqual(repo.queries, wantQueries) {
+		t.Fatalf("queried roles = %v, want %v", repo.queries, wantQueries)
+	}
+
+	repo.roles["support"] = append(repo.roles["support"], domain.PermissionAPIKeysManage)
+	if err := resolver.Invalidate(ctx, "support"); err != nil {
+		t.Fatal(err)
+	}
+	got, err := resolver.PermissionsForRoles(ctx, roles("support"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(got, domain.PermissionAPIKeysManage) {
+		t.Errorf("PermissionsForRoles() after Invalidate = %v, want the new permission", got)
+	}
+}
+
+func TestPermissionResolverCacheFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := newTestRoleRepo()
+	resolver := NewPermissionResolver(repo, failingPermissionCache{})
+
+	// The database answers when the cache cannot
+	got, err := resolver.PermissionsForRoles(ctx, roles("support"))
+	if err != nil {
+		t.Fatalf("PermissionsForRoles() error = %v", err)
+	}
+	if want := []domain.PermissionID{domain.PermissionUsersRead, domain.PermissionSessionsManage}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PermissionsForRoles() = %v, want %v", got, want)
+	}
+
+	// A lost invalidation would keep stale permissions, it must be reported
+	if err := resolver.Invalidate(ctx, "support"); !errors.Is(err, domain.ErrInternalServerError) {
+		t.Errorf("Invalidate() error = %v, want %v", err, domain.ErrInternalServerError)
+	}
+
+	repo.err = errors.New("database unavailable")
+	if _, err := resolver.PermissionsForRoles(ctx, roles("support")); !errors.Is(err, domain.ErrInternalServerError) {
+		t.Errorf("PermissionsForRoles() error = %v, want %v", err, domain.ErrInternalServerError)
+	}
+}
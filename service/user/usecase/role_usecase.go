@@ -0,0 +1,375 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/common"
+	"go-clean-arch/domain"
+	"go-clean-arch/pkg/utils"
+
+	"github.com/samber/lo"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *domain.Role) error
+	FindByID(ctx context.Context, id domain.RoleID, option *domain.FindOneOption) (*domain.Role, error)
+	FindMany(ctx context.Context, filter *domain.RoleFilter, option *domain.FindManyOption) ([]*domain.Role, error)
+	UpdateFields(ctx context.Context, id domain.RoleID, fields map[string]any) error
+	ReplacePermissions(ctx context.Context, id domain.RoleID, permissionIDs []domain.PermissionID) error
+	Delete(ctx context.Context, id domain.RoleID) error
+	AddUserRoles(ctx context.Context, userID string, ids []domain.RoleID) error
+	RemoveUserRole(ctx context.Context, userID string, id domain.RoleID) (bool, error)
+	CountUsers(ctx context.Context, id domain.RoleID) (int64, error)
+}
+
+type PermissionRepository interface {
+	FindMany(ctx context.Context, filter *domain.PermissionFilter, option *domain.FindManyOption) ([]*domain.Permission, error)
+}
+
+type RolePermissionResolver interface {
+	PermissionsForRoles(ctx context.Context, roles []*domain.Role) ([]domain.PermissionID, error)
+	Invalidate(ctx context.Context, roleIDs ...domain.RoleID) error
+}
+
+type roleUsecase struct {
+	roleRepo           RoleRepository
+	permissionRepo     PermissionRepository
+	userRepo           UserRepository
+	permissionResolver RolePermissionResolver
+	securityEvents     domain.SecurityEventEmitter
+}
+
+func NewRoleUsecase(
+	roleRepo RoleRepository,
+	permissionRepo PermissionRepository,
+	userRepo UserRepository,
+	permissionResolver RolePermissionResolver,
+	securityEvents domain.SecurityEventEmitter,
+) domain.RoleUsecase {
+	return &roleUsecase{
+		roleRepo:           roleRepo,
+		permissionRepo:     permissionRepo,
+		userRepo:           userRepo,
+		permissionResolver: permissionResolver,
+		securityEvents:     securityEvents,
+	}
+}
+
+func (r *roleUsecase) ListRoles(ctx context.Context) ([]*domain.Role, error) {
+	roles, err := r.roleRepo.FindMany(ctx, nil, &domain.FindManyOption{
+		Sort:     []string{"id ASC"},
+		Preloads: []string{common.FieldPermissions},
+	})
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return roles, nil
+}
+
+func (r *roleUsecase) GetRole(ctx context.Context, id domain.RoleID) (*domain.Role, error) {
+	return r.findRole(ctx, id)
+}
+
+func (r *roleUsecase) CreateRole(ctx context.Context, req *domain.CreateRoleRequest) (*domain.Role, error) {
+	role := &domain.Role{
+		ID:          req.ID,
+		Name:        req.Name,
+		Description: req.Description,
+	}
+	if err := role.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := r.roleRepo.FindByID(ctx, role.ID, nil)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if existing != nil {
+		return nil, domain.ErrRoleAlreadyExists
+	}
+
+	permissions, err := r.findPermissions(ctx, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = permissions
+	actor, err := r.principal(ctx, req.ActorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGrantable(actor, role.PermissionIDs()); err != nil {
+		return nil, err
+	}
+
+	if err := r.roleRepo.Create(ctx, role); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return role, nil
+}
+
+func (r *roleUsecase) UpdateRole(ctx context.Context, req *domain.UpdateRoleRequest) (*domain.Role, error) {
+	role, err := r.findRole(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := map[string]any{}
+	if req.Name != nil {
+		role.Name = *req.Name
+		fields["name"] = role.Name
+	}
+	if req.Description != nil {
+		role.Description = *req.Description
+		fields["description"] = role.Description
+	}
+	if err := role.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Both the permissions taken away and the ones granted must be held by the actor
+	var permissions []*domain.Permission
+	permissionIDs := lo.Uniq(req.Permissions)
+	if req.Permissions != nil {
+		if role.ID == domain.RoleIDSuperAdmin {
+			return nil, domain.ErrRoleProtected.WithReason("super admins always hold every permission")
+		}
+		permissions, err = r.findPermissions(ctx, permissionIDs)
+		if err != nil {
+			return nil, err
+		}
+		actor, err := r.principal(ctx, req.ActorID)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkGrantable(actor, append(role.PermissionIDs(), permissionIDs...)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(fields) > 0 {
+		if err := r.roleRepo.UpdateFields(ctx, role.ID, fields); err != nil {
+			return nil, domain.ErrInternalServerError.WithWrap(err)
+		}
+	}
+	if req.Permissions != nil {
+		if err := r.roleRepo.ReplacePermissions(ctx, role.ID, permissionIDs); err != nil {
+			return nil, domain.ErrInternalServerError.WithWrap(err)
+		}
+		if err := r.permissionResolver.Invalidate(ctx, role.ID); err != nil {
+			return nil, err
+		}
+		role.Permissions = permissions
+	}
+	return role, nil
+}
+
+func (r *roleUsecase) DeleteRole(ctx context.Context, req *domain.DeleteRoleRequest) error {
+	role, err := r.findRole(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return domain.ErrRoleProtected.WithReason("built-in roles cannot be deleted")
+	}
+	actor, err := r.principal(ctx, req.ActorID)
+	if err != nil {
+		return err
+	}
+	if err := checkGrantable(actor, role.PermissionIDs()); err != nil {
+		return err
+	}
+
+	if err := r.roleRepo.Delete(ctx, role.ID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return r.permissionResolver.Invalidate(ctx, role.ID)
+}
+
+func (r *roleUsecase) ListPermissions(ctx context.Context) ([]*domain.Permission, error) {
+	permissions, err := r.permissionRepo.FindMany(ctx, nil, &domain.FindManyOption{
+		Sort: []string{"id ASC"},
+	})
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return permissions, nil
+}
+
+// AssignRoles gives the roles to the user, in addition to the roles the user already has.
+func (r *roleUsecase) AssignRoles(ctx context.Context, req *domain.AssignRolesRequest) (*domain.User, error) {
+	user, err := r.findUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	roleIDs := lo.Uniq(req.RoleIDs)
+	roles, err := r.roleRepo.FindMany(ctx, &domain.RoleFilter{
+		IDIn: roleIDs,
+	}, &domain.FindManyOption{
+		Preloads: []string{common.FieldPermissions},
+	})
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if len(roles) != len(roleIDs) {
+		return nil, domain.ErrRoleNotFound.WithDetail("role_ids", roleIDs)
+	}
+
+	actor, err := r.principal(ctx, req.ActorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUserAction(actor, domain.UserActionManageRoles, user); err != nil {
+		return nil, err
+	}
+	var permissionIDs []domain.PermissionID
+	for _, role := range roles {
+		permissionIDs = append(permissionIDs, role.PermissionIDs()...)
+	}
+	if err := checkGrantable(actor, permissionIDs); err != nil {
+		return nil, err
+	}
+
+	if err := r.roleRepo.AddUserRoles(ctx, user.ID, roleIDs); err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	r.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:    domain.SecurityEventRoleAssigned,
+		UserID:  user.ID,
+		ActorID: req.ActorID,
+		Metadata: map[string]any{
+			"role_ids": roleIDs,
+		},
+		OccurredAt: utils.NowUnixMillis(),
+	})
+
+	return r.findUser(ctx, user.ID)
+}
+
+func (r *roleUsecase) RevokeRole(ctx context.Context, req *domain.RevokeRoleRequest) (*domain.User, error) {
+	user, err := r.findUser(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+	role, err := r.findRole(ctx, req.RoleID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.HasAnyRole(role.ID) {
+		return user, nil
+	}
+	actor, err := r.principal(ctx, req.ActorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUserAction(actor, domain.UserActionManageRoles, user); err != nil {
+		return nil, err
+	}
+	if err := checkGrantable(actor, role.PermissionIDs()); err != nil {
+		return nil, err
+	}
+
+	// Nobody could manage roles anymore
+	if role.ID == domain.RoleIDSuperAdmin {
+		count, err := r.roleRepo.CountUsers(ctx, role.ID)
+		if err != nil {
+			return nil, domain.ErrInternalServerError.WithWrap(err)
+		}
+		if count <= 1 {
+			return nil, domain.ErrRoleProtected.WithReason("the last super admin cannot lose the role")
+		}
+	}
+
+	removed, err := r.roleRepo.RemoveUserRole(ctx, user.ID, role.ID)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if removed {
+		r.securityEvents.Emit(ctx, &domain.SecurityEvent{
+			Type:    domain.SecurityEventRoleRevoked,
+			UserID:  user.ID,
+			ActorID: req.ActorID,
+			Metadata: map[string]any{
+				"role_id": role.ID,
+			},
+			OccurredAt: utils.NowUnixMillis(),
+		})
+	}
+
+	return r.findUser(ctx, user.ID)
+}
+
+func (r *roleUsecase) findRole(ctx context.Context, id domain.RoleID) (*domain.Role, error) {
+	role, err := r.roleRepo.FindByID(ctx, id, &domain.FindOneOption{
+		Preloads: []string{common.FieldPermissions},
+	})
+	if err != nil {
+		if errors.Is(err, domain.ErrRecordNotFound) {
+			return nil, domain.ErrRoleNotFound
+		}
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return role, nil
+}
+
+func (r *roleUsecase) findUser(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := r.userRepo.FindByID(ctx, userID, &domain.FindOneOption{
+		Preloads: []string{common.FieldRoles},
+	})
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound.WithWrap(err)
+	}
+	return user, nil
+}
+
+// findPermissions loads the given permissions without duplicates and rejects unknown ones.
+func (r *roleUsecase) findPermissions(ctx context.Context, ids []domain.PermissionID) ([]*domain.Permission, error) {
+	ids = lo.Uniq(ids)
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	permissions, err := r.permissionRepo.FindMany(ctx, &domain.PermissionFilter{
+		IDIn: ids,
+	}, nil)
+	if err != nil {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if len(permissions) != len(ids) {
+		found := make(map[domain.PermissionID]struct{}, len(permissions))
+		for _, permission := range permissions {
+			found[permission.ID] = struct{}{}
+		}
+		var unknown []domain.PermissionID
+		for _, id := range ids {
+			if _, ok := found[id]; !ok {
+				unknown = append(unknown, id)
+			}
+		}
+		return nil, domain.ErrInvalidPermission.WithDetail("permissions", unknown)
+	}
+	return permissions, nil
+}
+
+// principal loads the actor with the permissions its roles grant
+func (r *roleUsecase) principal(ctx context.Context, actorID string) (*domain.Principal, error) {
+	actor, err := r.findUser(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	granted, err := r.permissionResolver.PermissionsForRoles(ctx, actor.Roles)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewPrincipal(actor, granted), nil
+}
+
+// checkGrantable makes sure the actor holds every one of the permissions, so nobody can hand out
+// or take away more than they have themselves.
+func checkGrantable(actor *domain.Principal, permissionIDs []domain.PermissionID) error {
+	if !actor.HasPermissions(permissionIDs...) {
+		return domain.ErrPermissionDenied.WithReason("you cannot grant or revoke permissions you do not hold")
+	}
+	return nil
+}
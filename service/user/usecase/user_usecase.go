@@ -3,14 +3,18 @@ package usecase
 import (
 	"context"
 	"errors"
+	"fmt"
+	"go-clean-arch/common"
 	"go-clean-arch/domain"
-
-	"golang.org/x/crypto/bcrypt"
+	"go-clean-arch/pkg/password"
+	"go-clean-arch/pkg/utils"
+	"strings"
 )
 
 type Hasher interface {
 	Hash(password string) (string, error)
 	Compare(hashed, password string) bool
+	NeedsRehash(hashed string) bool
 }
 
 type UserRepository interface {
@@ -21,41 +25,128 @@ type UserRepository interface {
 	FindPage(ctx context.Context, filter *domain.UserFilter, option *domain.FindPageOption) ([]*domain.User, *domain.Pagination, error)
 	Update(ctx context.Context, user *domain.User) error
 	UpdatePassword(ctx context.Context, userID string, newPassword string) error
+	ReplacePasswordHash(ctx context.Context, userID, oldHash, newHash string) (bool, error)
+	UpdateFields(ctx context.Context, userID string, fields map[string]any) error
+	LiftBan(ctx context.Context, userID string, bannedAt int64) (bool, error)
 	Delete(ctx context.Context, userID string) error
+	Restore(ctx context.Context, userID string) (bool, error)
 	Count(ctx context.Context, filter *domain.UserFilter) (int64, error)
 }
 
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, history *domain.PasswordHistory) error
+	FindRecentByUserID(ctx context.Context, userID string, limit int) ([]*domain.PasswordHistory, error)
+	Prune(ctx context.Context, userID string, keep int) error
+}
+
+type PasswordPolicy interface {
+	Validate(password string, personalInfo ...string) []password.Violation
+}
+
+type PasswordPolicyConfig interface {
+	HistorySize() int
+}
+
+// TokenRevocationList revokes the access tokens and sessions a user holds, when the password
+// changes or the user gets banned
+type TokenRevocationList interface {
+	RevokeUser(ctx context.Context, userID string) error
+}
+
+// SessionRepository ends the sessions of a user, so they cannot be refreshed even once the
+// revocation list forgot about them
+type SessionRepository interface {
+	RevokeAllByUserIDExcept(ctx context.Context, userID, exceptSessionID string) (int64, error)
+}
+
+// maxBanReasonLength matches the ban_reason column
+const maxBanReasonLength = 500
+
+var userSortableFields = map[string]string{ // map[SortField]DBColumn
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"username":   "username",
+	"email":      "email",
+	"first_name": "first_name",
+	"last_name":  "last_name",
+	"banned_at":  "banned_at",
+}
+
 type userUsecase struct {
-	repo   UserRepository
-	hasher Hasher
+	repo                UserRepository
+	passwordHistoryRepo PasswordHistoryRepository
+	sessionRepo         SessionRepository
+	hasher              Hasher
+	passwordPolicy      PasswordPolicy
+	revocationList      TokenRevocationList
+	securityEvents      domain.SecurityEventEmitter
+	passwordPolicyCfg   PasswordPolicyConfig
 }
 
-func NewUserUsecase(repo UserRepository, hasher Hasher) domain.UserUsecase {
-	return &userUsecase{repo: repo, hasher: hasher}
+func NewUserUsecase(
+	repo UserRepository,
+	passwordHistoryRepo PasswordHistoryRepository,
+	sessionRepo SessionRepository,
+	hasher Hasher,
+	passwordPolicy PasswordPolicy,
+	revocationList TokenRevocationList,
+	securityEvents domain.SecurityEventEmitter,
+	passwordPolicyCfg PasswordPolicyConfig,
+) domain.UserUsecase {
+	return &userUsecase{
+		repo:                repo,
+		passwordHistoryRepo: passwordHistoryRepo,
+		sessionRepo:         sessionRepo,
+		hasher:              hasher,
+		passwordPolicy:      passwordPolicy,
+		revocationList:      revocationList,
+		securityEvents:      securityEvents,
+		passwordPolicyCfg:   passwordPolicyCfg,
+	}
 }
 
 func (u *userUsecase) Create(ctx context.Context, req *domain.UserCreateRequest) (*domain.User, error) {
+	if req.Principal != nil {
+		if err := domain.AuthorizeUserCreation(req.Principal); err != nil {
+			return nil, err
+		}
+	}
 	user := &domain.User{
-		Email:     req.Email,
+		Username:  domain.NormalizeUsername(req.Username),
+		Email:     domain.NormalizeEmail(req.Email),
 		Password:  req.Password,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Status:    domain.UserSTTWaitingVerify,
 	}
+	if req.External {
+		user.Password = ""
+		user.Status = domain.UserSTTActive
+	}
 	if err := user.Validate(); err != nil {
 		return nil, err
 	}
+	// External users can choose a username later
+	if user.Username == "" && !req.External {
+		return nil, domain.ErrUserValidationFailed.WithError("username must be not empty")
+	}
 
-	// Check if email already exists
-	existingByEmail, err := u.repo.FindOne(ctx, &domain.UserFilter{
-		Email: &user.Email,
-	}, nil)
-	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
-		return nil, domain.ErrInternalServerError.WithError(err.Error())
+	if err := u.checkEmailAvailable(ctx, user.Email, ""); err != nil {
+		return nil, err
+	}
+	if err := u.checkUsernameAvailable(ctx, user.Username, ""); err != nil {
+		return nil, err
 	}
 
-	if existingByEmail != nil {
-		return nil, domain.ErrEmailAlreadyExists
+	if req.External {
+		if err := u.repo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	if err := u.checkPassword(ctx, user, req.Password); err != nil {
+		return nil, err
 	}
 
 	// Hash password and create user
@@ -68,6 +159,9 @@ func (u *userUsecase) Create(ctx context.Context, req *domain.UserCreateRequest)
 	if err := u.repo.Create(ctx, user); err != nil {
 		return nil, err
 	}
+	if err := u.recordPassword(ctx, user.ID, hashedPassword); err != nil {
+		return nil, err
+	}
 	return user, nil
 }
 
@@ -89,19 +183,39 @@ func (u *userUsecase) FindByEmail(ctx context.Context, email string, option *dom
 
 func (u *userUsecase) FindOne(ctx context.Context, filter *domain.UserFilter, option *domain.FindOneOption) (*domain.User, error) {
 	user, err := u.repo.FindOne(ctx, filter, option)
-	if err != nil || user == nil {
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	if user == nil {
 		return nil, domain.ErrUserNotFound.WithWrap(err)
 	}
 	return user, nil
 }
 
 func (u *userUsecase) Update(ctx context.Context, userID string, req *domain.UserUpdateRequest) error {
-	user, err := u.repo.FindByID(ctx, userID, nil)
-	if err != nil || user == nil {
-		return domain.ErrUserNotFound.WithWrap(err)
+	if req.Principal != nil {
+		if err := domain.AuthorizeUserUpdateLookup(req.Principal, userID, req); err != nil {
+			return err
+		}
+	}
+	user, err := u.findUserWithRoles(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if req.Principal != nil {
+		if err := domain.AuthorizeUserUpdate(req.Principal, user, req); err != nil {
+			return err
+		}
+	}
+	previousEmail := user.Email
+	if req.Username != nil {
+		user.Username = domain.NormalizeUsername(*req.Username)
+		if user.Username == "" {
+			return domain.ErrUserValidationFailed.WithError("username must be not empty")
+		}
 	}
 	if req.Email != nil {
-		user.Email = *req.Email
+		user.Email = domain.NormalizeEmail(*req.Email)
 	}
 	if req.FirstName != nil {
 		user.FirstName = *req.FirstName
@@ -110,31 +224,550 @@ func (u *userUsecase) Update(ctx context.Context, userID string, req *domain.Use
 		user.LastName = *req.LastName
 	}
 	if req.Status != nil {
+		// Bans keep their reason, expiry and previous status, only Ban and Unban maintain them
+		if *req.Status != user.Status && (*req.Status == domain.UserSTTBanned || user.Status == domain.UserSTTBanned) {
+			return domain.ErrInvalidUserStatus.WithError("users are banned and unbanned with the ban and unban actions")
+		}
 		user.Status = *req.Status
 	}
+	if req.PasswordResetRequired != nil {
+		user.PasswordResetRequired = *req.PasswordResetRequired
+	}
 	if err := user.Validate(); err != nil {
 		return err
 	}
-	return u.repo.Update(ctx, user)
+	if user.Email != previousEmail {
+		if err := u.checkEmailAvailable(ctx, user.Email, user.ID); err != nil {
+			return err
+		}
+	}
+	if req.Username != nil {
+		if err := u.checkUsernameAvailable(ctx, user.Username, user.ID); err != nil {
+			return err
+		}
+	}
+	if err := u.repo.Update(ctx, user); err != nil {
+		return err
+	}
+	if req.ClearPassword {
+		if err := u.repo.UpdateFields(ctx, user.ID, map[string]any{
+			"password": "",
+		}); err != nil {
+			return domain.ErrInternalServerError.WithWrap(err)
+		}
+		if _, err := u.endSessions(ctx, user.ID); err != nil {
+			return err
+		}
+	}
+
+	if user.Email != previousEmail {
+		u.securityEvents.Emit(ctx, &domain.SecurityEvent{
+			Type:   domain.SecurityEventEmailChanged,
+			UserID: user.ID,
+			Metadata: map[string]any{
+				"previous_email": previousEmail,
+				"email":          user.Email,
+			},
+			OccurredAt: utils.NowUnixMillis(),
+		})
+	}
+	return nil
 }
 
 func (u *userUsecase) ChangePassword(ctx context.Context, req *domain.UserChangePasswordRequest) error {
+	if err := domain.AuthorizeUserLookup(req.Principal, domain.UserActionChangePassword, req.UserID); err != nil {
+		return err
+	}
 	user, err := u.repo.FindByID(ctx, req.UserID, nil)
 	if err != nil || user == nil {
 		return domain.ErrUserNotFound.WithWrap(err)
 	}
+	if err := domain.AuthorizeUserAction(req.Principal, domain.UserActionChangePassword, user); err != nil {
+		return err
+	}
 	// Verify old password (hash check)
-	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.OldPassword)) != nil {
+	if !u.hasher.Compare(user.Password, req.OldPassword) {
 		return domain.ErrInvalidCredentials.WithError("old password is incorrect")
 	}
+	if err := u.checkPassword(ctx, user, req.NewPassword); err != nil {
+		return err
+	}
 	// Hash new password before saving
-	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	hashed, err := u.hasher.Hash(req.NewPassword)
+	if err != nil {
+		return domain.ErrPasswordHashFailed.WithWrap(err)
+	}
+	if err := u.repo.UpdatePassword(ctx, req.UserID, hashed); err != nil {
+		return err
+	}
+	if err := u.recordPassword(ctx, req.UserID, hashed); err != nil {
+		return err
+	}
+
+	u.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventPasswordChanged,
+		UserID:     req.UserID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+	return u.revokeUser(ctx, req.UserID)
+}
+
+// UpdatePassword sets a new password without checking the old one, e.g. after a verified password reset
+func (u *userUsecase) UpdatePassword(ctx context.Context, userID string, newPassword string) error {
+	user, err := u.repo.FindByID(ctx, userID, nil)
+	if err != nil || user == nil {
+		return domain.ErrUserNotFound.WithWrap(err)
+	}
+	if err := u.checkPassword(ctx, user, newPassword); err != nil {
+		return err
+	}
+	hashed, err := u.hasher.Hash(newPassword)
 	if err != nil {
 		return domain.ErrPasswordHashFailed.WithWrap(err)
 	}
-	return u.repo.UpdatePassword(ctx, req.UserID, string(hashed))
+	if err := u.repo.UpdatePassword(ctx, userID, hashed); err != nil {
+		return err
+	}
+	if err := u.recordPassword(ctx, userID, hashed); err != nil {
+		return err
+	}
+	return u.revokeUser(ctx, userID)
+}
+
+// CheckPassword returns the error UpdatePassword would answer with for the password policy and the
+// password history, without setting the password.
+func (u *userUsecase) CheckPassword(ctx context.Context, userID string, newPassword string) error {
+	user, err := u.repo.FindByID(ctx, userID, nil)
+	if err != nil || user == nil {
+		return domain.ErrUserNotFound.WithWrap(err)
+	}
+	return u.checkPassword(ctx, user, newPassword)
+}
+
+// RehashPassword hashes the password again with the current algorithm and cost, when the stored
+// hash is outdated. The password is verified first, as it comes from a login in another service.
+// It reports whether the stored hash was replaced.
+func (u *userUsecase) RehashPassword(ctx context.Context, userID string, password string) (bool, error) {
+	user, err := u.repo.FindByID(ctx, userID, nil)
+	if err != nil || user == nil {
+		return false, domain.ErrUserNotFound.WithWrap(err)
+	}
+	if !u.hasher.NeedsRehash(user.Password) {
+		return false, nil
+	}
+	if !u.hasher.Compare(user.Password, password) {
+		return false, domain.ErrInvalidCredentials
+	}
+
+	hashed, err := u.hasher.Hash(password)
+	if err != nil {
+		return false, domain.ErrPasswordHashFailed.WithWrap(err)
+	}
+	replaced, err := u.repo.ReplacePasswordHash(ctx, userID, user.Password, hashed)
+	if err != nil {
+		return false, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return replaced, nil
+}
+
+// checkEmailAvailable fails when another user holds the email in any case, deleted users included
+func (u *userUsecase) checkEmailAvailable(ctx context.Context, email, excludeUserID string) error {
+	includeDeleted := true
+	filter := &domain.UserFilter{
+		Email:          &email,
+		IncludeDeleted: &includeDeleted,
+	}
+	if excludeUserID != "" {
+		filter.IDNe = &excludeUserID
+	}
+	existing, err := u.repo.FindOne(ctx, filter, nil)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if existing != nil {
+		return domain.ErrEmailAlreadyExists
+	}
+	return nil
+}
+
+// checkUsernameAvailable fails when another user holds the username in any case. Deleted users
+// keep their username, like their email. excludeUserID leaves out the user being updated, so the
+// case of its own username can change.
+func (u *userUsecase) checkUsernameAvailable(ctx context.Context, username, excludeUserID string) error {
+	if username == "" {
+		return nil
+	}
+	includeDeleted := true
+	filter := &domain.UserFilter{
+		Username:       &username,
+		IncludeDeleted: &includeDeleted,
+	}
+	if excludeUserID != "" {
+		filter.IDNe = &excludeUserID
+	}
+	existing, err := u.repo.FindOne(ctx, filter, nil)
+	if err != nil && !errors.Is(err, domain.ErrRecordNotFound) {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if existing != nil {
+		return domain.ErrUsernameAlreadyExists
+	}
+	return nil
+}
+
+// checkPassword validates a new password of the user against the password policy and the password
+// history, and reports every violated rule at once.
+func (u *userUsecase) checkPassword(ctx context.Context, user *domain.User, newPassword string) error {
+	violations := u.passwordPolicy.Validate(newPassword, user.Username, user.Email, user.FirstName, user.LastName)
+
+	reused, err := u.isPasswordReused(ctx, user, newPassword)
+	if err != nil {
+		return err
+	}
+	if reused {
+		violations = append(violations, password.Violation{
+			Rule:    password.RulePasswordReuse,
+			Message: fmt.Sprintf("must not match any of your last %d passwords", u.passwordPolicyCfg.HistorySize()),
+		})
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		messages = append(messages, violation.Message)
+	}
+	return domain.ErrPasswordTooWeak.
+		WithReason("Password "+strings.Join(messages, ", ")).
+		WithDetail("violations", violations)
+}
+
+// isPasswordReused compares the new password with the current one and the recent ones kept in the
+// password history. Users created before the history existed only have their current password.
+func (u *userUsecase) isPasswordReused(ctx context.Context, user *domain.User, newPassword string) (bool, error) {
+	historySize := u.passwordPolicyCfg.HistorySize()
+	if user.ID == "" || historySize <= 0 {
+		return false, nil
+	}
+	if user.Password != "" && u.hasher.Compare(user.Password, newPassword) {
+		return true, nil
+	}
+
+	history, err := u.passwordHistoryRepo.FindRecentByUserID(ctx, user.ID, historySize)
+	if err != nil {
+		return false, domain.ErrInternalServerError.WithWrap(err)
+	}
+	for _, entry := range history {
+		if entry.PasswordHash == user.Password {
+			continue
+		}
+		if u.hasher.Compare(entry.PasswordHash, newPassword) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordPassword adds the new password hash to the history and drops the entries that are too old
+// to be checked anymore
+func (u *userUsecase) recordPassword(ctx context.Context, userID, hashedPassword string) error {
+	historySize := u.passwordPolicyCfg.HistorySize()
+	if historySize <= 0 {
+		return nil
+	}
+	if err := u.passwordHistoryRepo.Create(ctx, &domain.PasswordHistory{
+		UserID:       userID,
+		PasswordHash: hashedPassword,
+	}); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	if err := u.passwordHistoryRepo.Prune(ctx, userID, historySize); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return nil
+}
+
+// revokeUser signs the user out everywhere, the tokens issued so far stop working immediately
+func (u *userUsecase) revokeUser(ctx context.Context, userID string) error {
+	if err := u.revocationList.RevokeUser(ctx, userID); err != nil {
+		return domain.ErrInternalServerError.WithWrap(err)
+	}
+	return nil
 }
 
 func (u *userUsecase) FindPage(ctx context.Context, filter *domain.UserFilter, option *domain.FindPageOption) ([]*domain.User, *domain.Pagination, error) {
 	return u.repo.FindPage(ctx, filter, option)
 }
+
+// ListUsers returns a page of the users matching the filter, the most recent first by default.
+func (u *userUsecase) ListUsers(ctx context.Context, req *domain.ListUsersRequest) ([]*domain.User, *domain.Pagination, error) {
+	sort := "created_at DESC"
+	if req.Sort != "" {
+		field, descending := strings.CutPrefix(req.Sort, "-")
+		column, ok := userSortableFields[field]
+		if !ok {
+			return nil, nil, domain.ErrBadRequest.WithErrorf("cannot sort users by %q", field)
+		}
+		sort = column + " ASC"
+		if descending {
+			sort = column + " DESC"
+		}
+	}
+
+	users, pagination, err := u.repo.FindPage(ctx, &req.UserFilter, &domain.FindPageOption{
+		Preloads: []string{common.FieldRoles},
+		Sort:     []string{sort},
+		Page:     req.Page,
+		PerPage:  req.PerPage,
+	})
+	if err != nil {
+		return nil, nil, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return users, pagination, nil
+}
+
+// Delete soft deletes the user and signs it out everywhere. The email and username stay taken, so
+// the user can be restored.
+func (u *userUsecase) Delete(ctx context.Context, req *domain.UserAdminActionRequest) error {
+	user, err := u.findManagedUser(ctx, req.UserID, req.Principal, domain.UserActionDelete)
+	if err != nil {
+		return err
+	}
+	if err := u.repo.Delete(ctx, user.ID); err != nil {
+		return domain.ErrUserDeletionFailed.WithWrap(err)
+	}
+	if _, err := u.endSessions(ctx, user.ID); err != nil {
+		return err
+	}
+
+	u.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventUserDeleted,
+		UserID:     user.ID,
+		ActorID:    req.Principal.UserID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+	return nil
+}
+
+func (u *userUsecase) Restore(ctx context.Context, req *domain.UserAdminActionRequest) (*domain.User, error) {
+	includeDeleted := true
+	user, err := u.repo.FindOne(ctx, &domain.UserFilter{
+		ID:             &req.UserID,
+		IncludeDeleted: &includeDeleted,
+	}, &domain.FindOneOption{
+		Preloads: []string{common.FieldRoles},
+	})
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound.WithWrap(err)
+	}
+	if err := domain.AuthorizeUserAction(req.Principal, domain.UserActionRestore, user); err != nil {
+		return nil, err
+	}
+	if user.DeletedAt == 0 {
+		return nil, domain.ErrUserNotDeleted
+	}
+
+	restored, err := u.repo.Restore(ctx, user.ID)
+	if err != nil {
+		return nil, domain.ErrUserUpdateFailed.WithWrap(err)
+	}
+	if !restored {
+		return nil, domain.ErrUserNotDeleted
+	}
+
+	u.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventUserRestored,
+		UserID:     user.ID,
+		ActorID:    req.Principal.UserID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+	return u.findUserWithRoles(ctx, user.ID)
+}
+
+// Ban bans the user until ExpiresAt, or for good, and ends its sessions at once. Banning a banned
+// user replaces the reason and the expiry of the ban.
+func (u *userUsecase) Ban(ctx context.Context, req *domain.BanUserRequest) (*domain.User, error) {
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, domain.ErrBadRequest.WithError("reason must be not empty")
+	}
+	if len(reason) > maxBanReasonLength {
+		return nil, domain.ErrBadRequest.WithErrorf("reason must be at most %d characters", maxBanReasonLength)
+	}
+	now := utils.NowUnixMillis()
+	var bannedUntil int64
+	if req.ExpiresAt != nil {
+		if *req.ExpiresAt <= now {
+			return nil, domain.ErrBadRequest.WithError("expires_at must be in the future")
+		}
+		bannedUntil = *req.ExpiresAt
+	}
+
+	user, err := u.findManagedUser(ctx, req.UserID, req.Principal, domain.UserActionBan)
+	if err != nil {
+		return nil, err
+	}
+	statusBeforeBan := user.Status
+	if user.Status == domain.UserSTTBanned {
+		statusBeforeBan = user.StatusBeforeBan
+	}
+
+	if err := u.repo.UpdateFields(ctx, user.ID, map[string]any{
+		"status":            domain.UserSTTBanned,
+		"ban_reason":        reason,
+		"banned_by":         req.Principal.UserID,
+		"banned_at":         now,
+		"banned_until":      bannedUntil,
+		"status_before_ban": statusBeforeBan,
+	}); err != nil {
+		return nil, domain.ErrUserUpdateFailed.WithWrap(err)
+	}
+	if _, err := u.endSessions(ctx, user.ID); err != nil {
+		return nil, err
+	}
+
+	metadata := map[string]any{"reason": reason}
+	if bannedUntil > 0 {
+		metadata["expires_at"] = bannedUntil
+	}
+	u.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventUserBanned,
+		UserID:     user.ID,
+		ActorID:    req.Principal.UserID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		Metadata:   metadata,
+		OccurredAt: now,
+	})
+	return u.findUserWithRoles(ctx, user.ID)
+}
+
+// Unban lifts the ban of the user and gives it the status it had before.
+func (u *userUsecase) Unban(ctx context.Context, req *domain.UserAdminActionRequest) (*domain.User, error) {
+	user, err := u.findManagedUser(ctx, req.UserID, req.Principal, domain.UserActionBan)
+	if err != nil {
+		return nil, err
+	}
+	if user.Status != domain.UserSTTBanned {
+		return nil, domain.ErrUserNotBanned
+	}
+
+	lifted, err := u.repo.LiftBan(ctx, user.ID, user.BannedAt)
+	if err != nil {
+		return nil, domain.ErrUserUpdateFailed.WithWrap(err)
+	}
+	// Lifted by the expiry or banned again in the meantime
+	if !lifted {
+		return nil, domain.ErrUserNotBanned
+	}
+
+	u.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:       domain.SecurityEventUserUnbanned,
+		UserID:     user.ID,
+		ActorID:    req.Principal.UserID,
+		IPAddress:  req.IPAddress,
+		UserAgent:  req.UserAgent,
+		OccurredAt: utils.NowUnixMillis(),
+	})
+	return u.findUserWithRoles(ctx, user.ID)
+}
+
+// ForceLogout ends every session of the user, the user can sign in again right away.
+func (u *userUsecase) ForceLogout(ctx context.Context, req *domain.UserAdminActionRequest) error {
+	user, err := u.findManagedUser(ctx, req.UserID, req.Principal, domain.UserActionForceLogout)
+	if err != nil {
+		return err
+	}
+	revoked, err := u.endSessions(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+
+	u.securityEvents.Emit(ctx, &domain.SecurityEvent{
+		Type:      domain.SecurityEventForcedLogout,
+		UserID:    user.ID,
+		ActorID:   req.Principal.UserID,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+		Metadata: map[string]any{
+			"revoked_sessions": revoked,
+		},
+		OccurredAt: utils.NowUnixMillis(),
+	})
+	return nil
+}
+
+func (u *userUsecase) LiftExpiredBans(ctx context.Context) (int64, error) {
+	now := utils.NowUnixMillis()
+	includeDeleted := true
+	users, err := u.repo.FindMany(ctx, &domain.UserFilter{
+		BannedUntilBefore: &now,
+		IncludeDeleted:    &includeDeleted,
+	}, nil)
+	if err != nil {
+		return 0, domain.ErrInternalServerError.WithWrap(err)
+	}
+
+	var lifted int64
+	for _, user := range users {
+		ok, err := u.repo.LiftBan(ctx, user.ID, user.BannedAt)
+		if err != nil {
+			return lifted, domain.ErrInternalServerError.WithWrap(err)
+		}
+		if !ok {
+			continue
+		}
+		lifted++
+		u.securityEvents.Emit(ctx, &domain.SecurityEvent{
+			Type:   domain.SecurityEventUserUnbanned,
+			UserID: user.ID,
+			Metadata: map[string]any{
+				"expired": true,
+			},
+			OccurredAt: now,
+		})
+	}
+	return lifted, nil
+}
+
+// findManagedUser loads the user an administrator acts on, once the policy allows the action
+func (u *userUsecase) findManagedUser(ctx context.Context, userID string, principal *domain.Principal, action domain.UserAction) (*domain.User, error) {
+	if err := domain.AuthorizeUserLookup(principal, action, userID); err != nil {
+		return nil, err
+	}
+	user, err := u.findUserWithRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.AuthorizeUserAction(principal, action, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (u *userUsecase) findUserWithRoles(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := u.repo.FindOne(ctx, &domain.UserFilter{ID: &userID}, &domain.FindOneOption{
+		Preloads: []string{common.FieldRoles},
+	})
+	if err != nil || user == nil {
+		return nil, domain.ErrUserNotFound.WithWrap(err)
+	}
+	return user, nil
+}
+
+// endSessions revokes the sessions of the user in the database and its tokens in the revocation
+// list, and returns how many sessions were active
+func (u *userUsecase) endSessions(ctx context.Context, userID string) (int64, error) {
+	revoked, err := u.sessionRepo.RevokeAllByUserIDExcept(ctx, userID, "")
+	if err != nil {
+		return 0, domain.ErrInternalServerError.WithWrap(err)
+	}
+	return revoked, u.revokeUser(ctx, userID)
+}
@@ -0,0 +1,200 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"go-clean-arch/domain"
+	"strings"
+	"testing"
+)
+
+// fakeUserRepo keeps users by ID and matches emails and usernames regardless of case, as the
+// repository does. The methods the tests do not need are left to the nil interface.
+type fakeUserRepo struct {
+	UserRepository
+	users map[string]*domain.User
+}
+
+func newFakeUserRepo(users ...*domain.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: map[string]*domain.User{}}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *fakeUserRepo) FindOne(_ context.Context, filter *domain.UserFilter, _ *domain.FindOneOption) (*domain.User, error) {
+	for _, user := range r.users {
+		if filter.ID != nil && *filter.ID != user.ID ||
+			filter.IDNe != nil && *filter.IDNe == user.ID ||
+			filter.Email != nil && !strings.EqualFold(*filter.Email, user.Email) ||
+			filter.Username != nil && !strings.EqualFold(*filter.Username, user.Username) {
+			continue
+		}
+		copied := *user
+		return &copied, nil
+	}
+	return nil, domain.ErrRecordNotFound
+}
+
+func (r *fakeUserRepo) Create(_ context.Context, user *domain.User) error {
+	user.ID = "created-user"
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *fakeUserRepo) Update(_ context.Context, user *domain.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+type nopSecurityEvents struct{}
+
+func (nopSecurityEvents) Emit(context.Context, *domain.SecurityEvent) {}
+
+// hasErrorID reports whether err is the given error, or nil for a nil want. Errors with their own
+// message are matched by ID.
+func hasErrorID(err error, want *domain.DetailedError) bool {
+	if want == nil {
+		return err == nil
+	}
+	var de *domain.DetailedError
+	return errors.As(err, &de) && de.IDField == want.IDField
+}
+
+func newTestUser(id, username, email string) *domain.User {
+	user := &domain.User{Username: username, Email: email, FirstName: "Jane", LastName: "Doe", Status: domain.UserSTTActive}
+	user.ID = id
+	return user
+}
+
+func newTestUserUsecase(repo UserRepository) *userUsecase {
+	return NewUserUsecase(repo, nil, nil, nil, nil, nil, nopSecurityEvents{}, nil).(*userUsecase)
+}
+
+func TestUserUsecaseCreateEmail(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		wantEmail string
+		wantErr   error
+	}{
+		{name: "normalized email", email: " Jane.Doe@Example.com ", wantEmail: "jane.doe@example.com"},
+		{name: "email of another user", email: "jdoe@example.com", wantErr: domain.ErrEmailAlreadyExists},
+		{name: "email of another user in another case", email: "JDoe@Example.com", wantErr: domain.ErrEmailAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Stored before emails were normalized
+			repo := newFakeUserRepo(newTestUser("user-1", "jdoe", "JDoe@example.com"))
+			u := newTestUserUsecase(repo)
+
+			user, err := u.Create(context.Background(), &domain.UserCreateRequest{
+				Email:     tt.email,
+				FirstName: "Jane",
+				LastName:  "Doe",
+				External:  true,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && user.Email != tt.wantEmail {
+				t.Errorf("email = %q, want %q", user.Email, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestUserUsecaseUpdateEmail(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		wantEmail string
+		wantErr   error
+	}{
+		{name: "normalized email", email: " Jane@Example.com", wantEmail: "jane@example.com"},
+		{name: "own email in another case", email: "JANE.DOE@example.com", wantEmail: "jane.doe@example.com"},
+		{name: "email of another user in another case", email: "JDoe@Example.com", wantErr: domain.ErrEmailAlreadyExists},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeUserRepo(
+				newTestUser("user-1", "jane", "jane.doe@example.com"),
+				newTestUser("user-2", "jdoe", "jdoe@example.com"),
+			)
+			u := newTestUserUsecase(repo)
+
+			err := u.Update(context.Background(), "user-1", &domain.UserUpdateRequest{Email: &tt.email})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && repo.users["user-1"].Email != tt.wantEmail {
+				t.Errorf("email = %q, want %q", repo.users["user-1"].Email, tt.wantEmail)
+			}
+		})
+	}
+}
+
+func TestUserUsecaseUpdateStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     domain.UserStatus
+		to         domain.UserStatus
+		wantStatus domain.UserStatus
+		wantErr    *domain.DetailedError
+	}{
+		{name: "back to waiting for verification", status: domain.UserSTTActive, to: domain.UserSTTWaitingVerify, wantStatus: domain.UserSTTWaitingVerify},
+		{name: "ban", status: domain.UserSTTActive, to: domain.UserSTTBanned, wantStatus: domain.UserSTTActive, wantErr: domain.ErrInvalidUserStatus},
+		{name: "unban", status: domain.UserSTTBanned, to: domain.UserSTTActive, wantStatus: domain.UserSTTBanned, wantErr: domain.ErrInvalidUserStatus},
+		{name: "banned user kept banned", status: domain.UserSTTBanned, to: domain.UserSTTBanned, wantStatus: domain.UserSTTBanned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := newTestUser("user-1", "jane", "jane.doe@example.com")
+			user.Status = tt.status
+			repo := newFakeUserRepo(user)
+			u := newTestUserUsecase(repo)
+
+			err := u.Update(context.Background(), "user-1", &domain.UserUpdateRequest{Status: &tt.to})
+			if !hasErrorID(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.users["user-1"].Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", repo.users["user-1"].Status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestUserUsecaseAuthorizesBeforeLookup(t *testing.T) {
+	user := &domain.Principal{UserID: "user-1", Roles: []domain.RoleID{domain.RoleIDUser}}
+	name := "Jane"
+	status := domain.UserSTTActive
+
+	for _, targetID := range []string{"user-2", "missing-user"} {
+		t.Run(targetID, func(t *testing.T) {
+			u := newTestUserUsecase(newFakeUserRepo(
+				newTestUser("user-1", "jane", "jane.doe@example.com"),
+				newTestUser("user-2", "jdoe", "jdoe@example.com"),
+			))
+			ctx := context.Background()
+
+			// Refused the same way whether the user exists or not
+			err := u.Update(ctx, targetID, &domain.UserUpdateRequest{FirstName: &name, Principal: user})
+			if !errors.Is(err, domain.ErrForbidden) {
+				t.Errorf("Update() error = %v, want %v", err, domain.ErrForbidden)
+			}
+			err = u.Update(ctx, targetID, &domain.UserUpdateRequest{Status: &status, Principal: user})
+			if !errors.Is(err, domain.ErrForbidden) {
+				t.Errorf("Update() of the status error = %v, want %v", err, domain.ErrForbidden)
+			}
+			err = u.ChangePassword(ctx, &domain.UserChangePasswordRequest{UserID: targetID, Principal: user})
+			if !errors.Is(err, domain.ErrForbidden) {
+				t.Errorf("ChangePassword() error = %v, want %v", err, domain.ErrForbidden)
+			}
+		})
+	}
+}
@@ -70,26 +70,13 @@ var defaultRegistrations = [...]Registration{
 }
 
 func IsValidAction(fl validator.FieldLevel) bool {
-	input := domain.Action(fl.Field().String())
-	switch input {
-	case domain.Register,
-		domain.Login,
-		domain.ResetPassword,
-		domain.DeleteAccount:
-		return true
-	default:
-		return false
-	}
+	input := fl.Field().String()
+	return domain.Action(input).IsValid()
 }
 
 func IsValidOTPDeliveryMethod(fl validator.FieldLevel) bool {
-	input := domain.OTPDeliveryMethod(fl.Field().String())
-	switch input {
-	case domain.Zalo:
-		return true
-	default:
-		return false
-	}
+	input := fl.Field().String()
+	return domain.OTPDeliveryMethod(input).IsValid()
 }
 
 func IsValidServiceType(fl validator.FieldLevel) bool {